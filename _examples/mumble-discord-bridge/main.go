@@ -0,0 +1,128 @@
+package main // import "github.com/bmmcginty/gumble/_examples/mumble-discord-bridge"
+
+import (
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"regexp"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumblebridge"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	_ "github.com/bmmcginty/gumble/opus"
+	"github.com/bwmarrin/discordgo"
+)
+
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+func main() {
+	token := flag.String("discord-token", "", "Discord bot token")
+	guild := flag.String("discord-guild", "", "Discord guild (server) ID")
+	voiceChannel := flag.String("discord-voice", "", "Discord voice channel ID")
+	textChannel := flag.String("discord-text", "", "Discord text channel ID (optional)")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s: [flags]\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+
+	var discord *discordgo.Session
+	var bridge *gumblebridge.Bridge
+
+	// The flags are parsed by gumbleutil.Main, along with its own.
+	gumbleutil.Main(gumbleutil.Listener{
+		Connect: func(e *gumble.ConnectEvent) {
+			if *token == "" || *guild == "" || *voiceChannel == "" {
+				flag.Usage()
+				e.Client.Disconnect()
+				return
+			}
+			var err error
+			discord, err = discordgo.New("Bot " + *token)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				e.Client.Disconnect()
+				return
+			}
+			discord.Identify.Intents = discordgo.IntentsGuilds | discordgo.IntentsGuildVoiceStates | discordgo.IntentsGuildMessages | discordgo.IntentsMessageContent
+			client := e.Client
+			discord.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) {
+				if m.ChannelID != *textChannel || m.Author == nil || m.Author.ID == s.State.User.ID {
+					return
+				}
+				client.Do(func() {
+					if client.Self != nil && client.Self.Channel != nil {
+						client.Self.Channel.Send(html.EscapeString(m.Author.Username+": "+m.Content), false)
+					}
+				})
+			})
+			if err := discord.Open(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				e.Client.Disconnect()
+				return
+			}
+			voice, err := discord.ChannelVoiceJoin(*guild, *voiceChannel, false, false)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				e.Client.Disconnect()
+				return
+			}
+
+			bridge = gumblebridge.New(e.Client)
+			bridge.Error = func(err error) {
+				fmt.Fprintf(os.Stderr, "bridge: %s\n", err)
+			}
+			if err := bridge.Start(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s\n", err)
+				e.Client.Disconnect()
+				return
+			}
+
+			// Mumble to Discord: raw Opus frames from the bridge's mixer.
+			go func() {
+				speaking := false
+				for frame := range bridge.Frames() {
+					if frame == nil {
+						if speaking {
+							voice.Speaking(false)
+							speaking = false
+						}
+						continue
+					}
+					if !speaking {
+						voice.Speaking(true)
+						speaking = true
+					}
+					voice.OpusSend <- frame
+				}
+			}()
+			// Discord to Mumble: Opus passthrough, mixed when several people
+			// talk at once.
+			go func() {
+				for packet := range voice.OpusRecv {
+					bridge.Write(packet.SSRC, packet.Opus)
+				}
+			}()
+
+			fmt.Printf("bridging Mumble to Discord channel %s\n", *voiceChannel)
+		},
+
+		TextMessage: func(e *gumble.TextMessageEvent) {
+			if discord == nil || *textChannel == "" || e.Sender == nil {
+				return
+			}
+			message := html.UnescapeString(htmlTag.ReplaceAllString(e.Message, ""))
+			discord.ChannelMessageSend(*textChannel, e.Sender.Name+": "+message)
+		},
+
+		Disconnect: func(e *gumble.DisconnectEvent) {
+			if bridge != nil {
+				bridge.Stop()
+			}
+			if discord != nil {
+				discord.Close()
+			}
+		},
+	})
+}
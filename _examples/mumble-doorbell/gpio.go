@@ -0,0 +1,79 @@
+//go:build linux
+// +build linux
+
+package main
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"time"
+)
+
+// gpioPollInterval is how often the buttons are read. A button's new state is
+// only reported once it has been read twice in a row, which debounces it.
+const gpioPollInterval = 20 * time.Millisecond
+
+// button is a push button connected to a GPIO pin, read through the sysfs
+// GPIO interface.
+type button struct {
+	value     *os.File
+	activeLow bool
+}
+
+// openButton exports the given GPIO pin, and configures it as an input.
+func openButton(pin int, activeLow bool) (*button, error) {
+	dir := "/sys/class/gpio/gpio" + strconv.Itoa(pin)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := ioutil.WriteFile("/sys/class/gpio/export", []byte(strconv.Itoa(pin)), 0); err != nil {
+			return nil, err
+		}
+		// udev may take a moment to make the new files writable.
+		time.Sleep(100 * time.Millisecond)
+	}
+	if err := ioutil.WriteFile(dir+"/direction", []byte("in"), 0); err != nil {
+		return nil, err
+	}
+	value, err := os.Open(dir + "/value")
+	if err != nil {
+		return nil, err
+	}
+	return &button{
+		value:     value,
+		activeLow: activeLow,
+	}, nil
+}
+
+func (b *button) pressed() (bool, error) {
+	var buf [1]byte
+	if _, err := b.value.ReadAt(buf[:], 0); err != nil {
+		return false, err
+	}
+	switch buf[0] {
+	case '0':
+		return b.activeLow, nil
+	case '1':
+		return !b.activeLow, nil
+	}
+	return false, errors.New("invalid GPIO value")
+}
+
+// watch sends the button's state to changes each time it changes, until the
+// button cannot be read.
+func (b *button) watch(changes chan<- bool) {
+	var state, last bool
+	ticker := time.NewTicker(gpioPollInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		pressed, err := b.pressed()
+		if err != nil {
+			return
+		}
+		if pressed == last && pressed != state {
+			state = pressed
+			changes <- state
+		}
+		last = pressed
+	}
+}
@@ -0,0 +1,200 @@
+//go:build linux
+// +build linux
+
+// mumble-doorbell is an intercom for embedded Linux boards, such as the
+// Raspberry Pi. It stays connected to a server (reconnecting when the
+// connection is lost), joins the channel given with --channel, plays the
+// channel's audio through an ALSA device, and has two buttons wired to GPIO
+// pins:
+//  the talk button sends the microphone's audio while it is held down
+//  the bell button plays a WAV file (e.g. a chime) to the channel
+//
+// Example:
+//  mumble-doorbell --server mumble.example.com --username front-door \
+//    --channel Home --talk-gpio 17 --bell-gpio 27 --bell chime.wav
+package main // import "github.com/bmmcginty/gumble/_examples/mumble-doorbell"
+
+import (
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumblealsa"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	_ "github.com/bmmcginty/gumble/opus"
+)
+
+// The delays between connection attempts: the first retry waits
+// minReconnectDelay, and each failure doubles the delay up to
+// maxReconnectDelay.
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+	os.Exit(1)
+}
+
+func main() {
+	talkPin := flag.Int("talk-gpio", -1, "GPIO pin of the push-to-talk button")
+	bellPin := flag.Int("bell-gpio", -1, "GPIO pin of the bell button")
+	activeLow := flag.Bool("active-low", true, "buttons pull their pin low when pressed")
+	bellFile := flag.String("bell", "", "WAV file played when the bell button is pressed")
+	input := flag.String("input", "", "ALSA capture device (hw:C,D)")
+	output := flag.String("output", "", "ALSA playback device (hw:C,D)")
+
+	mainConfig, err := gumbleutil.LoadMainConfig()
+	if err != nil {
+		fatal(err)
+	}
+	if err := mainConfig.ReadPassword(); err != nil {
+		fatal(err)
+	}
+
+	talk := make(chan bool)
+	bell := make(chan bool)
+	for _, b := range []struct {
+		pin     int
+		changes chan bool
+	}{{*talkPin, talk}, {*bellPin, bell}} {
+		if b.pin < 0 {
+			continue
+		}
+		button, err := openButton(b.pin, *activeLow)
+		if err != nil {
+			fatal(err)
+		}
+		go button.watch(b.changes)
+	}
+
+	host, port, err := net.SplitHostPort(mainConfig.Server)
+	if err != nil {
+		host = mainConfig.Server
+		port = strconv.Itoa(gumble.DefaultPort)
+	}
+	config := mainConfig.GumbleConfig()
+	config.Address = net.JoinHostPort(host, port)
+	config.Attach(gumbleutil.AutoBitrate)
+	disconnected := make(chan *gumble.DisconnectEvent, 1)
+	config.Attach(gumbleutil.Listener{
+		Disconnect: func(e *gumble.DisconnectEvent) {
+			disconnected <- e
+		},
+	})
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: mainConfig.Insecure,
+	}
+	if mainConfig.Certificate != "" {
+		keyFile := mainConfig.Key
+		if keyFile == "" {
+			keyFile = mainConfig.Certificate
+		}
+		certificate, err := tls.LoadX509KeyPair(mainConfig.Certificate, keyFile)
+		if err != nil {
+			fatal(err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, certificate)
+	}
+
+	// The Config is copied by each dial, so it can be reused to reconnect.
+	delay := minReconnectDelay
+	for {
+		// A failed dial can be reported as a disconnection.
+		select {
+		case <-disconnected:
+		default:
+		}
+		client, err := gumble.DialWithDialer(new(net.Dialer), config, tlsConfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s (retrying in %s)\n", os.Args[0], err, delay)
+			time.Sleep(delay)
+			if delay *= 2; delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
+			continue
+		}
+		delay = minReconnectDelay
+		fmt.Printf("connected to %s\n", config.Address)
+
+		e := run(client, *input, *output, *bellFile, talk, bell, disconnected)
+		fmt.Printf("disconnected: %s\n", e.String)
+	}
+}
+
+// run operates the intercom until the client disconnects.
+func run(client *gumble.Client, input, output, bellFile string, talk, bell <-chan bool, disconnected <-chan *gumble.DisconnectEvent) *gumble.DisconnectEvent {
+	stream, err := gumblealsa.New(client, &input, &output, nil)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+		client.Disconnect()
+		return <-disconnected
+	}
+	stream.AttachStream(client)
+	defer stream.Destroy()
+
+	var chime *gumbleutil.WAVSource
+	if bellFile != "" {
+		if chime, err = gumbleutil.OpenWAVSource(client, bellFile); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+		}
+	}
+	// Closed once the chime has finished playing.
+	var chimeDone chan struct{}
+	talking := false
+
+	for {
+		select {
+		case pressed := <-talk:
+			// The microphone is not used while the chime plays.
+			if pressed == talking || chimeDone != nil {
+				continue
+			}
+			if pressed {
+				err = stream.StartSource(nil)
+			} else {
+				err = stream.StopSource()
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+				continue
+			}
+			talking = pressed
+
+		case pressed := <-bell:
+			if !pressed || chime == nil || chimeDone != nil {
+				continue
+			}
+			if talking {
+				stream.StopSource()
+				talking = false
+			}
+			if err := chime.Play(); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+				continue
+			}
+			done := make(chan struct{})
+			go func() {
+				chime.Wait()
+				close(done)
+			}()
+			chimeDone = done
+
+		case <-chimeDone:
+			chimeDone = nil
+
+		case e := <-disconnected:
+			if chimeDone != nil {
+				chime.Stop()
+			}
+			return e
+		}
+	}
+}
@@ -0,0 +1,321 @@
+// mumble-meeting-recorder is a bot that records meetings, with one WAV track
+// per user. The tracks are padded with silence so that they stay aligned,
+// and can be mixed or transcribed separately.
+//
+// Commands, sent to the bot as text messages:
+//  !record  join the sender's channel and start recording
+//  !stop    stop recording, and post the location of the tracks
+//
+// The recording also stops once nobody has spoken for --idle-stop.
+package main // import "github.com/bmmcginty/gumble/_examples/mumble-meeting-recorder"
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	_ "github.com/bmmcginty/gumble/opus"
+)
+
+// talkingTimeout is how long a user can go without sending audio before they
+// are considered to have stopped talking.
+const talkingTimeout = 500 * time.Millisecond
+
+// track is the WAV file of a single user.
+type track struct {
+	file    *os.File
+	samples int64
+	// How much of the track the user spent talking.
+	talked time.Duration
+	// Fires when the user stops talking.
+	talking *time.Timer
+}
+
+func createTrack(filename string) (*track, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	// The sizes are written once the track is closed.
+	if _, err := file.Write(make([]byte, 44)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &track{file: file}, nil
+}
+
+func (t *track) write(samples []int16) error {
+	t.samples += int64(len(samples))
+	return binary.Write(t.file, binary.LittleEndian, samples)
+}
+
+func (t *track) close() error {
+	dataSize := uint32(t.samples * 2)
+	header := struct {
+		RIFF          [4]byte
+		Size          uint32
+		WAVE, Fmt     [4]byte
+		FmtSize       uint32
+		Format        uint16
+		Channels      uint16
+		SampleRate    uint32
+		ByteRate      uint32
+		BlockAlign    uint16
+		BitsPerSample uint16
+		Data          [4]byte
+		DataSize      uint32
+	}{
+		RIFF:          [4]byte{'R', 'I', 'F', 'F'},
+		Size:          36 + dataSize,
+		WAVE:          [4]byte{'W', 'A', 'V', 'E'},
+		Fmt:           [4]byte{'f', 'm', 't', ' '},
+		FmtSize:       16,
+		Format:        1,
+		Channels:      1,
+		SampleRate:    gumble.AudioSampleRate,
+		ByteRate:      gumble.AudioSampleRate * 2,
+		BlockAlign:    2,
+		BitsPerSample: 16,
+		Data:          [4]byte{'d', 'a', 't', 'a'},
+		DataSize:      dataSize,
+	}
+	if _, err := t.file.Seek(0, 0); err != nil {
+		t.file.Close()
+		return err
+	}
+	if err := binary.Write(t.file, binary.LittleEndian, &header); err != nil {
+		t.file.Close()
+		return err
+	}
+	return t.file.Close()
+}
+
+// trackName returns the name of the user's track file.
+func trackName(user *gumble.User) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < ' ' {
+			return '_'
+		}
+		return r
+	}, user.Name)
+	if name == "" || name[0] == '.' {
+		name = "_" + name
+	}
+	return name + ".wav"
+}
+
+// meeting is a recording in progress.
+type meeting struct {
+	channel *gumble.Channel
+	dir     string
+	start   time.Time
+	// When someone last spoke.
+	lastActivity time.Time
+	tracks       map[*gumble.User]*track
+}
+
+type recorder struct {
+	baseDir  string
+	idleStop time.Duration
+
+	mu      sync.Mutex
+	meeting *meeting
+}
+
+// start starts recording the given channel.
+func (r *recorder) start(channel *gumble.Channel) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.meeting != nil {
+		return fmt.Errorf("already recording %s", r.meeting.channel.Name)
+	}
+	now := time.Now()
+	dir := filepath.Join(r.baseDir, now.Format("2006-01-02T15-04-05"))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	r.meeting = &meeting{
+		channel:      channel,
+		dir:          dir,
+		start:        now,
+		lastActivity: now,
+		tracks:       make(map[*gumble.User]*track),
+	}
+	return nil
+}
+
+// stop stops the recording, and returns its summary, or the empty string if
+// nothing is being recorded.
+func (r *recorder) stop() string {
+	r.mu.Lock()
+	m := r.meeting
+	r.meeting = nil
+	r.mu.Unlock()
+	if m == nil {
+		return ""
+	}
+
+	type entry struct {
+		name, file string
+		talked     time.Duration
+	}
+	var entries []entry
+	for user, t := range m.tracks {
+		t.talking.Stop()
+		if err := t.close(); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+		}
+		entries = append(entries, entry{user.Name, t.file.Name(), t.talked})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].talked > entries[j].talked })
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Recording of %s stopped after %s.", html.EscapeString(m.channel.Name), time.Since(m.start).Round(time.Second))
+	if len(entries) == 0 {
+		b.WriteString("<br>Nobody spoke.")
+	}
+	for _, e := range entries {
+		fmt.Fprintf(&b, "<br>%s (%s): %s", html.EscapeString(e.name), e.talked.Round(time.Second), html.EscapeString(e.file))
+	}
+	return b.String()
+}
+
+// idle returns true if nobody has spoken in the recorded channel for longer
+// than idleStop.
+func (r *recorder) idle() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.meeting != nil && r.idleStop > 0 && time.Since(r.meeting.lastActivity) > r.idleStop
+}
+
+// OnAudioStream implements gumble.AudioListener.
+func (r *recorder) OnAudioStream(e *gumble.AudioStreamEvent) {
+	go func() {
+		for packet := range e.C {
+			r.audio(e.User, packet)
+		}
+	}()
+}
+
+func (r *recorder) audio(user *gumble.User, packet *gumble.AudioPacket) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	m := r.meeting
+	if m == nil || user.Channel != m.channel {
+		return
+	}
+	t := m.tracks[user]
+	if t == nil {
+		var err error
+		t, err = createTrack(filepath.Join(m.dir, trackName(user)))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+			return
+		}
+		t.talking = time.AfterFunc(talkingTimeout, func() {
+			fmt.Printf("%s stopped talking\n", user.Name)
+		})
+		t.talking.Stop()
+		m.tracks[user] = t
+	}
+
+	now := time.Now()
+	if !t.talking.Stop() {
+		fmt.Printf("%s started talking\n", user.Name)
+	}
+	t.talking.Reset(talkingTimeout)
+	m.lastActivity = now
+
+	// Pad the track with the silence since the user last spoke, so that
+	// every track starts when the recording started.
+	position := int64(now.Sub(m.start)) * gumble.AudioSampleRate / int64(time.Second)
+	if gap := position - t.samples - int64(len(packet.AudioBuffer)); gap > int64(gumble.AudioDefaultFrameSize) {
+		t.write(make([]int16, gap))
+	}
+	if err := t.write(packet.AudioBuffer); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+	}
+	t.talked += time.Duration(len(packet.AudioBuffer)) * time.Second / gumble.AudioSampleRate
+}
+
+func main() {
+	dir := flag.String("dir", "recordings", "directory in which the recordings are saved")
+	idleStop := flag.Duration("idle-stop", 10*time.Minute, "stop recording once nobody has spoken for this long (0 to disable)")
+
+	r := &recorder{}
+	var idleTicker *time.Ticker
+
+	gumbleutil.Main(gumbleutil.Listener{
+		Connect: func(e *gumble.ConnectEvent) {
+			r.baseDir = *dir
+			r.idleStop = *idleStop
+			e.Client.AttachAudio(r)
+
+			idleTicker = time.NewTicker(time.Minute)
+			client := e.Client
+			go func() {
+				for range idleTicker.C {
+					if !r.idle() {
+						continue
+					}
+					if summary := r.stop(); summary != "" {
+						client.Do(func() {
+							client.Self.Channel.Send("Nobody is speaking. "+summary, false)
+						})
+					}
+				}
+			}()
+		},
+		TextMessage: func(e *gumble.TextMessageEvent) {
+			if e.Sender == nil {
+				return
+			}
+			switch strings.TrimSpace(gumbleutil.PlainText(&e.TextMessage)) {
+			case "!record":
+				channel := e.Sender.Channel
+				if err := r.start(channel); err != nil {
+					e.Sender.Send(err.Error())
+					return
+				}
+				if e.Client.Self.Channel != channel {
+					e.Client.Self.Move(channel)
+				}
+				channel.Send("Recording started.", false)
+			case "!stop":
+				if summary := r.stop(); summary != "" {
+					e.Client.Self.Channel.Send(summary, false)
+				} else {
+					e.Sender.Send("Nothing is being recorded.")
+				}
+			}
+		},
+		UserChange: func(e *gumble.UserChangeEvent) {
+			if e.Type.Has(gumble.UserChangeChannel) && e.User == e.Client.Self {
+				// The bot was moved away from the meeting.
+				r.mu.Lock()
+				moved := r.meeting != nil && e.User.Channel != r.meeting.channel
+				r.mu.Unlock()
+				if moved {
+					if summary := r.stop(); summary != "" {
+						e.User.Channel.Send(summary, false)
+					}
+				}
+			}
+		},
+		Disconnect: func(e *gumble.DisconnectEvent) {
+			if idleTicker != nil {
+				idleTicker.Stop()
+			}
+			r.stop()
+		},
+	})
+}
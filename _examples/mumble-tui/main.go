@@ -0,0 +1,371 @@
+// mumble-tui is a minimal terminal Mumble client. It shows the server's
+// channel tree with the users in each channel, and the last text messages.
+//
+// Keys:
+//  up/down, k/j  select a channel or user
+//  enter         join the selected channel
+//  space         start/stop talking (terminals do not report key releases,
+//                so push-to-talk is a toggle)
+//  + / -         raise/lower the local volume of the selected user
+//  ] / [         raise/lower the microphone volume
+//  m / d         toggle self-mute / self-deafen
+//  t             write a text message to the selected channel or user
+//  q             quit
+package main // import "github.com/bmmcginty/gumble/_examples/mumble-tui"
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleopenal"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	_ "github.com/bmmcginty/gumble/opus"
+	"golang.org/x/term"
+)
+
+// maxMessages is the number of text messages kept on screen.
+const maxMessages = 8
+
+// row is an entry of the channel tree, either a channel or a user.
+type row struct {
+	depth   int
+	channel *gumble.Channel
+	user    *gumble.User
+}
+
+type ui struct {
+	client *gumble.Client
+	stream *gumbleopenal.Stream
+
+	mu       sync.Mutex
+	selected int
+	messages []string
+	status   string
+	talking  bool
+	// The text message being written, and whether one is being written.
+	input   []rune
+	writing bool
+
+	redraw chan struct{}
+}
+
+func (u *ui) addMessage(format string, args ...interface{}) {
+	u.mu.Lock()
+	u.messages = append(u.messages, fmt.Sprintf(format, args...))
+	if len(u.messages) > maxMessages {
+		u.messages = u.messages[len(u.messages)-maxMessages:]
+	}
+	u.mu.Unlock()
+	u.requestRedraw()
+}
+
+func (u *ui) requestRedraw() {
+	select {
+	case u.redraw <- struct{}{}:
+	default:
+	}
+}
+
+// rows returns the channel tree, channels sorted by position and name, the
+// users of each channel below it. It must be called inside of Client.Do.
+func (u *ui) rows() []row {
+	var rows []row
+	var walk func(channel *gumble.Channel, depth int)
+	walk = func(channel *gumble.Channel, depth int) {
+		rows = append(rows, row{depth: depth, channel: channel})
+		users := make([]*gumble.User, 0, len(channel.Users))
+		for _, user := range channel.Users {
+			users = append(users, user)
+		}
+		sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+		for _, user := range users {
+			rows = append(rows, row{depth: depth + 1, user: user})
+		}
+		children := make([]*gumble.Channel, 0, len(channel.Children))
+		for _, child := range channel.Children {
+			children = append(children, child)
+		}
+		sort.Slice(children, func(i, j int) bool {
+			if children[i].Position != children[j].Position {
+				return children[i].Position < children[j].Position
+			}
+			return children[i].Name < children[j].Name
+		})
+		for _, child := range children {
+			walk(child, depth+1)
+		}
+	}
+	if root := u.client.Channels[0]; root != nil {
+		walk(root, 0)
+	}
+	return rows
+}
+
+func (u *ui) draw() {
+	var b strings.Builder
+	b.WriteString("\x1b[H\x1b[2J")
+	u.client.Do(func() {
+		rows := u.rows()
+		u.mu.Lock()
+		defer u.mu.Unlock()
+		if u.selected >= len(rows) {
+			u.selected = len(rows) - 1
+		}
+		if u.selected < 0 {
+			u.selected = 0
+		}
+		for i, r := range rows {
+			cursor := "  "
+			if i == u.selected {
+				cursor = "> "
+			}
+			b.WriteString(cursor + strings.Repeat("  ", r.depth))
+			if r.channel != nil {
+				fmt.Fprintf(&b, "[%s]", r.channel.Name)
+			} else {
+				b.WriteString(r.user.Name)
+				if r.user == u.client.Self {
+					b.WriteString(" (you)")
+				}
+				if r.user.SelfMuted || r.user.Muted {
+					b.WriteString(" muted")
+				}
+				if r.user.SelfDeafened || r.user.Deafened {
+					b.WriteString(" deafened")
+				}
+				if volume := r.user.LocalVolume(); volume != 1 {
+					fmt.Fprintf(&b, " vol %.0f%%", volume*100)
+				}
+			}
+			b.WriteString("\r\n")
+		}
+
+		b.WriteString("\r\n")
+		for _, message := range u.messages {
+			b.WriteString(message + "\r\n")
+		}
+		b.WriteString("\r\n")
+		talking := "idle"
+		if u.talking {
+			talking = "TALKING"
+		}
+		fmt.Fprintf(&b, "%s | mic %.0f%% | %s\r\n", talking, u.stream.GetMicVolume()*100, u.status)
+		if u.writing {
+			b.WriteString("message: " + string(u.input))
+		}
+	})
+	os.Stdout.WriteString(b.String())
+}
+
+// selection returns the selected row. It must be called inside of Client.Do.
+func (u *ui) selection() row {
+	rows := u.rows()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.selected < 0 || u.selected >= len(rows) {
+		return row{}
+	}
+	return rows[u.selected]
+}
+
+func (u *ui) setStatus(status string) {
+	u.mu.Lock()
+	u.status = status
+	u.mu.Unlock()
+}
+
+// key handles a key press. It returns false when the program should exit.
+func (u *ui) key(k string) bool {
+	u.mu.Lock()
+	writing := u.writing
+	u.mu.Unlock()
+	if writing {
+		u.writeKey(k)
+		return true
+	}
+
+	switch k {
+	case "q":
+		return false
+	case "\x1b[A", "k":
+		u.mu.Lock()
+		u.selected--
+		u.mu.Unlock()
+	case "\x1b[B", "j":
+		u.mu.Lock()
+		u.selected++
+		u.mu.Unlock()
+	case "\r":
+		var channel *gumble.Channel
+		u.client.Do(func() {
+			channel = u.selection().channel
+		})
+		if channel != nil {
+			if err := channel.Join(); err != nil {
+				u.setStatus(err.Error())
+			}
+		}
+	case " ":
+		u.mu.Lock()
+		u.talking = !u.talking
+		talking := u.talking
+		u.mu.Unlock()
+		var err error
+		if talking {
+			err = u.stream.StartSource(nil)
+		} else {
+			err = u.stream.StopSource()
+		}
+		if err != nil {
+			u.setStatus(err.Error())
+		}
+	case "+", "-":
+		u.client.Do(func() {
+			user := u.selection().user
+			if user == nil {
+				return
+			}
+			change := float32(0.1)
+			if k == "-" {
+				change = -change
+			}
+			user.SetLocalVolume(user.LocalVolume() + change)
+		})
+	case "]":
+		u.stream.SetMicVolume(0.1, true)
+	case "[":
+		u.stream.SetMicVolume(-0.1, true)
+	case "m", "d":
+		u.client.Do(func() {
+			self := u.client.Self
+			if k == "m" {
+				self.SetSelfMuted(!self.SelfMuted)
+			} else {
+				self.SetSelfDeafened(!self.SelfDeafened)
+			}
+		})
+	case "t":
+		u.mu.Lock()
+		u.writing = true
+		u.input = nil
+		u.mu.Unlock()
+	}
+	return true
+}
+
+// writeKey handles a key press while a text message is being written.
+func (u *ui) writeKey(k string) {
+	u.mu.Lock()
+	var message string
+	switch k {
+	case "\x1b":
+		u.writing = false
+	case "\x7f", "\b":
+		if len(u.input) > 0 {
+			u.input = u.input[:len(u.input)-1]
+		}
+	case "\r":
+		u.writing = false
+		message = string(u.input)
+	default:
+		if !strings.HasPrefix(k, "\x1b") {
+			u.input = append(u.input, []rune(k)...)
+		}
+	}
+	u.mu.Unlock()
+	if message == "" {
+		return
+	}
+
+	u.client.Do(func() {
+		target := u.selection()
+		switch {
+		case target.user != nil:
+			target.user.Send(message)
+		case target.channel != nil:
+			target.channel.Send(message, false)
+		}
+	})
+	u.addMessage("<%s> %s", u.client.Self.Name, message)
+}
+
+// run reads key presses and redraws the screen until q is pressed.
+func (u *ui) run() {
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+		u.client.Disconnect()
+		return
+	}
+	defer term.Restore(int(os.Stdin.Fd()), state)
+
+	keys := make(chan string)
+	go func() {
+		buffer := make([]byte, 16)
+		for {
+			n, err := os.Stdin.Read(buffer)
+			if err != nil {
+				close(keys)
+				return
+			}
+			keys <- string(buffer[:n])
+		}
+	}()
+
+	u.draw()
+	for {
+		select {
+		case k, ok := <-keys:
+			if !ok || !u.key(k) {
+				os.Stdout.WriteString("\x1b[H\x1b[2J")
+				u.client.Disconnect()
+				return
+			}
+		case <-u.redraw:
+		}
+		u.draw()
+	}
+}
+
+func main() {
+	u := &ui{
+		redraw: make(chan struct{}, 1),
+	}
+
+	gumbleutil.Main(gumbleutil.ListenerFunc(func(e interface{}) {
+		u.requestRedraw()
+	}), gumbleutil.Listener{
+		Connect: func(e *gumble.ConnectEvent) {
+			stream, err := gumbleopenal.New(e.Client, nil, nil, false)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+				e.Client.Disconnect()
+				return
+			}
+			stream.AttachStream(e.Client)
+			u.client = e.Client
+			u.stream = stream
+			u.setStatus("connected to " + e.Client.Config.Address)
+			go u.run()
+		},
+		TextMessage: func(e *gumble.TextMessageEvent) {
+			sender := "server"
+			if e.Sender != nil {
+				sender = e.Sender.Name
+			}
+			u.addMessage("<%s> %s", sender, gumbleutil.PlainText(&e.TextMessage))
+		},
+		PermissionDenied: func(e *gumble.PermissionDeniedEvent) {
+			u.setStatus("permission denied: " + e.String)
+		},
+		Disconnect: func(e *gumble.DisconnectEvent) {
+			if u.stream != nil {
+				u.stream.Destroy()
+			}
+		},
+	})
+}
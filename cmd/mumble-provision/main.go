@@ -0,0 +1,70 @@
+package main // import "github.com/bmmcginty/gumble/cmd/mumble-provision"
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleprovision"
+	"github.com/bmmcginty/gumble/gumbleutil"
+)
+
+func main() {
+	specFile := flag.String("spec", "", "YAML description of the server's channels, groups and ACLs")
+	dryRun := flag.Bool("dry-run", false, "print the planned changes without applying them")
+	export := flag.Bool("export", false, "print the description of the server instead of converging it")
+	timeout := flag.Duration("timeout", time.Minute, "maximum time to converge the server")
+	flag.Parse()
+	var spec *gumbleprovision.Spec
+	if !*export {
+		if *specFile == "" {
+			fmt.Fprintf(os.Stderr, "%s: no -spec given\n", os.Args[0])
+			os.Exit(1)
+		}
+		var err error
+		if spec, err = gumbleprovision.LoadFile(*specFile); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+			os.Exit(1)
+		}
+	}
+
+	status := 0
+	gumbleutil.Main(gumbleutil.Listener{
+		Connect: func(e *gumble.ConnectEvent) {
+			// Planning waits for the server's replies, which are not
+			// processed while a listener runs.
+			go func() {
+				ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+				defer cancel()
+				var err error
+				if *export {
+					err = exportSpec(ctx, e.Client)
+				} else {
+					_, err = gumbleprovision.Converge(ctx, e.Client, spec, *dryRun, os.Stdout)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+					status = 1
+				}
+				e.Client.Disconnect()
+			}()
+		},
+	})
+	os.Exit(status)
+}
+
+func exportSpec(ctx context.Context, client *gumble.Client) error {
+	spec, err := gumbleprovision.Export(ctx, client)
+	if err != nil {
+		return err
+	}
+	data, err := spec.Marshal()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
@@ -811,8 +811,17 @@ type UserState struct {
 	// True if the user is a priority speaker.
 	PrioritySpeaker *bool `protobuf:"varint,18,opt,name=priority_speaker,json=prioritySpeaker" json:"priority_speaker,omitempty"`
 	// True if the user is currently recording.
-	Recording        *bool  `protobuf:"varint,19,opt,name=recording" json:"recording,omitempty"`
-	XXX_unrecognized []byte `json:"-"`
+	Recording *bool `protobuf:"varint,19,opt,name=recording" json:"recording,omitempty"`
+	// A list of temporary access tokens to be respected when processing this
+	// request.
+	TemporaryAccessTokens []string `protobuf:"bytes,20,rep,name=temporary_access_tokens,json=temporaryAccessTokens" json:"temporary_access_tokens,omitempty"`
+	// A list of channels the user wants to start listening to.
+	ListeningChannelAdd []uint32 `protobuf:"varint,21,rep,name=listening_channel_add,json=listeningChannelAdd" json:"listening_channel_add,omitempty"`
+	// A list of channels the user does no longer want to listen to.
+	ListeningChannelRemove []uint32 `protobuf:"varint,22,rep,name=listening_channel_remove,json=listeningChannelRemove" json:"listening_channel_remove,omitempty"`
+	// A list of volume adjustments the user has applied to listeners.
+	ListeningVolumeAdjustment []*UserState_VolumeAdjustment `protobuf:"bytes,23,rep,name=listening_volume_adjustment,json=listeningVolumeAdjustment" json:"listening_volume_adjustment,omitempty"`
+	XXX_unrecognized          []byte                        `json:"-"`
 }
 
 func (m *UserState) Reset()                    { *m = UserState{} }
@@ -953,6 +962,61 @@ func (m *UserState) GetRecording() bool {
 	return false
 }
 
+func (m *UserState) GetTemporaryAccessTokens() []string {
+	if m != nil {
+		return m.TemporaryAccessTokens
+	}
+	return nil
+}
+
+func (m *UserState) GetListeningChannelAdd() []uint32 {
+	if m != nil {
+		return m.ListeningChannelAdd
+	}
+	return nil
+}
+
+func (m *UserState) GetListeningChannelRemove() []uint32 {
+	if m != nil {
+		return m.ListeningChannelRemove
+	}
+	return nil
+}
+
+func (m *UserState) GetListeningVolumeAdjustment() []*UserState_VolumeAdjustment {
+	if m != nil {
+		return m.ListeningVolumeAdjustment
+	}
+	return nil
+}
+
+type UserState_VolumeAdjustment struct {
+	ListeningChannel *uint32  `protobuf:"varint,1,opt,name=listening_channel,json=listeningChannel" json:"listening_channel,omitempty"`
+	VolumeAdjustment *float32 `protobuf:"fixed32,2,opt,name=volume_adjustment,json=volumeAdjustment" json:"volume_adjustment,omitempty"`
+	XXX_unrecognized []byte   `json:"-"`
+}
+
+func (m *UserState_VolumeAdjustment) Reset()         { *m = UserState_VolumeAdjustment{} }
+func (m *UserState_VolumeAdjustment) String() string { return proto.CompactTextString(m) }
+func (*UserState_VolumeAdjustment) ProtoMessage()    {}
+func (*UserState_VolumeAdjustment) Descriptor() ([]byte, []int) {
+	return fileDescriptor0, []int{9, 0}
+}
+
+func (m *UserState_VolumeAdjustment) GetListeningChannel() uint32 {
+	if m != nil && m.ListeningChannel != nil {
+		return *m.ListeningChannel
+	}
+	return 0
+}
+
+func (m *UserState_VolumeAdjustment) GetVolumeAdjustment() float32 {
+	if m != nil && m.VolumeAdjustment != nil {
+		return *m.VolumeAdjustment
+	}
+	return 0
+}
+
 // Relays information on the bans. The client may send the BanList message to
 // either modify the list of bans or query them from the server. The server
 // sends this list only after a client queries for it.
@@ -2171,6 +2235,7 @@ func init() {
 	proto.RegisterType((*ChannelState)(nil), "MumbleProto.ChannelState")
 	proto.RegisterType((*UserRemove)(nil), "MumbleProto.UserRemove")
 	proto.RegisterType((*UserState)(nil), "MumbleProto.UserState")
+	proto.RegisterType((*UserState_VolumeAdjustment)(nil), "MumbleProto.UserState.VolumeAdjustment")
 	proto.RegisterType((*BanList)(nil), "MumbleProto.BanList")
 	proto.RegisterType((*BanList_BanEntry)(nil), "MumbleProto.BanList.BanEntry")
 	proto.RegisterType((*TextMessage)(nil), "MumbleProto.TextMessage")
@@ -2202,158 +2267,164 @@ func init() {
 func init() { proto.RegisterFile("Mumble.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 2445 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xdc, 0x58, 0xcd, 0x72, 0x2c, 0x37,
-	0x15, 0x4e, 0xcf, 0xff, 0x9c, 0x99, 0xb1, 0xdb, 0xba, 0x26, 0x19, 0x9c, 0xdc, 0xc4, 0xe9, 0x0b,
-	0x89, 0x03, 0x29, 0x13, 0x5c, 0xd9, 0x24, 0x55, 0x2c, 0x7c, 0x7d, 0x09, 0x76, 0x61, 0xdf, 0x98,
-	0xb6, 0x73, 0xb3, 0x60, 0xd1, 0xc8, 0xd3, 0xf2, 0x4c, 0xe3, 0x9e, 0x56, 0xa7, 0xa5, 0xf6, 0xbd,
-	0x53, 0xc5, 0x12, 0xd8, 0x92, 0x2a, 0x16, 0xec, 0x78, 0x00, 0x8a, 0x4a, 0x15, 0x0f, 0xc0, 0x86,
-	0x27, 0xe0, 0x05, 0xd8, 0xb0, 0x65, 0x47, 0x15, 0x7b, 0xea, 0x1c, 0xa9, 0xff, 0xec, 0xc9, 0x0f,
-	0x5b, 0x36, 0x33, 0x3a, 0x9f, 0x3e, 0x49, 0xa7, 0x8f, 0xce, 0x8f, 0x24, 0x18, 0x9f, 0xe5, 0xcb,
-	0xab, 0x58, 0xec, 0xa7, 0x99, 0xd4, 0x92, 0x8d, 0x8c, 0x74, 0x8e, 0x82, 0x17, 0x43, 0xff, 0x99,
-	0xc8, 0x54, 0x24, 0x13, 0x36, 0x85, 0xfe, 0xad, 0x69, 0x4e, 0x9d, 0x5d, 0x67, 0x6f, 0xe2, 0x17,
-	0x22, 0xf6, 0x64, 0x22, 0x16, 0x5c, 0x89, 0x69, 0x6b, 0xd7, 0xd9, 0x1b, 0xfa, 0x85, 0xc8, 0x36,
-	0xa0, 0x25, 0xd5, 0xb4, 0x4d, 0x60, 0x4b, 0x2a, 0xf6, 0x10, 0x40, 0xaa, 0xa0, 0x98, 0xa6, 0x43,
-	0xf8, 0x50, 0x2a, 0xbb, 0x84, 0xf7, 0x08, 0x86, 0x9f, 0x3c, 0x39, 0xbf, 0xcc, 0x93, 0x44, 0xc4,
-	0xec, 0x65, 0xe8, 0xa5, 0x7c, 0x76, 0x23, 0xf4, 0xd4, 0xd9, 0x6d, 0xed, 0x8d, 0x7d, 0x2b, 0x79,
-	0x7f, 0x74, 0x60, 0x7c, 0x98, 0xeb, 0x85, 0x48, 0x74, 0x34, 0xe3, 0x5a, 0xb0, 0x1d, 0x18, 0xe4,
-	0x4a, 0x64, 0x09, 0x5f, 0x0a, 0xd2, 0x6c, 0xe8, 0x97, 0x32, 0xf6, 0xa5, 0x5c, 0xa9, 0xe7, 0x32,
-	0x0b, 0xad, 0x6e, 0xa5, 0x8c, 0x0b, 0x68, 0x79, 0x23, 0x12, 0x54, 0xb0, 0xbd, 0x37, 0xf4, 0xad,
-	0xc4, 0x1e, 0xc1, 0x64, 0x26, 0x62, 0x5d, 0xa8, 0xa9, 0xa6, 0x9d, 0xdd, 0xf6, 0x5e, 0xd7, 0x1f,
-	0x23, 0x68, 0x35, 0x55, 0xec, 0xdb, 0xd0, 0x91, 0x69, 0xae, 0xa6, 0xdd, 0x5d, 0x67, 0x6f, 0xf0,
-	0x61, 0xf7, 0x9a, 0xc7, 0x4a, 0xf8, 0x04, 0x79, 0x7f, 0x6b, 0x41, 0xe7, 0x3c, 0x4a, 0xe6, 0xec,
-	0x35, 0x18, 0xea, 0x68, 0x29, 0x94, 0xe6, 0xcb, 0x94, 0x34, 0xeb, 0xf8, 0x15, 0xc0, 0x18, 0x74,
-	0xe6, 0x52, 0x1a, 0xb5, 0x26, 0x3e, 0xb5, 0x11, 0x8b, 0xb9, 0x16, 0x64, 0xb1, 0x89, 0x4f, 0x6d,
-	0xc2, 0xa4, 0xd2, 0x64, 0x2d, 0xc4, 0xa4, 0xd2, 0xa8, 0x7a, 0x26, 0xd4, 0x2a, 0x99, 0xd1, 0xfa,
-	0x13, 0xdf, 0x4a, 0xec, 0x0d, 0x18, 0xe5, 0x61, 0x1a, 0x18, 0x4b, 0xa9, 0x69, 0x8f, 0x3a, 0x21,
-	0x0f, 0xd3, 0x73, 0x83, 0x20, 0x41, 0xcf, 0x2a, 0x42, 0xdf, 0x10, 0xf4, 0xac, 0x24, 0xec, 0xc2,
-	0x98, 0x66, 0x88, 0x92, 0x79, 0xc0, 0x6f, 0xe7, 0xd3, 0xc1, 0xae, 0xb3, 0xd7, 0x32, 0x53, 0x44,
-	0xc9, 0xfc, 0xf0, 0x76, 0xde, 0x60, 0xdc, 0xf2, 0x6c, 0x3a, 0x6c, 0x30, 0x9e, 0xf1, 0x0c, 0x19,
-	0xb4, 0x48, 0x31, 0x07, 0x18, 0x06, 0xae, 0x52, 0xcd, 0x51, 0x32, 0x70, 0x8e, 0x51, 0x83, 0xf1,
-	0x8c, 0x67, 0xde, 0x6f, 0x5a, 0xd0, 0xf3, 0xc5, 0x2f, 0xc5, 0x4c, 0xb3, 0x03, 0xe8, 0xe8, 0x55,
-	0x6a, 0xf6, 0x76, 0xe3, 0xe0, 0xf5, 0xfd, 0x9a, 0x7f, 0xee, 0x1b, 0x8a, 0xfd, 0xbb, 0x5c, 0xa5,
-	0xc2, 0x27, 0xae, 0x31, 0x10, 0x57, 0x32, 0xb1, 0xbb, 0x6e, 0x25, 0xef, 0x0b, 0x07, 0xa0, 0x22,
-	0xb3, 0x01, 0x74, 0x9e, 0xca, 0x44, 0xb8, 0x2f, 0x31, 0x17, 0xc6, 0x9f, 0x66, 0x32, 0x99, 0xdb,
-	0x0d, 0x76, 0x1d, 0xf6, 0x00, 0x36, 0x4f, 0x92, 0x5b, 0x1e, 0x47, 0xe1, 0x27, 0xd6, 0x9b, 0xdc,
-	0x16, 0xdb, 0x84, 0x11, 0xd1, 0x10, 0x3a, 0xff, 0xd4, 0x6d, 0xb3, 0x2d, 0x98, 0x10, 0x70, 0x21,
-	0xb2, 0x5b, 0x82, 0x3a, 0x08, 0x15, 0x23, 0x4e, 0x92, 0x4f, 0x94, 0x70, 0xbb, 0x6c, 0x03, 0xc0,
-	0x10, 0x3e, 0xca, 0xe3, 0xd8, 0xed, 0x21, 0xe5, 0xa9, 0x3c, 0x12, 0x99, 0x8e, 0xae, 0xc9, 0x87,
-	0xdd, 0x3e, 0xfb, 0x16, 0x6c, 0xd5, 0xbc, 0x5a, 0x66, 0x1f, 0xf1, 0x28, 0x76, 0x07, 0xde, 0xe7,
-	0x4e, 0x31, 0xf4, 0x02, 0x37, 0x78, 0x0a, 0x7d, 0x25, 0x54, 0x3d, 0x08, 0xad, 0x88, 0x5e, 0xbb,
-	0xe4, 0x2f, 0x82, 0x2b, 0x9e, 0x84, 0xcf, 0xa3, 0x50, 0x2f, 0xac, 0x5f, 0x8d, 0x97, 0xfc, 0xc5,
-	0xe3, 0x02, 0x63, 0x6f, 0xc2, 0xf8, 0xb9, 0x88, 0x67, 0x72, 0x29, 0x02, 0x2d, 0x5e, 0x68, 0x1b,
-	0x99, 0x23, 0x8b, 0x5d, 0x8a, 0x17, 0x9a, 0xed, 0xc2, 0x28, 0x15, 0xd9, 0x32, 0x52, 0x85, 0xef,
-	0xa3, 0xdb, 0xd6, 0x21, 0x6f, 0x1f, 0x26, 0x47, 0x0b, 0x8e, 0x31, 0xea, 0x8b, 0xa5, 0xbc, 0x15,
-	0x18, 0xd5, 0x33, 0x03, 0x04, 0x51, 0x48, 0xd1, 0x3a, 0xf1, 0x87, 0x16, 0x39, 0x09, 0xbd, 0x7f,
-	0xb4, 0x60, 0x6c, 0x07, 0x5c, 0x68, 0xf4, 0xe8, 0xbb, 0x7c, 0xa7, 0xc1, 0x37, 0x81, 0x9f, 0x89,
-	0x44, 0xdb, 0x4f, 0xb0, 0x12, 0x06, 0x02, 0xc5, 0xb8, 0x51, 0x9a, 0xda, 0x6c, 0x1b, 0xba, 0x71,
-	0x94, 0xdc, 0x98, 0x18, 0x9d, 0xf8, 0x46, 0xc0, 0x6f, 0x08, 0x85, 0x9a, 0x65, 0x51, 0xaa, 0xd1,
-	0x52, 0x5d, 0xf3, 0x95, 0x35, 0x88, 0xbd, 0x0a, 0x43, 0xa2, 0x06, 0x3c, 0x0c, 0xa7, 0x3d, 0x1a,
-	0x3b, 0x20, 0xe0, 0x30, 0x0c, 0xd1, 0x4a, 0xa6, 0x33, 0xa3, 0xef, 0x9b, 0xf6, 0xa9, 0x7f, 0x44,
-	0x98, 0xfd, 0xe4, 0x47, 0x30, 0xd4, 0x62, 0x99, 0xca, 0x8c, 0x67, 0x2b, 0x8a, 0x91, 0x32, 0x07,
-	0x54, 0x38, 0x7b, 0x08, 0x83, 0x54, 0xaa, 0x88, 0x74, 0xc0, 0x28, 0xe9, 0x7e, 0xe8, 0xbc, 0xe7,
-	0x97, 0x10, 0x7b, 0x07, 0xdc, 0x9a, 0x4a, 0xc1, 0x82, 0xab, 0x05, 0x85, 0xca, 0xd8, 0xdf, 0xac,
-	0xe1, 0xc7, 0x5c, 0x2d, 0x50, 0x5d, 0xdc, 0x5c, 0x4c, 0x6b, 0x8a, 0x82, 0x65, 0xe2, 0x0f, 0x96,
-	0xfc, 0x05, 0xba, 0x99, 0xf2, 0xae, 0x01, 0xb0, 0x61, 0x35, 0x6b, 0x78, 0x48, 0xab, 0xee, 0x21,
-	0xdb, 0xd0, 0xe5, 0x33, 0x2d, 0x33, 0x6b, 0x56, 0x23, 0xd4, 0x22, 0xa5, 0x5d, 0x8f, 0x14, 0xe6,
-	0x42, 0xfb, 0x8a, 0x9b, 0x1c, 0x3d, 0xf0, 0xb1, 0xe9, 0xfd, 0xb9, 0x03, 0x43, 0x5c, 0xc8, 0x6c,
-	0xe2, 0x97, 0x7b, 0xe2, 0xfa, 0x75, 0xd6, 0xed, 0xde, 0x2b, 0xd0, 0xc7, 0x4f, 0x42, 0x2f, 0x30,
-	0xd9, 0xad, 0x87, 0xe2, 0x49, 0x78, 0xc7, 0x43, 0xba, 0x77, 0x3d, 0x84, 0x41, 0x67, 0x99, 0x6b,
-	0x41, 0xf9, 0x6d, 0xe0, 0x53, 0x1b, 0xb1, 0x50, 0xf0, 0x6b, 0x4a, 0x69, 0x03, 0x9f, 0xda, 0x98,
-	0xfd, 0x55, 0x9e, 0xa6, 0x99, 0x50, 0xca, 0x6c, 0x92, 0x5f, 0xca, 0x68, 0x52, 0x25, 0xe2, 0xeb,
-	0x80, 0x26, 0x1a, 0xda, 0x4e, 0x11, 0x5f, 0x9f, 0xe1, 0x64, 0x45, 0x27, 0xcd, 0x08, 0x55, 0xe7,
-	0x13, 0x9c, 0x75, 0x0a, 0x7d, 0x0c, 0x9e, 0x3c, 0x13, 0xb4, 0x15, 0x63, 0xbf, 0x10, 0xd9, 0x77,
-	0x61, 0x23, 0x8d, 0xf3, 0x79, 0x94, 0x04, 0x33, 0x99, 0x50, 0x80, 0x8d, 0x89, 0x30, 0x31, 0xe8,
-	0x91, 0x01, 0xd9, 0xdb, 0xb0, 0x69, 0x69, 0x51, 0x88, 0xf1, 0xae, 0x57, 0xd3, 0x09, 0x59, 0xc5,
-	0x8e, 0x3e, 0xb1, 0x28, 0xae, 0x34, 0x93, 0xcb, 0x25, 0x86, 0xc2, 0x86, 0x29, 0xac, 0x56, 0xc4,
-	0xaf, 0x25, 0x7f, 0xd9, 0x34, 0xd6, 0xc4, 0x36, 0xba, 0xad, 0xed, 0x36, 0xbe, 0xe4, 0xd2, 0xda,
-	0x23, 0x8b, 0x1d, 0x5b, 0x8a, 0xd5, 0xd5, 0x50, 0xb6, 0x0c, 0xc5, 0x62, 0x44, 0x79, 0x07, 0xdc,
-	0x34, 0x8b, 0x64, 0x16, 0xe9, 0x55, 0xa0, 0x52, 0xc1, 0x6f, 0x44, 0x36, 0x65, 0x64, 0x81, 0xcd,
-	0x02, 0xbf, 0x30, 0x30, 0xd6, 0xb7, 0x4c, 0xcc, 0x64, 0x16, 0x46, 0xc9, 0x7c, 0xfa, 0x80, 0x38,
-	0x15, 0xe0, 0xfd, 0xb6, 0x05, 0xfd, 0xc7, 0x3c, 0x39, 0x8d, 0x94, 0x66, 0x3f, 0x84, 0xce, 0x15,
-	0x4f, 0xd4, 0xd4, 0xd9, 0x6d, 0xef, 0x8d, 0x0e, 0x1e, 0x36, 0x52, 0xb8, 0xe5, 0xe0, 0xff, 0x8f,
-	0x13, 0x9d, 0xad, 0x7c, 0xa2, 0xb2, 0x57, 0xa1, 0xfb, 0x59, 0x2e, 0xb2, 0x15, 0x79, 0x51, 0x19,
-	0x5d, 0x06, 0xdb, 0xf9, 0x93, 0x03, 0x83, 0x82, 0x8f, 0x56, 0xe2, 0x61, 0x48, 0x9b, 0x6c, 0x4e,
-	0x0a, 0x85, 0x48, 0x7e, 0xc2, 0xd5, 0xcd, 0xb4, 0x45, 0x81, 0x40, 0xed, 0xb5, 0x7e, 0x58, 0x58,
-	0xb3, 0x53, 0xb3, 0x66, 0x15, 0x17, 0xdd, 0x46, 0x5c, 0x6c, 0x43, 0x57, 0x69, 0x9e, 0x69, 0x72,
-	0xbe, 0xa1, 0x6f, 0x04, 0xf4, 0xb4, 0x30, 0xcf, 0x38, 0x85, 0xba, 0x29, 0xaa, 0xa5, 0xec, 0xfd,
-	0xce, 0x81, 0x11, 0xa6, 0xd6, 0x33, 0xa1, 0x14, 0x9f, 0x8b, 0x2a, 0x3e, 0x9c, 0x7a, 0x7c, 0xd4,
-	0xe2, 0xa9, 0x45, 0xf9, 0xa6, 0x8c, 0xa7, 0x66, 0x30, 0xb4, 0xa9, 0xb3, 0x16, 0x0c, 0xaf, 0x40,
-	0x5f, 0x67, 0x42, 0x98, 0x20, 0xc2, 0xbe, 0x1e, 0x8a, 0x27, 0x21, 0xce, 0xb8, 0x34, 0x4b, 0x4e,
-	0xbb, 0xbb, 0x2d, 0xf4, 0x1e, 0x2b, 0x7a, 0xbf, 0x6f, 0x83, 0x7b, 0x5e, 0x66, 0xf4, 0x27, 0x22,
-	0x89, 0x44, 0xc8, 0x5e, 0x07, 0xa8, 0xb2, 0xbc, 0xd5, 0xad, 0x86, 0xdc, 0x51, 0xa3, 0x75, 0x37,
-	0x26, 0x6b, 0xfa, 0xb7, 0x9b, 0xf9, 0xa0, 0xb2, 0x64, 0xa7, 0x61, 0xc9, 0x0f, 0x6d, 0x5d, 0xef,
-	0x52, 0x5d, 0x7f, 0xab, 0xe1, 0x14, 0x77, 0xb5, 0xdb, 0x7f, 0x22, 0x92, 0x55, 0xad, 0xbe, 0x17,
-	0xbb, 0xd8, 0xab, 0x76, 0xd1, 0xfb, 0xab, 0x03, 0x83, 0x82, 0x86, 0x95, 0x1d, 0x6d, 0xee, 0xbe,
-	0x84, 0xb5, 0xb7, 0x9a, 0xcd, 0x75, 0xd8, 0x04, 0x86, 0x17, 0x79, 0x2a, 0x32, 0x4c, 0x65, 0xa6,
-	0xa2, 0xdb, 0xe2, 0xf4, 0x14, 0x4b, 0x7c, 0x1b, 0x01, 0x1c, 0x79, 0x29, 0xe5, 0xa9, 0x4c, 0xe6,
-	0x6e, 0x87, 0xf5, 0xa1, 0x7d, 0xfc, 0xc1, 0x4f, 0xdd, 0x2e, 0xdb, 0x06, 0xf7, 0xb2, 0x48, 0xee,
-	0x76, 0x8c, 0xdb, 0x63, 0x2f, 0x03, 0x3b, 0xc3, 0xc9, 0x93, 0x79, 0xb3, 0xa0, 0x8f, 0x61, 0x80,
-	0x4b, 0xd0, 0xac, 0x83, 0xda, 0x32, 0x74, 0x04, 0x18, 0xe2, 0x81, 0xe3, 0xa9, 0x50, 0x3a, 0x4a,
-	0xe6, 0xa7, 0xd1, 0x32, 0xd2, 0x2e, 0x78, 0xbf, 0xee, 0x42, 0xfb, 0xf0, 0xe8, 0xf4, 0x6b, 0xca,
-	0x29, 0x7b, 0x1b, 0xc6, 0x51, 0xb2, 0x10, 0x59, 0xa4, 0x03, 0x3e, 0x8b, 0x95, 0x8d, 0x8f, 0x8e,
-	0xce, 0x72, 0xe1, 0x8f, 0x6c, 0xcf, 0xe1, 0x2c, 0x56, 0xec, 0x00, 0x7a, 0xf3, 0x4c, 0xe6, 0xa9,
-	0x39, 0xdf, 0x8e, 0x0e, 0x76, 0x1a, 0x16, 0x3e, 0x3c, 0x3a, 0xdd, 0x47, 0x8d, 0x7e, 0x82, 0x14,
-	0xdf, 0x32, 0xd9, 0xbb, 0xd0, 0xa1, 0x49, 0x3b, 0x34, 0x62, 0xba, 0x76, 0xc4, 0xe1, 0xd1, 0xa9,
-	0x4f, 0xac, 0x2a, 0x46, 0xbb, 0x6b, 0x62, 0xf4, 0x9f, 0x0e, 0x0c, 0xcb, 0x05, 0xca, 0x0d, 0x73,
-	0xc8, 0x13, 0x4d, 0xd8, 0x79, 0x30, 0xb4, 0xfa, 0x8a, 0xb0, 0xf1, 0x19, 0x15, 0xcc, 0x5e, 0x87,
-	0xbe, 0x15, 0xc8, 0xad, 0x0a, 0x46, 0x01, 0xb2, 0xb7, 0xa0, 0xf8, 0x66, 0x7e, 0x15, 0x0b, 0x53,
-	0xae, 0xee, 0x18, 0x03, 0x3b, 0xb0, 0x9c, 0x61, 0xa9, 0xef, 0x52, 0x84, 0x60, 0xd3, 0xb8, 0x25,
-	0xd5, 0x77, 0x53, 0xff, 0xad, 0xc4, 0xbe, 0x0f, 0x5b, 0xe5, 0xf2, 0xc1, 0x52, 0x2c, 0xaf, 0xb0,
-	0xe6, 0x9a, 0x23, 0x80, 0x5b, 0x76, 0x9c, 0x19, 0x7c, 0xe7, 0xef, 0x0e, 0xf4, 0xad, 0x4d, 0xd8,
-	0x23, 0x00, 0x9e, 0xa6, 0xf1, 0x2a, 0x58, 0x88, 0xcc, 0x9c, 0x56, 0xcb, 0xef, 0x21, 0xfc, 0x58,
-	0x64, 0xa2, 0x22, 0xa9, 0xfc, 0xaa, 0xb9, 0x77, 0x86, 0x74, 0x91, 0x5f, 0xa9, 0xa6, 0x61, 0xda,
-	0xeb, 0x0d, 0xf3, 0xa5, 0xb5, 0x73, 0x1b, 0xba, 0xb4, 0x99, 0x36, 0x6f, 0x19, 0xc1, 0xa0, 0x3c,
-	0xd1, 0xf6, 0x4e, 0x60, 0x04, 0x53, 0x34, 0x93, 0x95, 0x4d, 0x59, 0xd4, 0xf6, 0xde, 0x07, 0xf8,
-	0x19, 0x6e, 0x20, 0x1d, 0x2e, 0xd0, 0x6e, 0x51, 0x68, 0x12, 0xf7, 0xc4, 0xc7, 0x26, 0xce, 0x84,
-	0xbb, 0xa7, 0x28, 0x4d, 0x0d, 0x7d, 0x23, 0x78, 0x21, 0xc0, 0x51, 0xb6, 0x4a, 0xf5, 0x85, 0xd0,
-	0x79, 0x8a, 0xa3, 0x6e, 0xc4, 0x8a, 0x6c, 0x30, 0xf6, 0xb1, 0x49, 0xc5, 0x29, 0x8e, 0xb0, 0x36,
-	0x25, 0x32, 0x99, 0x99, 0x8b, 0x22, 0x16, 0x27, 0xc2, 0x9e, 0x22, 0x84, 0x14, 0x45, 0x27, 0x5d,
-	0x4b, 0x69, 0x1b, 0x8a, 0xc1, 0x88, 0xe2, 0xfd, 0xc7, 0x81, 0x07, 0xb6, 0x8a, 0x1e, 0xce, 0x30,
-	0xb9, 0x9e, 0xc9, 0x30, 0xba, 0x5e, 0xe1, 0x5e, 0x72, 0x92, 0xad, 0x7f, 0x59, 0x09, 0xbf, 0x8f,
-	0xca, 0xb0, 0xb9, 0x04, 0x50, 0xdb, 0x14, 0xd5, 0xa4, 0x3c, 0xfe, 0x4e, 0xfc, 0x42, 0x64, 0xc7,
-	0x30, 0x94, 0xa9, 0xb0, 0x59, 0xbc, 0x43, 0x59, 0xe9, 0x7b, 0x8d, 0x08, 0x58, 0xb3, 0xf4, 0xfe,
-	0xc7, 0xc5, 0x08, 0xbf, 0x1a, 0xec, 0xbd, 0x0b, 0xfd, 0xa2, 0xd8, 0x03, 0xf4, 0xcc, 0xf9, 0xdd,
-	0x75, 0xd8, 0xc8, 0x38, 0x0b, 0xe6, 0x8d, 0x16, 0x66, 0x28, 0x4a, 0x41, 0x1d, 0x6f, 0x17, 0x86,
-	0xe5, 0x2c, 0x98, 0x6d, 0x0e, 0xc3, 0xd0, 0x7d, 0x09, 0x07, 0x9a, 0x23, 0x9d, 0xeb, 0x78, 0xbf,
-	0x80, 0x49, 0x63, 0xed, 0xaf, 0x38, 0x7d, 0x7d, 0x4d, 0x9a, 0xae, 0x2c, 0xd5, 0xae, 0x5b, 0xca,
-	0xfb, 0x8b, 0x63, 0xd2, 0x15, 0x95, 0xeb, 0xf7, 0xa0, 0x6b, 0x8e, 0x9a, 0xce, 0x9a, 0xc4, 0x51,
-	0xb0, 0xa8, 0xe1, 0x1b, 0xe2, 0x8e, 0x32, 0x1f, 0x53, 0xf7, 0x4a, 0x93, 0xb8, 0x0a, 0xaf, 0x2c,
-	0xe2, 0xbf, 0x55, 0x2b, 0xbb, 0x78, 0x08, 0xe7, 0x4a, 0x07, 0x4a, 0x88, 0xe2, 0xf4, 0x39, 0x40,
-	0xe0, 0x42, 0x88, 0x84, 0x0e, 0xe1, 0xd8, 0x69, 0x55, 0xb7, 0x4e, 0x3e, 0x42, 0xcc, 0xda, 0xd0,
-	0xfb, 0xb7, 0x03, 0xa3, 0x67, 0x32, 0x9a, 0x89, 0x4b, 0x9e, 0xcd, 0x85, 0x66, 0x1b, 0xd0, 0x2a,
-	0xef, 0x13, 0xad, 0x28, 0x64, 0x1f, 0x40, 0x5f, 0x53, 0x8f, 0xf1, 0xd5, 0xd1, 0xc1, 0x1b, 0x8d,
-	0x0f, 0xa9, 0x0d, 0xdd, 0x37, 0x7f, 0x7e, 0xc1, 0xdf, 0xf9, 0x83, 0x03, 0x3d, 0x3b, 0x6b, 0xc3,
-	0xd4, 0xed, 0xff, 0xc1, 0xd4, 0x65, 0x20, 0xb6, 0xeb, 0x81, 0xf8, 0x6a, 0x75, 0x63, 0xa9, 0xe7,
-	0x4c, 0x73, 0x71, 0x79, 0x13, 0x06, 0xb3, 0x45, 0x14, 0x87, 0x99, 0x48, 0x9a, 0x39, 0xb5, 0x84,
-	0x3d, 0x09, 0x9b, 0x55, 0x39, 0xa3, 0x40, 0xfd, 0xba, 0xfb, 0xd4, 0x9d, 0x1b, 0x9d, 0xd1, 0xb3,
-	0x0e, 0xa1, 0x4e, 0xd7, 0x71, 0xae, 0x16, 0x36, 0xd7, 0x14, 0x3a, 0x11, 0xe6, 0xfd, 0x0a, 0xc6,
-	0x47, 0x32, 0x14, 0xb3, 0xe2, 0x1d, 0x08, 0x8f, 0x2f, 0x71, 0xba, 0xe0, 0xb4, 0xc1, 0x5d, 0xdf,
-	0x08, 0xb8, 0xbf, 0x57, 0x42, 0x73, 0x3a, 0x6a, 0x75, 0x7d, 0x6a, 0x63, 0xa5, 0x4a, 0x33, 0x71,
-	0x2d, 0xb2, 0xc0, 0x0c, 0x40, 0x8f, 0x2b, 0x93, 0xb3, 0xe9, 0x39, 0xa4, 0xc1, 0xc5, 0x63, 0x4a,
-	0xe7, 0xfe, 0x63, 0xca, 0x17, 0xbd, 0xea, 0xd2, 0xa1, 0xbe, 0xc2, 0xed, 0xbf, 0x03, 0xa0, 0x90,
-	0x12, 0xc8, 0x24, 0xbe, 0x73, 0x66, 0x1c, 0x52, 0xc7, 0xc7, 0x49, 0xbc, 0x62, 0x1e, 0x8c, 0x67,
-	0x55, 0x91, 0x36, 0x85, 0x71, 0xec, 0x37, 0x30, 0xf6, 0x23, 0x18, 0x5d, 0x67, 0x72, 0x19, 0x98,
-	0xd4, 0x44, 0x3a, 0x8d, 0x0e, 0x5e, 0xbb, 0x17, 0x02, 0xa4, 0xd0, 0x3e, 0xfd, 0xfa, 0x80, 0x03,
-	0x8e, 0x88, 0x5f, 0x0e, 0x37, 0x69, 0x8b, 0x76, 0xf1, 0x1b, 0x0d, 0x37, 0x49, 0xe2, 0xff, 0xe7,
-	0x05, 0x87, 0xed, 0x57, 0xef, 0x85, 0x63, 0x32, 0xc2, 0x76, 0x33, 0xfa, 0x4c, 0x5f, 0xf5, 0x8a,
-	0x78, 0xef, 0xd9, 0x6d, 0xb2, 0xe6, 0xd9, 0xad, 0x76, 0xd6, 0xdf, 0x30, 0x77, 0xaf, 0xe2, 0xac,
-	0xff, 0x1a, 0x0c, 0xab, 0xb7, 0x8f, 0x4d, 0x13, 0x03, 0x25, 0x80, 0x87, 0x5b, 0x99, 0xc4, 0x51,
-	0x22, 0x94, 0x98, 0x29, 0xba, 0x19, 0x4d, 0xfc, 0x1a, 0x82, 0xe7, 0xf7, 0x28, 0x8c, 0x4d, 0xef,
-	0x96, 0x39, 0xbf, 0x17, 0x32, 0x7b, 0x1f, 0x98, 0xd2, 0x99, 0x4c, 0xe6, 0x41, 0xcd, 0x4f, 0xcc,
-	0x9d, 0xa8, 0x70, 0xb1, 0x2d, 0x43, 0xa8, 0x1d, 0x00, 0x4b, 0x9f, 0x7e, 0x70, 0xcf, 0xa7, 0x77,
-	0x7e, 0x0e, 0x5d, 0xe3, 0xce, 0xc5, 0x13, 0xa0, 0xb3, 0xe6, 0x09, 0xb0, 0xb5, 0xe6, 0x09, 0xb0,
-	0xbd, 0xf6, 0x09, 0xb0, 0x53, 0x7f, 0x02, 0xf4, 0x3e, 0x77, 0x60, 0xe4, 0x8b, 0xcf, 0x72, 0xa1,
-	0xf4, 0xe3, 0x58, 0x5e, 0xe1, 0x65, 0xd3, 0xc6, 0x48, 0x50, 0xdc, 0x5a, 0x4d, 0x1a, 0xdb, 0xb0,
-	0xf0, 0xa5, 0xbd, 0xbc, 0xd6, 0x88, 0xc5, 0xa5, 0xb3, 0xd5, 0x20, 0x1e, 0xd9, 0xbb, 0xe7, 0x0f,
-	0xe0, 0x41, 0x91, 0x6e, 0xea, 0xaf, 0x2c, 0xe6, 0x62, 0xc2, 0x6c, 0xd7, 0x93, 0xaa, 0xc7, 0xfb,
-	0x97, 0x03, 0x63, 0xe3, 0xde, 0x47, 0x32, 0xb9, 0x8e, 0xe6, 0xf7, 0xdf, 0xaa, 0x9c, 0x6f, 0xf0,
-	0x56, 0xd5, 0xba, 0xff, 0x56, 0xf5, 0x10, 0x80, 0xc7, 0xb1, 0x7c, 0x1e, 0x2c, 0xf4, 0x32, 0x36,
-	0xc9, 0xcb, 0x1f, 0x12, 0x72, 0xac, 0x97, 0x31, 0x5e, 0xc7, 0xed, 0x8d, 0x27, 0x88, 0x45, 0x32,
-	0xd7, 0x0b, 0x6b, 0xaa, 0x89, 0x45, 0x4f, 0x09, 0x64, 0xef, 0xc1, 0x76, 0xb4, 0x44, 0xd2, 0x1d,
-	0xb2, 0x79, 0x76, 0x60, 0xd4, 0x77, 0xd6, 0x18, 0xd1, 0x78, 0x8e, 0xe9, 0xdd, 0x79, 0x8e, 0xb9,
-	0x81, 0xc9, 0x45, 0x3e, 0x9f, 0x0b, 0xa5, 0xed, 0xd7, 0x7e, 0xf9, 0xc3, 0x39, 0x5e, 0xb9, 0xec,
-	0x6b, 0x10, 0x8f, 0x4d, 0xd2, 0xf2, 0x6b, 0x08, 0x06, 0x59, 0x9a, 0xab, 0x45, 0xa0, 0x65, 0xa0,
-	0x79, 0x7c, 0x63, 0xbf, 0x10, 0x10, 0xbb, 0x94, 0x97, 0x3c, 0xbe, 0x79, 0xdc, 0x3a, 0x76, 0xfe,
-	0x1b, 0x00, 0x00, 0xff, 0xff, 0x85, 0xe0, 0xf0, 0x3c, 0xbf, 0x17, 0x00, 0x00,
+	// 2530 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xdc, 0x59, 0x3f, 0x73, 0x24, 0x47,
+	0x15, 0xf7, 0xec, 0xff, 0x7d, 0xbb, 0x2b, 0x8d, 0xfa, 0x64, 0xdf, 0x58, 0xe7, 0xb3, 0xe5, 0x39,
+	0xb0, 0x65, 0x70, 0x09, 0xa3, 0x72, 0x62, 0x57, 0x11, 0xe8, 0x74, 0x18, 0x5d, 0x21, 0x9d, 0x8f,
+	0x91, 0x7c, 0x0e, 0x08, 0x86, 0xd6, 0x4c, 0x6b, 0x77, 0xac, 0xd9, 0x99, 0xf1, 0x74, 0x8f, 0xee,
+	0xb6, 0x8a, 0x10, 0x48, 0xa1, 0x8a, 0x80, 0x8c, 0x90, 0x80, 0xc0, 0x55, 0x7c, 0x00, 0x12, 0x3e,
+	0x01, 0x5f, 0x80, 0x84, 0x94, 0x8c, 0x2a, 0x72, 0xea, 0xbd, 0xee, 0xf9, 0x27, 0xad, 0x7d, 0x26,
+	0x25, 0xd1, 0xf6, 0xfb, 0xf5, 0xaf, 0xff, 0xcc, 0xeb, 0xf7, 0x5e, 0xbf, 0x7e, 0x82, 0xe9, 0x69,
+	0xb1, 0xbc, 0x88, 0xc5, 0x7e, 0x96, 0xa7, 0x2a, 0x65, 0x13, 0x2d, 0x3d, 0x45, 0xc1, 0x8d, 0x61,
+	0xf8, 0x4c, 0xe4, 0x32, 0x4a, 0x13, 0xe6, 0xc0, 0xf0, 0x5a, 0x37, 0x1d, 0x6b, 0xd7, 0xda, 0x9b,
+	0x79, 0xc3, 0xeb, 0xba, 0x27, 0x17, 0xb1, 0xe0, 0x52, 0x38, 0x9d, 0x5d, 0x6b, 0x6f, 0xec, 0x95,
+	0x22, 0xdb, 0x80, 0x4e, 0x2a, 0x9d, 0x2e, 0x81, 0x9d, 0x54, 0xb2, 0xfb, 0x00, 0xa9, 0xf4, 0xcb,
+	0x69, 0x7a, 0x84, 0x8f, 0x53, 0x69, 0x96, 0x70, 0x1f, 0xc0, 0xf8, 0xb3, 0x47, 0x4f, 0xcf, 0x8b,
+	0x24, 0x11, 0x31, 0x7b, 0x0d, 0x06, 0x19, 0x0f, 0xae, 0x84, 0x72, 0xac, 0xdd, 0xce, 0xde, 0xd4,
+	0x33, 0x92, 0xfb, 0x47, 0x0b, 0xa6, 0x87, 0x85, 0x5a, 0x88, 0x44, 0x45, 0x01, 0x57, 0x82, 0xed,
+	0xc0, 0xa8, 0x90, 0x22, 0x4f, 0xf8, 0x52, 0xd0, 0xce, 0xc6, 0x5e, 0x25, 0x63, 0x5f, 0xc6, 0xa5,
+	0x7c, 0x9e, 0xe6, 0xa1, 0xd9, 0x5b, 0x25, 0xe3, 0x02, 0x2a, 0xbd, 0x12, 0x09, 0x6e, 0xb0, 0xbb,
+	0x37, 0xf6, 0x8c, 0xc4, 0x1e, 0xc0, 0x2c, 0x10, 0xb1, 0x2a, 0xb7, 0x29, 0x9d, 0xde, 0x6e, 0x77,
+	0xaf, 0xef, 0x4d, 0x11, 0x34, 0x3b, 0x95, 0xec, 0x75, 0xe8, 0xa5, 0x59, 0x21, 0x9d, 0xfe, 0xae,
+	0xb5, 0x37, 0xfa, 0xb8, 0x7f, 0xc9, 0x63, 0x29, 0x3c, 0x82, 0xdc, 0xbf, 0x75, 0xa0, 0xf7, 0x34,
+	0x4a, 0xe6, 0xec, 0x0d, 0x18, 0xab, 0x68, 0x29, 0xa4, 0xe2, 0xcb, 0x8c, 0x76, 0xd6, 0xf3, 0x6a,
+	0x80, 0x31, 0xe8, 0xcd, 0xd3, 0x54, 0x6f, 0x6b, 0xe6, 0x51, 0x1b, 0xb1, 0x98, 0x2b, 0x41, 0x1a,
+	0x9b, 0x79, 0xd4, 0x26, 0x2c, 0x95, 0xca, 0xe9, 0x19, 0x2c, 0x95, 0x0a, 0xb7, 0x9e, 0x0b, 0xb9,
+	0x4a, 0x02, 0x5a, 0x7f, 0xe6, 0x19, 0x89, 0xbd, 0x05, 0x93, 0x22, 0xcc, 0x7c, 0xad, 0x29, 0xe9,
+	0x0c, 0xa8, 0x13, 0x8a, 0x30, 0x7b, 0xaa, 0x11, 0x24, 0xa8, 0xa0, 0x26, 0x0c, 0x35, 0x41, 0x05,
+	0x15, 0x61, 0x17, 0xa6, 0x34, 0x43, 0x94, 0xcc, 0x7d, 0x7e, 0x3d, 0x77, 0x46, 0xbb, 0xd6, 0x5e,
+	0x47, 0x4f, 0x11, 0x25, 0xf3, 0xc3, 0xeb, 0x79, 0x8b, 0x71, 0xcd, 0x73, 0x67, 0xdc, 0x62, 0x3c,
+	0xe3, 0x39, 0x32, 0x54, 0x60, 0x18, 0x38, 0x07, 0x68, 0x86, 0x0a, 0x9a, 0x73, 0xa8, 0xa0, 0x31,
+	0xc7, 0xa4, 0xc5, 0x78, 0xc6, 0x73, 0xf7, 0xd7, 0x1d, 0x18, 0x78, 0xe2, 0x0b, 0x11, 0x28, 0x76,
+	0x00, 0x3d, 0xb5, 0xca, 0xf4, 0xd9, 0x6e, 0x1c, 0xbc, 0xb9, 0xdf, 0xb0, 0xcf, 0x7d, 0x4d, 0x31,
+	0x3f, 0xe7, 0xab, 0x4c, 0x78, 0xc4, 0xd5, 0x0a, 0xe2, 0x32, 0x4d, 0xcc, 0xa9, 0x1b, 0xc9, 0xfd,
+	0xca, 0x02, 0xa8, 0xc9, 0x6c, 0x04, 0xbd, 0x27, 0x69, 0x22, 0xec, 0x57, 0x98, 0x0d, 0xd3, 0xcf,
+	0xf3, 0x34, 0x99, 0x9b, 0x03, 0xb6, 0x2d, 0x76, 0x07, 0x36, 0x1f, 0x27, 0xd7, 0x3c, 0x8e, 0xc2,
+	0xcf, 0x8c, 0x35, 0xd9, 0x1d, 0xb6, 0x09, 0x13, 0xa2, 0x21, 0xf4, 0xf4, 0x73, 0xbb, 0xcb, 0xb6,
+	0x60, 0x46, 0xc0, 0x99, 0xc8, 0xaf, 0x09, 0xea, 0x21, 0x54, 0x8e, 0x78, 0x9c, 0x7c, 0x26, 0x85,
+	0xdd, 0x67, 0x1b, 0x00, 0x9a, 0xf0, 0x49, 0x11, 0xc7, 0xf6, 0x00, 0x29, 0x4f, 0xd2, 0x23, 0x91,
+	0xab, 0xe8, 0x92, 0x6c, 0xd8, 0x1e, 0xb2, 0x57, 0x61, 0xab, 0x61, 0xd5, 0x69, 0xfe, 0x09, 0x8f,
+	0x62, 0x7b, 0xe4, 0xfe, 0xce, 0x2a, 0x87, 0x9e, 0xe1, 0x01, 0x3b, 0x30, 0x94, 0x42, 0x36, 0x9d,
+	0xd0, 0x88, 0x68, 0xb5, 0x4b, 0xfe, 0xc2, 0xbf, 0xe0, 0x49, 0xf8, 0x3c, 0x0a, 0xd5, 0xc2, 0xd8,
+	0xd5, 0x74, 0xc9, 0x5f, 0x3c, 0x2c, 0x31, 0xf6, 0x36, 0x4c, 0x9f, 0x8b, 0x38, 0x48, 0x97, 0xc2,
+	0x57, 0xe2, 0x85, 0x32, 0x9e, 0x39, 0x31, 0xd8, 0xb9, 0x78, 0xa1, 0xd8, 0x2e, 0x4c, 0x32, 0x91,
+	0x2f, 0x23, 0x59, 0xda, 0x3e, 0x9a, 0x6d, 0x13, 0x72, 0xf7, 0x61, 0x76, 0xb4, 0xe0, 0xe8, 0xa3,
+	0x9e, 0x58, 0xa6, 0xd7, 0x02, 0xbd, 0x3a, 0xd0, 0x80, 0x1f, 0x85, 0xe4, 0xad, 0x33, 0x6f, 0x6c,
+	0x90, 0xc7, 0xa1, 0xfb, 0x8f, 0x0e, 0x4c, 0xcd, 0x80, 0x33, 0xc5, 0xd5, 0x6d, 0xbe, 0xd5, 0xe2,
+	0x6b, 0xc7, 0xcf, 0x45, 0xa2, 0xcc, 0x27, 0x18, 0x09, 0x1d, 0x81, 0x7c, 0x5c, 0x6f, 0x9a, 0xda,
+	0x6c, 0x1b, 0xfa, 0x71, 0x94, 0x5c, 0x69, 0x1f, 0x9d, 0x79, 0x5a, 0xc0, 0x6f, 0x08, 0x85, 0x0c,
+	0xf2, 0x28, 0x53, 0xa8, 0xa9, 0xbe, 0xfe, 0xca, 0x06, 0xc4, 0xee, 0xc1, 0x98, 0xa8, 0x3e, 0x0f,
+	0x43, 0x67, 0x40, 0x63, 0x47, 0x04, 0x1c, 0x86, 0x21, 0x6a, 0x49, 0x77, 0xe6, 0xf4, 0x7d, 0xce,
+	0x90, 0xfa, 0x27, 0x84, 0x99, 0x4f, 0x7e, 0x00, 0x63, 0x25, 0x96, 0x59, 0x9a, 0xf3, 0x7c, 0xe5,
+	0x8c, 0x9a, 0x31, 0xa0, 0xc6, 0xd9, 0x7d, 0x18, 0x65, 0xa9, 0x8c, 0x68, 0x0f, 0xe8, 0x25, 0xfd,
+	0x8f, 0xad, 0x0f, 0xbc, 0x0a, 0x62, 0xef, 0x81, 0xdd, 0xd8, 0x92, 0xbf, 0xe0, 0x72, 0x41, 0xae,
+	0x32, 0xf5, 0x36, 0x1b, 0xf8, 0x31, 0x97, 0x0b, 0xdc, 0x2e, 0x1e, 0x2e, 0x86, 0x35, 0x49, 0xce,
+	0x32, 0xf3, 0x46, 0x4b, 0xfe, 0x02, 0xcd, 0x4c, 0xba, 0x97, 0x00, 0xd8, 0x30, 0x3b, 0x6b, 0x59,
+	0x48, 0xa7, 0x69, 0x21, 0xdb, 0xd0, 0xe7, 0x81, 0x4a, 0x73, 0xa3, 0x56, 0x2d, 0x34, 0x3c, 0xa5,
+	0xdb, 0xf4, 0x14, 0x66, 0x43, 0xf7, 0x82, 0xeb, 0x18, 0x3d, 0xf2, 0xb0, 0xe9, 0xfe, 0x69, 0x00,
+	0x63, 0x5c, 0x48, 0x1f, 0xe2, 0xd7, 0x5b, 0xe2, 0xfa, 0x75, 0xd6, 0x9d, 0xde, 0x5d, 0x18, 0xe2,
+	0x27, 0xa1, 0x15, 0xe8, 0xe8, 0x36, 0x40, 0xf1, 0x71, 0x78, 0xc3, 0x42, 0xfa, 0x37, 0x2d, 0x84,
+	0x41, 0x6f, 0x59, 0x28, 0x41, 0xf1, 0x6d, 0xe4, 0x51, 0x1b, 0xb1, 0x50, 0xf0, 0x4b, 0x0a, 0x69,
+	0x23, 0x8f, 0xda, 0x18, 0xfd, 0x65, 0x91, 0x65, 0xb9, 0x90, 0x52, 0x1f, 0x92, 0x57, 0xc9, 0xa8,
+	0x52, 0x29, 0xe2, 0x4b, 0x9f, 0x26, 0x1a, 0x9b, 0x4e, 0x11, 0x5f, 0x9e, 0xe2, 0x64, 0x65, 0x27,
+	0xcd, 0x08, 0x75, 0xe7, 0x23, 0x9c, 0xd5, 0x81, 0x21, 0x3a, 0x4f, 0x91, 0x0b, 0x3a, 0x8a, 0xa9,
+	0x57, 0x8a, 0xec, 0xbb, 0xb0, 0x91, 0xc5, 0xc5, 0x3c, 0x4a, 0xfc, 0x20, 0x4d, 0x10, 0x74, 0xa6,
+	0x44, 0x98, 0x69, 0xf4, 0x48, 0x83, 0xec, 0x5d, 0xd8, 0x34, 0xb4, 0x28, 0x44, 0x7f, 0x57, 0x2b,
+	0x67, 0x46, 0x5a, 0x31, 0xa3, 0x1f, 0x1b, 0x14, 0x57, 0x0a, 0xd2, 0xe5, 0x12, 0x5d, 0x61, 0x43,
+	0x5f, 0xac, 0x46, 0xc4, 0xaf, 0x25, 0x7b, 0xd9, 0xd4, 0xda, 0xc4, 0x36, 0x9a, 0xad, 0xe9, 0xd6,
+	0xb6, 0x64, 0xd3, 0xda, 0x13, 0x83, 0x1d, 0x1b, 0x8a, 0xd9, 0xab, 0xa6, 0x6c, 0x69, 0x8a, 0xc1,
+	0x88, 0xf2, 0x1e, 0xd8, 0x59, 0x1e, 0xa5, 0x79, 0xa4, 0x56, 0xbe, 0xcc, 0x04, 0xbf, 0x12, 0xb9,
+	0xc3, 0x48, 0x03, 0x9b, 0x25, 0x7e, 0xa6, 0x61, 0xbc, 0xdf, 0x72, 0x11, 0xa4, 0x79, 0x18, 0x25,
+	0x73, 0xe7, 0x0e, 0x71, 0x6a, 0x80, 0xbd, 0x05, 0x77, 0x2b, 0x57, 0xf0, 0x79, 0x10, 0x08, 0x29,
+	0x7d, 0x73, 0xdf, 0x6e, 0xe3, 0x7d, 0xcb, 0xee, 0xc3, 0xab, 0x71, 0x24, 0x95, 0x48, 0xf0, 0x16,
+	0x28, 0x8f, 0x1b, 0xfd, 0xf1, 0x55, 0xf4, 0x37, 0xb6, 0x0b, 0xce, 0xed, 0x6e, 0xe3, 0x91, 0xaf,
+	0x11, 0xe3, 0x04, 0xee, 0xd5, 0x8c, 0xeb, 0x34, 0x2e, 0x96, 0xc2, 0xe7, 0xe1, 0x17, 0x85, 0x54,
+	0xa4, 0xb2, 0xbb, 0xbb, 0xdd, 0xbd, 0xc9, 0xc1, 0xbb, 0xad, 0xfb, 0xa2, 0xb2, 0xdf, 0xfd, 0x67,
+	0xc4, 0x3f, 0xac, 0xe8, 0x3b, 0xc7, 0x60, 0xdf, 0xc4, 0xd8, 0xeb, 0xb0, 0x75, 0x6b, 0x0f, 0xda,
+	0xdc, 0xb1, 0xeb, 0xf6, 0x92, 0x68, 0xf1, 0x1d, 0xf7, 0x37, 0x1d, 0x18, 0x3e, 0xe4, 0xc9, 0x49,
+	0x24, 0x15, 0xfb, 0x21, 0xf4, 0x2e, 0x78, 0x22, 0x1d, 0x8b, 0x36, 0x73, 0xbf, 0xb5, 0x19, 0xc3,
+	0xc1, 0xdf, 0x1f, 0x27, 0x2a, 0x5f, 0x79, 0x44, 0x65, 0xf7, 0xa0, 0xff, 0x65, 0x21, 0xf2, 0x95,
+	0xd3, 0x69, 0xc6, 0x15, 0x8d, 0xed, 0xfc, 0xd9, 0x82, 0x51, 0xc9, 0x47, 0xfb, 0xe0, 0x61, 0x48,
+	0xe6, 0xad, 0x73, 0xa4, 0x52, 0x24, 0x0f, 0xe1, 0xf2, 0xca, 0xe9, 0x50, 0x08, 0xa0, 0xf6, 0x5a,
+	0x0f, 0x2c, 0xed, 0xa8, 0xd7, 0xb0, 0xa3, 0x3a, 0x22, 0xf4, 0x5b, 0x11, 0x61, 0x1b, 0xfa, 0x52,
+	0xf1, 0x5c, 0x91, 0xdb, 0x8d, 0x3d, 0x2d, 0xa0, 0x8f, 0x85, 0x45, 0xce, 0x29, 0xc8, 0xe9, 0x74,
+	0xa2, 0x92, 0xdd, 0xdf, 0x5a, 0x30, 0xc1, 0x4b, 0xe5, 0x54, 0x48, 0xc9, 0xe7, 0xa2, 0x8e, 0x0c,
+	0x56, 0x33, 0x32, 0x34, 0x22, 0x49, 0x87, 0x22, 0x6d, 0x29, 0xde, 0x08, 0x03, 0xdd, 0xdd, 0x6e,
+	0x3b, 0x0c, 0xdc, 0x85, 0xa1, 0xca, 0x85, 0xd0, 0xe1, 0x03, 0xfb, 0x06, 0x28, 0x3e, 0x0e, 0x71,
+	0xc6, 0xa5, 0x5e, 0xd2, 0xe9, 0xef, 0x76, 0xd0, 0x6f, 0x8c, 0xe8, 0xfe, 0xbe, 0x0b, 0xf6, 0xd3,
+	0xea, 0x2e, 0x7b, 0x24, 0x92, 0x48, 0x84, 0xec, 0x4d, 0x80, 0xfa, 0x7e, 0x33, 0x7b, 0x6b, 0x20,
+	0x37, 0xb6, 0xd1, 0xb9, 0x19, 0x8d, 0x1a, 0xfb, 0xef, 0xb6, 0x23, 0x61, 0xad, 0xc9, 0x5e, 0x4b,
+	0x93, 0x1f, 0x9b, 0x8c, 0xa6, 0x4f, 0x19, 0xcd, 0x3b, 0x2d, 0xa3, 0xb8, 0xb9, 0xbb, 0xfd, 0x47,
+	0x22, 0x59, 0x35, 0x32, 0x9b, 0xf2, 0x14, 0x07, 0xf5, 0x29, 0xba, 0x7f, 0xb5, 0x60, 0x54, 0xd2,
+	0x30, 0xa7, 0x41, 0x9d, 0xdb, 0xaf, 0x60, 0xd6, 0x51, 0xcf, 0x66, 0x5b, 0x6c, 0x06, 0xe3, 0xb3,
+	0x22, 0x13, 0x39, 0x3a, 0x81, 0xce, 0x65, 0xcc, 0xb5, 0xfc, 0x04, 0x93, 0x9b, 0x2e, 0x02, 0x38,
+	0xf2, 0x3c, 0x4d, 0x4f, 0xd2, 0x64, 0x6e, 0xf7, 0xd8, 0x10, 0xba, 0xc7, 0x1f, 0xfd, 0xd4, 0xee,
+	0xb3, 0x6d, 0xb0, 0xcf, 0x4b, 0x5f, 0x36, 0x63, 0xec, 0x01, 0x7b, 0x0d, 0xd8, 0x29, 0x4e, 0x9e,
+	0xcc, 0xdb, 0xa9, 0xcc, 0x14, 0x46, 0xb8, 0x04, 0xcd, 0x3a, 0x6a, 0x2c, 0x43, 0xc9, 0xcf, 0x18,
+	0x53, 0xad, 0x27, 0x42, 0xaa, 0x28, 0x99, 0x9f, 0x44, 0xcb, 0x48, 0xd9, 0xe0, 0xfe, 0xaa, 0x0f,
+	0xdd, 0xc3, 0xa3, 0x93, 0x97, 0x24, 0x12, 0xec, 0x5d, 0x98, 0x46, 0xc9, 0x42, 0xe4, 0x91, 0xf2,
+	0x79, 0x10, 0x4b, 0xe3, 0x1f, 0x3d, 0x95, 0x17, 0xc2, 0x9b, 0x98, 0x9e, 0xc3, 0x20, 0x96, 0xec,
+	0x00, 0x06, 0xf3, 0x3c, 0x2d, 0x32, 0x9d, 0xd9, 0x4f, 0x0e, 0x76, 0x5a, 0x1a, 0x3e, 0x3c, 0x3a,
+	0xd9, 0xc7, 0x1d, 0xfd, 0x04, 0x29, 0x9e, 0x61, 0xb2, 0xf7, 0xa1, 0x47, 0x93, 0xf6, 0x68, 0x84,
+	0xb3, 0x76, 0xc4, 0xe1, 0xd1, 0x89, 0x47, 0xac, 0xda, 0x47, 0xfb, 0x6b, 0x7c, 0xf4, 0x9f, 0x16,
+	0x8c, 0xab, 0x05, 0xaa, 0x03, 0xb3, 0xc8, 0x12, 0xa9, 0xcd, 0x5c, 0x18, 0x9b, 0xfd, 0x8a, 0xb0,
+	0xf5, 0x19, 0x35, 0xcc, 0xde, 0x84, 0xa1, 0x11, 0x9c, 0x6e, 0x83, 0x51, 0x82, 0xec, 0x1d, 0x28,
+	0xbf, 0x99, 0x5f, 0xc4, 0xc2, 0xe9, 0x35, 0x38, 0xcd, 0x0e, 0xbc, 0xc8, 0x31, 0xa8, 0xf6, 0xc9,
+	0x43, 0xb0, 0xa9, 0xcd, 0x92, 0xe2, 0xa8, 0xce, 0x7c, 0x8c, 0xc4, 0xbe, 0x0f, 0x5b, 0xd5, 0xf2,
+	0xfe, 0x52, 0x2c, 0x2f, 0x30, 0xdb, 0xd0, 0xc9, 0x8f, 0x5d, 0x75, 0x9c, 0x6a, 0x7c, 0xe7, 0xef,
+	0x16, 0x0c, 0x8d, 0x4e, 0xd8, 0x03, 0x00, 0x9e, 0x65, 0xf1, 0xca, 0x5f, 0x88, 0x5c, 0xe7, 0xe9,
+	0xd5, 0xf7, 0x10, 0x7e, 0x2c, 0x72, 0x51, 0x93, 0x64, 0x71, 0xd1, 0x3e, 0x3b, 0x4d, 0x3a, 0x2b,
+	0x2e, 0x64, 0x5b, 0x31, 0xdd, 0xf5, 0x8a, 0xf9, 0xda, 0xac, 0x61, 0x1b, 0xfa, 0x74, 0x98, 0x26,
+	0x6e, 0x69, 0x41, 0xa3, 0x3c, 0x51, 0xe6, 0x35, 0xa4, 0x05, 0x9d, 0x2e, 0x24, 0x2b, 0x13, 0xb2,
+	0xa8, 0xed, 0x7e, 0x08, 0xf0, 0x33, 0x3c, 0x40, 0x4a, 0xab, 0x50, 0x6f, 0x51, 0xa8, 0x03, 0xf7,
+	0xcc, 0xc3, 0x26, 0xce, 0x84, 0xa7, 0x27, 0x29, 0x4c, 0x8d, 0x3d, 0x2d, 0xb8, 0x21, 0xc0, 0x51,
+	0xbe, 0xca, 0xd4, 0x99, 0x50, 0x45, 0x86, 0xa3, 0xae, 0xc4, 0x8a, 0x74, 0x30, 0xf5, 0xb0, 0x49,
+	0xd7, 0x72, 0x1c, 0xe1, 0xad, 0x9c, 0xa4, 0x49, 0xa0, 0x9f, 0xc8, 0x78, 0x2d, 0x13, 0xf6, 0x04,
+	0x21, 0xa4, 0x48, 0xca, 0xf1, 0x0d, 0xa5, 0xab, 0x29, 0x1a, 0x23, 0x8a, 0xfb, 0x1f, 0x0b, 0xee,
+	0x98, 0xfc, 0xe1, 0x30, 0xc0, 0xe0, 0x7a, 0x9a, 0x86, 0xd1, 0xe5, 0x0a, 0xcf, 0x92, 0x93, 0x6c,
+	0xec, 0xcb, 0x48, 0xf8, 0x7d, 0xc8, 0x35, 0xcf, 0x1f, 0x6a, 0xeb, 0x74, 0x22, 0xa9, 0x12, 0xff,
+	0x99, 0x57, 0x8a, 0xec, 0x18, 0xc6, 0x69, 0x26, 0x4c, 0x14, 0xef, 0x51, 0x54, 0xfa, 0x5e, 0xcb,
+	0x03, 0xd6, 0x2c, 0xbd, 0xff, 0x69, 0x39, 0xc2, 0xab, 0x07, 0xbb, 0xef, 0xc3, 0xd0, 0x70, 0x19,
+	0xc0, 0x40, 0xbf, 0x5c, 0x6c, 0x8b, 0x4d, 0x60, 0x58, 0xc6, 0x8d, 0x0e, 0x46, 0x28, 0x0a, 0x41,
+	0x3d, 0x77, 0x17, 0xc6, 0xd5, 0x2c, 0x18, 0x6d, 0x0e, 0xc3, 0xd0, 0x7e, 0x05, 0x07, 0xea, 0x64,
+	0xd6, 0xb6, 0xdc, 0x5f, 0xc0, 0xac, 0xb5, 0xf6, 0x37, 0xe4, 0x9d, 0x2f, 0x09, 0xd3, 0xb5, 0xa6,
+	0xba, 0x4d, 0x4d, 0xb9, 0x7f, 0xb1, 0x74, 0xb8, 0xa2, 0xeb, 0xfa, 0x03, 0xe8, 0xeb, 0x24, 0xdb,
+	0x5a, 0x13, 0x38, 0x4a, 0x16, 0x35, 0x3c, 0x4d, 0xdc, 0x91, 0xfa, 0x63, 0x9a, 0x56, 0xa9, 0x03,
+	0x57, 0x69, 0x95, 0xa5, 0xff, 0x77, 0x1a, 0xd7, 0x2e, 0x3e, 0x3f, 0xb8, 0x54, 0xbe, 0x14, 0xa2,
+	0xcc, 0xbb, 0x47, 0x08, 0x9c, 0x09, 0x91, 0xd0, 0xf3, 0x03, 0x3b, 0xcb, 0x7c, 0x43, 0x1b, 0xf9,
+	0x04, 0x31, 0xa3, 0x43, 0xf7, 0xdf, 0x16, 0x4c, 0x9e, 0xa5, 0x51, 0x20, 0xce, 0x79, 0x3e, 0x17,
+	0x0a, 0xeb, 0x2c, 0xd5, 0x4b, 0xaa, 0x13, 0x85, 0xec, 0x23, 0x18, 0x2a, 0xea, 0xd1, 0xb6, 0x3a,
+	0x39, 0x78, 0xab, 0xf5, 0x21, 0x8d, 0xa1, 0xfb, 0xfa, 0xc7, 0x2b, 0xf9, 0x3b, 0x7f, 0xb0, 0x60,
+	0x60, 0x66, 0x6d, 0xa9, 0xba, 0xfb, 0x3f, 0xa8, 0xba, 0x72, 0xc4, 0x6e, 0xd3, 0x11, 0xef, 0xd5,
+	0x6f, 0xb5, 0x66, 0xcc, 0x24, 0x8c, 0xbd, 0x0d, 0xa3, 0x60, 0x11, 0xc5, 0x61, 0x2e, 0x92, 0x76,
+	0x4c, 0xad, 0x60, 0x37, 0x85, 0xcd, 0xfa, 0x3a, 0x23, 0x47, 0x7d, 0xd9, 0x4b, 0xf2, 0xc6, 0x5b,
+	0x56, 0xef, 0xb3, 0x09, 0xe1, 0x9e, 0x2e, 0xe3, 0x42, 0x2e, 0x9c, 0x6e, 0x73, 0x4d, 0x8d, 0xb9,
+	0xbf, 0x84, 0xe9, 0x51, 0x1a, 0x8a, 0xa0, 0xac, 0x80, 0x61, 0xfa, 0x12, 0x67, 0x0b, 0x4e, 0x07,
+	0xdc, 0xf7, 0xb4, 0x80, 0xe7, 0x7b, 0x21, 0x14, 0xa7, 0x54, 0xab, 0xef, 0x51, 0x1b, 0x6f, 0xaa,
+	0x2c, 0x17, 0x97, 0x22, 0xf7, 0xf5, 0x00, 0xb4, 0xb8, 0x2a, 0x38, 0xeb, 0x9e, 0x43, 0x1a, 0x5c,
+	0x96, 0x91, 0x7a, 0xb7, 0xcb, 0x48, 0x5f, 0x35, 0x9e, 0x5b, 0xf2, 0x1b, 0xcc, 0xfe, 0x3b, 0x00,
+	0x12, 0x29, 0x7e, 0x9a, 0xc4, 0x37, 0x72, 0xc6, 0x31, 0x75, 0x7c, 0x9a, 0xc4, 0x2b, 0xe6, 0xc2,
+	0x34, 0xa8, 0x2f, 0x69, 0x7d, 0x31, 0x4e, 0xbd, 0x16, 0xc6, 0x7e, 0x04, 0x93, 0xcb, 0x3c, 0x5d,
+	0xfa, 0x3a, 0x34, 0xd1, 0x9e, 0x26, 0x07, 0x6f, 0xac, 0xcd, 0x9f, 0xe5, 0x3e, 0xfd, 0xf5, 0x00,
+	0x07, 0x1c, 0x11, 0xbf, 0x1a, 0xae, 0xc3, 0x96, 0xd3, 0xff, 0xb6, 0xc3, 0x75, 0x90, 0xf8, 0xff,
+	0xa9, 0x5d, 0xb1, 0xfd, 0xba, 0x52, 0x3a, 0x25, 0x25, 0x6c, 0xb7, 0xbd, 0x4f, 0xf7, 0xd5, 0xf5,
+	0xd3, 0x5b, 0x05, 0xc7, 0xd9, 0x9a, 0x82, 0x63, 0x23, 0xd7, 0xdf, 0xd0, 0xaf, 0x4e, 0x23, 0xe2,
+	0x33, 0xac, 0xae, 0xfa, 0x6c, 0x6a, 0x1f, 0xa8, 0x00, 0x4c, 0x6e, 0xd3, 0x24, 0x8e, 0x12, 0x21,
+	0x45, 0x20, 0xe9, 0x4d, 0x38, 0xf3, 0x1a, 0x08, 0xe6, 0xef, 0x51, 0x18, 0xeb, 0xde, 0x2d, 0xea,
+	0xad, 0x64, 0xf6, 0x21, 0x30, 0xa9, 0xb0, 0xba, 0xe5, 0x37, 0xec, 0xc4, 0x61, 0x4d, 0x13, 0xdb,
+	0xd2, 0x84, 0x46, 0x02, 0x58, 0xd9, 0xf4, 0x9d, 0x5b, 0x36, 0xbd, 0xf3, 0x73, 0xe8, 0x6b, 0x73,
+	0x2e, 0x8b, 0x9f, 0xd6, 0x9a, 0xe2, 0x67, 0x67, 0x4d, 0xf1, 0xb3, 0xbb, 0xb6, 0xf8, 0xd9, 0x6b,
+	0x16, 0x3f, 0xb1, 0x54, 0x36, 0xf1, 0xc4, 0x97, 0x85, 0x90, 0xea, 0x61, 0x9c, 0x5e, 0xe0, 0x33,
+	0xdb, 0xf8, 0x88, 0x5f, 0xbe, 0xd7, 0x75, 0x18, 0xdb, 0x30, 0xf0, 0xb9, 0x46, 0x9b, 0xc4, 0xf2,
+	0xb9, 0xdd, 0x69, 0x11, 0x8f, 0x34, 0xca, 0x7e, 0x00, 0x77, 0xca, 0x70, 0xd3, 0xac, 0x2f, 0xe9,
+	0x87, 0x09, 0x33, 0x5d, 0x8f, 0xea, 0x1e, 0xf7, 0x5f, 0x16, 0x4c, 0xb5, 0x79, 0x1f, 0xa5, 0xc9,
+	0x65, 0x34, 0xbf, 0x5d, 0xa5, 0xb3, 0xbe, 0x45, 0x95, 0xae, 0x73, 0xbb, 0x4a, 0x77, 0x1f, 0x80,
+	0xc7, 0x71, 0xfa, 0xdc, 0x5f, 0xa8, 0x65, 0xac, 0x83, 0x97, 0x37, 0x26, 0xe4, 0x58, 0x2d, 0x63,
+	0x2c, 0x44, 0x98, 0x17, 0x8f, 0x1f, 0x8b, 0x64, 0xae, 0x16, 0x46, 0x55, 0x33, 0x83, 0x9e, 0x10,
+	0xc8, 0x3e, 0x80, 0xed, 0x68, 0x89, 0xa4, 0x1b, 0x64, 0x5d, 0x70, 0x61, 0xd4, 0x77, 0xda, 0x1a,
+	0xd1, 0x2a, 0x44, 0x0d, 0x6e, 0x14, 0xa2, 0xae, 0x60, 0x76, 0x56, 0xcc, 0xe7, 0x42, 0x2a, 0xf3,
+	0xb5, 0x5f, 0xff, 0x2f, 0x03, 0x7c, 0x72, 0x99, 0x3a, 0x18, 0x8f, 0x75, 0xd0, 0xf2, 0x1a, 0x08,
+	0x3a, 0x59, 0x56, 0xc8, 0x85, 0xaf, 0x52, 0x5f, 0xf1, 0xf8, 0xca, 0x7c, 0x21, 0x20, 0x76, 0x9e,
+	0x9e, 0xf3, 0xf8, 0xea, 0x61, 0xe7, 0xd8, 0xfa, 0xef, 0x00, 0x44, 0xe2, 0xc6, 0xf0, 0xb9, 0x18,
+	0x00, 0x00,
 }
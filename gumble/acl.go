@@ -1,6 +1,9 @@
 package gumble
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/bmmcginty/gumble/gumble/MumbleProto"
 )
@@ -85,6 +88,37 @@ type ACLGroup struct {
 	UsersAdd, UsersRemove, UsersInherited map[uint32]*ACLUser
 }
 
+// Members returns the IDs of the registered users who are effectively part of
+// the group: the inherited users (if the group inherits its users), plus the
+// users explicitly added, minus the users explicitly removed.
+func (g *ACLGroup) Members() map[uint32]*ACLUser {
+	members := make(map[uint32]*ACLUser)
+	if g.InheritUsers {
+		for id, user := range g.UsersInherited {
+			members[id] = user
+		}
+	}
+	for id, user := range g.UsersAdd {
+		members[id] = user
+	}
+	for id := range g.UsersRemove {
+		delete(members, id)
+	}
+	return members
+}
+
+// HasUser returns true if the registered user with the given ID is
+// effectively part of the group.
+func (g *ACLGroup) HasUser(userID uint32) bool {
+	if g.UsersRemove[userID] != nil {
+		return false
+	}
+	if g.UsersAdd[userID] != nil {
+		return true
+	}
+	return g.InheritUsers && g.UsersInherited[userID] != nil
+}
+
 // ACL group names that are built-in.
 const (
 	ACLGroupEveryone       = "all"
@@ -114,3 +148,179 @@ type ACLRule struct {
 	// The ACL group the rule applies to. Can be nil.
 	Group *ACLGroup
 }
+
+// ACLDefaultPermissions are the permissions a user has before any ACL rule
+// has been applied.
+const ACLDefaultPermissions = PermissionTraverse | PermissionEnter | PermissionSpeak | PermissionWhisper | PermissionTextMessage | PermissionListen
+
+// Group returns the ACL's group with the given name, or nil if the group does
+// not exist.
+func (a *ACL) Group(name string) *ACLGroup {
+	for _, group := range a.Groups {
+		if group.Name == name {
+			return group
+		}
+	}
+	return nil
+}
+
+// IsMember returns true if the user is part of the group with the given name,
+// as it would be evaluated by the server in the ACL's channel.
+//
+// In addition to the groups defined in the ACL, the built-in groups and group
+// syntax are supported:
+//  all        everyone
+//  auth       registered users
+//  in         users in the channel
+//  out        users not in the channel
+//  sub,a,b,c  users in a sub-channel of the channel (see the Mumble ACL
+//             documentation for the meaning of the optional a, b and c)
+//  $hash      the user with the given certificate hash
+//  #token     users who have provided the given access token
+//  !group     users who are not part of the group
+//  ~group     the group as evaluated in the channel that defines the rule
+//
+// Because only the ACL of a single channel is known, "~group" is evaluated in
+// the ACL's channel. Access tokens are only known for Client.Self(), and are
+// taken from Config.Tokens.
+func (a *ACL) IsMember(name string, user *User) bool {
+	invert := false
+	for len(name) > 0 && (name[0] == '!' || name[0] == '~') {
+		if name[0] == '!' {
+			invert = !invert
+		}
+		name = name[1:]
+	}
+	return a.isMember(name, user) != invert
+}
+
+func (a *ACL) isMember(name string, user *User) bool {
+	switch {
+	case name == ACLGroupEveryone:
+		return true
+	case name == ACLGroupAuthenticated:
+		return user.IsRegistered()
+	case name == ACLGroupInsideChannel:
+		return user.Channel == a.Channel
+	case name == ACLGroupOutsideChannel:
+		return user.Channel != a.Channel
+	case name == "sub" || strings.HasPrefix(name, "sub,"):
+		return a.isSubMember(strings.Split(name, ",")[1:], user)
+	case strings.HasPrefix(name, "$"):
+		return user.Hash != "" && user.Hash == name[1:]
+	case strings.HasPrefix(name, "#"):
+		client := user.client
+		if client == nil || client.Self != user {
+			return false
+		}
+		for _, token := range client.Config.Tokens {
+			if token == name[1:] {
+				return true
+			}
+		}
+		return false
+	}
+	group := a.Group(name)
+	if group == nil || !user.IsRegistered() {
+		return false
+	}
+	return group.HasUser(user.UserID)
+}
+
+// isSubMember evaluates the "sub,minpath,mindesc,maxdesc" group.
+func (a *ACL) isSubMember(args []string, user *User) bool {
+	minPath, minDesc, maxDesc := 0, 1, 1000
+	for i, dst := range []*int{&minPath, &minDesc, &maxDesc} {
+		if i < len(args) {
+			if value, err := strconv.Atoi(args[i]); err == nil {
+				*dst = value
+			}
+		}
+	}
+	if user.Channel == nil {
+		return false
+	}
+
+	groupChain := channelChain(a.Channel)
+	userChain := channelChain(user.Channel)
+
+	offset := len(groupChain) - 1 + minPath
+	if offset >= len(groupChain) {
+		return false
+	}
+	if offset < 0 {
+		offset = 0
+	}
+	needed := groupChain[offset]
+	found := false
+	for _, channel := range userChain {
+		if channel == needed {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false
+	}
+	depth := len(userChain) - 1
+	return depth >= offset+minDesc && depth <= offset+maxDesc
+}
+
+// channelChain returns the channels from the root channel to channel.
+func channelChain(channel *Channel) []*Channel {
+	var chain []*Channel
+	for ; channel != nil; channel = channel.Parent {
+		chain = append([]*Channel{channel}, chain...)
+	}
+	return chain
+}
+
+// Permissions computes the effective permissions of user in the ACL's
+// channel, in the same way the server does: starting from
+// ACLDefaultPermissions (if the ACL does not inherit its parent's rules), each
+// matching rule that applies to the channel grants, then denies, its
+// permissions, in order. SuperUser has every permission but Speak and
+// Whisper, whatever the rules.
+//
+// The server includes the rules inherited from parent channels in the ACL it
+// sends, so they are taken into account. However, a missing traverse
+// permission in a parent channel cannot be detected from a single ACL.
+// Permissions that can only be applied in the root channel are only
+// meaningful when computed from the root channel's ACL.
+func (a *ACL) Permissions(user *User) Permission {
+	if user.IsSuperUser() {
+		return PermissionAll &^ (PermissionSpeak | PermissionWhisper)
+	}
+	granted := ACLDefaultPermissions
+	for _, rule := range a.Rules {
+		if rule.Inherited {
+			if !rule.AppliesChildren {
+				continue
+			}
+		} else if !rule.AppliesCurrent {
+			continue
+		}
+		if !a.ruleMatches(rule, user) {
+			continue
+		}
+		granted |= rule.Granted
+		granted &^= rule.Denied
+	}
+	if granted.Has(PermissionWrite) {
+		granted |= PermissionTraverse | PermissionEnter | PermissionMuteDeafen | PermissionMove | PermissionMakeChannel | PermissionLinkChannel | PermissionTextMessage | PermissionMakeTemporaryChannel | PermissionListen
+		if a.Channel != nil && a.Channel.IsRoot() {
+			granted |= PermissionKick | PermissionBan | PermissionRegister | PermissionRegisterSelf | PermissionResetUserContent
+		}
+	}
+	return granted
+}
+
+func (a *ACL) ruleMatches(rule *ACLRule, user *User) bool {
+	if rule.User != nil {
+		return user.IsRegistered() && rule.User.UserID == user.UserID
+	}
+	if rule.Group != nil {
+		return a.IsMember(rule.Group.Name, user)
+	}
+	return false
+}
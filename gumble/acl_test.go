@@ -0,0 +1,248 @@
+package gumble
+
+import (
+	"testing"
+)
+
+// testACLTree is a root channel with a child channel, which has a
+// grandchild channel.
+type testACLTree struct {
+	root, child, grandchild *Channel
+}
+
+func newTestACLTree() *testACLTree {
+	root := &Channel{ID: 0, Name: "Root"}
+	child := &Channel{ID: 1, Name: "Child", Parent: root}
+	grandchild := &Channel{ID: 2, Name: "Grandchild", Parent: child}
+	return &testACLTree{root, child, grandchild}
+}
+
+func testACLGroup(name string, members ...uint32) *ACLGroup {
+	group := &ACLGroup{
+		Name:        name,
+		Inheritable: true,
+		UsersAdd:    make(map[uint32]*ACLUser),
+	}
+	for _, id := range members {
+		group.UsersAdd[id] = &ACLUser{UserID: id}
+	}
+	return group
+}
+
+func TestACLIsMember(t *testing.T) {
+	tree := newTestACLTree()
+	acl := &ACL{
+		Channel: tree.child,
+		Groups:  []*ACLGroup{testACLGroup("admins", 5)},
+	}
+	registered := &User{UserID: 5, Channel: tree.child, Hash: "abcdef"}
+	guest := &User{Channel: tree.grandchild}
+	outsider := &User{UserID: 6, Channel: tree.root}
+
+	tests := []struct {
+		group string
+		user  *User
+		want  bool
+	}{
+		{"all", guest, true},
+		{"auth", registered, true},
+		{"auth", guest, false},
+		{"!auth", guest, true},
+		{"in", registered, true},
+		{"in", guest, false},
+		{"out", outsider, true},
+		{"out", registered, false},
+		{"sub", guest, true},
+		{"sub", registered, false},
+		{"sub,0,2", guest, false},
+		{"~sub", guest, true},
+		{"$abcdef", registered, true},
+		{"$abcdef", guest, false},
+		{"admins", registered, true},
+		{"admins", outsider, false},
+		{"!admins", outsider, true},
+		{"~admins", registered, true},
+		{"!~admins", registered, false},
+		{"!!admins", registered, true},
+		{"missing", registered, false},
+		{"!missing", registered, true},
+	}
+	for _, test := range tests {
+		if got := acl.IsMember(test.group, test.user); got != test.want {
+			t.Errorf("IsMember(%q, user %d in %s) = %v, expected %v\n", test.group, test.user.UserID, test.user.Channel.Name, got, test.want)
+		}
+	}
+}
+
+func TestACLPermissions(t *testing.T) {
+	tree := newTestACLTree()
+	admins := testACLGroup("admins", 5)
+	admin := &User{UserID: 5, Channel: tree.child}
+	registered := &User{UserID: 6, Channel: tree.child}
+	guest := &User{Channel: tree.child}
+	superUser := &User{UserID: 0, Channel: tree.child, superUser: true}
+	group := func(name string) *ACLGroup { return &ACLGroup{Name: name} }
+
+	writeExpansion := PermissionWrite | PermissionTraverse | PermissionEnter | PermissionMuteDeafen | PermissionMove | PermissionMakeChannel | PermissionLinkChannel | PermissionTextMessage | PermissionMakeTemporaryChannel | PermissionListen
+	rootExpansion := PermissionKick | PermissionBan | PermissionRegister | PermissionRegisterSelf | PermissionResetUserContent
+
+	tests := []struct {
+		name    string
+		channel *Channel
+		inherit bool
+		rules   []*ACLRule
+		user    *User
+		want    Permission
+	}{
+		{
+			name:    "defaults",
+			channel: tree.child,
+			user:    guest,
+			want:    ACLDefaultPermissions,
+		},
+		{
+			name:    "rule applying to the channel",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, Group: group("all"), Denied: PermissionSpeak},
+			},
+			user: guest,
+			want: ACLDefaultPermissions &^ PermissionSpeak,
+		},
+		{
+			name:    "rule applying only to sub-channels",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesChildren: true, Group: group("all"), Denied: PermissionSpeak},
+			},
+			user: guest,
+			want: ACLDefaultPermissions,
+		},
+		{
+			name:    "inherited rule applying to sub-channels",
+			channel: tree.child,
+			inherit: true,
+			rules: []*ACLRule{
+				{Inherited: true, AppliesChildren: true, Group: group("all"), Denied: PermissionEnter},
+			},
+			user: guest,
+			want: ACLDefaultPermissions &^ PermissionEnter,
+		},
+		{
+			name:    "inherited rule applying only to its channel",
+			channel: tree.child,
+			inherit: true,
+			rules: []*ACLRule{
+				{Inherited: true, AppliesCurrent: true, Group: group("all"), Denied: PermissionEnter},
+			},
+			user: guest,
+			want: ACLDefaultPermissions,
+		},
+		{
+			// The server does not send the parent's rules when the ACL
+			// does not inherit them.
+			name:    "no inherited rules",
+			channel: tree.child,
+			inherit: false,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, Group: group("auth"), Granted: PermissionMakeChannel},
+			},
+			user: registered,
+			want: ACLDefaultPermissions | PermissionMakeChannel,
+		},
+		{
+			name:    "auth does not match guests",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, Group: group("auth"), Granted: PermissionMakeChannel},
+			},
+			user: guest,
+			want: ACLDefaultPermissions,
+		},
+		{
+			name:    "negated group",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, Group: group("!auth"), Denied: PermissionTextMessage},
+			},
+			user: guest,
+			want: ACLDefaultPermissions &^ PermissionTextMessage,
+		},
+		{
+			name:    "later rules override earlier ones",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, Group: group("all"), Denied: PermissionSpeak},
+				{AppliesCurrent: true, Group: group("auth"), Granted: PermissionSpeak},
+			},
+			user: registered,
+			want: ACLDefaultPermissions,
+		},
+		{
+			name:    "user rule",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, User: &ACLUser{UserID: 6}, Granted: PermissionMove},
+			},
+			user: registered,
+			want: ACLDefaultPermissions | PermissionMove,
+		},
+		{
+			name:    "user rule of another user",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, User: &ACLUser{UserID: 6}, Granted: PermissionMove},
+			},
+			user: admin,
+			want: ACLDefaultPermissions,
+		},
+		{
+			name:    "write expansion",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, Group: admins, Granted: PermissionWrite},
+			},
+			user: admin,
+			want: ACLDefaultPermissions | writeExpansion,
+		},
+		{
+			name:    "write expansion in the root channel",
+			channel: tree.root,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, Group: admins, Granted: PermissionWrite},
+			},
+			user: admin,
+			want: ACLDefaultPermissions | writeExpansion | rootExpansion,
+		},
+		{
+			name:    "write expansion of a denied permission",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, Group: group("all"), Denied: PermissionListen},
+				{AppliesCurrent: true, Group: admins, Granted: PermissionWrite},
+			},
+			user: admin,
+			want: ACLDefaultPermissions | writeExpansion,
+		},
+		{
+			name:    "SuperUser",
+			channel: tree.child,
+			rules: []*ACLRule{
+				{AppliesCurrent: true, Group: group("all"), Denied: PermissionAll},
+			},
+			user: superUser,
+			want: PermissionAll &^ (PermissionSpeak | PermissionWhisper),
+		},
+	}
+	for _, test := range tests {
+		acl := &ACL{
+			Channel:  test.channel,
+			Groups:   []*ACLGroup{admins},
+			Rules:    test.rules,
+			Inherits: test.inherit,
+		}
+		if got := acl.Permissions(test.user); got != test.want {
+			t.Errorf("%s: got %v, expected %v\n", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package gumble
+
+import (
+	"time"
+)
+
+// channelSpeech records that user has spoken in their channel, and triggers a
+// ChannelActivityEvent if the channel was silent.
+func (c *Client) channelSpeech(user *User) {
+	channel := user.Channel
+	if c.Config.ChannelSilenceTimeout <= 0 || channel == nil || user == c.Self {
+		return
+	}
+	if ignore := c.Config.ChannelActivityIgnore; ignore != nil && ignore(user) {
+		return
+	}
+	channel.lastSpeech = time.Now()
+	if channel.active {
+		return
+	}
+	channel.active = true
+	event := ChannelActivityEvent{
+		Client:     c,
+		Channel:    channel,
+		Active:     true,
+		User:       user,
+		LastSpeech: channel.lastSpeech,
+	}
+	c.Config.Listeners.onChannelActivityChange(&event)
+}
+
+// silentChannels returns the active channels in which nobody has spoken for
+// Config.ChannelSilenceTimeout, and marks them as silent. c.volatile must be
+// held.
+func (c *Client) silentChannels(now time.Time) []*Channel {
+	timeout := c.Config.ChannelSilenceTimeout
+	if timeout <= 0 {
+		return nil
+	}
+	var silent []*Channel
+	for _, channel := range c.Channels {
+		if channel.active && now.Sub(channel.lastSpeech) >= timeout {
+			channel.active = false
+			silent = append(silent, channel)
+		}
+	}
+	return silent
+}
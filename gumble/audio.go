@@ -1,6 +1,8 @@
 package gumble
 
 import (
+	"math"
+	"sync/atomic"
 	"time"
 )
 
@@ -50,12 +52,36 @@ type AudioStreamEvent struct {
 // AudioBuffer is a slice of PCM audio samples.
 type AudioBuffer []int16
 
-func (a AudioBuffer) writeAudio(client *Client, seq int64, final bool) error {
+// scale multiplies each sample by volume, clipping the result to the range of
+// an int16.
+func (a AudioBuffer) scale(volume float32) {
+	for i, sample := range a {
+		value := float32(sample) * volume
+		switch {
+		case value > math.MaxInt16:
+			value = math.MaxInt16
+		case value < math.MinInt16:
+			value = math.MinInt16
+		}
+		a[i] = int16(value)
+	}
+}
+
+// writeAudio encodes and sends a. If dataBytes is zero, the number of bytes
+// that the frame can use is given by the client's config and bandwidth.
+func (a AudioBuffer) writeAudio(client *Client, seq int64, final bool, dataBytes int) error {
 	encoder := client.AudioEncoder
 	if encoder == nil {
 		return nil
 	}
-	dataBytes := client.Config.AudioDataBytes
+	if !client.Config.AudioIgnoreSelfState && atomic.LoadUint32(&client.selfMuted) == 1 {
+		return nil
+	}
+	if dataBytes == 0 {
+		interval := time.Duration(len(a)) * time.Second / AudioSampleRate
+		dataBytes = client.audioDataBytes(interval)
+	}
+	start := time.Now()
 	raw, err := encoder.Encode(a, len(a), dataBytes)
 	if final {
 		defer encoder.Reset()
@@ -64,12 +90,30 @@ func (a AudioBuffer) writeAudio(client *Client, seq int64, final bool) error {
 		return err
 	}
 
+	trace := client.Config.AudioTrace
+	if trace == nil {
+		return client.writeEncodedAudio(raw, seq, final)
+	}
+	encoded := time.Now()
+	err = client.writeEncodedAudio(raw, seq, final)
+	trace.outgoing(start, seq, a, raw, encoded.Sub(start), time.Since(encoded), final)
+	return err
+}
+
+// writeEncodedAudio sends the given encoded audio data to the server, or
+// passes it to loopbackAudio if loopback is enabled.
+func (c *Client) writeEncodedAudio(data []byte, seq int64, final bool) error {
+	if c.Config.AudioLoopback {
+		return c.loopbackAudio(data, seq, final)
+	}
+
 	var targetID byte
-	if target := client.VoiceTarget; target != nil {
+	if target := c.VoiceTarget; target != nil {
 		targetID = byte(target.ID)
 	}
+	c.bandwidth.add(len(data) + audioPacketOverhead)
 	// TODO: re-enable positional audio
-	return client.Conn.WriteAudio(byte(4), targetID, seq, final, raw, nil, nil, nil)
+	return c.Conn.WriteAudio(byte(4), targetID, seq, final, data, nil, nil, nil)
 }
 
 // AudioPacket contains incoming audio samples and information.
@@ -77,9 +121,49 @@ type AudioPacket struct {
 	Client *Client
 	Sender *User
 	Target *VoiceTarget
+	// The sequence number of the packet.
+	Sequence int64
 
 	AudioBuffer
+	// The encoded audio data from which AudioBuffer was decoded. Only
+	// populated for listeners attached with AudioListeners.AttachEncoded.
+	// When a packet contains several frames, which are passed as one
+	// AudioPacket each, Encoded holds the whole packet in the first
+	// AudioPacket, and is empty in the others.
+	Encoded []byte
 
 	HasPosition bool
 	X, Y, Z     float32
 }
+
+// loopbackAudio decodes the given outgoing audio data and passes it to the
+// client's AudioListeners.
+func (c *Client) loopbackAudio(data []byte, seq int64, final bool) error {
+	self := c.Self
+	if self == nil || c.audioCodec == nil {
+		return errNoCodec
+	}
+	if c.loopbackDecoder == nil {
+		c.loopbackDecoder = c.audioCodec.NewDecoder()
+	}
+	decoder := c.loopbackDecoder
+	if final {
+		defer decoder.Reset()
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	pcm, err := decoder.Decode(data, AudioMaximumFrameSize)
+	if err != nil {
+		return err
+	}
+	packet := AudioPacket{
+		Client:      c,
+		Sender:      self,
+		Target:      VoiceTargetLoopback,
+		Sequence:    seq,
+		AudioBuffer: AudioBuffer(pcm),
+	}
+	c.dispatchAudio(self, &packet, data)
+	return nil
+}
@@ -0,0 +1,138 @@
+package gumble
+
+import (
+	"sync"
+	"time"
+)
+
+// audioMux hands the client's outgoing transmission over between the
+// channels created by AudioOutgoing and AudioHandoff. The most recently
+// created channel that is still open is the active one; the buffers written
+// to the others are kept only for crossfading.
+type audioMux struct {
+	mu        sync.Mutex
+	producers []*audioProducer
+	t         audioTransmission
+}
+
+type audioProducer struct {
+	// The number of samples over which this producer's audio fades in when it
+	// takes over, or when it is handed back to.
+	crossfade int
+	// The progress of the fade in of this producer's audio.
+	fadePos, fadeLen int
+	// The producer whose audio fades out while this one fades in.
+	fadeFrom *audioProducer
+	// The last buffer written while the producer was not active.
+	last AudioBuffer
+}
+
+// AudioHandoff creates a new channel that outgoing audio data can be written
+// to, like AudioOutgoing, which takes over the transmission from the channel
+// that is currently active, if any, without ending it.
+//
+// While the new channel is open, the buffers written to the previous one are
+// discarded; the previous producer (e.g. a microphone) keeps running. Over
+// the first crossfade of audio written to the new channel, its audio fades in
+// while the previous channel's fades out. Once the new channel is closed, the
+// previous channel is active again, and its audio fades back in over
+// crossfade. The transmission ends once every channel has been closed.
+//
+// For example, a jingle can be played over a microphone with:
+//  jingle := client.AudioHandoff(50 * time.Millisecond)
+//  // write the jingle's audio to jingle
+//  close(jingle)
+func (c *Client) AudioHandoff(crossfade time.Duration) chan<- AudioBuffer {
+	p := &audioProducer{
+		crossfade: int(int64(crossfade) * AudioSampleRate / int64(time.Second)),
+	}
+
+	m := &c.audioMux
+	m.mu.Lock()
+	m.t.client = c
+	if len(m.producers) > 0 {
+		p.fadeFrom = m.producers[len(m.producers)-1]
+		p.fadeLen = p.crossfade
+	}
+	m.producers = append(m.producers, p)
+	m.mu.Unlock()
+
+	ch := make(chan AudioBuffer)
+	go func() {
+		for buffer := range ch {
+			m.write(p, buffer)
+		}
+		m.remove(p)
+	}()
+	return ch
+}
+
+// active returns the producer whose audio is transmitted. m.mu must be held.
+func (m *audioMux) active() *audioProducer {
+	if len(m.producers) == 0 {
+		return nil
+	}
+	return m.producers[len(m.producers)-1]
+}
+
+func (m *audioMux) write(p *audioProducer, buffer AudioBuffer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if p != m.active() {
+		p.last = buffer
+		return
+	}
+	if p.fadePos < p.fadeLen {
+		buffer = append(AudioBuffer(nil), buffer...)
+		var previous AudioBuffer
+		if p.fadeFrom != nil {
+			previous = p.fadeFrom.last
+			p.fadeFrom.last = nil
+		}
+		for i, sample := range buffer {
+			gain := float32(p.fadePos+i) / float32(p.fadeLen)
+			if gain > 1 {
+				gain = 1
+			}
+			value := float32(sample) * gain
+			if i < len(previous) {
+				value += float32(previous[i]) * (1 - gain)
+			}
+			buffer[i] = int16(value)
+		}
+		p.fadePos += len(buffer)
+	}
+	m.t.add(buffer)
+}
+
+// remove removes p from the producers once its channel has been closed.
+func (m *audioMux) remove(p *audioProducer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	wasActive := p == m.active()
+	for i, producer := range m.producers {
+		if producer == p {
+			m.producers = append(m.producers[:i], m.producers[i+1:]...)
+			break
+		}
+	}
+	for _, producer := range m.producers {
+		if producer.fadeFrom == p {
+			producer.fadeFrom = nil
+		}
+	}
+	if !wasActive {
+		return
+	}
+	next := m.active()
+	if next == nil {
+		m.t.end()
+		return
+	}
+	next.last = nil
+	next.fadeFrom = nil
+	next.fadePos = 0
+	next.fadeLen = p.crossfade
+}
@@ -1,10 +1,41 @@
 package gumble
 
+import (
+	"time"
+)
+
 type audioEventItem struct {
 	parent     *AudioListeners
 	prev, next *audioEventItem
 	listener   AudioListener
 	streams    map[*User]chan *AudioPacket
+	encoded    bool
+	// The users whose stream queue is full (see Client.sendAudio). It is only
+	// used by the client's read goroutine.
+	limited map[*User]bool
+}
+
+// closeStream ends the audio stream of user, if one exists.
+func (e *audioEventItem) closeStream(user *User) {
+	if ch := e.streams[user]; ch != nil {
+		close(ch)
+		delete(e.streams, user)
+		delete(e.limited, user)
+	}
+}
+
+// closeOldestStream ends the stream of the user that has been silent the
+// longest.
+func (e *audioEventItem) closeOldestStream() {
+	var oldest *User
+	for user := range e.streams {
+		if oldest == nil || user.lastAudio.Before(oldest.lastAudio) {
+			oldest = user
+		}
+	}
+	if oldest != nil {
+		e.closeStream(oldest)
+	}
 }
 
 func (e *audioEventItem) Detach() {
@@ -18,6 +49,7 @@ func (e *audioEventItem) Detach() {
 	} else {
 		e.next.prev = e.prev
 	}
+	detachCopies(e)
 }
 
 // AudioListeners is a list of audio listeners. Each attached listener is
@@ -28,19 +60,68 @@ type AudioListeners struct {
 
 // Attach adds a new audio listener to the end of the current list of listeners.
 func (e *AudioListeners) Attach(listener AudioListener) Detacher {
+	return e.attach(listener, false)
+}
+
+// AttachEncoded is like Attach, except that the AudioPackets passed to the
+// listener also contain the undecoded audio data (AudioPacket.Encoded). This
+// is useful for recording or re-streaming audio without transcoding it.
+func (e *AudioListeners) AttachEncoded(listener AudioListener) Detacher {
+	return e.attach(listener, true)
+}
+
+// closeStreams ends the audio streams of user in every listener, and releases
+// the user's decoder. The client's volatile lock must be held, and the
+// function must be called from the client's read goroutine.
+func (e *AudioListeners) closeStreams(user *User) {
+	for item := e.head; item != nil; item = item.next {
+		item.closeStream(user)
+	}
+	user.decoder = nil
+}
+
+// closeIdleStreams ends the audio streams of users that have not sent audio
+// since before deadline. The same locking rules as closeStreams apply.
+func (e *AudioListeners) closeIdleStreams(deadline time.Time) {
+	idle := make(map[*User]bool)
+	for item := e.head; item != nil; item = item.next {
+		for user := range item.streams {
+			if user.lastAudio.Before(deadline) {
+				idle[user] = true
+			}
+		}
+	}
+	for user := range idle {
+		e.closeStreams(user)
+	}
+}
+
+// closeAllStreams ends every audio stream. The same locking rules as
+// closeStreams apply.
+func (e *AudioListeners) closeAllStreams() {
+	for item := e.head; item != nil; item = item.next {
+		for user := range item.streams {
+			item.closeStream(user)
+			user.decoder = nil
+		}
+	}
+}
+
+func (e *AudioListeners) attach(listener AudioListener, encoded bool) Detacher {
 	item := &audioEventItem{
 		parent:   e,
 		prev:     e.tail,
 		listener: listener,
 		streams:  make(map[*User]chan *AudioPacket),
+		encoded:  encoded,
+		limited:  make(map[*User]bool),
 	}
 	if e.head == nil {
 		e.head = item
 	}
-	if e.tail == nil {
-		e.tail = item
-	} else {
+	if e.tail != nil {
 		e.tail.next = item
 	}
+	e.tail = item
 	return item
 }
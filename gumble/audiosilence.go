@@ -0,0 +1,144 @@
+package gumble
+
+import (
+	"math"
+	"math/rand"
+)
+
+// AudioSilence specifies how the silent buffers written to AudioOutgoing are
+// sent (see Config.AudioSilenceLevel).
+type AudioSilence int
+
+const (
+	// AudioSilenceTransmit sends silent buffers like any other.
+	AudioSilenceTransmit AudioSilence = iota
+
+	// AudioSilenceTerminate ends the transmission (see AudioTerminator) when
+	// the audio becomes silent, and does not send silent buffers. A new
+	// transmission is started when the audio is no longer silent. This
+	// gives voice activity detection, where other clients only see the user
+	// talking while they speak.
+	AudioSilenceTerminate
+
+	// AudioSilenceComfortNoise replaces silent buffers with quiet noise,
+	// encoded at a low bitrate, so that the transmission goes on without
+	// the listeners hearing dead air.
+	AudioSilenceComfortNoise
+)
+
+// AudioTerminator specifies how the end of a transmission is marked, so that
+// other clients stop showing the user as talking and close their jitter
+// buffers.
+type AudioTerminator int
+
+const (
+	// AudioTerminatorLastFrame marks the last buffer of the transmission as
+	// its end.
+	AudioTerminatorLastFrame AudioTerminator = iota
+
+	// AudioTerminatorSilence sends a frame of silence, marked as the end of
+	// the transmission, after the last buffer. This is what the official
+	// client does, and lets the remote decoders fade out rather than stop
+	// abruptly.
+	AudioTerminatorSilence
+
+	// AudioTerminatorEmpty sends an empty frame, marked as the end of the
+	// transmission, after the last buffer.
+	AudioTerminatorEmpty
+)
+
+const (
+	// comfortNoiseAmplitude is the peak amplitude of comfort noise (about
+	// -60 dBFS).
+	comfortNoiseAmplitude = 32
+	// comfortNoiseDataBytes is the number of bytes that a frame of comfort
+	// noise can use.
+	comfortNoiseDataBytes = 10
+)
+
+// audioTransmission sends the buffers written to an AudioOutgoing channel.
+// The last buffer is held back until the next one is written, so that it
+// can be marked as the end of the transmission.
+type audioTransmission struct {
+	client   *Client
+	seq      int64
+	previous AudioBuffer
+	// The number of bytes that previous can use; zero for the default.
+	previousBytes int
+}
+
+// add sends the buffer held back, if any, and holds back buffer.
+func (t *audioTransmission) add(buffer AudioBuffer) {
+	config := t.client.Config
+	dataBytes := 0
+	if config.AudioSilenceLevel > 0 && buffer.peak() < config.AudioSilenceLevel {
+		switch config.AudioSilence {
+		case AudioSilenceTerminate:
+			t.end()
+			return
+		case AudioSilenceComfortNoise:
+			buffer = comfortNoise(len(buffer))
+			dataBytes = comfortNoiseDataBytes
+		}
+	}
+	if t.previous != nil {
+		t.write(t.previous, false, t.previousBytes)
+	}
+	t.previous = buffer
+	t.previousBytes = dataBytes
+}
+
+// end sends the buffer held back, if any, as the end of the transmission.
+func (t *audioTransmission) end() {
+	last := t.previous
+	if last == nil {
+		return
+	}
+	t.previous = nil
+
+	switch t.client.Config.AudioTerminator {
+	case AudioTerminatorSilence:
+		t.write(last, false, t.previousBytes)
+		t.write(make(AudioBuffer, len(last)), true, 0)
+	case AudioTerminatorEmpty:
+		t.write(last, false, t.previousBytes)
+		if encoder := t.client.AudioEncoder; encoder != nil {
+			encoder.Reset()
+		}
+		t.client.writeEncodedAudio(nil, t.seq, true)
+		t.seq = (t.seq + 1) % math.MaxInt32
+	default:
+		t.write(last, true, t.previousBytes)
+	}
+}
+
+func (t *audioTransmission) write(buffer AudioBuffer, final bool, dataBytes int) {
+	buffer.writeAudio(t.client, t.seq, final, dataBytes)
+	t.seq = (t.seq + 1) % math.MaxInt32
+}
+
+// peak returns the largest absolute value of the samples of a.
+func (a AudioBuffer) peak() int16 {
+	var peak int16
+	for _, sample := range a {
+		if sample < 0 {
+			if sample == math.MinInt16 {
+				return math.MaxInt16
+			}
+			sample = -sample
+		}
+		if sample > peak {
+			peak = sample
+		}
+	}
+	return peak
+}
+
+// comfortNoise returns samples of quiet white noise.
+func comfortNoise(samples int) AudioBuffer {
+	buffer := make(AudioBuffer, samples)
+	for i := range buffer {
+		buffer[i] = int16(rand.Intn(2*comfortNoiseAmplitude+1) - comfortNoiseAmplitude)
+	}
+	return buffer
+}
@@ -0,0 +1,130 @@
+package gumble
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// AudioTrace writes a timestamped line for each audio frame that the client
+// sends or receives, to help diagnose choppy or robotic audio. See
+// Config.AudioTrace.
+//
+// Outgoing frames are traced as:
+//  2006-01-02T15:04:05.000000Z07:00 out seq=12 samples=480 interval=10.2ms jitter=200µs encode=85µs bytes=40 send=120µs final=false
+// where interval is the time since the previous frame was written to
+// AudioOutgoing, jitter is how much it differs from the frame's duration,
+// encode is the time taken to encode the frame and send the time taken to
+// write it to the connection. Incoming frames are traced as:
+//  2006-01-02T15:04:05.000000Z07:00 in session=3 seq=40 bytes=38 samples=480 decode=60µs
+//
+// An AudioTrace can also dump the outgoing audio to files with Dump.
+type AudioTrace struct {
+	mu           sync.Mutex
+	w            io.Writer
+	lastOutgoing time.Time
+
+	dumpUntil time.Time
+	pcmDump   *os.File
+	opusDump  *os.File
+}
+
+// NewAudioTrace returns a new AudioTrace that writes to w.
+func NewAudioTrace(w io.Writer) *AudioTrace {
+	return &AudioTrace{
+		w: w,
+	}
+}
+
+// Dump writes the outgoing audio for the given duration to two new files in
+// dir, whose names are returned: the PCM audio before it is encoded (signed
+// 16-bit little-endian samples at AudioSampleRate, as ".pcm"), and the
+// encoded frames (each preceded by its length as a 16-bit big-endian
+// integer, as ".opus"). A dump that is in progress is stopped.
+func (t *AudioTrace) Dump(dir string, duration time.Duration) (pcmFile, opusFile string, err error) {
+	name := filepath.Join(dir, "gumble-audio-"+time.Now().Format("20060102-150405"))
+	pcmDump, err := os.Create(name + ".pcm")
+	if err != nil {
+		return "", "", err
+	}
+	opusDump, err := os.Create(name + ".opus")
+	if err != nil {
+		pcmDump.Close()
+		return "", "", err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.closeDump()
+	t.pcmDump = pcmDump
+	t.opusDump = opusDump
+	t.dumpUntil = time.Now().Add(duration)
+	return pcmDump.Name(), opusDump.Name(), nil
+}
+
+// StopDump stops the dump in progress, if any, and closes its files.
+func (t *AudioTrace) StopDump() {
+	t.mu.Lock()
+	t.closeDump()
+	t.mu.Unlock()
+}
+
+func (t *AudioTrace) closeDump() {
+	if t.pcmDump != nil {
+		t.pcmDump.Close()
+		t.opusDump.Close()
+		t.pcmDump = nil
+		t.opusDump = nil
+	}
+}
+
+// outgoing traces an outgoing frame, which was written to AudioOutgoing at
+// start.
+func (t *AudioTrace) outgoing(start time.Time, seq int64, pcm AudioBuffer, encoded []byte, encode, send time.Duration, final bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var interval, jitter time.Duration
+	if !t.lastOutgoing.IsZero() {
+		interval = start.Sub(t.lastOutgoing)
+		jitter = interval - time.Duration(len(pcm))*time.Second/AudioSampleRate
+	}
+	t.lastOutgoing = start
+	if final {
+		// The next frame starts a new transmission.
+		t.lastOutgoing = time.Time{}
+	}
+	fmt.Fprintf(t.w, "%s out seq=%d samples=%d interval=%s jitter=%s encode=%s bytes=%d send=%s final=%t\n",
+		start.Format(audioTraceTimeFormat), seq, len(pcm), interval, jitter, encode, len(encoded), send, final)
+
+	if t.pcmDump == nil {
+		return
+	}
+	if start.After(t.dumpUntil) {
+		t.closeDump()
+		return
+	}
+	raw := make([]byte, len(pcm)*2)
+	for i, sample := range pcm {
+		binary.LittleEndian.PutUint16(raw[i*2:], uint16(sample))
+	}
+	t.pcmDump.Write(raw)
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(encoded)))
+	t.opusDump.Write(length[:])
+	t.opusDump.Write(encoded)
+}
+
+// incoming traces a frame received from user.
+func (t *AudioTrace) incoming(user *User, seq int64, encoded []byte, samples int, decode time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	fmt.Fprintf(t.w, "%s in session=%d seq=%d bytes=%d samples=%d decode=%s\n",
+		time.Now().Format(audioTraceTimeFormat), user.Session, seq, len(encoded), samples, decode)
+}
+
+const audioTraceTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
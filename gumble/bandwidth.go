@@ -0,0 +1,82 @@
+package gumble
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// audioPacketOverhead is the approximate number of bytes, in addition to the
+// encoded audio data, that are used when sending one audio packet through the
+// control connection: the IPv4 and TCP headers (40), the TLS record header and
+// authentication tag (21), the Mumble packet prefix (6), and the voice packet
+// header (5).
+const audioPacketOverhead = 40 + 21 + 6 + 5
+
+// bandwidthMeter measures the outgoing audio bandwidth of a client.
+type bandwidthMeter struct {
+	sync.Mutex
+	start time.Time
+	bytes int
+	rate  int
+	last  time.Time
+}
+
+// add records that a packet with the given number of bytes was sent.
+func (b *bandwidthMeter) add(bytes int) {
+	b.Lock()
+	defer b.Unlock()
+
+	now := time.Now()
+	if b.start.IsZero() || now.Sub(b.last) > time.Second {
+		b.start = now
+		b.bytes = 0
+		b.rate = 0
+	}
+	b.last = now
+	b.bytes += bytes
+	if elapsed := now.Sub(b.start); elapsed >= time.Second {
+		b.rate = int(float64(b.bytes*8) / elapsed.Seconds())
+		b.start = now
+		b.bytes = 0
+	}
+}
+
+// bitrate returns the measured bandwidth, in bits per second.
+func (b *bandwidthMeter) bitrate() int {
+	b.Lock()
+	defer b.Unlock()
+
+	if time.Since(b.last) > time.Second {
+		return 0
+	}
+	return b.rate
+}
+
+// Bandwidth returns the client's current outgoing audio bandwidth usage (in
+// bits per second), including the estimated network overhead of each packet.
+func (c *Client) Bandwidth() int {
+	return c.bandwidth.bitrate()
+}
+
+// audioDataBytes returns the number of bytes that an outgoing audio frame of
+// the given interval can use. Config.AudioDataBytes is reduced if using it
+// would exceed the server's maximum bandwidth.
+func (c *Client) audioDataBytes(interval time.Duration) int {
+	c.volatile.RLock()
+	dataBytes := c.Config.AudioDataBytes
+	c.volatile.RUnlock()
+	maximum := int(atomic.LoadInt32(&c.maximumBitrate))
+	if maximum <= 0 || interval <= 0 {
+		return dataBytes
+	}
+	packetsPerSecond := float64(time.Second) / float64(interval)
+	allowed := int(float64(maximum)/8/packetsPerSecond) - audioPacketOverhead
+	if allowed < 1 {
+		allowed = 1
+	}
+	if dataBytes > allowed {
+		return allowed
+	}
+	return dataBytes
+}
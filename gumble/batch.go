@@ -0,0 +1,61 @@
+package gumble
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// Batch collects the packets that are sent to the server during a call to
+// Client.Batch.
+type Batch struct {
+	client *Client
+	err    error
+}
+
+// Send adds message to the batch.
+func (b *Batch) Send(message Message) {
+	if err := message.writeMessage(b.client); err != nil && b.err == nil {
+		b.err = err
+	}
+}
+
+// WriteProto adds a protocol buffer message to the batch.
+func (b *Batch) WriteProto(message proto.Message) error {
+	err := b.client.Conn.WriteProto(message)
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+	return err
+}
+
+// Batch calls f, and writes the packets that are sent to the server while it
+// runs to the connection at once, in a single write (and, if it is small
+// enough, a single TLS record), rather than one write per packet. No other
+// packet is written in the middle of the batch, so, for example, a voice
+// target can be registered and used by the audio that follows it without any
+// other packet between them.
+//
+// The packets that f sends with b, and those sent with the other functions
+// that write to the server (e.g. User.Move) while f runs, including those
+// sent by other goroutines, are part of the batch. f should thus return
+// quickly. Calls to Batch from different goroutines are serialized, and Batch
+// must not be called from f.
+//
+// The error of the first packet that could not be added to the batch, or of
+// queuing the batch, is returned.
+func (c *Client) Batch(f func(b *Batch)) (err error) {
+	c.batchLock.Lock()
+	defer c.batchLock.Unlock()
+
+	b := &Batch{client: c}
+	c.Conn.beginBatch()
+	// The batch is queued even if f panics, so that the write queue does not
+	// keep holding back packets.
+	defer func() {
+		if err := c.Conn.endBatch(); err != nil && b.err == nil {
+			b.err = err
+		}
+		err = b.err
+	}()
+	f(b)
+	return nil
+}
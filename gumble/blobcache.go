@@ -0,0 +1,179 @@
+package gumble
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// BlobCache stores the large blobs that the server identifies by hash: user
+// comments and textures, and channel descriptions. When the server only sends
+// the hash of a blob that is in the cache, the client uses the cached blob
+// rather than leaving it to be requested (see User.RequestComment,
+// User.RequestTexture and Channel.RequestDescription).
+//
+// Hashes are SHA-1 sums of the blobs. Implementations must be safe for
+// concurrent use.
+type BlobCache interface {
+	// Get returns the blob whose hash is hash, and true if it is in the
+	// cache.
+	Get(hash []byte) ([]byte, bool)
+	// Put adds blob, whose hash is hash, to the cache.
+	Put(hash, blob []byte)
+}
+
+// DefaultBlobCacheSize is the size, in bytes, of the BlobCache created by
+// NewConfig.
+const DefaultBlobCacheSize = 8 * 1024 * 1024
+
+// blobHashThreshold is the size from which the server sends the hash of a
+// blob, rather than the blob itself.
+const blobHashThreshold = 128
+
+// MemoryBlobCache is a BlobCache that keeps blobs in memory, evicting the
+// least recently used ones when the cache is full.
+type MemoryBlobCache struct {
+	mu      sync.Mutex
+	size    int
+	maxSize int
+	lru     *list.List
+	entries map[string]*list.Element
+}
+
+type memoryBlob struct {
+	key  string
+	blob []byte
+}
+
+// NewMemoryBlobCache returns a new MemoryBlobCache that holds at most maxSize
+// bytes of blobs.
+func NewMemoryBlobCache(maxSize int) *MemoryBlobCache {
+	return &MemoryBlobCache{
+		maxSize: maxSize,
+		lru:     list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// Get implements BlobCache.
+func (m *MemoryBlobCache) Get(hash []byte) ([]byte, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.entries[string(hash)]
+	if !ok {
+		return nil, false
+	}
+	m.lru.MoveToFront(element)
+	return element.Value.(*memoryBlob).blob, true
+}
+
+// Put implements BlobCache. Blobs larger than the cache are not stored.
+func (m *MemoryBlobCache) Put(hash, blob []byte) {
+	if len(blob) > m.maxSize {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := string(hash)
+	if element, ok := m.entries[key]; ok {
+		m.lru.MoveToFront(element)
+		return
+	}
+	m.entries[key] = m.lru.PushFront(&memoryBlob{
+		key:  key,
+		blob: append([]byte(nil), blob...),
+	})
+	m.size += len(blob)
+	for m.size > m.maxSize {
+		element := m.lru.Back()
+		entry := element.Value.(*memoryBlob)
+		m.lru.Remove(element)
+		delete(m.entries, entry.key)
+		m.size -= len(entry.blob)
+	}
+}
+
+// DiskBlobCache is a BlobCache that stores each blob in a file of a
+// directory, so that blobs are kept across program restarts. Blobs are never
+// evicted.
+type DiskBlobCache struct {
+	dir string
+}
+
+// NewDiskBlobCache returns a new DiskBlobCache that stores blobs in dir,
+// creating the directory if needed.
+func NewDiskBlobCache(dir string) (*DiskBlobCache, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+	return &DiskBlobCache{
+		dir: dir,
+	}, nil
+}
+
+// Get implements BlobCache. Files whose content does not match their hash
+// (e.g. that were partially written) are ignored.
+func (d *DiskBlobCache) Get(hash []byte) ([]byte, bool) {
+	blob, err := ioutil.ReadFile(d.filename(hash))
+	if err != nil {
+		return nil, false
+	}
+	sum := sha1.Sum(blob)
+	if !bytes.Equal(sum[:], hash) {
+		return nil, false
+	}
+	return blob, true
+}
+
+// Put implements BlobCache. Errors writing the blob are ignored; the blob is
+// then requested from the server when needed, as if it was not cached.
+func (d *DiskBlobCache) Put(hash, blob []byte) {
+	filename := d.filename(hash)
+	if _, err := os.Stat(filename); err == nil {
+		return
+	}
+	file, err := ioutil.TempFile(d.dir, "blob-")
+	if err != nil {
+		return
+	}
+	_, err = file.Write(blob)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+}
+
+func (d *DiskBlobCache) filename(hash []byte) string {
+	return filepath.Join(d.dir, hex.EncodeToString(hash))
+}
+
+// cachedBlob returns the blob whose hash is hash from the client's
+// BlobCache, if any.
+func (c *Client) cachedBlob(hash []byte) ([]byte, bool) {
+	if c.Config.BlobCache == nil || len(hash) == 0 {
+		return nil, false
+	}
+	return c.Config.BlobCache.Get(hash)
+}
+
+// cacheBlob adds blob to the client's BlobCache, if it is large enough for
+// the server to refer to it by hash.
+func (c *Client) cacheBlob(blob []byte) {
+	if c.Config.BlobCache == nil || len(blob) < blobHashThreshold {
+		return
+	}
+	sum := sha1.Sum(blob)
+	c.Config.BlobCache.Put(sum[:], blob)
+}
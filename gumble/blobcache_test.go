@@ -0,0 +1,126 @@
+package gumble
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+	"github.com/golang/protobuf/proto"
+)
+
+func testBlob(b byte, n int) []byte {
+	return bytes.Repeat([]byte{b}, n)
+}
+
+func checkTestCacheHit(t *testing.T, cache BlobCache, blob []byte, want bool) {
+	got, ok := cache.Get(blobHash(blob))
+	switch {
+	case ok != want:
+		t.Errorf("blob of %d bytes %q: cached is %v, expected %v\n", len(blob), blob[:1], ok, want)
+	case ok && !bytes.Equal(got, blob):
+		t.Errorf("blob of %d bytes %q: got a different blob\n", len(blob), blob[:1])
+	}
+}
+
+func TestMemoryBlobCache(t *testing.T) {
+	cache := NewMemoryBlobCache(300)
+	a, b, c := testBlob('a', 100), testBlob('b', 100), testBlob('c', 100)
+	for _, blob := range [][]byte{a, b, c} {
+		cache.Put(blobHash(blob), blob)
+	}
+	checkTestCacheHit(t, cache, a, true)
+	checkTestCacheHit(t, cache, testBlob('a', 101), false)
+
+	// b is the least recently used blob, so it is evicted.
+	d := testBlob('d', 100)
+	cache.Put(blobHash(d), d)
+	checkTestCacheHit(t, cache, b, false)
+	checkTestCacheHit(t, cache, a, true)
+	checkTestCacheHit(t, cache, c, true)
+	checkTestCacheHit(t, cache, d, true)
+
+	// A blob larger than the cache is not stored, and does not evict the
+	// others.
+	large := testBlob('e', 301)
+	cache.Put(blobHash(large), large)
+	checkTestCacheHit(t, cache, large, false)
+	checkTestCacheHit(t, cache, a, true)
+}
+
+func TestDiskBlobCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gumble-blobs-")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache, err := NewDiskBlobCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, b := testBlob('a', 200), testBlob('b', 200)
+	cache.Put(blobHash(a), a)
+	cache.Put(blobHash(b), b)
+
+	// The blobs are kept across caches of the same directory.
+	cache, err = NewDiskBlobCache(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkTestCacheHit(t, cache, a, true)
+	checkTestCacheHit(t, cache, testBlob('c', 200), false)
+
+	if err := ioutil.WriteFile(cache.filename(blobHash(b)), b[:100], 0600); err != nil {
+		t.Fatal(err)
+	}
+	checkTestCacheHit(t, cache, b, false)
+}
+
+func TestBlobCacheHashes(t *testing.T) {
+	config := NewConfig()
+	client, server := NewReplayClient(config)
+	defer server.Close()
+
+	comment := strings.Repeat("c", blobHashThreshold)
+	description := strings.Repeat("d", blobHashThreshold)
+	texture := testBlob('t', blobHashThreshold)
+	uncached := strings.Repeat("u", blobHashThreshold)
+	config.BlobCache.Put(blobHash([]byte(description)), []byte(description))
+
+	packets := []proto.Message{
+		&MumbleProto.ChannelState{ChannelId: proto.Uint32(0), Name: proto.String("Root")},
+		// The blobs sent by the server are cached.
+		&MumbleProto.UserState{Session: proto.Uint32(1), Name: proto.String("alice"), ChannelId: proto.Uint32(0), Comment: proto.String(comment), Texture: texture},
+		// The blobs of matching hashes are taken from the cache.
+		&MumbleProto.UserState{Session: proto.Uint32(2), Name: proto.String("bob"), ChannelId: proto.Uint32(0), CommentHash: blobHash([]byte(comment)), TextureHash: blobHash(texture)},
+		&MumbleProto.ChannelState{ChannelId: proto.Uint32(1), Parent: proto.Uint32(0), Name: proto.String("Lobby"), DescriptionHash: blobHash([]byte(description))},
+		&MumbleProto.ChannelState{ChannelId: proto.Uint32(2), Parent: proto.Uint32(0), Name: proto.String("Games"), DescriptionHash: blobHash([]byte(uncached))},
+		&MumbleProto.Ping{},
+	}
+	for _, packet := range packets {
+		if err := server.WriteProto(packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	client.Do(func() {
+		bob := client.Users[2]
+		if bob.Comment != comment || bob.CommentHash != nil {
+			t.Errorf("got a comment of %d bytes and hash %x, expected the cached comment\n", len(bob.Comment), bob.CommentHash)
+		}
+		if !bytes.Equal(bob.Texture, texture) || bob.TextureHash != nil {
+			t.Errorf("got a texture of %d bytes and hash %x, expected the cached texture\n", len(bob.Texture), bob.TextureHash)
+		}
+		if lobby := client.Channels[1]; lobby.Description != description || lobby.DescriptionHash != nil {
+			t.Errorf("got a description of %d bytes and hash %x, expected the cached description\n", len(lobby.Description), lobby.DescriptionHash)
+		}
+		// A hash that is not in the cache is kept, so that the blob can be
+		// requested.
+		if games := client.Channels[2]; games.Description != "" || !bytes.Equal(games.DescriptionHash, blobHash([]byte(uncached))) {
+			t.Errorf("got a description of %d bytes and hash %x, expected the hash of the uncached description\n", len(games.Description), games.DescriptionHash)
+		}
+	})
+}
@@ -1,6 +1,8 @@
 package gumble
 
 import (
+	"time"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/bmmcginty/gumble/gumble/MumbleProto"
 )
@@ -19,6 +21,8 @@ type Channel struct {
 	Links Channels
 	// The users currently in the channel.
 	Users Users
+	// The users currently listening to the channel.
+	Listeners Users
 	// The channel's description. Contains the empty string if the channel does
 	// not have a description, or if it needs to be requested.
 	Description string
@@ -35,6 +39,12 @@ type Channel struct {
 	Temporary bool
 
 	client *Client
+	// Values attached with SetTag.
+	tags tags
+	// When a user last spoke in the channel, and if the channel is active
+	// (see ChannelActivityEvent).
+	lastSpeech time.Time
+	active     bool
 }
 
 // IsRoot returns true if the channel is the server's root channel.
@@ -42,7 +52,8 @@ func (c *Channel) IsRoot() bool {
 	return c.ID == 0
 }
 
-// Add will add a sub-channel to the given channel.
+// Add will add a sub-channel to the given channel. Use Client.CreateChannel
+// to set the channel's other properties when it is created.
 func (c *Channel) Add(name string, temporary bool) {
 	packet := MumbleProto.ChannelState{
 		Parent:    &c.ID,
@@ -124,7 +135,9 @@ func (c *Channel) Find(names ...string) *Channel {
 }
 
 // RequestDescription requests that the actual channel description
-// (i.e. non-hashed) be sent to the client.
+// (i.e. non-hashed) be sent to the client. Descriptions in the client's
+// BlobCache are used without being requested, so DescriptionHash is only set
+// if the description is not cached.
 func (c *Channel) RequestDescription() {
 	packet := MumbleProto.RequestBlob{
 		ChannelDescription: []uint32{c.ID},
@@ -138,6 +151,7 @@ func (c *Channel) RequestACL() {
 		ChannelId: &c.ID,
 		Query:     proto.Bool(true),
 	}
+	c.client.requestSent(RequestTypeACL, c.ID)
 	c.client.Conn.WriteProto(&packet)
 }
 
@@ -150,6 +164,7 @@ func (c *Channel) RequestPermission() {
 	packet := MumbleProto.PermissionQuery{
 		ChannelId: &c.ID,
 	}
+	c.client.requestSent(RequestTypePermission, c.ID)
 	c.client.Conn.WriteProto(&packet)
 }
 
@@ -0,0 +1,114 @@
+package gumble
+
+// ChannelRemovedPolicy specifies what the client does when the channel it is
+// in is removed, which makes the server move the client to another channel
+// (see Config.ChannelRemoved).
+type ChannelRemovedPolicy int
+
+const (
+	// ChannelRemovedStay leaves the client in the channel that the server
+	// moved it to.
+	ChannelRemovedStay ChannelRemovedPolicy = iota
+
+	// ChannelRemovedRecreate asks the server to create the channel again,
+	// with the same name, parent and properties, and moves the client back
+	// into it once it has been created. The client must have permission to
+	// create the channel.
+	ChannelRemovedRecreate
+
+	// ChannelRemovedJoinFallback moves the client to the channel given by
+	// Config.ChannelRemovedFallback.
+	ChannelRemovedJoinFallback
+)
+
+// pendingRejoin is a channel that the client has asked the server to
+// recreate, and which it joins once it is.
+type pendingRejoin struct {
+	parent uint32
+	name   string
+}
+
+// channelLost returns true if channel, which has been removed, is the
+// channel the client was in. c.volatile must be held.
+func (c *Client) channelLost(channel *Channel) bool {
+	if c.Self == nil {
+		return false
+	}
+	if c.Self.Channel == channel {
+		return true
+	}
+	// The server moves the users of a removed channel before removing it,
+	// usually to its parent.
+	if c.selfPreviousChannel != channel {
+		return false
+	}
+	for parent := channel.Parent; parent != nil; parent = parent.Parent {
+		if c.Self.Channel == parent {
+			return true
+		}
+	}
+	return false
+}
+
+// handleChannelLost applies Config.ChannelRemoved to channel, the removed
+// channel the client was in, and triggers the ChannelLostEvent.
+func (c *Client) handleChannelLost(channel *Channel) {
+	event := ChannelLostEvent{
+		Client:  c,
+		Channel: channel,
+		Policy:  c.Config.ChannelRemoved,
+	}
+	switch event.Policy {
+	case ChannelRemovedRecreate:
+		c.volatile.RLock()
+		parent := channel.Parent
+		if parent != nil && c.Channels[parent.ID] != parent {
+			// The parent has been removed too.
+			parent = nil
+		}
+		c.volatile.RUnlock()
+		var parentID uint32
+		if parent != nil {
+			parentID = parent.ID
+		}
+		c.rejoinLock.Lock()
+		c.rejoin = &pendingRejoin{
+			parent: parentID,
+			name:   channel.Name,
+		}
+		c.rejoinLock.Unlock()
+		event.Err = c.CreateChannel(ChannelSpec{
+			Name:        channel.Name,
+			Parent:      parent,
+			Description: channel.Description,
+			Position:    channel.Position,
+			Temporary:   channel.Temporary,
+			MaxUsers:    channel.MaxUsers,
+		})
+	case ChannelRemovedJoinFallback:
+		c.volatile.RLock()
+		event.Fallback = c.findChannel(c.Config.ChannelRemovedFallback)
+		here := event.Fallback != nil && c.Self.Channel == event.Fallback
+		c.volatile.RUnlock()
+		if event.Fallback != nil && !here {
+			c.Self.Move(event.Fallback)
+		}
+	}
+	c.Config.Listeners.onChannelLost(&event)
+}
+
+// rejoinCreated moves the client to channel, if it is the channel that
+// ChannelRemovedRecreate asked the server to create again.
+func (c *Client) rejoinCreated(channel *Channel) {
+	c.rejoinLock.Lock()
+	rejoin := c.rejoin
+	if rejoin == nil || channel.Parent == nil || rejoin.parent != channel.Parent.ID || rejoin.name != channel.Name {
+		c.rejoinLock.Unlock()
+		return
+	}
+	c.rejoin = nil
+	c.rejoinLock.Unlock()
+	if c.Self != nil && c.Self.Channel != channel {
+		c.Self.Move(channel)
+	}
+}
@@ -1,5 +1,9 @@
 package gumble
 
+import (
+	"sort"
+)
+
 // Channels is a map of server channels.
 type Channels map[uint32]*Channel
 
@@ -7,10 +11,11 @@ type Channels map[uint32]*Channel
 // with the given id already exists, it is overwritten.
 func (c Channels) create(id uint32) *Channel {
 	channel := &Channel{
-		ID:       id,
-		Links:    Channels{},
-		Children: Channels{},
-		Users:    Users{},
+		ID:        id,
+		Links:     Channels{},
+		Children:  Channels{},
+		Users:     Users{},
+		Listeners: Users{},
 	}
 	c[id] = channel
 	return channel
@@ -26,3 +31,13 @@ func (c Channels) Find(names ...string) *Channel {
 	}
 	return root.Find(names...)
 }
+
+// sorted returns the channels of c, sorted by ID.
+func (c Channels) sorted() []*Channel {
+	channels := make([]*Channel, 0, len(c))
+	for _, channel := range c {
+		channels = append(channels, channel)
+	}
+	sort.Slice(channels, func(i, j int) bool { return channels[i].ID < channels[j].ID })
+	return channels
+}
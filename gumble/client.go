@@ -3,9 +3,12 @@ package gumble
 import (
 	"crypto/tls"
 	"errors"
+	"io"
 	"math"
 	"net"
 	"runtime"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -14,6 +17,10 @@ import (
 )
 
 // State is the current state of the client's connection to the server.
+//
+// There is no reconnecting state: a Client is not reused once it has
+// disconnected. To reconnect, a new Client is dialed with the same Config,
+// and its states start over from StateConnected.
 type State int
 
 const (
@@ -21,23 +28,44 @@ const (
 	StateDisconnected State = iota
 
 	// StateConnected means the client is connected to the server and is
-	// syncing initial information. This is an internal state that will
-	// never be returned by Client.State().
+	// syncing initial information.
 	StateConnected
 
 	// StateSynced means the client is connected to a server and has been sent
 	// the server state.
 	StateSynced
+
+	// StateDisconnecting means Client.Disconnect has been called, and the
+	// connection is being closed.
+	StateDisconnecting
 )
 
+func (s State) String() string {
+	switch s {
+	case StateDisconnected:
+		return "disconnected"
+	case StateConnected:
+		return "connected"
+	case StateSynced:
+		return "synced"
+	case StateDisconnecting:
+		return "disconnecting"
+	}
+	return "unknown state"
+}
+
 // ClientVersion is the protocol version that Client implements.
-const ClientVersion = 1<<16 | 3<<8 | 0
+const ClientVersion = 1<<16 | 4<<8 | 0
 
 // Client is the type used to create a connection to a server.
 type Client struct {
 	// The User associated with the client.
 	Self *User
-	// The client's configuration.
+	// The client's configuration: a copy of the Config passed to Dial, so
+	// that the Config can be reused, e.g. to reconnect. Use the Client's
+	// setters and Attach methods to change it while the client is connected,
+	// and its getters (e.g. AudioFrameSize) to read the settings that the
+	// setters change.
 	Config *Config
 	// The underlying Conn to the server.
 	Conn *Conn
@@ -49,6 +77,10 @@ type Client struct {
 	permissions map[uint32]*Permission
 	tmpACL      *ACL
 
+	// Users who left the server, by StableID. Only accessed by the read
+	// goroutine.
+	departedUsers map[string]*departedUser
+
 	// Ping stats
 	tcpPacketsReceived uint32
 	tcpPingTimes       [12]float32
@@ -61,20 +93,86 @@ type Client struct {
 	// The audio encoder used when sending audio to the server.
 	AudioEncoder AudioEncoder
 	audioCodec   AudioCodec
+	// loopbackDecoder decodes outgoing audio when Config.AudioLoopback is set.
+	loopbackDecoder AudioDecoder
+	// Serializes the changes to the listener lists made with Attach,
+	// AttachAudio and their Detachers.
+	listenersLock sync.Mutex
+	// The listener items of the Config that the client was dialed with,
+	// which have been copied to the client's listeners.
+	copiedListeners []Detacher
+	// Sequence number of the frames sent with SendOpusFrame.
+	opusLock     sync.Mutex
+	opusSequence int64
+
+	// The most recent configuration suggested by the server.
+	suggestions ServerSuggestions
+	// The server's version and welcome message, if they have been sent.
+	serverVersion  *Version
+	welcomeMessage string
+	// The maximum number of users allowed by the server, if it was sent.
+	maximumUsers int
+	// Progress in joining Config.JoinChannel.
+	join autoJoin
+	// Users waiting in User.RegisterWait.
+	registrations registrations
+	// Channels created with CreateChannel whose password has not been set.
+	pendingChannelsLock sync.Mutex
+	pendingChannels     []*pendingChannel
+	// Self's channel before its last move, and the removed channel that
+	// ChannelRemovedRecreate is waiting to join.
+	selfPreviousChannel *Channel
+	rejoinLock          sync.Mutex
+	rejoin              *pendingRejoin
+	// Serializes the calls to Batch.
+	batchLock sync.Mutex
+	// The Follower started with Follow, while it follows its user.
+	followLock sync.Mutex
+	follower   *Follower
+
+	// Outgoing audio bandwidth, and the maximum allowed by the server.
+	bandwidth      bandwidthMeter
+	maximumBitrate int32
+	// Response times of requests.
+	requests requestTimer
+	// Self-muted and self-deafened state of Self, read by the audio
+	// goroutines.
+	selfMuted, selfDeafened uint32
+	// When idle audio streams were last closed.
+	lastAudioPrune time.Time
+	reorderStats   ReorderStats
+	// The number of bytes of the blobs of Users and Channels (see
+	// Config.BlobMaxBytes).
+	blobBytes int
 	// To whom transmitted audio will be sent. The VoiceTarget must have already
 	// been sent to the server for targeting to work correctly. Setting to nil
 	// will disable voice targeting (i.e. switch back to regular speaking).
 	VoiceTarget *VoiceTarget
 
 	state uint32
+	// Has the client been synced (i.e. was its state ever StateSynced)?
+	synced uint32
+	// Outgoing audio channels.
+	audioMux audioMux
+	// Listener calls waiting to be dispatched, if Config.EventQueueSize is
+	// set.
+	eventQueue *eventQueue
+	// Events of the initial state, delivered once it has been received (see
+	// Config.SyncEvents). Only accessed by the read goroutine.
+	syncEvents []func()
 
 	// volatile is held by the client when the internal data structures are being
 	// modified.
 	volatile rpwMutex
 
 	connect         chan *RejectError
+	version         chan struct{}
 	end             chan struct{}
 	disconnectEvent DisconnectEvent
+
+	// Closed, and replaced, each time an event is triggered (see waitFor).
+	waitLock    sync.Mutex
+	waitChanged chan struct{}
 }
 
 // Dial is an alias of DialWithDialer(new(net.Dialer), config, nil).
@@ -90,28 +188,103 @@ func Dial(config *Config) (*Client, error) {
 //
 // nil and an error is returned if server synchronization does not complete by
 // min(time.Now() + dialer.Timeout, dialer.Deadline), or if the server rejects
-// the client.
+// the client. Each stage of the connection can also have its own timeout
+// (e.g. Config.ConnectTimeout). Failures other than rejections are returned
+// as a *DialError, which tells which stage failed.
 func DialWithDialer(dialer *net.Dialer, config *Config, tlsConfig *tls.Config) (*Client, error) {
+	return dial(dialer, config, tlsConfig, nil)
+}
+
+// dial implements DialWithDialer. listener, if not nil, is attached to the
+// client's copy of config, so that it receives all of the client's events
+// without config being modified.
+func dial(dialer *net.Dialer, config *Config, tlsConfig *tls.Config, listener EventListener) (*Client, error) {
 	start := time.Now()
 
-	conn, err := tls.DialWithDialer(dialer, "tcp", config.Address, tlsConfig)
+	var deadline time.Time
+	if !dialer.Deadline.IsZero() {
+		deadline = dialer.Deadline
+	}
+	if dialer.Timeout > 0 {
+		diff := start.Add(dialer.Timeout)
+		if deadline.IsZero() || diff.Before(deadline) {
+			deadline = diff
+		}
+	}
+	// stageDeadline returns the deadline of a stage that starts now.
+	stageDeadline := func(timeout time.Duration) time.Time {
+		if timeout <= 0 {
+			return deadline
+		}
+		d := time.Now().Add(timeout)
+		if !deadline.IsZero() && deadline.Before(d) {
+			return deadline
+		}
+		return d
+	}
+
+	if config.TLSSessionCache != nil && (tlsConfig == nil || tlsConfig.ClientSessionCache == nil) {
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		} else {
+			tlsConfig = tlsConfig.Clone()
+		}
+		tlsConfig.ClientSessionCache = config.TLSSessionCache
+	}
+
+	netDialer := *dialer
+	netDialer.Timeout = 0
+	netDialer.Deadline = stageDeadline(config.ConnectTimeout)
+	rawConn, err := netDialer.Dial("tcp", config.Address)
 	if err != nil {
-		return nil, err
+		return nil, &DialError{Stage: DialStageConnect, Err: err}
+	}
+
+	if tlsConfig == nil {
+		tlsConfig = &tls.Config{}
+	}
+	if tlsConfig.ServerName == "" {
+		// As tls.Dial does.
+		host := config.Address
+		if i := strings.LastIndex(host, ":"); i >= 0 {
+			host = host[:i]
+		}
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = strings.Trim(host, "[]")
+	}
+	conn := tls.Client(rawConn, tlsConfig)
+	if handshakeDeadline := stageDeadline(config.TLSHandshakeTimeout); !handshakeDeadline.IsZero() {
+		conn.SetDeadline(handshakeDeadline)
 	}
+	if err := conn.Handshake(); err != nil {
+		rawConn.Close()
+		return nil, &DialError{Stage: DialStageTLS, Err: err}
+	}
+	conn.SetDeadline(time.Time{})
 
 	client := &Client{
 		Conn:     NewConn(conn),
-		Config:   config,
 		Users:    make(Users),
 		Channels: make(Channels),
 
 		permissions: make(map[uint32]*Permission),
 
-		state: uint32(StateConnected),
-
 		connect: make(chan *RejectError),
+		version: make(chan struct{}),
 		end:     make(chan struct{}),
 	}
+	client.Config = config.clone(client)
+	if IsSuperUserName(client.Config.Username) {
+		// Send the canonical name, without the surrounding white space that
+		// the server would reject.
+		client.Config.Username = SuperUserName
+	}
+	if listener != nil {
+		client.Config.Listeners.Attach(listener)
+	}
+	client.Conn.startWriter()
+	client.startDispatcher()
+	client.setState(StateConnected)
 
 	go client.readRoutine()
 
@@ -133,37 +306,62 @@ func DialWithDialer(dialer *net.Dialer, config *Config, tlsConfig *tls.Config) (
 
 	go client.pingRoutine()
 
-	var timeout <-chan time.Time
-	{
-		var deadline time.Time
-		if !dialer.Deadline.IsZero() {
-			deadline = dialer.Deadline
-		}
-		if dialer.Timeout > 0 {
-			diff := start.Add(dialer.Timeout)
-			if deadline.IsZero() || diff.Before(deadline) {
-				deadline = diff
+	stage := DialStageVersion
+	version := client.version
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	setTimer := func(deadline time.Time) <-chan time.Time {
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
 			}
 		}
-		if !deadline.IsZero() {
-			timer := time.NewTimer(deadline.Sub(start))
-			defer timer.Stop()
-			timeout = timer.C
+		if deadline.IsZero() {
+			return nil
 		}
+		timer.Reset(time.Until(deadline))
+		return timer.C
 	}
+	timeout := setTimer(stageDeadline(config.VersionTimeout))
 
-	select {
-	case <-timeout:
-		client.Conn.Close()
-		return nil, errors.New("gumble: synchronization timeout")
-	case err := <-client.connect:
-		if err != nil {
+	for {
+		select {
+		case <-timeout:
 			client.Conn.Close()
-			return nil, err
+			return nil, &DialError{Stage: stage, Err: ErrDialTimeout}
+		case <-version:
+			version = nil
+			stage = DialStageSync
+			timeout = setTimer(stageDeadline(config.SyncTimeout))
+		case err := <-client.connect:
+			if err != nil {
+				client.Conn.Close()
+				return nil, err
+			}
+
+			return client, nil
+		case <-client.end:
+			select {
+			case <-client.connect:
+				// The client was accepted, and disconnected right away.
+				return client, nil
+			default:
+			}
+			// The server closed the connection before accepting the client.
+			return nil, &DialError{Stage: stage, Err: io.ErrUnexpectedEOF}
 		}
+	}
+}
 
-		return client, nil
+// SessionResumed returns true if the client's TLS connection resumed a
+// previous session (see Config.TLSSessionCache), rather than performing a
+// full handshake.
+func (c *Client) SessionResumed() bool {
+	if conn, ok := c.Conn.Conn.(*tls.Conn); ok {
+		return conn.ConnectionState().DidResume
 	}
+	return false
 }
 
 // State returns the current state of the client.
@@ -171,25 +369,254 @@ func (c *Client) State() State {
 	return State(atomic.LoadUint32(&c.state))
 }
 
+// setState changes the state of the client, and triggers a StateChangeEvent.
+// A disconnected client can only become connected again from
+// StateDisconnected, and a disconnecting client can only become
+// disconnected.
+func (c *Client) setState(state State) {
+	for {
+		old := State(atomic.LoadUint32(&c.state))
+		if old == state ||
+			(old == StateDisconnected && state == StateDisconnecting) ||
+			(old == StateDisconnecting && state != StateDisconnected) {
+			return
+		}
+		if atomic.CompareAndSwapUint32(&c.state, uint32(old), uint32(state)) {
+			if state == StateSynced {
+				atomic.StoreUint32(&c.synced, 1)
+			}
+			event := StateChangeEvent{
+				Client: c,
+				Old:    old,
+				New:    state,
+			}
+			c.Config.Listeners.onStateChange(&event)
+			return
+		}
+	}
+}
+
+// isSynced returns true if the client has received the server's initial
+// state, and has not disconnected since.
+func (c *Client) isSynced() bool {
+	return atomic.LoadUint32(&c.synced) == 1 && c.State() != StateDisconnected
+}
+
 // AudioOutgoing creates a new channel that outgoing audio data can be written
-// to. The channel must be closed after the audio stream is completed. Only
-// a single channel should be open at any given time (i.e. close the channel
-// before opening another).
+// to. The channel must be closed after the audio stream is completed.
+//
+// If another channel is open, the new one takes over the transmission until it
+// is closed, as with AudioHandoff without a crossfade.
+//
+// Silent buffers, and the end of the transmission, are sent as specified by
+// Config.AudioSilence and Config.AudioTerminator.
 func (c *Client) AudioOutgoing() chan<- AudioBuffer {
-	ch := make(chan AudioBuffer)
-	go func() {
-		var seq int64
-		previous := <-ch
-		for p := range ch {
-			previous.writeAudio(c, seq, false)
-			previous = p
-			seq = (seq + 1) % math.MaxInt32
+	return c.AudioHandoff(0)
+}
+
+// updateSelfState stores the self-muted and self-deafened state of c.Self for
+// the audio goroutines.
+func (c *Client) updateSelfState() {
+	var muted, deafened uint32
+	if c.Self != nil {
+		if c.Self.SelfMuted {
+			muted = 1
+		}
+		if c.Self.SelfDeafened {
+			deafened = 1
+		}
+	}
+	atomic.StoreUint32(&c.selfMuted, muted)
+	atomic.StoreUint32(&c.selfDeafened, deafened)
+}
+
+// ServerSuggestions returns the client configuration that was most recently
+// suggested by the server.
+func (c *Client) ServerSuggestions() ServerSuggestions {
+	c.volatile.RLock()
+	defer c.volatile.RUnlock()
+	return c.suggestions
+}
+
+// ServerVersion returns the version of the server, or nil if the server has not
+// sent it.
+func (c *Client) ServerVersion() *Version {
+	c.volatile.RLock()
+	defer c.volatile.RUnlock()
+	if c.serverVersion == nil {
+		return nil
+	}
+	version := *c.serverVersion
+	return &version
+}
+
+// SendOpusFrame sends an already encoded Opus frame to the server, bypassing
+// Client.AudioEncoder. samples is the number of audio samples (per channel)
+// that the frame contains.
+//
+// Calling the function with a nil frame terminates the current transmission.
+// SendOpusFrame should not be used while an AudioOutgoing channel is open.
+func (c *Client) SendOpusFrame(frame []byte, samples int) error {
+	c.volatile.RLock()
+	codec := c.audioCodec
+	c.volatile.RUnlock()
+	if codec == nil || codec.ID() != audioCodecIDOpus {
+		return errNoCodec
+	}
+	final := frame == nil
+	if !final && (samples <= 0 || samples > AudioMaximumFrameSize) {
+		return errors.New("gumble: invalid number of samples")
+	}
+
+	c.opusLock.Lock()
+	defer c.opusLock.Unlock()
+
+	seq := c.opusSequence
+	if final {
+		c.opusSequence = 0
+	} else {
+		frames := int64(samples / AudioDefaultFrameSize)
+		if frames < 1 {
+			frames = 1
 		}
-		if previous != nil {
-			previous.writeAudio(c, seq, true)
+		c.opusSequence = (seq + frames) % math.MaxInt32
+	}
+	return c.writeEncodedAudio(frame, seq, final)
+}
+
+// SetAudioFramesPerPacket changes the number of 10ms audio frames that are
+// sent in each audio packet (i.e. Config.AudioInterval). Valid values are 1,
+// 2, 4, and 6.
+//
+// The audio encoder is re-created, and an AudioConfigEvent is sent to the
+// client's listeners so that audio sources can adjust their frame sizes.
+func (c *Client) SetAudioFramesPerPacket(frames int) error {
+	switch frames {
+	case 1, 2, 4, 6:
+	default:
+		return errors.New("gumble: invalid number of audio frames per packet")
+	}
+
+	event := AudioConfigEvent{
+		Client: c,
+	}
+	{
+		c.volatile.Lock()
+
+		c.Config.AudioInterval = time.Duration(frames) * AudioDefaultInterval
+		if c.audioCodec != nil {
+			c.AudioEncoder = c.audioCodec.NewEncoder()
 		}
-	}()
-	return ch
+		event.AudioInterval = c.Config.AudioInterval
+		event.AudioFrameSize = c.Config.AudioFrameSize()
+
+		c.volatile.Unlock()
+	}
+
+	if c.isSynced() {
+		c.Config.Listeners.onAudioConfig(&event)
+	}
+	return nil
+}
+
+// SetAudioDataBytes changes the number of bytes that an audio frame can use
+// (i.e. Config.AudioDataBytes).
+func (c *Client) SetAudioDataBytes(dataBytes int) error {
+	if dataBytes <= 0 {
+		return errors.New("gumble: invalid number of audio data bytes")
+	}
+	c.volatile.Lock()
+	c.Config.AudioDataBytes = dataBytes
+	c.volatile.Unlock()
+	return nil
+}
+
+// SetAudioBitrate changes the number of bytes that an audio frame can use so
+// that outgoing audio uses about bitrate bits per second, at the current
+// audio interval.
+func (c *Client) SetAudioBitrate(bitrate int) error {
+	return c.SetAudioDataBytes(int(int64(bitrate) * int64(c.AudioInterval()) / int64(8*time.Second)))
+}
+
+// AudioInterval returns the interval at which audio packets are sent (i.e.
+// Config.AudioInterval). Unlike reading the Config, it is safe to call while
+// SetAudioFramesPerPacket can change the interval.
+func (c *Client) AudioInterval() time.Duration {
+	c.volatile.RLock()
+	defer c.volatile.RUnlock()
+	return c.Config.AudioInterval
+}
+
+// AudioFrameSize returns the size of the audio frames that are sent, based
+// off of the audio interval (see Config.AudioFrameSize and AudioInterval).
+func (c *Client) AudioFrameSize() int {
+	c.volatile.RLock()
+	defer c.volatile.RUnlock()
+	return c.Config.AudioFrameSize()
+}
+
+// AudioDataBytes returns the number of bytes that an audio frame can use
+// (i.e. Config.AudioDataBytes). See AudioInterval.
+func (c *Client) AudioDataBytes() int {
+	c.volatile.RLock()
+	defer c.volatile.RUnlock()
+	return c.Config.AudioDataBytes
+}
+
+// Attach adds an event listener to the client. Unlike c.Config.Attach, it is
+// safe to call at any time, including from listeners and inside Do, as is
+// the returned Detacher.
+func (c *Client) Attach(listener EventListener) Detacher {
+	return c.attach(func() Detacher {
+		return c.Config.Listeners.Attach(listener)
+	})
+}
+
+// AttachAudio adds an audio listener to the client. See Attach.
+func (c *Client) AttachAudio(listener AudioListener) Detacher {
+	return c.attach(func() Detacher {
+		return c.Config.AudioListeners.Attach(listener)
+	})
+}
+
+// AttachAudioEncoded adds an audio listener to the client, which receives
+// the undecoded audio data (see AudioListeners.AttachEncoded). See Attach.
+func (c *Client) AttachAudioEncoded(listener AudioListener) Detacher {
+	return c.attach(func() Detacher {
+		return c.Config.AudioListeners.AttachEncoded(listener)
+	})
+}
+
+// lockedDetacher is a Detacher returned by Client.Attach.
+type lockedDetacher struct {
+	client   *Client
+	detacher Detacher
+}
+
+func (d *lockedDetacher) Detach() {
+	d.client.attach(func() Detacher {
+		d.detacher.Detach()
+		return nil
+	})
+}
+
+// attach calls fn while the listener lists cannot be read by the client's
+// handlers (which hold the volatile lock while they walk the lists), nor
+// changed by another call.
+func (c *Client) attach(fn func() Detacher) Detacher {
+	c.volatile.RLock()
+	defer c.volatile.RUnlock()
+	c.listenersLock.Lock()
+	defer c.listenersLock.Unlock()
+
+	detacher := fn()
+	if detacher == nil {
+		return nil
+	}
+	return &lockedDetacher{
+		client:   c,
+		detacher: detacher,
+	}
 }
 
 // pingRoutine sends ping packets to the server at regular intervals.
@@ -235,23 +662,36 @@ func (c *Client) readRoutine() {
 		if err != nil {
 			break
 		}
+		if recorder := c.Config.PacketRecorder; recorder != nil {
+			recorder.RecordPacket(pType, data)
+		}
 		if int(pType) < len(handlers) {
 			handlers[pType](c, data)
 		}
 	}
 
-	wasSynced := c.State() == StateSynced
-	atomic.StoreUint32(&c.state, uint32(StateDisconnected))
+	// Stop the writer goroutine.
+	c.Conn.Close()
+
+	c.volatile.Lock()
+	c.Config.AudioListeners.closeAllStreams()
+	c.volatile.Unlock()
+
+	wasSynced := c.isSynced()
+	c.setState(StateDisconnected)
+	c.releaseListenerCopies()
 	close(c.end)
 	if wasSynced {
 		c.Config.Listeners.onDisconnect(&c.disconnectEvent)
 	}
+	c.closeDispatcher()
 }
 
 // RequestUserList requests that the server's registered user list be sent to
 // the client.
 func (c *Client) RequestUserList() {
 	packet := MumbleProto.UserList{}
+	c.requestSent(RequestTypeUserList, 0)
 	c.Conn.WriteProto(&packet)
 }
 
@@ -260,15 +700,18 @@ func (c *Client) RequestBanList() {
 	packet := MumbleProto.BanList{
 		Query: proto.Bool(true),
 	}
+	c.requestSent(RequestTypeBanList, 0)
 	c.Conn.WriteProto(&packet)
 }
 
-// Disconnect disconnects the client from the server.
+// Disconnect disconnects the client from the server. The packets that have
+// been sent are written to the connection first, for at most a second.
 func (c *Client) Disconnect() error {
 	if c.State() == StateDisconnected {
 		return errors.New("gumble: client is already disconnected")
 	}
 	c.disconnectEvent.Type = DisconnectUser
+	c.setState(StateDisconnecting)
 	c.Conn.Close()
 	return nil
 }
@@ -284,6 +727,12 @@ func (c *Client) Do(f func()) {
 }
 
 // Send will send a Message to the server.
+//
+// Send, like the other functions that send packets to the server, can be
+// called from any goroutine, including from event listeners: packets are
+// queued, and written to the connection by a separate goroutine, so they
+// never wait for the connection. Packets are written in the order in which
+// they are sent.
 func (c *Client) Send(message Message) {
 	message.writeMessage(c)
 }
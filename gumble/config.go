@@ -1,16 +1,25 @@
 package gumble
 
 import (
+	"crypto/tls"
 	"time"
 )
 
-// Config holds the Mumble configuration used by Client. A single Config should
-// not be shared between multiple Client instances.
+// Config holds the Mumble configuration used by Client. Dial copies the
+// Config, along with the listeners attached to it, so changing the Config
+// afterwards does not affect the client, and the same Config can be used for
+// multiple clients. The exception is detaching a listener of the Config,
+// which also detaches it from the clients. A connected client's
+// configuration is changed with the Client's methods (e.g. Client.Attach,
+// Client.SetAudioDataBytes).
 type Config struct {
 	// User name used when authenticating with the server.
 	Username string
 	// Password used when authenticating with the server. A password is not
-	// usually required to connect to a server.
+	// usually required to connect to a server. It is the server password for
+	// unregistered users, the user's password for registered users who log in
+	// without a certificate, and the SuperUser password when Username is
+	// SuperUserName (see LoginAsSuperUser).
 	Password string
 //the address to use
 Address string
@@ -19,11 +28,180 @@ Address string
 	//  client.Send(config.Tokens)
 	Tokens AccessTokens
 
+	// Timeouts of the stages of connecting to the server (see DialStage).
+	// ConnectTimeout limits the time it takes to establish the TCP
+	// connection, TLSHandshakeTimeout the TLS handshake, VersionTimeout the
+	// wait for the server's Version packet, and SyncTimeout the wait for the
+	// server's state after that. Zero means no limit, other than that of the
+	// dialer passed to DialWithDialer.
+	ConnectTimeout      time.Duration
+	TLSHandshakeTimeout time.Duration
+	VersionTimeout      time.Duration
+	SyncTimeout         time.Duration
+
 	// AudioInterval is the interval at which audio packets are sent. Valid
-	// values are: 10ms, 20ms, 40ms, and 60ms.
+	// values are: 10ms, 20ms, 40ms, and 60ms. Use
+	// Client.SetAudioFramesPerPacket to change the value once connected, and
+	// Client.AudioInterval to read it.
 	AudioInterval time.Duration
-	// AudioDataBytes is the number of bytes that an audio frame can use.
+	// AudioDataBytes is the number of bytes that an audio frame can use. The
+	// value is lowered when sending audio if it would cause the client to
+	// exceed the server's maximum bandwidth.
 	AudioDataBytes int
+	// AudioLoopback, if true, causes outgoing audio to be decoded locally and
+	// passed to AudioListeners as if it were spoken by Client.Self, rather
+	// than being sent to the server. This is useful for testing microphone
+	// setups without a second client.
+	AudioLoopback bool
+	// AudioIgnoreSelfState, if true, causes audio to be received and sent
+	// regardless of Client.Self's self-deafened and self-muted state. By
+	// default, incoming audio is dropped before it is decoded while
+	// self-deafened, and outgoing audio is not encoded while self-muted.
+	AudioIgnoreSelfState bool
+	// AudioSilenceLevel is the peak amplitude below which an outgoing audio
+	// buffer is considered silent. Zero disables silence detection, and
+	// AudioSilence is not used.
+	AudioSilenceLevel int16
+	// AudioSilence specifies how silent outgoing audio buffers are sent.
+	AudioSilence AudioSilence
+	// AudioNormalization, if not nil, evens out the loudness of the audio
+	// received from remote users before it reaches the AudioListeners.
+	AudioNormalization *AudioNormalization
+	// AudioTerminator specifies how the end of an outgoing transmission is
+	// marked.
+	AudioTerminator AudioTerminator
+	// AudioStreamTimeout is how long a user's audio streams can go without
+	// receiving audio before they are closed, releasing the user's decoder
+	// and the resources AudioListeners hold for the stream. A new stream is
+	// started when the user speaks again. Zero disables the timeout; streams
+	// are always closed when the user disconnects.
+	AudioStreamTimeout time.Duration
+	// AudioMaxStreams is the maximum number of audio streams that are kept
+	// open per AudioListener. When a new stream would exceed the limit, the
+	// least recently active one is closed. Zero means no limit.
+	AudioMaxStreams int
+	// AudioReorderTolerance is the number of incoming voice packets per user
+	// that can be held back while waiting for a missing, out of order packet.
+	// Packets that arrive later than that are dropped. Zero disables
+	// reordering, and packets are delivered as they arrive. See
+	// Client.ReorderStats.
+	AudioReorderTolerance int
+	// AudioStreamQueue is the number of audio packets that can be queued per
+	// audio stream, waiting for the AudioListener to receive them. Zero means
+	// each packet is handed to the listener directly.
+	AudioStreamQueue int
+	// AudioStreamQueuePolicy is what the client does with the audio of a
+	// stream whose queue is full (see ResourceLimitPolicy). With
+	// ResourceLimitPause, the default, and no AudioStreamQueue, the client
+	// waits for the listener without triggering a ResourceLimitEvent.
+	AudioStreamQueuePolicy ResourceLimitPolicy
+
+	// UserIdleTimeout is how long a user must go without sending audio or a
+	// text message before they are considered idle. A UserChangeEvent with
+	// the UserChangeIdle type is triggered when a user becomes idle, and again
+	// when they send audio or a text message afterwards. Users are checked
+	// each time the server replies to a ping. Zero disables the event. See
+	// User.IsIdle and User.IdleSince.
+	UserIdleTimeout time.Duration
+
+	// ChannelSilenceTimeout is how long nobody must speak in a channel before
+	// it is considered silent. A ChannelActivityEvent is triggered when a
+	// channel goes silent, and when a user speaks in a silent channel.
+	// Channels are checked each time the server replies to a ping. Zero
+	// disables the event.
+	ChannelSilenceTimeout time.Duration
+	// ChannelActivityIgnore, if not nil, returns true for the users whose
+	// audio does not make a channel active, such as other bots. Client.Self is
+	// always ignored.
+	ChannelActivityIgnore func(user *User) bool
+
+	// ChannelRemoved specifies what the client does when the channel it is in
+	// is removed (e.g. a temporary channel), which makes the server move the
+	// client to another channel. A ChannelLostEvent is triggered in any case.
+	ChannelRemoved ChannelRemovedPolicy
+	// ChannelRemovedFallback is the channel that ChannelRemovedJoinFallback
+	// joins, either by ID or by path, like JoinChannel.
+	ChannelRemovedFallback string
+
+	// JoinChannel, if not empty, is the channel that the client moves itself
+	// to once it has connected. It is either the channel's ID (e.g. "12"), or
+	// the names of the channels leading to it from the root channel,
+	// separated by slashes (e.g. "Games/Chess").
+	//
+	// If a channel given by path does not exist yet, or it is removed after
+	// the client has joined it (e.g. a temporary channel), the client moves
+	// itself as soon as a channel with that path is created. If the server
+	// denies the move, the client gives up, and the PermissionDeniedEvent
+	// has its Err set to a *JoinChannelError.
+	JoinChannel string
+
+	// SyncEvents specifies what happens to the UserChangeEvents and
+	// ChannelChangeEvents of the server's initial state, which is sent before
+	// the client is connected. Such events have Initial set, so that they can
+	// be told apart from later activity (e.g. users joining the server).
+	// SyncProgressEvents are triggered while the state is received
+	// regardless.
+	SyncEvents SyncEvents
+
+	// OutgoingTextMiddleware is run, in order, on each text message sent by
+	// the client (e.g. with Channel.Send or Client.SendTextMessage), before
+	// it is written to the server. The caller's message is not modified.
+	OutgoingTextMiddleware []TextMiddleware
+	// IncomingTextMiddleware is run, in order, on each text message received
+	// from the server, before TextMessageEvents are triggered. Dropped
+	// messages do not trigger an event.
+	IncomingTextMiddleware []TextMiddleware
+
+	// BlobCache, if not nil, stores the comments, textures and descriptions
+	// that the server refers to by hash, so that they are not requested
+	// again, e.g. after reconnecting. NewConfig sets it to a
+	// MemoryBlobCache of DefaultBlobCacheSize bytes.
+	BlobCache BlobCache
+	// BlobMaxBytes is the maximum number of bytes of blobs (the comments and
+	// textures of users, and the descriptions of channels) that the client
+	// holds at once. A blob that would exceed it is dropped, and only its
+	// hash is kept, as if the server had only sent the hash, so that it can
+	// still be requested later. A ResourceLimitEvent is then triggered. Blobs
+	// that are too small to be referred to by hash are never dropped. Zero
+	// means no limit. NewConfig sets it to DefaultBlobMaxBytes.
+	BlobMaxBytes int
+
+	// TLSSessionCache, if not nil, stores the TLS sessions of the client's
+	// connections, so that reconnecting with the same Config resumes the
+	// previous session, which makes the TLS handshake shorter. It is only
+	// used if the tls.Config passed to DialWithDialer has no
+	// ClientSessionCache. NewConfig sets it to an LRU cache of
+	// DefaultTLSSessionCacheSize sessions.
+	TLSSessionCache tls.ClientSessionCache
+
+	// AudioTrace, if not nil, traces the audio frames that are sent and
+	// received (see NewAudioTrace).
+	AudioTrace *AudioTrace
+
+	// RequestLatencyBuckets, if set, are the upper bounds, in increasing
+	// order, of the histogram buckets into which Client.RequestLatency sorts
+	// response times.
+	RequestLatencyBuckets []time.Duration
+
+	// EventQueueSize, if positive, makes the client call the event listeners
+	// from a separate goroutine, through a queue that holds up to that many
+	// events, so that slow listeners do not hold up the reading of packets
+	// (which delays pings, and can make the connection time out). Otherwise,
+	// the listeners are called from the goroutine that reads the packets.
+	//
+	// The queued events refer to the client's Users and Channels, which the
+	// read goroutine keeps changing as packets are read: by the time a
+	// listener handles an event, they can reflect later packets (e.g. a
+	// UserChangeEvent's User can already be in another channel). Their
+	// fields should be read within Client.Do.
+	EventQueueSize int
+	// EventOverflow specifies what happens when the event queue is full.
+	EventOverflow EventOverflow
+
+	// PacketRecorder, if not nil, is passed each packet read from the server
+	// before it is handled, e.g. to capture a session that can later be
+	// replayed with NewReplayClient.
+	PacketRecorder PacketRecorder
 
 	// The event listeners used when client events are triggered.
 	Listeners      Listeners
@@ -35,19 +213,60 @@ func NewConfig() *Config {
 	return &Config{
 		AudioInterval:  AudioDefaultInterval,
 		AudioDataBytes: AudioDefaultDataBytes,
+		BlobCache:      NewMemoryBlobCache(DefaultBlobCacheSize),
+		BlobMaxBytes:   DefaultBlobMaxBytes,
+
+		TLSSessionCache: tls.NewLRUClientSessionCache(DefaultTLSSessionCacheSize),
 	}
 }
 
-// Attach is an alias of c.Listeners.Attach.
+// DefaultTLSSessionCacheSize is the number of TLS sessions that the
+// Config.TLSSessionCache created by NewConfig holds.
+const DefaultTLSSessionCacheSize = 16
+
+// clone returns a copy of c for client. The copy has its own listener lists,
+// containing the listeners attached to c; detaching one of c's listeners also
+// detaches it from client.
+func (c *Config) clone(client *Client) *Config {
+	config := *c
+	config.Tokens = append(AccessTokens(nil), c.Tokens...)
+	config.RequestLatencyBuckets = append([]time.Duration(nil), c.RequestLatencyBuckets...)
+	config.OutgoingTextMiddleware = append([]TextMiddleware(nil), c.OutgoingTextMiddleware...)
+	config.IncomingTextMiddleware = append([]TextMiddleware(nil), c.IncomingTextMiddleware...)
+	config.Listeners = Listeners{}
+	for item := c.Listeners.head; item != nil; item = item.next {
+		client.addListenerCopy(item, config.Listeners.Attach(item.listener))
+	}
+	config.AudioListeners = AudioListeners{}
+	for item := c.AudioListeners.head; item != nil; item = item.next {
+		client.addListenerCopy(item, config.AudioListeners.attach(item.listener, item.encoded))
+	}
+	return &config
+}
+
+// Attach is an alias of c.Listeners.Attach. The returned Detacher also
+// detaches the listener from the clients that have been dialed with c.
 func (c *Config) Attach(l EventListener) Detacher {
 	return c.Listeners.Attach(l)
 }
 
-// AttachAudio is an alias of c.AudioListeners.Attach.
+// AttachAudio is an alias of c.AudioListeners.Attach. See Attach.
 func (c *Config) AttachAudio(l AudioListener) Detacher {
 	return c.AudioListeners.Attach(l)
 }
 
+// AttachAudioEncoded is an alias of c.AudioListeners.AttachEncoded. See
+// Attach.
+func (c *Config) AttachAudioEncoded(l AudioListener) Detacher {
+	return c.AudioListeners.AttachEncoded(l)
+}
+
+// AudioFramesPerPacket returns the number of 10ms audio frames that are sent
+// in each audio packet, based off of the audio interval.
+func (c *Config) AudioFramesPerPacket() int {
+	return int(c.AudioInterval / AudioDefaultInterval)
+}
+
 // AudioFrameSize returns the appropriate audio frame size, based off of the
 // audio interval.
 func (c *Config) AudioFrameSize() int {
@@ -0,0 +1,83 @@
+package gumble
+
+import (
+	"crypto/tls"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type testAudioConfigListener struct {
+	testListener
+	calls int32
+}
+
+func (l *testAudioConfigListener) OnAudioConfig(e *AudioConfigEvent) {
+	atomic.AddInt32(&l.calls, 1)
+}
+
+func (testAudioConfigListener) OnAudioStream(e *AudioStreamEvent) {}
+
+func dialTestClient(t *testing.T, config *Config) *Client {
+	client, err := DialWithDialer(new(net.Dialer), config, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return client
+}
+
+func TestConfigDetachFromClients(t *testing.T) {
+	s := newTestServer(t)
+	config := newTestConfig(s, "alice")
+	l := &testAudioConfigListener{}
+	detacher := config.Attach(l)
+	audioDetacher := config.AttachAudio(l)
+
+	clients := []*Client{dialTestClient(t, config), dialTestClient(t, config)}
+	defer func() {
+		for _, client := range clients {
+			client.Disconnect()
+		}
+	}()
+	for _, client := range clients {
+		client.SetAudioFramesPerPacket(2)
+	}
+	if calls := atomic.LoadInt32(&l.calls); calls != 2 {
+		t.Fatalf("listener called %d times, expected 2\n", calls)
+	}
+
+	detacher.Detach()
+	audioDetacher.Detach()
+	for _, client := range clients {
+		client.SetAudioFramesPerPacket(4)
+		client.Do(func() {
+			if client.Config.AudioListeners.head != nil {
+				t.Error("audio listener still attached to the client")
+			}
+		})
+	}
+	if calls := atomic.LoadInt32(&l.calls); calls != 2 {
+		t.Errorf("detached listener called %d times, expected 2\n", calls)
+	}
+}
+
+func TestConfigListenerCopiesReleased(t *testing.T) {
+	s := newTestServer(t)
+	config := newTestConfig(s, "alice")
+	config.Attach(testListener{})
+
+	client := dialTestClient(t, config)
+	client.Disconnect()
+	select {
+	case <-client.end:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client did not disconnect")
+	}
+
+	listenerCopies.Lock()
+	defer listenerCopies.Unlock()
+	if copies := listenerCopies.m[config.Listeners.head]; len(copies) != 0 {
+		t.Errorf("%d listener copies kept after the client disconnected\n", len(copies))
+	}
+}
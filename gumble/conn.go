@@ -10,7 +10,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/bmmcginty/gumble/gumble/MumbleProto"
-	"github.com/bmmcginty/gumble/gumble/varint"
+	"github.com/bmmcginty/gumble/gumble/voicepacket"
 )
 
 // DefaultPort is the default port on which Mumble servers listen.
@@ -23,8 +23,13 @@ type Conn struct {
 
 	MaximumPacketBytes int
 	Timeout            time.Duration
+	// The maximum number of bytes of audio and ping packets that can wait to
+	// be written, when the writes are queued (as they are for the Conn of a
+	// Client). Zero means no limit.
+	MaximumQueuedBytes int
 
 	buffer []byte
+	queue  *writeQueue
 }
 
 // NewConn creates a new Conn with the given net.Conn.
@@ -33,9 +38,20 @@ func NewConn(conn net.Conn) *Conn {
 		Conn:               conn,
 		Timeout:            time.Second * 20,
 		MaximumPacketBytes: 1024 * 1024 * 10,
+		MaximumQueuedBytes: 1024 * 1024,
 	}
 }
 
+// Close closes the connection. If the writes to the connection are queued,
+// the packets that are already queued are written first, unless that takes
+// longer than a second.
+func (c *Conn) Close() error {
+	if c.queue != nil {
+		c.closeWriter()
+	}
+	return c.Conn.Close()
+}
+
 // ReadPacket reads a packet from the server. Returns the packet type, the
 // packet data, and nil on success.
 //
@@ -63,59 +79,49 @@ func (c *Conn) ReadPacket() (uint16, []byte, error) {
 
 // WriteAudio writes an audio packet to the connection.
 func (c *Conn) WriteAudio(format, target byte, sequence int64, final bool, data []byte, X, Y, Z *float32) error {
-	var buff [1 + varint.MaxVarintLen*2]byte
-	buff[0] = (format << 5) | target
-	n := varint.Encode(buff[1:], sequence)
-	if n == 0 {
-		return errors.New("gumble: varint out of range")
-	}
-	l := int64(len(data))
-	if final {
-		l |= 0x2000
+	packet := voicepacket.Packet{
+		Type:     format,
+		Target:   target,
+		Sequence: sequence,
+		Data:     data,
+		Final:    final,
 	}
-	m := varint.Encode(buff[1+n:], l)
-	if m == 0 {
-		return errors.New("gumble: varint out of range")
-	}
-	header := buff[:1+n+m]
-
-	var positionalLength int
 	if X != nil {
-		positionalLength = 3 * 4
-	}
-
-	c.Lock()
-	defer c.Unlock()
-
-	if err := c.writeHeader(1, uint32(len(header)+len(data)+positionalLength)); err != nil {
-		return err
-	}
-	if _, err := c.Conn.Write(header); err != nil {
-		return err
+		packet.HasPosition = true
+		packet.X, packet.Y, packet.Z = *X, *Y, *Z
 	}
-	if _, err := c.Conn.Write(data); err != nil {
+	buff, err := voicepacket.Encode(&packet, false)
+	if err != nil {
 		return err
 	}
-
-	if positionalLength > 0 {
-		if err := binary.Write(c.Conn, binary.LittleEndian, *X); err != nil {
-			return err
-		}
-		if err := binary.Write(c.Conn, binary.LittleEndian, *Y); err != nil {
-			return err
-		}
-		if err := binary.Write(c.Conn, binary.LittleEndian, *Z); err != nil {
-			return err
-		}
-	}
-
-	return nil
+	return c.WritePacket(1, buff)
 }
 
 // WritePacket writes a data packet of the given type to the connection.
+//
+// If the writes to the connection are queued, the packet is added to the
+// queue, and the returned error is that of a previous write, if it failed, or
+// ErrWriteQueueFull, if an audio or ping packet is refused. Queued audio and
+// ping packets are written before the other queued packets.
 func (c *Conn) WritePacket(ptype uint16, data []byte) error {
+	return c.writePacket(ptype, data, nil)
+}
+
+func (c *Conn) writePacket(ptype uint16, data []byte, progress UploadProgress) error {
+	if c.queue != nil {
+		var header [6]byte
+		binary.BigEndian.PutUint16(header[:], ptype)
+		binary.BigEndian.PutUint32(header[2:], uint32(len(data)))
+		return c.queuePacket(ptype, header[:], data, progress)
+	}
 	c.Lock()
 	defer c.Unlock()
+	if progress != nil {
+		packet := make([]byte, 6, 6+len(data))
+		binary.BigEndian.PutUint16(packet, ptype)
+		binary.BigEndian.PutUint32(packet[2:], uint32(len(data)))
+		return c.writeChunks(append(packet, data...), progress)
+	}
 	if err := c.writeHeader(uint16(ptype), uint32(len(data))); err != nil {
 		return err
 	}
@@ -137,6 +143,10 @@ func (c *Conn) writeHeader(pType uint16, pLength uint32) error {
 
 // WriteProto writes a protocol buffer message to the connection.
 func (c *Conn) WriteProto(message proto.Message) error {
+	return c.writeProto(message, nil)
+}
+
+func (c *Conn) writeProto(message proto.Message, progress UploadProgress) error {
 	var protoType uint16
 	switch message.(type) {
 	case *MumbleProto.Version:
@@ -196,5 +206,5 @@ func (c *Conn) WriteProto(message proto.Message) error {
 	if err != nil {
 		return err
 	}
-	return c.WritePacket(protoType, data)
+	return c.writePacket(protoType, data, progress)
 }
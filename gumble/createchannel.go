@@ -0,0 +1,133 @@
+package gumble
+
+import (
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+	"github.com/golang/protobuf/proto"
+)
+
+// ChannelSpec describes a channel to be created with Client.CreateChannel.
+type ChannelSpec struct {
+	// The name of the channel.
+	Name string
+	// The channel underneath which the channel is created. nil means the
+	// root channel.
+	Parent *Channel
+	// The channel's description. Can be empty.
+	Description string
+	// The channel's position among its siblings.
+	Position int32
+	// Is the channel temporary (i.e. removed once it is empty)?
+	Temporary bool
+	// The maximum number of users allowed in the channel. Zero means the
+	// server's default.
+	MaxUsers uint32
+	// If not empty, only users who have Password as one of their access
+	// tokens can enter the channel. The password is set with an ACL once the
+	// server has created the channel, so the client must have permission to
+	// write it.
+	Password string
+}
+
+// pendingChannel is a channel that the client has asked the server to create,
+// and whose password must be set once it is.
+type pendingChannel struct {
+	parent   uint32
+	name     string
+	password string
+}
+
+// CreateChannel asks the server to create the channel described by spec. All
+// of the channel's properties are sent in a single message; a ChannelChangeEvent
+// with the ChannelChangeCreated type is triggered once the server has created
+// it.
+func (c *Client) CreateChannel(spec ChannelSpec) error {
+	var parent uint32
+	if spec.Parent != nil {
+		parent = spec.Parent.ID
+	}
+	packet := MumbleProto.ChannelState{
+		Parent:    &parent,
+		Name:      &spec.Name,
+		Temporary: &spec.Temporary,
+	}
+	if spec.Description != "" {
+		packet.Description = &spec.Description
+	}
+	if spec.Position != 0 {
+		packet.Position = &spec.Position
+	}
+	if spec.MaxUsers != 0 {
+		packet.MaxUsers = &spec.MaxUsers
+	}
+	if spec.Password != "" {
+		c.pendingChannelsLock.Lock()
+		c.pendingChannels = append(c.pendingChannels, &pendingChannel{
+			parent:   parent,
+			name:     spec.Name,
+			password: spec.Password,
+		})
+		c.pendingChannelsLock.Unlock()
+	}
+	return c.Conn.WriteProto(&packet)
+}
+
+// channelCreated sets the password of channel, if the client asked the server
+// to create it with one.
+func (c *Client) channelCreated(channel *Channel) {
+	if channel.Parent == nil {
+		return
+	}
+	var pending *pendingChannel
+	c.pendingChannelsLock.Lock()
+	for i, p := range c.pendingChannels {
+		if p.parent == channel.Parent.ID && p.name == channel.Name {
+			pending = p
+			c.pendingChannels = append(c.pendingChannels[:i], c.pendingChannels[i+1:]...)
+			break
+		}
+	}
+	c.pendingChannelsLock.Unlock()
+	if pending == nil {
+		return
+	}
+
+	rules := []*MumbleProto.ACL_ChanACL{
+		{
+			ApplyHere: proto.Bool(true),
+			ApplySubs: proto.Bool(true),
+			Group:     proto.String(ACLGroupEveryone),
+			Grant:     proto.Uint32(0),
+			Deny:      proto.Uint32(uint32(PermissionEnter)),
+		},
+		{
+			ApplyHere: proto.Bool(true),
+			ApplySubs: proto.Bool(true),
+			Group:     proto.String("#" + pending.password),
+			Grant:     proto.Uint32(uint32(PermissionEnter)),
+			Deny:      proto.Uint32(0),
+		},
+	}
+	// Setting the ACL replaces the rule that the server adds to let the
+	// creator of a channel edit it, so it is added again.
+	if self := c.Self; self != nil {
+		creator := &MumbleProto.ACL_ChanACL{
+			ApplyHere: proto.Bool(true),
+			ApplySubs: proto.Bool(true),
+			Grant:     proto.Uint32(uint32(PermissionWrite | PermissionTraverse)),
+			Deny:      proto.Uint32(0),
+		}
+		if self.IsRegistered() {
+			creator.UserId = proto.Uint32(self.UserID)
+		} else {
+			creator.Group = proto.String("$" + self.Hash)
+		}
+		rules = append(rules, creator)
+	}
+	packet := MumbleProto.ACL{
+		ChannelId:   &channel.ID,
+		InheritAcls: proto.Bool(true),
+		Acls:        rules,
+		Query:       proto.Bool(false),
+	}
+	c.Conn.WriteProto(&packet)
+}
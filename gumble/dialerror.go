@@ -0,0 +1,70 @@
+package gumble
+
+import (
+	"errors"
+)
+
+// DialStage is a stage of connecting to a server.
+type DialStage int
+
+// Dial stages, in order.
+const (
+	// DialStageConnect is the TCP connection to the server.
+	DialStageConnect DialStage = iota
+	// DialStageTLS is the TLS handshake.
+	DialStageTLS
+	// DialStageVersion is the wait for the server's Version packet, the first
+	// packet that the server sends.
+	DialStageVersion
+	// DialStageSync is the wait for the server to send its state and accept
+	// the client.
+	DialStageSync
+)
+
+func (s DialStage) String() string {
+	switch s {
+	case DialStageConnect:
+		return "connect"
+	case DialStageTLS:
+		return "TLS handshake"
+	case DialStageVersion:
+		return "version exchange"
+	case DialStageSync:
+		return "server sync"
+	}
+	return "unknown stage"
+}
+
+// ErrDialTimeout is the Err of a DialError when a stage did not complete in
+// time.
+var ErrDialTimeout = errors.New("timeout")
+
+// DialError is returned by DialWithDialer when connecting to the server
+// fails, e.g. because the server is unreachable (DialStageConnect), or it
+// accepted the connection but never sent its state (DialStageSync). A server
+// that rejects the client returns a *RejectError instead.
+type DialError struct {
+	// The stage that failed.
+	Stage DialStage
+	// The cause of the failure: ErrDialTimeout if the stage timed out.
+	Err error
+}
+
+// Error implements error.
+func (e *DialError) Error() string {
+	return "gumble: " + e.Stage.String() + ": " + e.Err.Error()
+}
+
+// Timeout returns true if the stage timed out, either because of its own
+// timeout in Config, or the dialer's Timeout or Deadline.
+func (e *DialError) Timeout() bool {
+	if e.Err == ErrDialTimeout {
+		return true
+	}
+	if err, ok := e.Err.(interface {
+		Timeout() bool
+	}); ok {
+		return err.Timeout()
+	}
+	return false
+}
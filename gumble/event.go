@@ -1,6 +1,8 @@
 package gumble
 
 import (
+	"time"
+
 	"github.com/bmmcginty/gumble/gumble/MumbleProto"
 )
 
@@ -8,9 +10,9 @@ import (
 // wishes to be notified of Client events.
 //
 // Listener methods are executed synchronously as event happen. They also block
-// network reads from happening until all handlers for an event are called.
-// Therefore, it is not recommended to do any long processing from inside of
-// these methods.
+// network reads from happening until all handlers for an event are called,
+// unless Config.EventQueueSize is set. Therefore, it is not recommended to do
+// any long processing from inside of these methods.
 type EventListener interface {
 	OnConnect(e *ConnectEvent)
 	OnDisconnect(e *DisconnectEvent)
@@ -25,11 +27,60 @@ type EventListener interface {
 	OnServerConfig(e *ServerConfigEvent)
 }
 
+// The following interfaces can be implemented, in addition to EventListener,
+// by a type that wishes to be notified of the corresponding events. Listeners
+// that do not implement them do not receive the events.
+
+// AudioConfigListener is notified when the audio configuration changes.
+type AudioConfigListener interface {
+	OnAudioConfig(e *AudioConfigEvent)
+}
+
+// SyncProgressListener is notified of the progress of the initial sync.
+type SyncProgressListener interface {
+	OnSyncProgress(e *SyncProgressEvent)
+}
+
+// StateChangeListener is notified when the client's State changes.
+type StateChangeListener interface {
+	OnStateChange(e *StateChangeEvent)
+}
+
+// UserReturnedListener is notified when a user returns to the server.
+type UserReturnedListener interface {
+	OnUserReturned(e *UserReturnedEvent)
+}
+
+// ChannelOccupancyListener is notified when users enter or leave channels.
+type ChannelOccupancyListener interface {
+	OnChannelOccupancyChange(e *ChannelOccupancyEvent)
+}
+
+// ChannelActivityListener is notified when channels become active or silent.
+type ChannelActivityListener interface {
+	OnChannelActivityChange(e *ChannelActivityEvent)
+}
+
+// ChannelLostListener is notified when the client's channel is removed.
+type ChannelLostListener interface {
+	OnChannelLost(e *ChannelLostEvent)
+}
+
+// ResourceLimitListener is notified when a memory limit of the client is hit.
+type ResourceLimitListener interface {
+	OnResourceLimit(e *ResourceLimitEvent)
+}
+
 // ConnectEvent is the event that is passed to EventListener.OnConnect.
 type ConnectEvent struct {
 	Client         *Client
 	WelcomeMessage *string
 	MaximumBitrate *int
+
+	// The users and channels of the server's initial state, sorted by
+	// session and ID.
+	Users    []*User
+	Channels []*Channel
 }
 
 // DisconnectType specifies why a Client disconnected from a server.
@@ -81,6 +132,8 @@ const (
 	UserChangePrioritySpeaker
 	UserChangeRecording
 	UserChangeStats
+	UserChangeListening
+	UserChangeIdle
 )
 
 // Has returns true if the UserChangeType has changeType part of its bitmask.
@@ -93,9 +146,22 @@ type UserChangeEvent struct {
 	Client *Client
 	Type   UserChangeType
 	User   *User
-	Actor  *User
+	// The user who caused the change (e.g. who muted, moved, kicked or banned
+	// User), taken from the actor of the server's message. It can be User
+	// itself, and is nil if the change was made by the server, or the actor
+	// is no longer connected.
+	Actor *User
 
+	// The reason of a kick or ban.
 	String string
+
+	// The channels that the user started and stopped listening to, if Type
+	// has UserChangeListening.
+	ListeningAdded, ListeningRemoved []*Channel
+
+	// Is the event part of the server's initial state, rather than a change
+	// that happened after the client connected? See Config.SyncEvents.
+	Initial bool
 }
 
 // ChannelChangeType is a bitmask of items that changed for a channel.
@@ -122,10 +188,17 @@ func (c ChannelChangeType) Has(changeType ChannelChangeType) bool {
 
 // ChannelChangeEvent is the event that is passed to
 // EventListener.OnChannelChange.
+//
+// Unlike UserChangeEvent, the event has no actor: the server's channel
+// messages do not say which user made the change.
 type ChannelChangeEvent struct {
 	Client  *Client
 	Type    ChannelChangeType
 	Channel *Channel
+
+	// Is the event part of the server's initial state, rather than a change
+	// that happened after the client connected? See Config.SyncEvents.
+	Initial bool
 }
 
 // PermissionDeniedType specifies why a Client was denied permission to perform
@@ -162,6 +235,11 @@ type PermissionDeniedEvent struct {
 
 	Permission Permission
 	String     string
+
+	// Err is non-nil if the denied request was one the client made on its
+	// own. It is a *JoinChannelError if the client could not move itself to
+	// Config.JoinChannel.
+	Err error
 }
 
 // UserListEvent is the event that is passed to EventListener.OnUserList.
@@ -220,3 +298,130 @@ type ServerConfigEvent struct {
 	SuggestPositional *bool
 	SuggestPushToTalk *bool
 }
+
+// AudioConfigEvent is the event that is passed to
+// AudioConfigListener.OnAudioConfig. It is triggered when the client's outgoing
+// audio settings are changed while the client is connected. Audio sources
+// should adjust the size and rate of the audio buffers they send to match the
+// new values.
+type AudioConfigEvent struct {
+	Client *Client
+
+	AudioInterval  time.Duration
+	AudioFrameSize int
+}
+
+// SyncProgressEvent is the event that is passed to
+// SyncProgressListener.OnSyncProgress. It is triggered while the client is
+// connecting, each time the server sends a channel or user of its initial
+// state, and once more when the state has been fully received (before the
+// ConnectEvent).
+type SyncProgressEvent struct {
+	Client *Client
+
+	// The number of channels and users received so far.
+	Channels int
+	Users    int
+	// Has the initial state been fully received?
+	Done bool
+}
+
+// StateChangeEvent is the event that is passed to
+// StateChangeListener.OnStateChange. It is triggered each time the client's
+// State changes: to StateConnected once the connection to the server is
+// established, to StateSynced before the ConnectEvent, to StateDisconnecting
+// when Client.Disconnect is called, and to StateDisconnected before the
+// DisconnectEvent (or when connecting fails).
+type StateChangeEvent struct {
+	Client *Client
+
+	Old, New State
+}
+
+// UserReturnedEvent is the event that is passed to
+// UserReturnedListener.OnUserReturned. It is triggered, after the
+// UserChangeEvent, when a user connects whose StableID is that of a user who
+// left the server while the client was connected. The tags of the previous User
+// are copied to the new one before the event is triggered.
+type UserReturnedEvent struct {
+	Client *Client
+	User   *User
+
+	// The session and name that the user had before they left.
+	PreviousSession uint32
+	PreviousName    string
+	// When the user left.
+	Left time.Time
+}
+
+// ChannelOccupancyEvent is the event that is passed to
+// ChannelOccupancyListener.OnChannelOccupancyChange. It is triggered, after the
+// UserChangeEvent, when a user enters or leaves a channel, by connecting,
+// disconnecting or moving. A move triggers an event for each channel.
+type ChannelOccupancyEvent struct {
+	Client  *Client
+	Channel *Channel
+
+	// The number of users in the channel after and before the change.
+	Users         int
+	PreviousUsers int
+}
+
+// ChannelActivityEvent is the event that is passed to
+// ChannelActivityListener.OnChannelActivityChange. It is only triggered if
+// Config.ChannelSilenceTimeout is set: when a user speaks in a channel in which
+// nobody had spoken, and once nobody has spoken in an active channel for
+// Config.ChannelSilenceTimeout. Client.Self, and the users that
+// Config.ChannelActivityIgnore returns true for, do not make a channel active.
+type ChannelActivityEvent struct {
+	Client  *Client
+	Channel *Channel
+
+	// If the channel became active. Otherwise, it went silent.
+	Active bool
+	// The user who spoke in the channel, if it became active.
+	User *User
+	// When a user last spoke in the channel.
+	LastSpeech time.Time
+}
+
+// ChannelLostEvent is the event that is passed to
+// ChannelLostListener.OnChannelLost. It is triggered, after the
+// ChannelChangeEvent, when the channel that the client was in is removed (e.g.
+// a temporary channel), and the server has moved the client to another channel.
+// Config.ChannelRemoved has been applied when the event is triggered.
+type ChannelLostEvent struct {
+	Client *Client
+	// The removed channel.
+	Channel *Channel
+
+	// What the client did about it.
+	Policy ChannelRemovedPolicy
+	// The channel that the client is moving to with
+	// ChannelRemovedJoinFallback. nil if Config.ChannelRemovedFallback does
+	// not exist, in which case the client stays where it is.
+	Fallback *Channel
+	// The error, if any, of asking the server to recreate the channel with
+	// ChannelRemovedRecreate.
+	Err error
+}
+
+// ResourceLimitEvent is the event that is passed to
+// ResourceLimitListener.OnResourceLimit. It is triggered when data received
+// from the server exceeds one of the client's memory limits (see
+// Config.BlobMaxBytes and Config.AudioStreamQueue).
+type ResourceLimitEvent struct {
+	Client *Client
+	Type   ResourceLimitType
+	// What the client did about it.
+	Policy ResourceLimitPolicy
+
+	// The user whose blob or audio was limited. nil for a channel
+	// description.
+	User *User
+	// The channel whose description was dropped.
+	Channel *Channel
+	// For ResourceLimitBlob, the size of the dropped blob, in bytes. For
+	// ResourceLimitAudio, the number of packets that the stream can queue.
+	Size int
+}
@@ -0,0 +1,119 @@
+package gumble
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// EventOverflow specifies what happens when the event queue is full (see
+// Config.EventQueueSize).
+type EventOverflow int
+
+const (
+	// EventOverflowBlock waits for the listeners to catch up, which stops
+	// the client from reading packets in the meantime.
+	EventOverflowBlock EventOverflow = iota
+
+	// EventOverflowDrop discards the event, and counts it in
+	// Client.DroppedEvents. ConnectEvents, DisconnectEvents and
+	// AudioConfigEvents are never dropped.
+	EventOverflowDrop
+)
+
+// eventQueue holds the listener calls waiting for the dispatcher goroutine.
+type eventQueue struct {
+	mu      sync.Mutex
+	cond    sync.Cond
+	events  []func()
+	size    int
+	closed  bool
+	dropped uint64
+}
+
+// startDispatcher starts the goroutine from which event listeners are
+// called, if Config.EventQueueSize is positive.
+func (c *Client) startDispatcher() {
+	if c.Config.EventQueueSize <= 0 {
+		return
+	}
+	q := &eventQueue{
+		size: c.Config.EventQueueSize,
+	}
+	q.cond.L = &q.mu
+	c.eventQueue = q
+	go q.dispatchRoutine()
+}
+
+func (q *eventQueue) dispatchRoutine() {
+	for {
+		q.mu.Lock()
+		for len(q.events) == 0 && !q.closed {
+			q.cond.Wait()
+		}
+		if len(q.events) == 0 {
+			q.mu.Unlock()
+			return
+		}
+		deliver := q.events[0]
+		q.events[0] = nil
+		q.events = q.events[1:]
+		q.cond.Broadcast()
+		q.mu.Unlock()
+
+		deliver()
+	}
+}
+
+// dispatchEvent calls deliver, which calls the event listeners, either
+// directly or from the dispatcher goroutine.
+//
+// If required is true, the event is queued even if the queue is full, so
+// that it is neither dropped nor waits. This is also what events that are
+// not triggered by the read goroutine use, as they may be triggered from a
+// listener, i.e. by the dispatcher goroutine itself.
+func (c *Client) dispatchEvent(required bool, deliver func()) {
+	c.notifyWaiters()
+	q := c.eventQueue
+	if q == nil {
+		deliver()
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if !required {
+		for len(q.events) >= q.size && !q.closed {
+			if c.Config.EventOverflow == EventOverflowDrop {
+				atomic.AddUint64(&q.dropped, 1)
+				return
+			}
+			q.cond.Wait()
+		}
+	}
+	if q.closed {
+		return
+	}
+	q.events = append(q.events, deliver)
+	q.cond.Broadcast()
+}
+
+// closeDispatcher stops the dispatcher goroutine once the queued events have
+// been delivered. Later events are discarded.
+func (c *Client) closeDispatcher() {
+	q := c.eventQueue
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	q.closed = true
+	q.cond.Broadcast()
+	q.mu.Unlock()
+}
+
+// DroppedEvents returns the number of events that have been discarded
+// because the event queue was full (see EventOverflowDrop).
+func (c *Client) DroppedEvents() uint64 {
+	if c.eventQueue == nil {
+		return 0
+	}
+	return atomic.LoadUint64(&c.eventQueue.dropped)
+}
@@ -0,0 +1,145 @@
+package gumble
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+	"github.com/golang/protobuf/proto"
+)
+
+// blockingListener blocks in OnTextMessage until release is closed.
+type blockingListener struct {
+	testListener
+	calls   chan struct{}
+	release chan struct{}
+}
+
+func newBlockingListener() *blockingListener {
+	return &blockingListener{
+		calls:   make(chan struct{}, 16),
+		release: make(chan struct{}),
+	}
+}
+
+func (l *blockingListener) OnTextMessage(e *TextMessageEvent) {
+	l.calls <- struct{}{}
+	<-l.release
+}
+
+// newTestQueueClient returns a replay client whose listener blocks, and the
+// Conn from which the client reads.
+func newTestQueueClient(t *testing.T, size int, overflow EventOverflow) (*Client, *Conn, *blockingListener) {
+	config := NewConfig()
+	config.EventQueueSize = size
+	config.EventOverflow = overflow
+	l := newBlockingListener()
+	config.Attach(l)
+	client, server := NewReplayClient(config)
+	t.Cleanup(func() {
+		select {
+		case <-l.release:
+		default:
+			close(l.release)
+		}
+		server.Close()
+	})
+	return client, server, l
+}
+
+// writeTestPackets writes the given number of text messages to server, and
+// then a ping, so that the messages have been handled once it returns. done
+// is closed when it returns.
+func writeTestPackets(server *Conn, messages, pings int, done chan struct{}) {
+	defer close(done)
+	for i := 0; i < messages; i++ {
+		server.WriteProto(&MumbleProto.TextMessage{Message: proto.String("message")})
+	}
+	for i := 0; i < pings+1; i++ {
+		server.WriteProto(&MumbleProto.Ping{})
+	}
+}
+
+func waitTestCall(t *testing.T, l *blockingListener) {
+	select {
+	case <-l.calls:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the listener was not called")
+	}
+}
+
+func TestEventQueueSlowListener(t *testing.T) {
+	client, server, l := newTestQueueClient(t, 4, EventOverflowBlock)
+
+	const pings = 10
+	done := make(chan struct{})
+	go writeTestPackets(server, 1, pings, done)
+	waitTestCall(t, l)
+	// The listener is blocked, but the client keeps reading packets.
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client stopped reading while the listener was blocked")
+	}
+	if received := atomic.LoadUint32(&client.tcpPacketsReceived); received < pings {
+		t.Errorf("handled %d pings, expected %d\n", received, pings)
+	}
+}
+
+func TestEventQueueOverflowBlock(t *testing.T) {
+	client, server, l := newTestQueueClient(t, 1, EventOverflowBlock)
+
+	const messages = 4
+	done := make(chan struct{})
+	go writeTestPackets(server, messages, 0, done)
+	waitTestCall(t, l)
+	// One event is being delivered, and one is queued, so the client waits
+	// for the listener before it reads the other packets.
+	select {
+	case <-done:
+		t.Fatal("the client kept reading while the event queue was full")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(l.release)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client did not resume reading")
+	}
+	for i := 1; i < messages; i++ {
+		waitTestCall(t, l)
+	}
+	if dropped := client.DroppedEvents(); dropped != 0 {
+		t.Errorf("%d events dropped\n", dropped)
+	}
+}
+
+func TestEventQueueOverflowDrop(t *testing.T) {
+	client, server, l := newTestQueueClient(t, 1, EventOverflowDrop)
+
+	const messages = 4
+	done := make(chan struct{})
+	go writeTestPackets(server, messages, 0, done)
+	waitTestCall(t, l)
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the client stopped reading while the event queue was full")
+	}
+
+	dropped := client.DroppedEvents()
+	if dropped == 0 || dropped >= messages {
+		t.Fatalf("%d events dropped, expected between 1 and %d\n", dropped, messages-1)
+	}
+	close(l.release)
+	for i := uint64(1); i < messages-dropped; i++ {
+		waitTestCall(t, l)
+	}
+	select {
+	case <-l.calls:
+		t.Error("a dropped event was delivered")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
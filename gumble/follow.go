@@ -0,0 +1,166 @@
+package gumble
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrFollowedUserLeft is returned by Follower.Err when the followed user has
+// disconnected from the server.
+var ErrFollowedUserLeft = errors.New("gumble: followed user left the server")
+
+// Follower moves the client to the channel of a user each time the user
+// changes channels. It is created with Client.Follow.
+type Follower struct {
+	client *Client
+	user   *User
+
+	mu sync.Mutex
+	// The channel the client was asked to join, and the reason the server
+	// denied it.
+	target *Channel
+	denied *JoinChannelError
+	done   chan struct{}
+	err    error
+}
+
+// Follow starts moving the client to user's channel, now and each time the
+// user changes channels, e.g. so that a recording bot shadows a particular
+// person. Following another user stops the previous Follower.
+//
+// If the server denies a move (see Follower.Denied), the client stays where
+// it is until the user moves again. Following stops when Follower.Stop is
+// called, when the user disconnects, or when the client disconnects.
+func (c *Client) Follow(user *User) *Follower {
+	f := &Follower{
+		client: c,
+		user:   user,
+		done:   make(chan struct{}),
+	}
+	c.followLock.Lock()
+	previous := c.follower
+	c.follower = f
+	c.followLock.Unlock()
+	if previous != nil {
+		previous.finish(nil)
+	}
+
+	go func() {
+		select {
+		case <-c.end:
+			f.finish(errors.New("gumble: client disconnected"))
+		case <-f.done:
+		}
+	}()
+	f.follow()
+	return f
+}
+
+// User returns the followed user.
+func (f *Follower) User() *User {
+	return f.user
+}
+
+// Denied returns why the server denied the client's last move to the
+// followed user's channel, or nil if it did not.
+func (f *Follower) Denied() *JoinChannelError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.denied
+}
+
+// Stop stops following the user. The client stays in its current channel.
+func (f *Follower) Stop() {
+	f.finish(nil)
+}
+
+// Done returns a channel that is closed once the Follower has stopped.
+func (f *Follower) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err returns why the Follower stopped: nil if Stop was called, or another
+// user was followed; ErrFollowedUserLeft if the user disconnected; or an error
+// if the client disconnected. nil is also returned if the Follower is still
+// following.
+func (f *Follower) Err() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.err
+}
+
+func (f *Follower) finish(err error) {
+	f.mu.Lock()
+	select {
+	case <-f.done:
+		f.mu.Unlock()
+		return
+	default:
+	}
+	f.err = err
+	close(f.done)
+	f.mu.Unlock()
+
+	c := f.client
+	c.followLock.Lock()
+	if c.follower == f {
+		c.follower = nil
+	}
+	c.followLock.Unlock()
+}
+
+// follow moves the client to the followed user's channel, if it is not
+// already there.
+func (f *Follower) follow() {
+	c := f.client
+	c.volatile.RLock()
+	channel := f.user.Channel
+	here := c.Self != nil && c.Self.Channel == channel
+	c.volatile.RUnlock()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.denied = nil
+	f.target = nil
+	if channel == nil || here {
+		return
+	}
+	f.target = channel
+	channel.Join()
+}
+
+// followMoved is called when user has changed channels, or disconnected
+// (user.Channel is then nil).
+func (c *Client) followMoved(user *User, disconnected bool) {
+	c.followLock.Lock()
+	f := c.follower
+	c.followLock.Unlock()
+	if f == nil || f.user != user {
+		return
+	}
+	if disconnected {
+		f.finish(ErrFollowedUserLeft)
+		return
+	}
+	f.follow()
+}
+
+// followDenied records the denial of the follower's move.
+func (c *Client) followDenied(event *PermissionDeniedEvent) {
+	err, ok := event.Err.(*JoinChannelError)
+	if !ok {
+		return
+	}
+	c.followLock.Lock()
+	f := c.follower
+	c.followLock.Unlock()
+	if f == nil {
+		return
+	}
+	f.mu.Lock()
+	if f.target != nil && f.target == err.Channel {
+		f.denied = err
+		f.target = nil
+	}
+	f.mu.Unlock()
+}
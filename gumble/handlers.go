@@ -2,7 +2,6 @@ package gumble
 
 import (
 	"crypto/x509"
-	"encoding/binary"
 	"errors"
 	"math"
 	"net"
@@ -11,7 +10,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/bmmcginty/gumble/gumble/MumbleProto"
-	"github.com/bmmcginty/gumble/gumble/varint"
+	"github.com/bmmcginty/gumble/gumble/voicepacket"
 )
 
 var (
@@ -73,33 +72,62 @@ func (c *Client) handleVersion(buffer []byte) error {
 	if err := proto.Unmarshal(buffer, &packet); err != nil {
 		return err
 	}
+
+	version := &Version{
+		Version:   packet.GetVersion(),
+		Release:   packet.GetRelease(),
+		OS:        packet.GetOs(),
+		OSVersion: packet.GetOsVersion(),
+	}
+	c.volatile.Lock()
+	first := c.serverVersion == nil
+	c.serverVersion = version
+	c.volatile.Unlock()
+	if first && c.version != nil {
+		close(c.version)
+	}
 	return nil
 }
 
 func (c *Client) handleUDPTunnel(buffer []byte) error {
-	if len(buffer) < 1 {
-		return errInvalidProtobuf
-	}
-	audioType := (buffer[0] >> 5) & 0x7
-	audioTarget := buffer[0] & 0x1F
-
-	// Opus only
-	// TODO: add handling for other packet types
-	if audioType != audioCodecIDOpus {
+	packet, err := voicepacket.Decode(buffer, true)
+	switch err {
+	case nil:
+	case voicepacket.ErrUnsupportedType:
+		// TODO: add handling for other packet types
 		return errUnsupportedAudio
-	}
-
-	// Session
-	buffer = buffer[1:]
-	session, n := varint.Decode(buffer)
-	if n <= 0 {
+	default:
 		return errInvalidProtobuf
 	}
-	buffer = buffer[n:]
-	user := c.Users[uint32(session)]
+
+	user := c.Users[packet.Session]
 	if user == nil {
 		return errInvalidProtobuf
 	}
+	c.userActive(user)
+	c.channelSpeech(user)
+	if !c.Config.AudioIgnoreSelfState && atomic.LoadUint32(&c.selfDeafened) == 1 {
+		return nil
+	}
+
+	if tolerance := c.Config.AudioReorderTolerance; tolerance > 0 {
+		if user.reorder == nil {
+			user.reorder = newReorderBuffer()
+		}
+		for _, p := range user.reorder.push(packet, tolerance, &c.reorderStats) {
+			if err := c.handleVoicePacket(user, p); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return c.handleVoicePacket(user, packet)
+}
+
+// handleVoicePacket decodes packet, which was sent by user, and passes it to
+// the AudioListeners. A packet that contains several Opus frames is passed as
+// one AudioPacket per frame, in order.
+func (c *Client) handleVoicePacket(user *User, packet *voicepacket.Packet) error {
 	decoder := user.decoder
 	if decoder == nil {
 		// TODO: decoder pool
@@ -112,70 +140,166 @@ func (c *Client) handleUDPTunnel(buffer []byte) error {
 		user.decoder = decoder
 	}
 
-	// Sequence
-	// TODO: use in jitter buffer
-	_, n = varint.Decode(buffer)
-	if n <= 0 {
-		return errInvalidProtobuf
-	}
-	buffer = buffer[n:]
-
-	// Length
-	length, n := varint.Decode(buffer)
-	if n <= 0 {
-		return errInvalidProtobuf
+	frames, frameSamples, err := voicepacket.OpusFrames(packet.Data)
+	if err != nil {
+		return err
 	}
-	buffer = buffer[n:]
-	// Opus audio packets set the 13th bit in the size field as the terminator.
-	audioLength := int(length) &^ 0x2000
-	if audioLength > len(buffer) {
-		return errInvalidProtobuf
+	frameSize := AudioMaximumFrameSize
+	if samples := frames * frameSamples; samples > frameSize {
+		frameSize = samples
 	}
 
-	pcm, err := decoder.Decode(buffer[:audioLength], AudioMaximumFrameSize)
+	start := time.Now()
+	pcm, err := decoder.Decode(packet.Data, frameSize)
 	if err != nil {
 		return err
 	}
+	if trace := c.Config.AudioTrace; trace != nil {
+		trace.incoming(user, packet.Sequence, packet.Data, len(pcm), time.Since(start))
+	}
 
-	event := AudioPacket{
-		Client: c,
-		Sender: user,
-		Target: &VoiceTarget{
-			ID: uint32(audioTarget),
-		},
-		AudioBuffer: AudioBuffer(pcm),
+	chunk := len(pcm)
+	if frames > 1 && len(pcm) == frames*frameSamples*AudioChannels {
+		chunk = frameSamples * AudioChannels
 	}
+	target := &VoiceTarget{
+		ID: uint32(packet.Target),
+	}
+	encoded := packet.Data
+	for offset := 0; offset == 0 || offset < len(pcm); offset += chunk {
+		event := AudioPacket{
+			Client: c,
+			Sender: user,
+			Target: target,
+			// Sequence numbers count 10ms frames.
+			Sequence:    packet.Sequence + int64(offset/(AudioDefaultFrameSize*AudioChannels)),
+			AudioBuffer: AudioBuffer(pcm[offset : offset+chunk]),
 
-	if len(buffer)-audioLength == 3*4 {
-		// the packet has positional audio data; 3x float32
-		buffer = buffer[audioLength:]
+			HasPosition: packet.HasPosition,
+			X:           packet.X,
+			Y:           packet.Y,
+			Z:           packet.Z,
+		}
 
-		event.X = math.Float32frombits(binary.LittleEndian.Uint32(buffer))
-		event.Y = math.Float32frombits(binary.LittleEndian.Uint32(buffer[4:]))
-		event.Z = math.Float32frombits(binary.LittleEndian.Uint32(buffer[8:]))
-		event.HasPosition = true
+		c.dispatchAudio(user, &event, encoded)
+		// The encoded packet is passed along with its first frame.
+		encoded = nil
+		if chunk == 0 {
+			// The decoder returned no audio (e.g. for an empty or a DTX
+			// frame); the packet has been passed once.
+			break
+		}
 	}
+	return nil
+}
 
+// dispatchAudio delivers packet to each attached AudioListener, starting a new
+// audio stream for user if one has not yet been started. Listeners attached
+// with AttachEncoded receive a copy of packet that also contains encoded.
+//
+// The audio normalization, the user's local mute and volume preferences, and
+// the volume adjustment of the listened channel through which the audio is
+// received, if any, are applied before the packet reaches any listener.
+func (c *Client) dispatchAudio(user *User, packet *AudioPacket, encoded []byte) {
+	var encodedPacket *AudioPacket
 	c.volatile.Lock()
-	for item := c.Config.AudioListeners.head; item != nil; item = item.next {
+	now := time.Now()
+	user.lastAudio = now
+	c.pruneAudioStreams(now)
+	if user.LocalMute() {
 		c.volatile.Unlock()
+		return
+	}
+	if settings := c.Config.AudioNormalization; settings != nil {
+		if user.normalizer == nil {
+			user.normalizer = newNormalizer()
+		}
+		user.normalizer.process(settings, packet.AudioBuffer)
+	}
+	volume := user.LocalVolume()
+	if self, channel := c.Self, user.Channel; packet.Target.ID == 0 && self != nil && channel != nil && channel != self.Channel && self.ListeningChannels[channel.ID] != nil {
+		// Received through a listened channel.
+		volume *= self.listeningVolume(channel)
+	}
+	if volume != 1 {
+		packet.AudioBuffer.scale(volume)
+	}
+	for item := c.Config.AudioListeners.head; item != nil; item = item.next {
+		p := packet
+		if item.encoded {
+			if encodedPacket == nil {
+				copied := *packet
+				copied.Encoded = append([]byte(nil), encoded...)
+				encodedPacket = &copied
+			}
+			p = encodedPacket
+		}
 		ch := item.streams[user]
 		if ch == nil {
-			ch = make(chan *AudioPacket)
+			if max := c.Config.AudioMaxStreams; max > 0 {
+				for len(item.streams) >= max {
+					item.closeOldestStream()
+				}
+			}
+			ch = make(chan *AudioPacket, c.Config.AudioStreamQueue)
 			item.streams[user] = ch
+			c.volatile.Unlock()
 			event := AudioStreamEvent{
 				Client: c,
 				User:   user,
 				C:      ch,
 			}
 			item.listener.OnAudioStream(&event)
+		} else {
+			c.volatile.Unlock()
 		}
-		ch <- &event
+		c.sendAudio(item, user, ch, p)
 		c.volatile.Lock()
 	}
 	c.volatile.Unlock()
+}
 
-	return nil
+// idleUsers returns the users who have become idle (see
+// Config.UserIdleTimeout) since the last call, and marks them as idle.
+func (c *Client) idleUsers(now time.Time) []*User {
+	timeout := c.Config.UserIdleTimeout
+	if timeout <= 0 {
+		return nil
+	}
+	var idle []*User
+	for _, user := range c.Users {
+		if !user.IsIdle() && now.Sub(user.IdleSince()) >= timeout {
+			atomic.StoreUint32(&user.idle, 1)
+			idle = append(idle, user)
+		}
+	}
+	return idle
+}
+
+// userActive records that user has sent audio or a text message, and
+// triggers a UserChangeIdle event if the user was idle.
+func (c *Client) userActive(user *User) {
+	if !user.active(time.Now()) {
+		return
+	}
+	event := UserChangeEvent{
+		Client: c,
+		Type:   UserChangeIdle,
+		User:   user,
+	}
+	c.Config.Listeners.onUserChange(&event)
+}
+
+// pruneAudioStreams closes audio streams that have been idle for longer than
+// Config.AudioStreamTimeout. The check is performed at most once a second.
+// c.volatile must be held.
+func (c *Client) pruneAudioStreams(now time.Time) {
+	timeout := c.Config.AudioStreamTimeout
+	if timeout <= 0 || now.Sub(c.lastAudioPrune) < time.Second {
+		return
+	}
+	c.lastAudioPrune = now
+	c.Config.AudioListeners.closeIdleStreams(now.Add(-timeout))
 }
 
 func (c *Client) handleAuthenticate(buffer []byte) error {
@@ -190,6 +314,29 @@ func (c *Client) handlePing(buffer []byte) error {
 
 	atomic.AddUint32(&c.tcpPacketsReceived, 1)
 
+	now := time.Now()
+	c.volatile.Lock()
+	c.pruneAudioStreams(now)
+	idle := c.idleUsers(now)
+	silent := c.silentChannels(now)
+	c.volatile.Unlock()
+	for _, user := range idle {
+		event := UserChangeEvent{
+			Client: c,
+			Type:   UserChangeIdle,
+			User:   user,
+		}
+		c.Config.Listeners.onUserChange(&event)
+	}
+	for _, channel := range silent {
+		event := ChannelActivityEvent{
+			Client:     c,
+			Channel:    channel,
+			LastSpeech: channel.lastSpeech,
+		}
+		c.Config.Listeners.onChannelActivityChange(&event)
+	}
+
 	if packet.Timestamp != nil {
 		diff := time.Since(time.Unix(0, int64(*packet.Timestamp)))
 
@@ -229,11 +376,13 @@ func (c *Client) handleReject(buffer []byte) error {
 		return err
 	}
 
-	if c.State() != StateConnected {
+	if c.isSynced() {
 		return errInvalidProtobuf
 	}
 
-	err := &RejectError{}
+	err := &RejectError{
+		SuperUser: IsSuperUserName(c.Config.Username),
+	}
 
 	if packet.Type != nil {
 		err.Type = RejectType(*packet.Type)
@@ -260,20 +409,34 @@ func (c *Client) handleServerSync(buffer []byte) error {
 			c.volatile.Lock()
 
 			c.Self = c.Users[*packet.Session]
+			c.updateSelfState()
+			c.resetJoin()
+			c.tryJoin()
 
 			c.volatile.Unlock()
 		}
 	}
 	if packet.WelcomeText != nil {
 		event.WelcomeMessage = packet.WelcomeText
+		c.volatile.Lock()
+		c.welcomeMessage = *packet.WelcomeText
+		c.volatile.Unlock()
 	}
 	if packet.MaxBandwidth != nil {
 		val := int(*packet.MaxBandwidth)
 		event.MaximumBitrate = &val
-	}
-	atomic.StoreUint32(&c.state, uint32(StateSynced))
+		atomic.StoreInt32(&c.maximumBitrate, int32(*packet.MaxBandwidth))
+	}
+	c.syncProgress(true)
+	c.volatile.RLock()
+	event.Users = c.Users.sorted()
+	event.Channels = c.Channels.sorted()
+	c.volatile.RUnlock()
+	c.setState(StateSynced)
+	c.flushSyncEvents()
 	c.Config.Listeners.onConnect(&event)
-	close(c.connect)
+	// Dial returns once the ConnectEvent has been delivered.
+	c.dispatchEvent(true, func() { close(c.connect) })
 	return nil
 }
 
@@ -288,6 +451,7 @@ func (c *Client) handleChannelRemove(buffer []byte) error {
 	}
 
 	var channel *Channel
+	var lost bool
 	{
 		c.volatile.Lock()
 
@@ -298,6 +462,7 @@ func (c *Client) handleChannelRemove(buffer []byte) error {
 			return errInvalidProtobuf
 		}
 		channel.client = nil
+		c.blobBytes -= len(channel.Description)
 		delete(c.Channels, channelID)
 		delete(c.permissions, channelID)
 		if parent := channel.Parent; parent != nil {
@@ -306,17 +471,28 @@ func (c *Client) handleChannelRemove(buffer []byte) error {
 		for _, link := range channel.Links {
 			delete(link.Links, channelID)
 		}
+		for _, listener := range channel.Listeners {
+			delete(listener.ListeningChannels, channelID)
+		}
+		c.joinChannelRemoved(channel)
+		lost = c.channelLost(channel)
 
 		c.volatile.Unlock()
 	}
 
-	if c.State() == StateSynced {
-		event := ChannelChangeEvent{
-			Client:  c,
-			Type:    ChannelChangeRemoved,
-			Channel: channel,
-		}
+	event := ChannelChangeEvent{
+		Client:  c,
+		Type:    ChannelChangeRemoved,
+		Channel: channel,
+	}
+	if c.isSynced() {
 		c.Config.Listeners.onChannelChange(&event)
+	} else {
+		event.Initial = true
+		c.syncEvent(func() { c.Config.Listeners.onChannelChange(&event) })
+	}
+	if lost && c.isSynced() {
+		c.handleChannelLost(channel)
 	}
 	return nil
 }
@@ -333,6 +509,7 @@ func (c *Client) handleChannelState(buffer []byte) error {
 	event := ChannelChangeEvent{
 		Client: c,
 	}
+	var limits []*ResourceLimitEvent
 
 	{
 		c.volatile.Lock()
@@ -392,8 +569,15 @@ func (c *Client) handleChannelState(buffer []byte) error {
 			if *packet.Description != channel.Description {
 				event.Type |= ChannelChangeDescription
 			}
-			channel.Description = *packet.Description
-			channel.DescriptionHash = nil
+			if description := *packet.Description; c.admitBlob(len(description), len(channel.Description)) {
+				c.setDescription(channel, description)
+				channel.DescriptionHash = nil
+				c.cacheBlob([]byte(channel.Description))
+			} else {
+				c.setDescription(channel, "")
+				channel.DescriptionHash = blobHash([]byte(description))
+				limits = append(limits, c.blobLimitEvent(nil, channel, len(description)))
+			}
 		}
 		if packet.Temporary != nil {
 			channel.Temporary = *packet.Temporary
@@ -405,21 +589,46 @@ func (c *Client) handleChannelState(buffer []byte) error {
 			channel.Position = *packet.Position
 		}
 		if packet.DescriptionHash != nil {
-			event.Type |= ChannelChangeDescription
-			channel.DescriptionHash = packet.DescriptionHash
-			channel.Description = ""
+			blob, ok := c.cachedBlob(packet.DescriptionHash)
+			if ok && !c.admitBlob(len(blob), len(channel.Description)) {
+				ok = false
+				limits = append(limits, c.blobLimitEvent(nil, channel, len(blob)))
+			}
+			if ok {
+				if string(blob) != channel.Description || channel.DescriptionHash != nil {
+					event.Type |= ChannelChangeDescription
+				}
+				c.setDescription(channel, string(blob))
+				channel.DescriptionHash = nil
+			} else {
+				event.Type |= ChannelChangeDescription
+				channel.DescriptionHash = packet.DescriptionHash
+				c.setDescription(channel, "")
+			}
 		}
 		if packet.MaxUsers != nil {
 			event.Type |= ChannelChangeMaxUsers
 			channel.MaxUsers = *packet.MaxUsers
 		}
+		if event.Type.Has(ChannelChangeCreated) || event.Type.Has(ChannelChangeName) || event.Type.Has(ChannelChangeMoved) {
+			c.tryJoin()
+		}
+		if event.Type.Has(ChannelChangeCreated) {
+			c.channelCreated(channel)
+			c.rejoinCreated(channel)
+		}
 
 		c.volatile.Unlock()
 	}
 
-	if c.State() == StateSynced {
+	if c.isSynced() {
 		c.Config.Listeners.onChannelChange(&event)
+	} else {
+		event.Initial = true
+		c.syncEvent(func() { c.Config.Listeners.onChannelChange(&event) })
+		c.syncProgress(false)
 	}
+	c.resourceLimited(limits)
 	return nil
 }
 
@@ -447,18 +656,21 @@ func (c *Client) handleUserRemove(buffer []byte) error {
 			return errInvalidProtobuf
 		}
 		if packet.Actor != nil {
+			// The actor can be unknown, e.g. if they have just disconnected.
 			event.Actor = c.Users[*packet.Actor]
-			if event.Actor == nil {
-				c.volatile.Unlock()
-				return errInvalidProtobuf
-			}
 			event.Type |= UserChangeKicked
 		}
 
+		c.Config.AudioListeners.closeStreams(event.User)
+		c.userLeft(event.User)
 		event.User.client = nil
 		if event.User.Channel != nil {
 			delete(event.User.Channel.Users, session)
 		}
+		for _, listened := range event.User.ListeningChannels {
+			delete(listened.Listeners, session)
+		}
+		c.releaseBlobs(event.User)
 		delete(c.Users, session)
 		if packet.Reason != nil {
 			event.String = *packet.Reason
@@ -477,9 +689,14 @@ func (c *Client) handleUserRemove(buffer []byte) error {
 		c.volatile.Unlock()
 	}
 
-	if c.State() == StateSynced {
+	if c.isSynced() {
 		c.Config.Listeners.onUserChange(&event)
+	} else {
+		event.Initial = true
+		c.syncEvent(func() { c.Config.Listeners.onUserChange(&event) })
 	}
+	c.occupancyChanged(event.User.Channel, nil)
+	c.followMoved(event.User, true)
 	return nil
 }
 
@@ -496,12 +713,16 @@ func (c *Client) handleUserState(buffer []byte) error {
 		Client: c,
 	}
 	var user, actor *User
+	var previousChannel *Channel
+	var limits []*ResourceLimitEvent
 	{
 		c.volatile.Lock()
 
 		session := *packet.Session
 		user = c.Users[session]
-		if user == nil {
+		if user != nil {
+			previousChannel = user.Channel
+		} else {
 			user = c.Users.create(session)
 			user.Channel = c.Channels[0]
 			user.client = c
@@ -519,10 +740,6 @@ func (c *Client) handleUserState(buffer []byte) error {
 		event.User = user
 		if packet.Actor != nil {
 			actor = c.Users[*packet.Actor]
-			if actor == nil {
-				c.volatile.Unlock()
-				return errInvalidProtobuf
-			}
 			event.Actor = actor
 		}
 		if packet.Name != nil {
@@ -532,6 +749,7 @@ func (c *Client) handleUserState(buffer []byte) error {
 			user.Name = *packet.Name
 		}
 		if packet.UserId != nil {
+			user.superUser = *packet.UserId == 0
 			if *packet.UserId != user.UserID && !event.Type.Has(UserChangeConnected) {
 				if *packet.UserId != math.MaxUint32 {
 					event.Type |= UserChangeRegistered
@@ -550,7 +768,7 @@ func (c *Client) handleUserState(buffer []byte) error {
 			}
 			newChannel := c.Channels[*packet.ChannelId]
 			if newChannel == nil {
-				c.volatile.Lock()
+				c.volatile.Unlock()
 				return errInvalidProtobuf
 			}
 			if newChannel != user.Channel {
@@ -589,30 +807,74 @@ func (c *Client) handleUserState(buffer []byte) error {
 			}
 			user.SelfDeafened = *packet.SelfDeaf
 		}
+		if user == c.Self {
+			c.updateSelfState()
+			if event.Type.Has(UserChangeChannel) {
+				c.joinMoved()
+				c.selfPreviousChannel = previousChannel
+			}
+		}
 		if packet.Texture != nil {
 			event.Type |= UserChangeTexture
-			user.Texture = packet.Texture
-			user.TextureHash = nil
+			if c.admitBlob(len(packet.Texture), len(user.Texture)) {
+				c.setTexture(user, packet.Texture)
+				user.TextureHash = nil
+				c.cacheBlob(user.Texture)
+			} else {
+				c.setTexture(user, nil)
+				user.TextureHash = blobHash(packet.Texture)
+				limits = append(limits, c.blobLimitEvent(user, nil, len(packet.Texture)))
+			}
 		}
 		if packet.Comment != nil {
 			if *packet.Comment != user.Comment {
 				event.Type |= UserChangeComment
 			}
-			user.Comment = *packet.Comment
-			user.CommentHash = nil
+			if comment := *packet.Comment; c.admitBlob(len(comment), len(user.Comment)) {
+				c.setComment(user, comment)
+				user.CommentHash = nil
+				c.cacheBlob([]byte(user.Comment))
+			} else {
+				c.setComment(user, "")
+				user.CommentHash = blobHash([]byte(comment))
+				limits = append(limits, c.blobLimitEvent(user, nil, len(comment)))
+			}
 		}
 		if packet.Hash != nil {
 			user.Hash = *packet.Hash
 		}
 		if packet.CommentHash != nil {
-			event.Type |= UserChangeComment
-			user.CommentHash = packet.CommentHash
-			user.Comment = ""
+			comment, ok := user.commentForHash(packet.CommentHash)
+			if ok && !c.admitBlob(len(comment), len(user.Comment)) {
+				ok = false
+				limits = append(limits, c.blobLimitEvent(user, nil, len(comment)))
+			}
+			if ok {
+				if comment != user.Comment || user.CommentHash != nil {
+					event.Type |= UserChangeComment
+				}
+				c.setComment(user, comment)
+				user.CommentHash = nil
+			} else {
+				event.Type |= UserChangeComment
+				user.CommentHash = packet.CommentHash
+				c.setComment(user, "")
+			}
 		}
 		if packet.TextureHash != nil {
 			event.Type |= UserChangeTexture
-			user.TextureHash = packet.TextureHash
-			user.Texture = nil
+			blob, ok := c.cachedBlob(packet.TextureHash)
+			if ok && !c.admitBlob(len(blob), len(user.Texture)) {
+				ok = false
+				limits = append(limits, c.blobLimitEvent(user, nil, len(blob)))
+			}
+			if ok {
+				c.setTexture(user, blob)
+				user.TextureHash = nil
+			} else {
+				user.TextureHash = packet.TextureHash
+				c.setTexture(user, nil)
+			}
 		}
 		if packet.PrioritySpeaker != nil {
 			if *packet.PrioritySpeaker != user.PrioritySpeaker {
@@ -626,13 +888,60 @@ func (c *Client) handleUserState(buffer []byte) error {
 			}
 			user.Recording = *packet.Recording
 		}
+		for _, channelID := range packet.ListeningChannelAdd {
+			channel := c.Channels[channelID]
+			if channel == nil {
+				continue
+			}
+			if user.ListeningChannels[channelID] == nil {
+				event.Type |= UserChangeListening
+				event.ListeningAdded = append(event.ListeningAdded, channel)
+			}
+			user.ListeningChannels[channelID] = channel
+			channel.Listeners[user.Session] = user
+		}
+		for _, channelID := range packet.ListeningChannelRemove {
+			channel := user.ListeningChannels[channelID]
+			if channel == nil {
+				continue
+			}
+			event.Type |= UserChangeListening
+			event.ListeningRemoved = append(event.ListeningRemoved, channel)
+			delete(user.ListeningChannels, channelID)
+			delete(channel.Listeners, user.Session)
+			delete(user.ListeningVolumes, channelID)
+		}
+		for _, adjustment := range packet.ListeningVolumeAdjustment {
+			if adjustment.ListeningChannel == nil || adjustment.VolumeAdjustment == nil {
+				continue
+			}
+			if user.ListeningVolumes == nil {
+				user.ListeningVolumes = make(map[uint32]float32)
+			}
+			user.ListeningVolumes[*adjustment.ListeningChannel] = *adjustment.VolumeAdjustment
+		}
 
 		c.volatile.Unlock()
 	}
 
-	if c.State() == StateSynced {
+	if event.Type.Has(UserChangeRegistered) {
+		c.registrations.finish(user, nil)
+	}
+	if c.isSynced() {
 		c.Config.Listeners.onUserChange(&event)
+	} else {
+		event.Initial = true
+		c.syncEvent(func() { c.Config.Listeners.onUserChange(&event) })
+		c.syncProgress(false)
+	}
+	if event.Type.Has(UserChangeConnected) && c.isSynced() {
+		c.userJoined(user)
 	}
+	c.occupancyChanged(previousChannel, user.Channel)
+	if previousChannel != user.Channel {
+		c.followMoved(user, false)
+	}
+	c.resourceLimited(limits)
 	return nil
 }
 
@@ -641,6 +950,7 @@ func (c *Client) handleBanList(buffer []byte) error {
 	if err := proto.Unmarshal(buffer, &packet); err != nil {
 		return err
 	}
+	c.requestAnswered(RequestTypeBanList, 0)
 
 	event := BanListEvent{
 		Client:  c,
@@ -691,6 +1001,9 @@ func (c *Client) handleTextMessage(buffer []byte) error {
 	}
 	if packet.Actor != nil {
 		event.Sender = c.Users[*packet.Actor]
+		if event.Sender != nil {
+			c.userActive(event.Sender)
+		}
 	}
 	if packet.Session != nil {
 		event.Users = make([]*User, 0, len(packet.Session))
@@ -719,6 +1032,9 @@ func (c *Client) handleTextMessage(buffer []byte) error {
 	if packet.Message != nil {
 		event.Message = *packet.Message
 	}
+	if err := runTextMiddleware(c.Config.IncomingTextMiddleware, &event.TextMessage); err != nil {
+		return nil
+	}
 
 	c.Config.Listeners.onTextMessage(&event)
 	return nil
@@ -759,6 +1075,16 @@ func (c *Client) handlePermissionDenied(buffer []byte) error {
 	if packet.Permission != nil {
 		event.Permission = Permission(*packet.Permission)
 	}
+	c.volatile.Lock()
+	event.Err = c.joinDenied(&event)
+	c.volatile.Unlock()
+	c.followDenied(&event)
+	if event.Type == PermissionDeniedMissingCertificate || (event.Type == PermissionDeniedPermission && (event.Permission.Has(PermissionRegister) || event.Permission.Has(PermissionRegisterSelf))) {
+		c.registrations.finish(event.User, &RegisterError{
+			Type:   event.Type,
+			Reason: event.String,
+		})
+	}
 
 	c.Config.Listeners.onPermissionDenied(&event)
 	return nil
@@ -776,6 +1102,7 @@ func (c *Client) handleACL(buffer []byte) error {
 	if packet.ChannelId == nil {
 		return errInvalidProtobuf
 	}
+	c.requestAnswered(RequestTypeACL, *packet.ChannelId)
 	acl.Channel = c.Channels[*packet.ChannelId]
 	if acl.Channel == nil {
 		return errInvalidProtobuf
@@ -961,6 +1288,7 @@ func (c *Client) handleUserList(buffer []byte) error {
 	if err := proto.Unmarshal(buffer, &packet); err != nil {
 		return err
 	}
+	c.requestAnswered(RequestTypeUserList, 0)
 
 	event := UserListEvent{
 		Client:   c,
@@ -999,6 +1327,9 @@ func (c *Client) handlePermissionQuery(buffer []byte) error {
 		return err
 	}
 
+	if packet.ChannelId != nil {
+		c.requestAnswered(RequestTypePermission, *packet.ChannelId)
+	}
 	var singleChannel *Channel
 	if packet.ChannelId != nil && packet.Permissions != nil {
 		singleChannel = c.Channels[*packet.ChannelId]
@@ -1091,6 +1422,7 @@ func (c *Client) handleUserStats(buffer []byte) error {
 	if packet.Session == nil {
 		return errIncompleteProtobuf
 	}
+	c.requestAnswered(RequestTypeUserStats, *packet.Session)
 	user := c.Users[*packet.Session]
 	if user == nil {
 		return errInvalidProtobuf
@@ -1125,13 +1457,13 @@ func (c *Client) handleUserStats(buffer []byte) error {
 			if packet.FromServer.Good != nil {
 				stats.FromServer.Good = *packet.FromServer.Good
 			}
-			if packet.FromClient.Late != nil {
+			if packet.FromServer.Late != nil {
 				stats.FromServer.Late = *packet.FromServer.Late
 			}
-			if packet.FromClient.Lost != nil {
+			if packet.FromServer.Lost != nil {
 				stats.FromServer.Lost = *packet.FromServer.Lost
 			}
-			if packet.FromClient.Resync != nil {
+			if packet.FromServer.Resync != nil {
 				stats.FromServer.Resync = *packet.FromServer.Resync
 			}
 		}
@@ -1214,9 +1546,13 @@ func (c *Client) handleServerConfig(buffer []byte) error {
 	if packet.MaxBandwidth != nil {
 		val := int(*packet.MaxBandwidth)
 		event.MaximumBitrate = &val
+		atomic.StoreInt32(&c.maximumBitrate, int32(*packet.MaxBandwidth))
 	}
 	if packet.WelcomeText != nil {
 		event.WelcomeMessage = packet.WelcomeText
+		c.volatile.Lock()
+		c.welcomeMessage = *packet.WelcomeText
+		c.volatile.Unlock()
 	}
 	if packet.AllowHtml != nil {
 		event.AllowHTML = packet.AllowHtml
@@ -1232,6 +1568,9 @@ func (c *Client) handleServerConfig(buffer []byte) error {
 	if packet.MaxUsers != nil {
 		val := int(*packet.MaxUsers)
 		event.MaximumUsers = &val
+		c.volatile.Lock()
+		c.maximumUsers = val
+		c.volatile.Unlock()
 	}
 	c.Config.Listeners.onServerConfig(&event)
 	return nil
@@ -1256,6 +1595,19 @@ func (c *Client) handleSuggestConfig(buffer []byte) error {
 	if packet.PushToTalk != nil {
 		event.SuggestPushToTalk = packet.PushToTalk
 	}
+
+	{
+		c.volatile.Lock()
+
+		c.suggestions = ServerSuggestions{
+			Version:    event.SuggestVersion,
+			Positional: event.SuggestPositional,
+			PushToTalk: event.SuggestPushToTalk,
+		}
+
+		c.volatile.Unlock()
+	}
+
 	c.Config.Listeners.onServerConfig(&event)
 	return nil
 }
@@ -0,0 +1,154 @@
+package gumble
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble/voicepacket"
+)
+
+// testCodec is an AudioCodec whose decoder returns AudioDefaultFrameSize
+// samples per packet, and, like the Opus decoder with DTX silence, as many
+// samples as the last frame for an empty packet.
+type testCodec struct{}
+
+func (testCodec) ID() int                  { return audioCodecIDOpus }
+func (testCodec) NewEncoder() AudioEncoder { return nil }
+func (testCodec) NewDecoder() AudioDecoder { return &testDecoder{} }
+
+type testDecoder struct {
+	lastSamples int
+	data        [][]byte
+}
+
+func (*testDecoder) ID() int { return audioCodecIDOpus }
+func (*testDecoder) Reset()  {}
+
+func (d *testDecoder) Decode(data []byte, frameSize int) ([]int16, error) {
+	d.data = append(d.data, append([]byte(nil), data...))
+	if len(data) == 0 {
+		return make([]int16, d.lastSamples), nil
+	}
+	d.lastSamples = AudioDefaultFrameSize * AudioChannels
+	return make([]int16, d.lastSamples), nil
+}
+
+type testAudioListener chan *AudioPacket
+
+func (l testAudioListener) OnAudioStream(e *AudioStreamEvent) {
+	go func() {
+		for packet := range e.C {
+			l <- packet
+		}
+	}()
+}
+
+// newTestAudioClient returns a client that has a user with session 1, and
+// the channel to which the audio received from the user is passed.
+func newTestAudioClient() (*Client, testAudioListener) {
+	c := &Client{
+		Config:     NewConfig(),
+		Users:      Users{},
+		Channels:   Channels{},
+		audioCodec: testCodec{},
+	}
+	root := c.Channels.create(0)
+	user := c.Users.create(1)
+	user.client = c
+	user.Channel = root
+	root.Users[user.Session] = user
+	listener := make(testAudioListener, 16)
+	c.Config.AttachAudio(listener)
+	return c, listener
+}
+
+func encodeTestVoicePacket(t *testing.T, sequence int64, data []byte) []byte {
+	b, err := voicepacket.Encode(&voicepacket.Packet{
+		Type:     voicepacket.TypeOpus,
+		Session:  1,
+		Sequence: sequence,
+		Data:     data,
+	}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// handleTestVoicePacket passes b to c.handleUDPTunnel, failing if it does not
+// return.
+func handleTestVoicePacket(t *testing.T, c *Client, b []byte) {
+	done := make(chan error, 1)
+	go func() {
+		done <- c.handleUDPTunnel(b)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handleUDPTunnel did not return")
+	}
+}
+
+func receiveTestAudio(t *testing.T, l testAudioListener) *AudioPacket {
+	select {
+	case packet := <-l:
+		return packet
+	case <-time.After(time.Second):
+		t.Fatal("no audio packet received")
+	}
+	return nil
+}
+
+func TestEmptyVoicePacket(t *testing.T) {
+	c, l := newTestAudioClient()
+	handleTestVoicePacket(t, c, encodeTestVoicePacket(t, 0, nil))
+	if packet := receiveTestAudio(t, l); len(packet.AudioBuffer) != 0 {
+		t.Errorf("got %d samples, expected 0\n", len(packet.AudioBuffer))
+	}
+}
+
+func TestDTXFrameOnFreshDecoder(t *testing.T) {
+	c, l := newTestAudioClient()
+	// A DTX frame before any audio decodes to no samples.
+	handleTestVoicePacket(t, c, encodeTestVoicePacket(t, 0, nil))
+	receiveTestAudio(t, l)
+	// 10ms CELT frame.
+	handleTestVoicePacket(t, c, encodeTestVoicePacket(t, 1, []byte{0xF0, 1, 2}))
+	packet := receiveTestAudio(t, l)
+	if expected := AudioDefaultFrameSize * AudioChannels; len(packet.AudioBuffer) != expected {
+		t.Errorf("got %d samples, expected %d\n", len(packet.AudioBuffer), expected)
+	}
+}
+
+func TestReorderHeldPacketData(t *testing.T) {
+	c, l := newTestAudioClient()
+	c.Config.AudioReorderTolerance = 2
+	// The packets are decoded from the same buffer, as Conn.ReadPacket
+	// reuses its buffer.
+	buffer := make([]byte, 0, 64)
+	read := func(sequence int64, data []byte) {
+		buffer = append(buffer[:0], encodeTestVoicePacket(t, sequence, data)...)
+		handleTestVoicePacket(t, c, buffer)
+	}
+	read(0, []byte{0xF0, 0})
+	read(1, []byte{0xF0, 1})
+	// 3 is held back until 2 arrives.
+	read(3, []byte{0xF0, 3})
+	read(2, []byte{0xF0, 2})
+	for i := 0; i < 4; i++ {
+		receiveTestAudio(t, l)
+	}
+
+	decoder := c.Users[1].decoder.(*testDecoder)
+	if len(decoder.data) != 4 {
+		t.Fatalf("decoded %d packets, expected 4\n", len(decoder.data))
+	}
+	for i, data := range decoder.data {
+		if data[1] != byte(i) {
+			t.Errorf("packet %d decoded with data %v\n", i, data)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package gumble
+
+import (
+	"strconv"
+	"time"
+)
+
+// maxDepartedUsers is the number of users that have left the server that a
+// client remembers, to recognize them when they return.
+const maxDepartedUsers = 1024
+
+// StableID returns an identifier of the user that, unlike Session, stays the
+// same when the user reconnects: "registered:" followed by the UserID for
+// registered users, "certificate:" followed by Hash for other users with a
+// certificate, and the empty string for users that cannot be recognized.
+func (u *User) StableID() string {
+	if u.IsRegistered() {
+		return "registered:" + strconv.FormatUint(uint64(u.UserID), 10)
+	}
+	if u.Hash != "" {
+		return "certificate:" + u.Hash
+	}
+	return ""
+}
+
+// departedUser is a user that has left the server.
+type departedUser struct {
+	session uint32
+	name    string
+	left    time.Time
+	tags    map[string]interface{}
+}
+
+// userLeft remembers user, who has left the server, so that they are
+// recognized if they return. Only called from the read goroutine.
+func (c *Client) userLeft(user *User) {
+	id := user.StableID()
+	if id == "" || user == c.Self {
+		return
+	}
+	if c.departedUsers == nil {
+		c.departedUsers = make(map[string]*departedUser)
+	}
+	if _, ok := c.departedUsers[id]; !ok && len(c.departedUsers) >= maxDepartedUsers {
+		var oldest string
+		for id, departed := range c.departedUsers {
+			if oldest == "" || departed.left.Before(c.departedUsers[oldest].left) {
+				oldest = id
+			}
+		}
+		delete(c.departedUsers, oldest)
+	}
+	c.departedUsers[id] = &departedUser{
+		session: user.Session,
+		name:    user.Name,
+		left:    time.Now(),
+		tags:    user.tags.copy(),
+	}
+}
+
+// userJoined triggers a UserReturnedEvent if user, who has just connected,
+// left the server earlier. The user's previous tags are restored. Only called
+// from the read goroutine.
+func (c *Client) userJoined(user *User) {
+	id := user.StableID()
+	departed := c.departedUsers[id]
+	if id == "" || departed == nil {
+		return
+	}
+	delete(c.departedUsers, id)
+	for key, value := range departed.tags {
+		if user.tags.get(key) == nil {
+			user.tags.set(key, value)
+		}
+	}
+
+	event := UserReturnedEvent{
+		Client:          c,
+		User:            user,
+		PreviousSession: departed.session,
+		PreviousName:    departed.name,
+		Left:            departed.left,
+	}
+	c.Config.Listeners.onUserReturned(&event)
+}
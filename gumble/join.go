@@ -0,0 +1,204 @@
+package gumble
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+)
+
+// JoinChannelError is the error set on a PermissionDeniedEvent when the server
+// denies the move to Config.JoinChannel, or to a channel joined with
+// Channel.Join.
+type JoinChannelError struct {
+	// The value of Config.JoinChannel, or the empty string if the channel
+	// was joined with Channel.Join.
+	JoinChannel string
+	// The channel the client tried to move to.
+	Channel *Channel
+	Type    PermissionDeniedType
+	Reason  string
+}
+
+// Error implements error.
+func (e *JoinChannelError) Error() string {
+	name := e.JoinChannel
+	if name == "" && e.Channel != nil {
+		name = e.Channel.Name
+	}
+	msg := "gumble: could not join channel " + strconv.Quote(name)
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// autoJoin tracks the client's progress in moving itself to
+// Config.JoinChannel, or to a channel joined with Channel.Join.
+type autoJoin struct {
+	mu sync.Mutex
+	// Is the client still waiting to join Config.JoinChannel?
+	pending bool
+	// Was the last channel requested with Channel.Join?
+	manual bool
+	// The channel the client has asked to be moved to, if the server has not
+	// replied yet.
+	requested *Channel
+	// The channel the client has joined.
+	joined *Channel
+}
+
+// resetJoin starts joining Config.JoinChannel, if it is set.
+func (c *Client) resetJoin() {
+	c.join.mu.Lock()
+	c.join.pending = c.Config.JoinChannel != ""
+	c.join.manual = false
+	c.join.requested = nil
+	c.join.joined = nil
+	c.join.mu.Unlock()
+}
+
+// joinChannel returns the channel that Config.JoinChannel refers to, or nil if
+// it does not exist.
+func (c *Client) joinChannel() *Channel {
+	return c.findChannel(c.Config.JoinChannel)
+}
+
+// findChannel returns the channel that name refers to, either by ID (e.g.
+// "12") or by path from the root channel (e.g. "Games/Chess"), or nil if it
+// does not exist.
+func (c *Client) findChannel(name string) *Channel {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return c.Channels[uint32(id)]
+	}
+	name = strings.Trim(name, "/")
+	if name == "" {
+		return c.Channels[0]
+	}
+	return c.Channels.Find(strings.Split(name, "/")...)
+}
+
+// tryJoin moves the client to Config.JoinChannel if it is still waiting to do
+// so, and the channel exists.
+func (c *Client) tryJoin() {
+	c.join.mu.Lock()
+	defer c.join.mu.Unlock()
+
+	if !c.join.pending || c.join.requested != nil || c.Self == nil {
+		return
+	}
+	channel := c.joinChannel()
+	if channel == nil {
+		return
+	}
+	if c.Self.Channel == channel {
+		c.join.pending = false
+		c.join.joined = channel
+		return
+	}
+	c.join.requested = channel
+	c.Self.Move(channel)
+}
+
+// joinMoved is called when the server has moved the client to another
+// channel.
+func (c *Client) joinMoved() {
+	c.join.mu.Lock()
+	defer c.join.mu.Unlock()
+
+	if c.join.requested == nil || c.Self.Channel != c.join.requested {
+		return
+	}
+	if !c.join.manual {
+		c.join.pending = false
+	}
+	c.join.joined = c.join.requested
+	c.join.requested = nil
+}
+
+// joinChannelRemoved is called when channel has been removed. If it was the
+// channel the client joined, the client joins it again once it is recreated.
+func (c *Client) joinChannelRemoved(channel *Channel) {
+	c.join.mu.Lock()
+	defer c.join.mu.Unlock()
+
+	if channel == c.join.requested {
+		c.join.requested = nil
+	}
+	if channel == c.join.joined {
+		c.join.joined = nil
+		if _, err := strconv.ParseUint(c.Config.JoinChannel, 10, 32); err != nil && !c.join.manual {
+			c.join.pending = true
+		}
+	}
+}
+
+// joinDenied returns a *JoinChannelError, and stops trying to join the
+// channel, if the denial is for the client's request to join it.
+func (c *Client) joinDenied(event *PermissionDeniedEvent) error {
+	c.join.mu.Lock()
+	defer c.join.mu.Unlock()
+
+	channel := c.join.requested
+	if channel == nil || (event.Channel != nil && event.Channel != channel) || (event.User != nil && event.User != c.Self) {
+		return nil
+	}
+	err := &JoinChannelError{
+		Channel: channel,
+		Type:    event.Type,
+		Reason:  event.String,
+	}
+	if !c.join.manual {
+		err.JoinChannel = c.Config.JoinChannel
+		c.join.pending = false
+	}
+	c.join.requested = nil
+	return err
+}
+
+// Join moves the client to the channel. The given passwords are first added to
+// the client's access tokens (Config.Tokens), and sent to the server if they
+// were not already part of them.
+//
+// If the server denies the move (e.g. because the password is wrong), the
+// PermissionDeniedEvent has its Err set to a *JoinChannelError. Joining a
+// channel cancels the automatic join of Config.JoinChannel.
+func (c *Channel) Join(password ...string) error {
+	client := c.client
+	if client == nil || client.Self == nil {
+		return errors.New("gumble: client is not connected")
+	}
+	tokens := client.Config.Tokens
+	for _, p := range password {
+		found := false
+		for _, token := range tokens {
+			if token == p {
+				found = true
+				break
+			}
+		}
+		if !found {
+			tokens = append(tokens, p)
+		}
+	}
+	if len(tokens) != len(client.Config.Tokens) {
+		client.Config.Tokens = tokens
+		if err := tokens.writeMessage(client); err != nil {
+			return err
+		}
+	}
+
+	client.join.mu.Lock()
+	client.join.pending = false
+	client.join.manual = true
+	client.join.requested = c
+	client.join.mu.Unlock()
+
+	packet := MumbleProto.UserState{
+		Session:   &client.Self.Session,
+		ChannelId: &c.ID,
+	}
+	return client.Conn.WriteProto(&packet)
+}
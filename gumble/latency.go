@@ -0,0 +1,118 @@
+package gumble
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// RequestType is a kind of request whose response time the client measures
+// (see Client.RequestLatency).
+type RequestType int
+
+// Request types.
+const (
+	// Channel.RequestACL, answered by an ACLEvent.
+	RequestTypeACL RequestType = iota
+	// Channel.RequestPermission. The server does not answer if the client's
+	// permission information is up to date, in which case nothing is
+	// measured.
+	RequestTypePermission
+	// User.RequestStats, answered by a UserChangeStats event.
+	RequestTypeUserStats
+	// Client.RequestUserList, answered by a UserListEvent.
+	RequestTypeUserList
+	// Client.RequestBanList, answered by a BanListEvent.
+	RequestTypeBanList
+
+	requestTypeCount
+)
+
+// RequestLatency contains the response times of a kind of request.
+type RequestLatency struct {
+	// The number of responses that have been received.
+	Count int
+	// The response time of the most recent, the slowest, and the average
+	// response.
+	Last, Max, Average time.Duration
+	// If Config.RequestLatencyBuckets is set, Histogram[i] is the number of
+	// responses that took at most RequestLatencyBuckets[i], and the last
+	// element is the number of responses that took longer than every bucket.
+	Histogram []uint64
+}
+
+// requestTimer correlates requests with their responses, and records the
+// response times.
+type requestTimer struct {
+	mu      sync.Mutex
+	pending map[requestKey]time.Time
+	total   [requestTypeCount]time.Duration
+	stats   [requestTypeCount]RequestLatency
+}
+
+// requestKey identifies a request: e.g. the ID of the channel, or the session
+// of the user, to which it refers.
+type requestKey struct {
+	Type RequestType
+	ID   uint32
+}
+
+// requestSent records that a request has been sent. If the same request is
+// already pending, the older one is considered lost.
+func (c *Client) requestSent(t RequestType, id uint32) {
+	r := &c.requests
+	r.mu.Lock()
+	if r.pending == nil {
+		r.pending = make(map[requestKey]time.Time)
+	}
+	r.pending[requestKey{t, id}] = time.Now()
+	r.mu.Unlock()
+}
+
+// requestAnswered records the response time of a request, if it is pending.
+// Responses that the client did not request (e.g. permissions that the
+// server sends unprompted) are ignored.
+func (c *Client) requestAnswered(t RequestType, id uint32) {
+	now := time.Now()
+	r := &c.requests
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key := requestKey{t, id}
+	sent, ok := r.pending[key]
+	if !ok {
+		return
+	}
+	delete(r.pending, key)
+
+	latency := now.Sub(sent)
+	stats := &r.stats[t]
+	stats.Count++
+	stats.Last = latency
+	if latency > stats.Max {
+		stats.Max = latency
+	}
+	r.total[t] += latency
+	stats.Average = r.total[t] / time.Duration(stats.Count)
+
+	if buckets := c.Config.RequestLatencyBuckets; len(buckets) > 0 {
+		if len(stats.Histogram) != len(buckets)+1 {
+			stats.Histogram = make([]uint64, len(buckets)+1)
+		}
+		i := sort.Search(len(buckets), func(i int) bool { return latency <= buckets[i] })
+		stats.Histogram[i]++
+	}
+}
+
+// RequestLatency returns the response times of the requests of type t that
+// the client has made.
+func (c *Client) RequestLatency(t RequestType) RequestLatency {
+	if t < 0 || t >= requestTypeCount {
+		return RequestLatency{}
+	}
+	r := &c.requests
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stats := r.stats[t]
+	stats.Histogram = append([]uint64(nil), stats.Histogram...)
+	return stats
+}
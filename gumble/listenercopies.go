@@ -0,0 +1,72 @@
+package gumble
+
+import (
+	"sync"
+)
+
+// listenerCopy is a listener of a Config that has been copied to the
+// listener lists of a client dialed with the Config.
+type listenerCopy struct {
+	client *Client
+	item   Detacher
+}
+
+// listenerCopies maps the listener items of Configs to their copies in the
+// clients that are running, so that the Detacher returned when a listener is
+// attached to a Config also detaches it from those clients.
+var listenerCopies = struct {
+	sync.Mutex
+	m map[Detacher][]listenerCopy
+}{
+	m: make(map[Detacher][]listenerCopy),
+}
+
+// addListenerCopy records that item, a listener of the Config that c was
+// dialed with, has been copied to c's listeners as copied.
+func (c *Client) addListenerCopy(item, copied Detacher) {
+	listenerCopies.Lock()
+	defer listenerCopies.Unlock()
+	listenerCopies.m[item] = append(listenerCopies.m[item], listenerCopy{
+		client: c,
+		item:   copied,
+	})
+	c.copiedListeners = append(c.copiedListeners, item)
+}
+
+// releaseListenerCopies forgets the listener copies of c, once c has
+// disconnected.
+func (c *Client) releaseListenerCopies() {
+	listenerCopies.Lock()
+	defer listenerCopies.Unlock()
+	for _, item := range c.copiedListeners {
+		copies := listenerCopies.m[item][:0]
+		for _, copied := range listenerCopies.m[item] {
+			if copied.client != c {
+				copies = append(copies, copied)
+			}
+		}
+		if len(copies) == 0 {
+			delete(listenerCopies.m, item)
+		} else {
+			listenerCopies.m[item] = copies
+		}
+	}
+	c.copiedListeners = nil
+}
+
+// detachCopies detaches the copies of item from the clients that are
+// running. It is called when item is detached.
+func detachCopies(item Detacher) {
+	listenerCopies.Lock()
+	copies := listenerCopies.m[item]
+	delete(listenerCopies.m, item)
+	listenerCopies.Unlock()
+
+	for _, copied := range copies {
+		copied := copied
+		copied.client.attach(func() Detacher {
+			copied.item.Detach()
+			return nil
+		})
+	}
+}
@@ -17,6 +17,7 @@ func (e *eventItem) Detach() {
 	} else {
 		e.next.prev = e.prev
 	}
+	detachCopies(e)
 }
 
 // Listeners is a list of event listeners. Each attached listener is called in
@@ -26,6 +27,8 @@ type Listeners struct {
 }
 
 // Attach adds a new event listener to the end of the current list of listeners.
+// The listener also receives the events of the optional listener interfaces
+// that it implements (e.g. StateChangeListener).
 func (e *Listeners) Attach(listener EventListener) Detacher {
 	item := &eventItem{
 		parent:   e,
@@ -43,111 +46,245 @@ func (e *Listeners) Attach(listener EventListener) Detacher {
 }
 
 func (e *Listeners) onConnect(event *ConnectEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnConnect(event)
+	event.Client.dispatchEvent(true, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnConnect(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onDisconnect(event *DisconnectEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnDisconnect(event)
+	event.Client.dispatchEvent(true, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnDisconnect(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onTextMessage(event *TextMessageEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnTextMessage(event)
+	event.Client.dispatchEvent(false, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnTextMessage(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onUserChange(event *UserChangeEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnUserChange(event)
+	event.Client.dispatchEvent(false, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnUserChange(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onChannelChange(event *ChannelChangeEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnChannelChange(event)
+	event.Client.dispatchEvent(false, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnChannelChange(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onPermissionDenied(event *PermissionDeniedEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnPermissionDenied(event)
+	event.Client.dispatchEvent(false, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnPermissionDenied(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onUserList(event *UserListEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnUserList(event)
+	event.Client.dispatchEvent(false, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnUserList(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onACL(event *ACLEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnACL(event)
+	event.Client.dispatchEvent(false, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnACL(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onBanList(event *BanListEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnBanList(event)
+	event.Client.dispatchEvent(false, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnBanList(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onContextActionChange(event *ContextActionChangeEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
-		event.Client.volatile.Unlock()
-		item.listener.OnContextActionChange(event)
+	event.Client.dispatchEvent(false, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnContextActionChange(event)
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
 
 func (e *Listeners) onServerConfig(event *ServerConfigEvent) {
-	event.Client.volatile.Lock()
-	for item := e.head; item != nil; item = item.next {
+	event.Client.dispatchEvent(false, func() {
+		event.Client.volatile.Lock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			item.listener.OnServerConfig(event)
+			event.Client.volatile.Lock()
+		}
 		event.Client.volatile.Unlock()
-		item.listener.OnServerConfig(event)
+	})
+}
+
+func (e *Listeners) onAudioConfig(event *AudioConfigEvent) {
+	event.Client.dispatchEvent(true, func() {
 		event.Client.volatile.Lock()
-	}
-	event.Client.volatile.Unlock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			if l, ok := item.listener.(AudioConfigListener); ok {
+				l.OnAudioConfig(event)
+			}
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
+}
+
+func (e *Listeners) onSyncProgress(event *SyncProgressEvent) {
+	event.Client.dispatchEvent(false, func() {
+		event.Client.volatile.Lock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			if l, ok := item.listener.(SyncProgressListener); ok {
+				l.OnSyncProgress(event)
+			}
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
+}
+
+func (e *Listeners) onStateChange(event *StateChangeEvent) {
+	event.Client.dispatchEvent(true, func() {
+		event.Client.volatile.Lock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			if l, ok := item.listener.(StateChangeListener); ok {
+				l.OnStateChange(event)
+			}
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
+}
+
+func (e *Listeners) onUserReturned(event *UserReturnedEvent) {
+	event.Client.dispatchEvent(false, func() {
+		event.Client.volatile.Lock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			if l, ok := item.listener.(UserReturnedListener); ok {
+				l.OnUserReturned(event)
+			}
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
+}
+
+func (e *Listeners) onChannelActivityChange(event *ChannelActivityEvent) {
+	event.Client.dispatchEvent(false, func() {
+		event.Client.volatile.Lock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			if l, ok := item.listener.(ChannelActivityListener); ok {
+				l.OnChannelActivityChange(event)
+			}
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
+}
+
+func (e *Listeners) onChannelLost(event *ChannelLostEvent) {
+	event.Client.dispatchEvent(false, func() {
+		event.Client.volatile.Lock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			if l, ok := item.listener.(ChannelLostListener); ok {
+				l.OnChannelLost(event)
+			}
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
+}
+
+func (e *Listeners) onResourceLimit(event *ResourceLimitEvent) {
+	event.Client.dispatchEvent(false, func() {
+		event.Client.volatile.Lock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			if l, ok := item.listener.(ResourceLimitListener); ok {
+				l.OnResourceLimit(event)
+			}
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
+}
+
+func (e *Listeners) onChannelOccupancyChange(event *ChannelOccupancyEvent) {
+	event.Client.dispatchEvent(false, func() {
+		event.Client.volatile.Lock()
+		for item := e.head; item != nil; item = item.next {
+			event.Client.volatile.Unlock()
+			if l, ok := item.listener.(ChannelOccupancyListener); ok {
+				l.OnChannelOccupancyChange(event)
+			}
+			event.Client.volatile.Lock()
+		}
+		event.Client.volatile.Unlock()
+	})
 }
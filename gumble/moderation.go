@@ -0,0 +1,112 @@
+package gumble
+
+import (
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+)
+
+// ModerationSnapshot records the server mute and deafen states that users had
+// before a channel-wide change (see Channel.MuteAll), so that they can be put
+// back with Restore.
+type ModerationSnapshot struct {
+	client *Client
+	users  []*User
+	states map[*User]moderationState
+}
+
+type moderationState struct {
+	muted, deafened bool
+}
+
+// Users returns the users for which a change was requested.
+func (s *ModerationSnapshot) Users() []*User {
+	if s == nil {
+		return nil
+	}
+	return s.users
+}
+
+// Restore puts back the mute and deafen states that the snapshot's users had
+// before the change. Users who have disconnected, or who are already in their
+// previous state, are skipped.
+func (s *ModerationSnapshot) Restore() {
+	if s == nil {
+		return
+	}
+	s.client.Do(func() {
+		for _, user := range s.users {
+			state := s.states[user]
+			if user.client == nil || (user.Muted == state.muted && user.Deafened == state.deafened) {
+				continue
+			}
+			packet := MumbleProto.UserState{
+				Session: &user.Session,
+				Mute:    &state.muted,
+				Deaf:    &state.deafened,
+			}
+			s.client.Conn.WriteProto(&packet)
+		}
+	})
+}
+
+// MuteAll server mutes every user in the channel, except the client's own user
+// and the given users. Requests are only sent for users who are not already
+// muted. The returned snapshot can be used to restore the users' previous
+// states.
+func (c *Channel) MuteAll(except ...*User) *ModerationSnapshot {
+	muted := true
+	return c.moderateAll(except, func(u *User) bool { return !u.Muted }, &muted, nil)
+}
+
+// UnmuteAll removes the server mute (and deafen) of every user in the channel,
+// except the client's own user. Requests are only sent for users who are muted
+// or deafened. The returned snapshot can be used to restore the users'
+// previous states.
+func (c *Channel) UnmuteAll() *ModerationSnapshot {
+	unmuted := false
+	return c.moderateAll(nil, func(u *User) bool { return u.Muted || u.Deafened }, &unmuted, &unmuted)
+}
+
+// DeafenAll server deafens (and so mutes) every user in the channel, except the
+// client's own user and the given users. Requests are only sent for users who
+// are not already deafened. The returned snapshot can be used to restore the
+// users' previous states.
+func (c *Channel) DeafenAll(except ...*User) *ModerationSnapshot {
+	deafened := true
+	return c.moderateAll(except, func(u *User) bool { return !u.Deafened }, nil, &deafened)
+}
+
+func (c *Channel) moderateAll(except []*User, needed func(u *User) bool, mute, deaf *bool) *ModerationSnapshot {
+	client := c.client
+	if client == nil {
+		return nil
+	}
+	s := &ModerationSnapshot{
+		client: client,
+		states: make(map[*User]moderationState),
+	}
+	client.Do(func() {
+	users:
+		for _, user := range c.Users {
+			if user == client.Self || !needed(user) {
+				continue
+			}
+			for _, e := range except {
+				if e == user {
+					continue users
+				}
+			}
+			s.users = append(s.users, user)
+			s.states[user] = moderationState{
+				muted:    user.Muted,
+				deafened: user.Deafened,
+			}
+			packet := MumbleProto.UserState{
+				Session: &user.Session,
+				Mute:    mute,
+				Deaf:    deaf,
+			}
+			client.Conn.WriteProto(&packet)
+		}
+	})
+	return s
+}
@@ -0,0 +1,168 @@
+package gumble
+
+import (
+	"math"
+	"time"
+)
+
+// Default audio normalization settings.
+const (
+	AudioNormalizationDefaultTarget  = -23
+	AudioNormalizationDefaultMaxGain = 12
+	AudioNormalizationDefaultGate    = -50
+	AudioNormalizationDefaultCeiling = -1
+	AudioNormalizationDefaultAttack  = 200 * time.Millisecond
+	AudioNormalizationDefaultRelease = 3 * time.Second
+)
+
+// The loudness window and limiter release of the normalization stage.
+const (
+	normalizationWindow         = 400 * time.Millisecond
+	normalizationLimiterRelease = 100 * time.Millisecond
+)
+
+// AudioNormalization evens out the loudness of remote users, so that a user
+// with a loud microphone is not much louder than the others. Each user's
+// decoded audio is K-weighted, as specified by ITU-R BS.1770, to measure its
+// momentary loudness; the gain that brings the loudness to Target is then
+// approached within Attack (when the gain is lowered) or Release (when it is
+// raised). A peak limiter finally keeps the samples below Ceiling.
+//
+// The gains are kept per user, for as long as the user is connected. The
+// normalization is applied before the user's local volume (see
+// User.SetLocalVolume), so that the local volume remains relative to the
+// other users.
+//
+// The settings must not be changed once the client has been dialed.
+type AudioNormalization struct {
+	// The loudness to reach, in LUFS.
+	Target float64
+	// The largest amplification and attenuation that is applied, in dB. Zero
+	// or below does not limit the gain.
+	MaxGain float64
+	// Audio quieter than Gate, in LUFS, is considered silent, and does not
+	// change the gain, so that background noise is not amplified between
+	// words.
+	Gate float64
+	// The largest sample value, in dBFS. Zero or above disables the limiter.
+	Ceiling float64
+	// How quickly the gain is lowered and raised.
+	Attack, Release time.Duration
+}
+
+// NewAudioNormalization returns a new AudioNormalization with the default
+// settings.
+func NewAudioNormalization() *AudioNormalization {
+	return &AudioNormalization{
+		Target:  AudioNormalizationDefaultTarget,
+		MaxGain: AudioNormalizationDefaultMaxGain,
+		Gate:    AudioNormalizationDefaultGate,
+		Ceiling: AudioNormalizationDefaultCeiling,
+		Attack:  AudioNormalizationDefaultAttack,
+		Release: AudioNormalizationDefaultRelease,
+	}
+}
+
+// biquad is a second order IIR filter, in transposed direct form II.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) filter(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// normalizer is the normalization state of a single user.
+type normalizer struct {
+	// The K-weighting filter: a high shelf followed by a high-pass, with the
+	// BS.1770 coefficients for 48 kHz.
+	shelf, highPass biquad
+	// The exponentially averaged mean square of the weighted signal.
+	meanSquare float64
+	// The normalization gain in dB, and the limiter's linear gain.
+	gain    float64
+	limiter float64
+}
+
+func newNormalizer() *normalizer {
+	return &normalizer{
+		shelf: biquad{
+			b0: 1.53512485958697, b1: -2.69169618940638, b2: 1.19839281085285,
+			a1: -1.69065929318241, a2: 0.73248077421585,
+		},
+		highPass: biquad{
+			b0: 1, b1: -2, b2: 1,
+			a1: -1.99004745483398, a2: 0.99007225036621,
+		},
+		limiter: 1,
+	}
+}
+
+// coefficient returns the smoothing coefficient of an exponential average
+// with the given time constant, over the given number of samples.
+func coefficient(samples int, timeConstant time.Duration) float64 {
+	if timeConstant <= 0 {
+		return 1
+	}
+	return 1 - math.Exp(-float64(samples)/(timeConstant.Seconds()*AudioSampleRate))
+}
+
+// process normalizes buffer, in place.
+func (n *normalizer) process(settings *AudioNormalization, buffer AudioBuffer) {
+	if len(buffer) == 0 {
+		return
+	}
+
+	window := coefficient(1, normalizationWindow)
+	for _, sample := range buffer {
+		value := n.highPass.filter(n.shelf.filter(float64(sample) / math.MaxInt16))
+		n.meanSquare += (value*value - n.meanSquare) * window
+	}
+
+	start := n.gain
+	if n.meanSquare > 0 {
+		loudness := -0.691 + 10*math.Log10(n.meanSquare)
+		if loudness > settings.Gate {
+			desired := settings.Target - loudness
+			if max := settings.MaxGain; max > 0 {
+				desired = math.Max(-max, math.Min(max, desired))
+			}
+			timeConstant := settings.Release
+			if desired < n.gain {
+				timeConstant = settings.Attack
+			}
+			n.gain += (desired - n.gain) * coefficient(len(buffer), timeConstant)
+		}
+	}
+
+	ceiling := math.MaxFloat64
+	if settings.Ceiling < 0 {
+		ceiling = math.Pow(10, settings.Ceiling/20) * math.MaxInt16
+	}
+	release := coefficient(1, normalizationLimiterRelease)
+
+	// Ramp from the previous gain to the new one over the buffer, to avoid
+	// steps in the output.
+	step := (n.gain - start) / float64(len(buffer))
+	gain := start
+	for i, sample := range buffer {
+		gain += step
+		value := float64(sample) * math.Pow(10, gain/20)
+		n.limiter += (1 - n.limiter) * release
+		if peak := math.Abs(value); peak*n.limiter > ceiling {
+			n.limiter = ceiling / peak
+		}
+		value *= n.limiter
+		switch {
+		case value > math.MaxInt16:
+			value = math.MaxInt16
+		case value < math.MinInt16:
+			value = math.MinInt16
+		}
+		buffer[i] = int16(value)
+	}
+}
@@ -0,0 +1,70 @@
+package gumble
+
+import (
+	"sort"
+)
+
+// UserCount returns the number of users in the channel.
+func (c *Channel) UserCount() int {
+	if client := c.client; client != nil {
+		client.volatile.RLock()
+		defer client.volatile.RUnlock()
+	}
+	return len(c.Users)
+}
+
+// TotalUserCount returns the number of users in the channel and in the
+// channels underneath it.
+func (c *Channel) TotalUserCount() int {
+	if client := c.client; client != nil {
+		client.volatile.RLock()
+		defer client.volatile.RUnlock()
+	}
+	return c.totalUserCount()
+}
+
+func (c *Channel) totalUserCount() int {
+	count := len(c.Users)
+	for _, child := range c.Children {
+		count += child.totalUserCount()
+	}
+	return count
+}
+
+// ByOccupancy returns the channels of c sorted by their number of users, the
+// most populated first. Channels with the same number of users are sorted by
+// ID.
+func (c Channels) ByOccupancy() []*Channel {
+	channels := c.sorted()
+	sort.SliceStable(channels, func(i, j int) bool {
+		return len(channels[i].Users) > len(channels[j].Users)
+	})
+	return channels
+}
+
+// occupancyChanged triggers the ChannelOccupancyEvents of a user who moved
+// from one channel to the other. Either channel can be nil, when the user
+// connected or disconnected.
+func (c *Client) occupancyChanged(from, to *Channel) {
+	if from == to || !c.isSynced() {
+		return
+	}
+	for _, change := range [...]struct {
+		channel *Channel
+		delta   int
+	}{{from, -1}, {to, 1}} {
+		if change.channel == nil {
+			continue
+		}
+		c.volatile.RLock()
+		users := len(change.channel.Users)
+		c.volatile.RUnlock()
+		event := ChannelOccupancyEvent{
+			Client:        c,
+			Channel:       change.channel,
+			Users:         users,
+			PreviousUsers: users - change.delta,
+		}
+		c.Config.Listeners.onChannelOccupancyChange(&event)
+	}
+}
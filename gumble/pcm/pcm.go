@@ -0,0 +1,69 @@
+// Package pcm provides fast conversions between 16-bit PCM samples and their
+// little-endian byte representation, as used by audio devices and by
+// gumble.AudioBuffer.
+//
+// On little-endian hosts, the conversions are a single memory copy; on other
+// hosts, they fall back to a per-sample loop.
+package pcm // import "github.com/bmmcginty/gumble/gumble/pcm"
+
+import (
+	"encoding/binary"
+	"unsafe"
+)
+
+var littleEndian = func() bool {
+	x := uint16(1)
+	return *(*byte)(unsafe.Pointer(&x)) == 1
+}()
+
+// bytesOf returns the memory of s as a byte slice.
+func bytesOf(s []int16) []byte {
+	if len(s) == 0 {
+		return nil
+	}
+	return (*[1 << 30]byte)(unsafe.Pointer(&s[0]))[: len(s)*2 : len(s)*2]
+}
+
+// Encode writes src to dst as little-endian samples, and returns the number of
+// bytes written. Only as many samples as fit in dst are written.
+func Encode(dst []byte, src []int16) int {
+	if len(src) > len(dst)/2 {
+		src = src[:len(dst)/2]
+	}
+	if littleEndian {
+		return copy(dst, bytesOf(src))
+	}
+	for i, sample := range src {
+		binary.LittleEndian.PutUint16(dst[i*2:], uint16(sample))
+	}
+	return len(src) * 2
+}
+
+// Decode reads little-endian samples from src into dst, and returns the number
+// of samples read. A trailing odd byte in src is ignored.
+func Decode(dst []int16, src []byte) int {
+	if len(dst) > len(src)/2 {
+		dst = dst[:len(src)/2]
+	}
+	if littleEndian {
+		return copy(bytesOf(dst), src) / 2
+	}
+	for i := range dst {
+		dst[i] = int16(binary.LittleEndian.Uint16(src[i*2:]))
+	}
+	return len(dst)
+}
+
+// EncodeAll returns src as little-endian samples in a new byte slice.
+func EncodeAll(src []int16) []byte {
+	dst := make([]byte, len(src)*2)
+	Encode(dst, src)
+	return dst
+}
+
+// DecodeAll returns the little-endian samples of src in a new slice.
+func DecodeAll(src []byte) []int16 {
+	dst := make([]int16, len(src)/2)
+	Decode(dst, src)
+	return dst
+}
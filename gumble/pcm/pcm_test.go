@@ -0,0 +1,81 @@
+package pcm // import "github.com/bmmcginty/gumble/gumble/pcm"
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	samples := []int16{0, 1, -1, 0x1234, -0x1234, 32767, -32768}
+
+	b := EncodeAll(samples)
+	for i, sample := range samples {
+		if got := int16(binary.LittleEndian.Uint16(b[i*2:])); got != sample {
+			t.Errorf("sample %d encoded as %d, expected %d\n", i, got, sample)
+		}
+	}
+
+	decoded := DecodeAll(b)
+	if len(decoded) != len(samples) {
+		t.Fatalf("decoded %d samples, expected %d\n", len(decoded), len(samples))
+	}
+	for i := range samples {
+		if decoded[i] != samples[i] {
+			t.Errorf("sample %d decoded as %d, expected %d\n", i, decoded[i], samples[i])
+		}
+	}
+}
+
+func TestShortBuffers(t *testing.T) {
+	if n := Encode(make([]byte, 5), []int16{1, 2, 3}); n != 4 {
+		t.Errorf("Encode wrote %d bytes, expected 4\n", n)
+	}
+	if n := Decode(make([]int16, 3), []byte{1, 0, 2}); n != 1 {
+		t.Errorf("Decode read %d samples, expected 1\n", n)
+	}
+	if n := Encode(nil, nil); n != 0 {
+		t.Errorf("Encode wrote %d bytes, expected 0\n", n)
+	}
+}
+
+const benchmarkSamples = 4800
+
+func BenchmarkEncode(b *testing.B) {
+	src := make([]int16, benchmarkSamples)
+	dst := make([]byte, benchmarkSamples*2)
+	b.SetBytes(int64(len(dst)))
+	for i := 0; i < b.N; i++ {
+		Encode(dst, src)
+	}
+}
+
+func BenchmarkEncodeLoop(b *testing.B) {
+	src := make([]int16, benchmarkSamples)
+	dst := make([]byte, benchmarkSamples*2)
+	b.SetBytes(int64(len(dst)))
+	for i := 0; i < b.N; i++ {
+		for j, sample := range src {
+			binary.LittleEndian.PutUint16(dst[j*2:], uint16(sample))
+		}
+	}
+}
+
+func BenchmarkDecode(b *testing.B) {
+	src := make([]byte, benchmarkSamples*2)
+	dst := make([]int16, benchmarkSamples)
+	b.SetBytes(int64(len(src)))
+	for i := 0; i < b.N; i++ {
+		Decode(dst, src)
+	}
+}
+
+func BenchmarkDecodeLoop(b *testing.B) {
+	src := make([]byte, benchmarkSamples*2)
+	dst := make([]int16, benchmarkSamples)
+	b.SetBytes(int64(len(src)))
+	for i := 0; i < b.N; i++ {
+		for j := range dst {
+			dst[j] = int16(binary.LittleEndian.Uint16(src[j*2:]))
+		}
+	}
+}
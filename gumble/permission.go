@@ -1,5 +1,11 @@
 package gumble
 
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
 // Permission is a bitmask of permissions given to a certain user.
 type Permission int
 
@@ -16,6 +22,7 @@ const (
 	PermissionWhisper
 	PermissionTextMessage
 	PermissionMakeTemporaryChannel
+	PermissionListen
 )
 
 // Permissions that can only be applied in the root channel.
@@ -24,10 +31,98 @@ const (
 	PermissionBan
 	PermissionRegister
 	PermissionRegisterSelf
+	PermissionResetUserContent
 )
 
+// PermissionNone is the empty set of permissions.
+const PermissionNone Permission = 0
+
+// PermissionAll contains every permission.
+const PermissionAll = PermissionWrite | PermissionTraverse | PermissionEnter | PermissionSpeak | PermissionMuteDeafen | PermissionMove | PermissionMakeChannel | PermissionLinkChannel | PermissionWhisper | PermissionTextMessage | PermissionMakeTemporaryChannel | PermissionListen | PermissionKick | PermissionBan | PermissionRegister | PermissionRegisterSelf | PermissionResetUserContent
+
+var permissionNames = []struct {
+	permission Permission
+	name       string
+}{
+	{PermissionWrite, "Write"},
+	{PermissionTraverse, "Traverse"},
+	{PermissionEnter, "Enter"},
+	{PermissionSpeak, "Speak"},
+	{PermissionMuteDeafen, "MuteDeafen"},
+	{PermissionMove, "Move"},
+	{PermissionMakeChannel, "MakeChannel"},
+	{PermissionLinkChannel, "LinkChannel"},
+	{PermissionWhisper, "Whisper"},
+	{PermissionTextMessage, "TextMessage"},
+	{PermissionMakeTemporaryChannel, "MakeTemporaryChannel"},
+	{PermissionListen, "Listen"},
+	{PermissionKick, "Kick"},
+	{PermissionBan, "Ban"},
+	{PermissionRegister, "Register"},
+	{PermissionRegisterSelf, "RegisterSelf"},
+	{PermissionResetUserContent, "ResetUserContent"},
+}
+
 // Has returns true if the Permission p contains Permission o has part of its
 // bitmask.
 func (p Permission) Has(o Permission) bool {
 	return p&o == o
 }
+
+// HasAny returns true if the Permission p contains at least one of the
+// permissions of o.
+func (p Permission) HasAny(o Permission) bool {
+	return p&o != 0
+}
+
+// String returns the names of the permissions in p, separated by "|" (e.g.
+// "Enter|Speak"). Unknown bits are written in hexadecimal.
+func (p Permission) String() string {
+	if p == PermissionNone {
+		return "None"
+	}
+	var names []string
+	rest := p
+	for _, n := range permissionNames {
+		if p.Has(n.permission) {
+			names = append(names, n.name)
+			rest &^= n.permission
+		}
+	}
+	if rest != 0 {
+		names = append(names, "0x"+strconv.FormatInt(int64(rest), 16))
+	}
+	return strings.Join(names, "|")
+}
+
+// ParsePermission parses the names of permissions separated by "|", as
+// written by Permission.String.
+func ParsePermission(s string) (Permission, error) {
+	var p Permission
+	for _, name := range strings.Split(s, "|") {
+		name = strings.TrimSpace(name)
+		if name == "" || name == "None" {
+			continue
+		}
+		if strings.HasPrefix(name, "0x") {
+			value, err := strconv.ParseInt(name[2:], 16, 32)
+			if err != nil {
+				return 0, errors.New("gumble: invalid permission " + strconv.Quote(name))
+			}
+			p |= Permission(value)
+			continue
+		}
+		found := false
+		for _, n := range permissionNames {
+			if strings.EqualFold(n.name, name) {
+				p |= n.permission
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, errors.New("gumble: unknown permission " + strconv.Quote(name))
+		}
+	}
+	return p, nil
+}
@@ -0,0 +1,323 @@
+package gumble
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// Pool manages a set of Clients that run in the same process.
+//
+// Clients created by a pool share a dial rate limiter and a TLS session cache.
+// Events from every client in the pool are delivered to the listeners attached
+// to the pool, in addition to the listeners of each client's own Config.
+type Pool struct {
+	// The dialer used to connect clients. If nil, a zero net.Dialer is used.
+	Dialer *net.Dialer
+	// The TLS configuration used to connect clients. If its
+	// ClientSessionCache is nil, a session cache that is shared between the
+	// pool's clients is created.
+	TLSConfig *tls.Config
+	// The minimum amount of time between two connection attempts.
+	DialInterval time.Duration
+
+	lock    sync.Mutex
+	clients map[*Client]struct{}
+	// Counts the clients whose DisconnectEvent has not been delivered yet.
+	active       sync.WaitGroup
+	sessionCache tls.ClientSessionCache
+	nextDial     time.Time
+	closed       bool
+
+	// Protects the list of listeners.
+	listenersLock sync.Mutex
+	listeners     Listeners
+}
+
+// NewPool returns a new, empty Pool.
+func NewPool() *Pool {
+	return &Pool{
+		clients: make(map[*Client]struct{}),
+	}
+}
+
+// Attach adds a new event listener that is called for the events of every
+// client in the pool. The listener is called from the goroutines of the
+// pool's clients, so it can be called concurrently for different clients.
+func (p *Pool) Attach(l EventListener) Detacher {
+	p.listenersLock.Lock()
+	defer p.listenersLock.Unlock()
+	return &poolDetacher{
+		pool:     p,
+		Detacher: p.listeners.Attach(l),
+	}
+}
+
+type poolDetacher struct {
+	pool *Pool
+	Detacher
+}
+
+func (d *poolDetacher) Detach() {
+	d.pool.listenersLock.Lock()
+	defer d.pool.listenersLock.Unlock()
+	d.Detacher.Detach()
+}
+
+// Dial connects a new client to the server at config.Address and adds it to
+// the pool. The client is removed from the pool once it disconnects.
+//
+// If needed, the function waits until Pool.DialInterval has elapsed since the
+// previous call to Dial.
+func (p *Pool) Dial(config *Config) (*Client, error) {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return nil, errors.New("gumble: pool is closed")
+	}
+	if p.sessionCache == nil {
+		if p.TLSConfig != nil && p.TLSConfig.ClientSessionCache != nil {
+			p.sessionCache = p.TLSConfig.ClientSessionCache
+		} else {
+			p.sessionCache = tls.NewLRUClientSessionCache(0)
+		}
+	}
+	now := time.Now()
+	wait := p.nextDial.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	p.nextDial = now.Add(wait + p.DialInterval)
+	p.lock.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+
+	var tlsConfig *tls.Config
+	if p.TLSConfig != nil {
+		tlsConfig = p.TLSConfig.Clone()
+	} else {
+		tlsConfig = &tls.Config{}
+	}
+	tlsConfig.ClientSessionCache = p.sessionCache
+
+	dialer := new(net.Dialer)
+	if p.Dialer != nil {
+		*dialer = *p.Dialer
+	}
+
+	client, err := dial(dialer, config, tlsConfig, &poolListener{Pool: p})
+	if err != nil {
+		return nil, err
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if p.closed {
+		client.Disconnect()
+		return nil, errors.New("gumble: pool is closed")
+	}
+	if client.State() != StateDisconnected {
+		p.clients[client] = struct{}{}
+		p.active.Add(1)
+	}
+	return client, nil
+}
+
+// Clients returns the clients that are currently in the pool.
+func (p *Pool) Clients() []*Client {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	clients := make([]*Client, 0, len(p.clients))
+	for client := range p.clients {
+		clients = append(clients, client)
+	}
+	return clients
+}
+
+// Close disconnects every client in the pool, and waits for each of them to
+// finish disconnecting, i.e. until the pool's listeners have been passed the
+// DisconnectEvent of each client. No more clients can be dialed after the
+// pool is closed.
+func (p *Pool) Close() error {
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return errors.New("gumble: pool is already closed")
+	}
+	p.closed = true
+	clients := make([]*Client, 0, len(p.clients))
+	for client := range p.clients {
+		clients = append(clients, client)
+	}
+	p.lock.Unlock()
+
+	for _, client := range clients {
+		client.Disconnect()
+	}
+	p.active.Wait()
+	return nil
+}
+
+func (p *Pool) remove(client *Client) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	if _, ok := p.clients[client]; ok {
+		delete(p.clients, client)
+		p.active.Done()
+	}
+}
+
+// attached returns the pool's listeners. They are called once the list has
+// been copied and unlocked, so that a listener can call back into its client
+// or the pool (e.g. Client.Disconnect triggers a StateChangeEvent on the
+// calling goroutine) without deadlocking every client of the pool.
+func (p *Pool) attached() []EventListener {
+	p.listenersLock.Lock()
+	defer p.listenersLock.Unlock()
+	var listeners []EventListener
+	for item := p.listeners.head; item != nil; item = item.next {
+		listeners = append(listeners, item.listener)
+	}
+	return listeners
+}
+
+// poolListener forwards the events of a single client to the pool's
+// listeners.
+type poolListener struct {
+	*Pool
+}
+
+func (l *poolListener) OnConnect(e *ConnectEvent) {
+	for _, listener := range l.attached() {
+		listener.OnConnect(e)
+	}
+}
+
+func (l *poolListener) OnDisconnect(e *DisconnectEvent) {
+	for _, listener := range l.attached() {
+		listener.OnDisconnect(e)
+	}
+	l.remove(e.Client)
+}
+
+func (l *poolListener) OnTextMessage(e *TextMessageEvent) {
+	for _, listener := range l.attached() {
+		listener.OnTextMessage(e)
+	}
+}
+
+func (l *poolListener) OnUserChange(e *UserChangeEvent) {
+	for _, listener := range l.attached() {
+		listener.OnUserChange(e)
+	}
+}
+
+func (l *poolListener) OnChannelChange(e *ChannelChangeEvent) {
+	for _, listener := range l.attached() {
+		listener.OnChannelChange(e)
+	}
+}
+
+func (l *poolListener) OnPermissionDenied(e *PermissionDeniedEvent) {
+	for _, listener := range l.attached() {
+		listener.OnPermissionDenied(e)
+	}
+}
+
+func (l *poolListener) OnUserList(e *UserListEvent) {
+	for _, listener := range l.attached() {
+		listener.OnUserList(e)
+	}
+}
+
+func (l *poolListener) OnACL(e *ACLEvent) {
+	for _, listener := range l.attached() {
+		listener.OnACL(e)
+	}
+}
+
+func (l *poolListener) OnBanList(e *BanListEvent) {
+	for _, listener := range l.attached() {
+		listener.OnBanList(e)
+	}
+}
+
+func (l *poolListener) OnContextActionChange(e *ContextActionChangeEvent) {
+	for _, listener := range l.attached() {
+		listener.OnContextActionChange(e)
+	}
+}
+
+func (l *poolListener) OnServerConfig(e *ServerConfigEvent) {
+	for _, listener := range l.attached() {
+		listener.OnServerConfig(e)
+	}
+}
+
+func (l *poolListener) OnAudioConfig(e *AudioConfigEvent) {
+	for _, listener := range l.attached() {
+		if l, ok := listener.(AudioConfigListener); ok {
+			l.OnAudioConfig(e)
+		}
+	}
+}
+
+func (l *poolListener) OnSyncProgress(e *SyncProgressEvent) {
+	for _, listener := range l.attached() {
+		if l, ok := listener.(SyncProgressListener); ok {
+			l.OnSyncProgress(e)
+		}
+	}
+}
+
+func (l *poolListener) OnStateChange(e *StateChangeEvent) {
+	for _, listener := range l.attached() {
+		if l, ok := listener.(StateChangeListener); ok {
+			l.OnStateChange(e)
+		}
+	}
+}
+
+func (l *poolListener) OnUserReturned(e *UserReturnedEvent) {
+	for _, listener := range l.attached() {
+		if l, ok := listener.(UserReturnedListener); ok {
+			l.OnUserReturned(e)
+		}
+	}
+}
+
+func (l *poolListener) OnChannelOccupancyChange(e *ChannelOccupancyEvent) {
+	for _, listener := range l.attached() {
+		if l, ok := listener.(ChannelOccupancyListener); ok {
+			l.OnChannelOccupancyChange(e)
+		}
+	}
+}
+
+func (l *poolListener) OnChannelActivityChange(e *ChannelActivityEvent) {
+	for _, listener := range l.attached() {
+		if l, ok := listener.(ChannelActivityListener); ok {
+			l.OnChannelActivityChange(e)
+		}
+	}
+}
+
+func (l *poolListener) OnChannelLost(e *ChannelLostEvent) {
+	for _, listener := range l.attached() {
+		if l, ok := listener.(ChannelLostListener); ok {
+			l.OnChannelLost(e)
+		}
+	}
+}
+
+func (l *poolListener) OnResourceLimit(e *ResourceLimitEvent) {
+	for _, listener := range l.attached() {
+		if l, ok := listener.(ResourceLimitListener); ok {
+			l.OnResourceLimit(e)
+		}
+	}
+}
@@ -0,0 +1,350 @@
+package gumble
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+	"github.com/golang/protobuf/proto"
+)
+
+// testServer is a minimal Mumble server: it syncs each client that connects
+// as a user named after the client's Username, sends it a text message that
+// holds the name, and reads until the client disconnects.
+type testServer struct {
+	Address string
+
+	lock    sync.Mutex
+	session uint32
+	accepts []time.Time
+}
+
+func newTestServer(t *testing.T) *testServer {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "gumble test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	s := &testServer{Address: listener.Addr().String()}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.lock.Lock()
+			s.session++
+			session := s.session
+			s.accepts = append(s.accepts, time.Now())
+			s.lock.Unlock()
+			go s.serve(NewConn(conn), session)
+		}
+	}()
+	return s
+}
+
+func (s *testServer) serve(conn *Conn, session uint32) {
+	defer conn.Close()
+	var name string
+	for name == "" {
+		pType, data, err := conn.ReadPacket()
+		if err != nil {
+			return
+		}
+		if pType == 2 {
+			var packet MumbleProto.Authenticate
+			if err := proto.Unmarshal(data, &packet); err != nil {
+				return
+			}
+			name = packet.GetUsername()
+		}
+	}
+	conn.WriteProto(&MumbleProto.Version{
+		Version: proto.Uint32(ClientVersion),
+	})
+	conn.WriteProto(&MumbleProto.ChannelState{
+		ChannelId: proto.Uint32(0),
+		Name:      proto.String("Root"),
+	})
+	conn.WriteProto(&MumbleProto.UserState{
+		Session:   &session,
+		Name:      &name,
+		ChannelId: proto.Uint32(0),
+	})
+	conn.WriteProto(&MumbleProto.ServerSync{
+		Session: &session,
+	})
+	conn.WriteProto(&MumbleProto.TextMessage{
+		Actor:   &session,
+		Session: []uint32{session},
+		Message: &name,
+	})
+	for {
+		if _, _, err := conn.ReadPacket(); err != nil {
+			return
+		}
+	}
+}
+
+// acceptTimes returns the times at which the server accepted connections.
+func (s *testServer) acceptTimes() []time.Time {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]time.Time(nil), s.accepts...)
+}
+
+func newTestPool() *Pool {
+	p := NewPool()
+	p.TLSConfig = &tls.Config{InsecureSkipVerify: true}
+	return p
+}
+
+func newTestConfig(s *testServer, username string) *Config {
+	config := NewConfig()
+	config.Address = s.Address
+	config.Username = username
+	return config
+}
+
+// testListener is an EventListener that ignores every event.
+type testListener struct{}
+
+func (testListener) OnConnect(e *ConnectEvent)                         {}
+func (testListener) OnDisconnect(e *DisconnectEvent)                   {}
+func (testListener) OnTextMessage(e *TextMessageEvent)                 {}
+func (testListener) OnUserChange(e *UserChangeEvent)                   {}
+func (testListener) OnChannelChange(e *ChannelChangeEvent)             {}
+func (testListener) OnPermissionDenied(e *PermissionDeniedEvent)       {}
+func (testListener) OnUserList(e *UserListEvent)                       {}
+func (testListener) OnACL(e *ACLEvent)                                 {}
+func (testListener) OnBanList(e *BanListEvent)                         {}
+func (testListener) OnContextActionChange(e *ContextActionChangeEvent) {}
+func (testListener) OnServerConfig(e *ServerConfigEvent)               {}
+
+// testPoolListener records the events of a pool.
+type testPoolListener struct {
+	testListener
+
+	lock        sync.Mutex
+	messages    map[*Client]string
+	disconnects map[*Client]bool
+	received    chan struct{}
+}
+
+func newTestPoolListener() *testPoolListener {
+	return &testPoolListener{
+		messages:    make(map[*Client]string),
+		disconnects: make(map[*Client]bool),
+		received:    make(chan struct{}, 16),
+	}
+}
+
+func (l *testPoolListener) OnTextMessage(e *TextMessageEvent) {
+	l.lock.Lock()
+	l.messages[e.Client] = e.Message
+	l.lock.Unlock()
+	l.received <- struct{}{}
+}
+
+func (l *testPoolListener) OnDisconnect(e *DisconnectEvent) {
+	l.lock.Lock()
+	l.disconnects[e.Client] = true
+	l.lock.Unlock()
+}
+
+func (l *testPoolListener) wait(t *testing.T, n int) {
+	for i := 0; i < n; i++ {
+		select {
+		case <-l.received:
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a text message")
+		}
+	}
+}
+
+func TestPoolEventAttribution(t *testing.T) {
+	s := newTestServer(t)
+	p := newTestPool()
+	defer p.Close()
+	l := newTestPoolListener()
+	p.Attach(l)
+
+	clients := make(map[string]*Client)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		client, err := p.Dial(newTestConfig(s, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients[name] = client
+	}
+	l.wait(t, len(clients))
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	for name, client := range clients {
+		if message := l.messages[client]; message != name {
+			t.Errorf("client %s received %q\n", name, message)
+		}
+	}
+	if n := len(p.Clients()); n != len(clients) {
+		t.Errorf("pool has %d clients, expected %d\n", n, len(clients))
+	}
+}
+
+func TestPoolDialInterval(t *testing.T) {
+	s := newTestServer(t)
+	p := newTestPool()
+	p.DialInterval = 100 * time.Millisecond
+	defer p.Close()
+
+	const n = 3
+	var wg sync.WaitGroup
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := p.Dial(newTestConfig(s, string(rune('a'+i))))
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	accepts := s.acceptTimes()
+	if len(accepts) != n {
+		t.Fatalf("server accepted %d connections, expected %d\n", len(accepts), n)
+	}
+	sort.Slice(accepts, func(i, j int) bool { return accepts[i].Before(accepts[j]) })
+	for i := 1; i < n; i++ {
+		// Allow for the accept times not being those of the dials.
+		if d := accepts[i].Sub(accepts[i-1]); d < p.DialInterval*8/10 {
+			t.Errorf("connections %d and %d were %v apart\n", i-1, i, d)
+		}
+	}
+}
+
+func TestPoolClose(t *testing.T) {
+	s := newTestServer(t)
+	p := newTestPool()
+	l := newTestPoolListener()
+	p.Attach(l)
+
+	var clients []*Client
+	for _, name := range []string{"alice", "bob"} {
+		client, err := p.Dial(newTestConfig(s, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		clients = append(clients, client)
+	}
+	l.wait(t, len(clients))
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, client := range clients {
+		if state := client.State(); state != StateDisconnected {
+			t.Errorf("client %s is %v after Close\n", client.Self.Name, state)
+		}
+	}
+	if n := len(p.Clients()); n != 0 {
+		t.Errorf("pool has %d clients after Close\n", n)
+	}
+	l.lock.Lock()
+	for _, client := range clients {
+		if !l.disconnects[client] {
+			t.Errorf("no DisconnectEvent for client %s\n", client.Self.Name)
+		}
+	}
+	l.lock.Unlock()
+
+	if _, err := p.Dial(newTestConfig(s, "dave")); err == nil {
+		t.Error("Dial succeeded after Close")
+	}
+	if err := p.Close(); err == nil {
+		t.Error("second Close succeeded")
+	}
+}
+
+// reentrantPoolListener calls back into the client from a pool listener.
+type reentrantPoolListener struct {
+	testListener
+	states chan State
+}
+
+func (l *reentrantPoolListener) OnTextMessage(e *TextMessageEvent) {
+	e.Client.SetAudioFramesPerPacket(2)
+	e.Client.Disconnect()
+}
+
+func (l *reentrantPoolListener) OnAudioConfig(e *AudioConfigEvent) {}
+
+func (l *reentrantPoolListener) OnStateChange(e *StateChangeEvent) {
+	l.states <- e.New
+}
+
+func TestPoolListenerDisconnect(t *testing.T) {
+	s := newTestServer(t)
+	p := newTestPool()
+	l := &reentrantPoolListener{
+		states: make(chan State, 16),
+	}
+	p.Attach(l)
+
+	if _, err := p.Dial(newTestConfig(s, "alice")); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(5 * time.Second)
+	for state := StateConnected; state != StateDisconnected; {
+		select {
+		case state = <-l.states:
+		case <-timeout:
+			t.Fatal("the client did not disconnect")
+		}
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- p.Close()
+	}()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-timeout:
+		t.Fatal("Close did not return")
+	}
+}
@@ -0,0 +1,110 @@
+package gumble
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRegisterTimeout is returned by User.RegisterWait if the server does not
+// reply in time.
+var ErrRegisterTimeout = errors.New("gumble: timed out waiting for registration")
+
+// RegisterError is returned by User.RegisterWait when the server denies the
+// registration, e.g. because the client has no certificate
+// (PermissionDeniedMissingCertificate).
+type RegisterError struct {
+	Type   PermissionDeniedType
+	Reason string
+}
+
+// Error implements error.
+func (e *RegisterError) Error() string {
+	msg := "gumble: registration denied"
+	switch e.Type {
+	case PermissionDeniedMissingCertificate:
+		msg += ": missing certificate"
+	case PermissionDeniedPermission:
+		msg += ": permission denied"
+	}
+	if e.Reason != "" {
+		msg += ": " + e.Reason
+	}
+	return msg
+}
+
+// registrations tracks the users waiting in User.RegisterWait.
+type registrations struct {
+	sync.Mutex
+	pending map[*User]chan error
+}
+
+func (r *registrations) add(user *User) chan error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.pending == nil {
+		r.pending = make(map[*User]chan error)
+	}
+	done := make(chan error, 1)
+	r.pending[user] = done
+	return done
+}
+
+func (r *registrations) remove(user *User, done chan error) {
+	r.Lock()
+	if r.pending[user] == done {
+		delete(r.pending, user)
+	}
+	r.Unlock()
+}
+
+// finish completes the registration of user, if it is pending. If user is
+// nil, any pending registration is completed.
+func (r *registrations) finish(user *User, err error) {
+	r.Lock()
+	defer r.Unlock()
+
+	for u, done := range r.pending {
+		if user == nil || u == user {
+			done <- err
+			delete(r.pending, u)
+			return
+		}
+	}
+}
+
+// RegisterWait registers the user with the server, like Register, and waits
+// until the server has given the user a UserID, or has denied the
+// registration (in which case a *RegisterError is returned). A timeout of
+// zero waits indefinitely.
+//
+// RegisterWait must not be called from an event listener, or from inside of
+// Client.Do, as the server's reply would never be processed.
+func (u *User) RegisterWait(timeout time.Duration) error {
+	client := u.client
+	if client == nil {
+		return errors.New("gumble: user is not connected")
+	}
+	if u.IsRegistered() {
+		return nil
+	}
+	done := client.registrations.add(u)
+	defer client.registrations.remove(u, done)
+	u.Register()
+
+	var timer <-chan time.Time
+	if timeout > 0 {
+		t := time.NewTimer(timeout)
+		defer t.Stop()
+		timer = t.C
+	}
+	select {
+	case err := <-done:
+		return err
+	case <-client.end:
+		return errors.New("gumble: client disconnected")
+	case <-timer:
+		return ErrRegisterTimeout
+	}
+}
@@ -27,6 +27,8 @@ const (
 type RejectError struct {
 	Type   RejectType
 	Reason string
+	// Did the client try to log in as SuperUser?
+	SuperUser bool
 }
 
 // Error implements error.
@@ -40,7 +42,11 @@ func (e RejectError) Error() string {
 	case RejectUserName:
 		msg = "invalid username"
 	case RejectUserCredentials:
-		msg = "incorrect user credentials"
+		if e.SuperUser {
+			msg = "incorrect SuperUser password"
+		} else {
+			msg = "incorrect user credentials"
+		}
 	case RejectServerPassword:
 		msg = "incorrect server password"
 	case RejectUsernameInUse:
@@ -0,0 +1,118 @@
+package gumble
+
+import (
+	"sync/atomic"
+
+	"github.com/bmmcginty/gumble/gumble/voicepacket"
+)
+
+// reorderResetDistance is how far a sequence number must be behind the last
+// delivered one for the packet to be treated as the start of a new stream,
+// rather than a late packet.
+const reorderResetDistance = 1000
+
+// ReorderStats contains the counters of the incoming voice reorder buffer
+// (see Config.AudioReorderTolerance).
+type ReorderStats struct {
+	// Packets that arrived after a packet with a higher sequence number, and
+	// were put back in order.
+	Reordered uint64
+	// Packets that were discarded because they arrived too late to be put
+	// back in order, or were duplicates.
+	Dropped uint64
+}
+
+// ReorderStats returns the counters of the incoming voice reorder buffer.
+func (c *Client) ReorderStats() ReorderStats {
+	return ReorderStats{
+		Reordered: atomic.LoadUint64(&c.reorderStats.Reordered),
+		Dropped:   atomic.LoadUint64(&c.reorderStats.Dropped),
+	}
+}
+
+// reorderBuffer puts the voice packets of a single user back in sequence
+// order.
+//
+// The amount by which the sequence number increases between packets depends
+// on the sending client (official clients count 10ms frames, gumble counts
+// packets), so it is learned from the packets that arrive.
+type reorderBuffer struct {
+	last    int64 // sequence number of the last delivered packet, or -1
+	step    int64 // smallest increase seen between packets, or 0 if unknown
+	pending []*voicepacket.Packet
+}
+
+func newReorderBuffer() *reorderBuffer {
+	return &reorderBuffer{
+		last: -1,
+	}
+}
+
+// push adds packet to the buffer, and returns the packets that can now be
+// delivered, in order. At most tolerance packets are held back while waiting
+// for a missing one. The data of a packet that is held back is copied.
+func (r *reorderBuffer) push(packet *voicepacket.Packet, tolerance int, stats *ReorderStats) []*voicepacket.Packet {
+	var ready []*voicepacket.Packet
+
+	if r.last >= 0 && packet.Sequence <= r.last {
+		if r.last-packet.Sequence < reorderResetDistance {
+			atomic.AddUint64(&stats.Dropped, 1)
+			return nil
+		}
+		// The sender started a new stream without a terminating packet
+		// reaching us.
+		ready = r.pending
+		r.pending = nil
+		r.last = -1
+	}
+
+	i := len(r.pending)
+	for i > 0 && r.pending[i-1].Sequence > packet.Sequence {
+		i--
+	}
+	if i > 0 && r.pending[i-1].Sequence == packet.Sequence {
+		atomic.AddUint64(&stats.Dropped, 1)
+		return ready
+	}
+	if i < len(r.pending) {
+		atomic.AddUint64(&stats.Reordered, 1)
+	}
+	r.pending = append(r.pending, nil)
+	copy(r.pending[i+1:], r.pending[i:])
+	r.pending[i] = packet
+
+	final := false
+	for _, p := range r.pending {
+		final = final || p.Final
+	}
+
+	for len(r.pending) > 0 {
+		head := r.pending[0]
+		delta := head.Sequence - r.last
+		inOrder := r.last < 0 || r.step == 0 || delta <= r.step
+		if !inOrder && !final && len(r.pending) <= tolerance {
+			break
+		}
+		if r.last >= 0 && delta > 0 && (r.step == 0 || delta < r.step) {
+			r.step = delta
+		}
+		ready = append(ready, head)
+		r.pending = r.pending[1:]
+		r.last = head.Sequence
+		if head.Final {
+			r.last = -1
+		}
+	}
+	if len(r.pending) == 0 {
+		r.pending = nil
+	}
+	for _, p := range r.pending {
+		if p == packet {
+			// The packet's data references the connection's read buffer,
+			// which is reused by the next read.
+			packet.Data = append([]byte(nil), packet.Data...)
+			break
+		}
+	}
+	return ready
+}
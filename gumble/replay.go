@@ -0,0 +1,53 @@
+package gumble
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"net"
+	"time"
+)
+
+// PacketRecorder is notified of each packet the client reads from the server,
+// before the packet is handled. See Config.PacketRecorder.
+type PacketRecorder interface {
+	// RecordPacket is called with the packet's type and data. data is only
+	// valid until RecordPacket returns.
+	RecordPacket(pType uint16, data []byte)
+}
+
+// NewReplayClient returns a client that is not connected to a real server.
+// Instead, the packets written to the returned Conn (e.g. packets that were
+// captured with a PacketRecorder) are handled by the client as if they were
+// sent by a server. Messages sent by the client are discarded.
+//
+// The client triggers the same events as a connected client: the
+// ConnectEvent once a ServerSync packet has been written, and the
+// DisconnectEvent once the returned Conn is closed. Writing to the returned
+// Conn blocks until the client has handled the previously written packet.
+func NewReplayClient(config *Config) (*Client, *Conn) {
+	clientConn, serverConn := net.Pipe()
+
+	client := &Client{
+		Conn:     NewConn(clientConn),
+		Users:    make(Users),
+		Channels: make(Channels),
+
+		permissions: make(map[uint32]*Permission),
+
+		connect: make(chan *RejectError, 1),
+		end:     make(chan struct{}),
+	}
+	client.Config = config.clone(client)
+	// A replay can pause for as long as the recorded session did.
+	client.Conn.Timeout = time.Duration(math.MaxInt64)
+	client.Conn.startWriter()
+	client.startDispatcher()
+	client.setState(StateConnected)
+
+	server := NewConn(serverConn)
+	go io.Copy(ioutil.Discard, serverConn)
+	go client.readRoutine()
+
+	return client, server
+}
@@ -0,0 +1,129 @@
+package gumble
+
+import (
+	"crypto/sha1"
+)
+
+// DefaultBlobMaxBytes is the Config.BlobMaxBytes set by NewConfig.
+const DefaultBlobMaxBytes = 64 * 1024 * 1024
+
+// ResourceLimitType is the kind of limit of a ResourceLimitEvent.
+type ResourceLimitType int
+
+const (
+	// ResourceLimitBlob means that a blob was dropped because of
+	// Config.BlobMaxBytes.
+	ResourceLimitBlob ResourceLimitType = iota
+
+	// ResourceLimitAudio means that the queue of an audio stream is full
+	// (see Config.AudioStreamQueue). The event is triggered once each time
+	// the queue fills up, rather than for every packet.
+	ResourceLimitAudio
+)
+
+// ResourceLimitPolicy specifies what the client does with data that exceeds
+// one of its limits.
+type ResourceLimitPolicy int
+
+const (
+	// ResourceLimitPause makes the client wait until there is room for the
+	// data. While it waits, nothing else is read from the server; if it waits
+	// for too long, the server closes the connection.
+	ResourceLimitPause ResourceLimitPolicy = iota
+
+	// ResourceLimitDrop discards the data.
+	ResourceLimitDrop
+)
+
+// setTexture, setComment and setDescription set a blob of a user or channel,
+// keeping track of the number of bytes of blobs that the client holds.
+// c.volatile must be held.
+func (c *Client) setTexture(user *User, texture []byte) {
+	c.blobBytes += len(texture) - len(user.Texture)
+	user.Texture = texture
+}
+
+func (c *Client) setComment(user *User, comment string) {
+	c.blobBytes += len(comment) - len(user.Comment)
+	user.Comment = comment
+}
+
+func (c *Client) setDescription(channel *Channel, description string) {
+	c.blobBytes += len(description) - len(channel.Description)
+	channel.Description = description
+}
+
+// releaseBlobs stops counting the blobs of user, who has been removed. The
+// blobs are kept, as the User can still be used (the description of a removed
+// channel is released likewise). c.volatile must be held.
+func (c *Client) releaseBlobs(user *User) {
+	c.blobBytes -= len(user.Texture) + len(user.Comment)
+}
+
+// admitBlob returns true if a blob of the given size can be held in place of
+// one of size replaced, within Config.BlobMaxBytes. c.volatile must be held.
+func (c *Client) admitBlob(size, replaced int) bool {
+	max := c.Config.BlobMaxBytes
+	if max <= 0 || size < blobHashThreshold || size <= replaced {
+		return true
+	}
+	return c.blobBytes-replaced+size <= max
+}
+
+// blobLimitEvent returns the ResourceLimitEvent of a dropped blob of user or
+// channel.
+func (c *Client) blobLimitEvent(user *User, channel *Channel, size int) *ResourceLimitEvent {
+	return &ResourceLimitEvent{
+		Client:  c,
+		Type:    ResourceLimitBlob,
+		Policy:  ResourceLimitDrop,
+		User:    user,
+		Channel: channel,
+		Size:    size,
+	}
+}
+
+// resourceLimited triggers the given ResourceLimitEvents.
+func (c *Client) resourceLimited(events []*ResourceLimitEvent) {
+	for _, event := range events {
+		c.Config.Listeners.onResourceLimit(event)
+	}
+}
+
+func blobHash(blob []byte) []byte {
+	sum := sha1.Sum(blob)
+	return sum[:]
+}
+
+// sendAudio passes p to ch, the audio stream of user for item's listener,
+// applying Config.AudioStreamQueuePolicy if the stream's queue is full. It is
+// called from the read goroutine, without c.volatile held.
+func (c *Client) sendAudio(item *audioEventItem, user *User, ch chan *AudioPacket, p *AudioPacket) {
+	policy := c.Config.AudioStreamQueuePolicy
+	if policy == ResourceLimitPause && cap(ch) == 0 {
+		ch <- p
+		return
+	}
+	select {
+	case ch <- p:
+		// The queue has to drain before the event is triggered again.
+		if len(ch) <= cap(ch)/2 {
+			delete(item.limited, user)
+		}
+		return
+	default:
+	}
+	if !item.limited[user] {
+		item.limited[user] = true
+		c.Config.Listeners.onResourceLimit(&ResourceLimitEvent{
+			Client: c,
+			Type:   ResourceLimitAudio,
+			Policy: policy,
+			User:   user,
+			Size:   cap(ch),
+		})
+	}
+	if policy == ResourceLimitPause {
+		ch <- p
+	}
+}
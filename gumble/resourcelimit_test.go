@@ -0,0 +1,156 @@
+package gumble
+
+import (
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+	"github.com/golang/protobuf/proto"
+)
+
+type testResourceLimitListener struct {
+	testListener
+	mu     sync.Mutex
+	events []*ResourceLimitEvent
+}
+
+func (l *testResourceLimitListener) OnResourceLimit(e *ResourceLimitEvent) {
+	l.mu.Lock()
+	l.events = append(l.events, e)
+	l.mu.Unlock()
+}
+
+// takeEvents returns the events received since the previous call.
+func (l *testResourceLimitListener) takeEvents() []*ResourceLimitEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	events := l.events
+	l.events = nil
+	return events
+}
+
+func TestBlobAccounting(t *testing.T) {
+	config := NewConfig()
+	config.BlobMaxBytes = 1000
+	config.BlobCache = nil
+	l := &testResourceLimitListener{}
+	config.Attach(l)
+	client, server := NewReplayClient(config)
+	defer server.Close()
+
+	comment := func(n int) *string { return proto.String(strings.Repeat("c", n)) }
+	description := func(n int) *string { return proto.String(strings.Repeat("d", n)) }
+	steps := []struct {
+		name    string
+		packet  proto.Message
+		bytes   int
+		dropped int
+	}{
+		{
+			name:   "root channel",
+			packet: &MumbleProto.ChannelState{ChannelId: proto.Uint32(0), Name: proto.String("Root")},
+		},
+		{
+			name:   "comment",
+			packet: &MumbleProto.UserState{Session: proto.Uint32(1), Name: proto.String("alice"), ChannelId: proto.Uint32(0), Comment: comment(900)},
+			bytes:  900,
+		},
+		{
+			name:    "comment over the limit",
+			packet:  &MumbleProto.UserState{Session: proto.Uint32(2), Name: proto.String("bob"), ChannelId: proto.Uint32(0), Comment: comment(200)},
+			bytes:   900,
+			dropped: 200,
+		},
+		{
+			name:   "small blob over the limit",
+			packet: &MumbleProto.UserState{Session: proto.Uint32(2), Texture: make([]byte, blobHashThreshold-1)},
+			bytes:  900 + blobHashThreshold - 1,
+		},
+		{
+			name:   "replaced by a smaller comment",
+			packet: &MumbleProto.UserState{Session: proto.Uint32(1), Comment: comment(200)},
+			bytes:  200 + blobHashThreshold - 1,
+		},
+		{
+			name:   "comment within the limit",
+			packet: &MumbleProto.UserState{Session: proto.Uint32(2), Comment: comment(600)},
+			bytes:  800 + blobHashThreshold - 1,
+		},
+		{
+			name:    "description over the limit",
+			packet:  &MumbleProto.ChannelState{ChannelId: proto.Uint32(1), Parent: proto.Uint32(0), Name: proto.String("Lobby"), Description: description(300)},
+			bytes:   800 + blobHashThreshold - 1,
+			dropped: 300,
+		},
+		{
+			name:   "removed user",
+			packet: &MumbleProto.UserRemove{Session: proto.Uint32(2)},
+			bytes:  200,
+		},
+		{
+			name:   "description within the limit",
+			packet: &MumbleProto.ChannelState{ChannelId: proto.Uint32(1), Description: description(300)},
+			bytes:  500,
+		},
+		{
+			name:   "removed comment",
+			packet: &MumbleProto.UserState{Session: proto.Uint32(1), Comment: proto.String("")},
+			bytes:  300,
+		},
+		{
+			name:   "removed channel",
+			packet: &MumbleProto.ChannelRemove{ChannelId: proto.Uint32(1)},
+			bytes:  0,
+		},
+	}
+	for _, step := range steps {
+		if err := server.WriteProto(step.packet); err != nil {
+			t.Fatal(err)
+		}
+		// The previous packet has been handled once the next one is read.
+		server.WriteProto(&MumbleProto.Ping{})
+
+		var bytes int
+		client.Do(func() {
+			bytes = client.blobBytes
+		})
+		if bytes != step.bytes {
+			t.Errorf("%s: holding %d bytes of blobs, expected %d\n", step.name, bytes, step.bytes)
+		}
+		events := l.takeEvents()
+		switch {
+		case step.dropped == 0 && len(events) != 0:
+			t.Errorf("%s: %d blobs dropped\n", step.name, len(events))
+		case step.dropped != 0 && len(events) != 1:
+			t.Errorf("%s: %d blobs dropped, expected 1\n", step.name, len(events))
+		case step.dropped != 0 && (events[0].Type != ResourceLimitBlob || events[0].Size != step.dropped):
+			t.Errorf("%s: dropped a blob of %d bytes, expected %d\n", step.name, events[0].Size, step.dropped)
+		}
+	}
+}
+
+func TestBlobDroppedHash(t *testing.T) {
+	config := NewConfig()
+	config.BlobMaxBytes = 500
+	config.BlobCache = nil
+	client, server := NewReplayClient(config)
+	defer server.Close()
+
+	comment := strings.Repeat("c", 600)
+	server.WriteProto(&MumbleProto.ChannelState{ChannelId: proto.Uint32(0), Name: proto.String("Root")})
+	server.WriteProto(&MumbleProto.UserState{Session: proto.Uint32(1), Name: proto.String("alice"), ChannelId: proto.Uint32(0), Comment: proto.String(comment)})
+	server.WriteProto(&MumbleProto.Ping{})
+
+	// The dropped comment can still be requested, as if the server had only
+	// sent its hash.
+	client.Do(func() {
+		user := client.Users[1]
+		if user.Comment != "" {
+			t.Errorf("got a comment of %d bytes, expected none\n", len(user.Comment))
+		}
+		if hash := blobHash([]byte(comment)); string(user.CommentHash) != string(hash) {
+			t.Errorf("got comment hash %x, expected %x\n", user.CommentHash, hash)
+		}
+	})
+}
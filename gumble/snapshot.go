@@ -0,0 +1,196 @@
+package gumble
+
+import (
+	"math"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Snapshot is a copy of the client's view of the server at a point in time. It
+// does not reference the client's data structures, so it can be used from any
+// goroutine, and marshaled with encoding/json.
+type Snapshot struct {
+	// When the snapshot was taken.
+	Time time.Time `json:"time"`
+	// The state of the client's connection.
+	State State `json:"state"`
+	// Information about the server.
+	Server ServerSnapshot `json:"server"`
+	// The session ID of the client's own user. nil if the client has not
+	// finished connecting.
+	Self *uint32 `json:"self,omitempty"`
+	// The server's channels, ordered by ID. The channel tree can be rebuilt
+	// using ChannelSnapshot.Parent and ChannelSnapshot.Children.
+	Channels []*ChannelSnapshot `json:"channels"`
+	// The connected users, ordered by session ID.
+	Users []*UserSnapshot `json:"users"`
+}
+
+// ServerSnapshot contains information about the server a client is connected
+// to.
+type ServerSnapshot struct {
+	// The address of the server, as given in the client's config.
+	Address string `json:"address"`
+	// The server's version. nil if the server has not sent it.
+	Version *Version `json:"version,omitempty"`
+	// The server's welcome message.
+	WelcomeMessage string `json:"welcome_message,omitempty"`
+	// The maximum audio bitrate allowed by the server, in bits per second.
+	// Zero if the server has no limit, or has not sent it.
+	MaximumBitrate int `json:"maximum_bitrate,omitempty"`
+	// The average round trip time of TCP pings to the server, in
+	// milliseconds.
+	Ping float32 `json:"ping"`
+}
+
+// ChannelSnapshot is a copy of a Channel.
+type ChannelSnapshot struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+	// The ID of the channel's parent. nil for the root channel.
+	Parent *uint32 `json:"parent,omitempty"`
+	// The IDs of the channels directly underneath the channel.
+	Children []uint32 `json:"children,omitempty"`
+	// The IDs of the channels linked to the channel.
+	Links []uint32 `json:"links,omitempty"`
+	// The session IDs of the users in the channel.
+	Users []uint32 `json:"users,omitempty"`
+	// The channel's description, if it is known.
+	Description string `json:"description,omitempty"`
+	MaxUsers    uint32 `json:"max_users,omitempty"`
+	Position    int32  `json:"position"`
+	Temporary   bool   `json:"temporary,omitempty"`
+}
+
+// UserSnapshot is a copy of a User.
+type UserSnapshot struct {
+	Session uint32 `json:"session"`
+	// The user's ID. Only valid if Registered is true.
+	UserID     uint32 `json:"user_id,omitempty"`
+	Registered bool   `json:"registered"`
+	Name       string `json:"name"`
+	// The ID of the channel the user is in.
+	Channel uint32 `json:"channel"`
+	// The IDs of the channels the user is listening to.
+	ListeningChannels []uint32 `json:"listening_channels,omitempty"`
+
+	Muted           bool `json:"muted,omitempty"`
+	Deafened        bool `json:"deafened,omitempty"`
+	Suppressed      bool `json:"suppressed,omitempty"`
+	SelfMuted       bool `json:"self_muted,omitempty"`
+	SelfDeafened    bool `json:"self_deafened,omitempty"`
+	PrioritySpeaker bool `json:"priority_speaker,omitempty"`
+	Recording       bool `json:"recording,omitempty"`
+
+	// The user's comment, if it is known.
+	Comment string `json:"comment,omitempty"`
+	// The hash of the user's certificate.
+	Hash string `json:"hash,omitempty"`
+	// When the user last sent audio or a text message (see User.IdleSince).
+	IdleSince time.Time `json:"idle_since"`
+}
+
+// Snapshot returns a copy of the client's current view of the server.
+func (c *Client) Snapshot() *Snapshot {
+	c.volatile.RLock()
+	defer c.volatile.RUnlock()
+
+	snapshot := &Snapshot{
+		Time:  time.Now(),
+		State: c.State(),
+		Server: ServerSnapshot{
+			Address:        c.Config.Address,
+			WelcomeMessage: c.welcomeMessage,
+			MaximumBitrate: int(atomic.LoadInt32(&c.maximumBitrate)),
+			Ping:           math.Float32frombits(atomic.LoadUint32(&c.tcpPingAvg)),
+		},
+		Channels: make([]*ChannelSnapshot, 0, len(c.Channels)),
+		Users:    make([]*UserSnapshot, 0, len(c.Users)),
+	}
+	if c.serverVersion != nil {
+		version := *c.serverVersion
+		snapshot.Server.Version = &version
+	}
+	if c.Self != nil {
+		session := c.Self.Session
+		snapshot.Self = &session
+	}
+
+	for _, channel := range c.Channels {
+		s := &ChannelSnapshot{
+			ID:          channel.ID,
+			Name:        channel.Name,
+			Children:    channelIDs(channel.Children),
+			Links:       channelIDs(channel.Links),
+			Users:       userSessions(channel.Users),
+			Description: channel.Description,
+			MaxUsers:    channel.MaxUsers,
+			Position:    channel.Position,
+			Temporary:   channel.Temporary,
+		}
+		if channel.Parent != nil {
+			parent := channel.Parent.ID
+			s.Parent = &parent
+		}
+		snapshot.Channels = append(snapshot.Channels, s)
+	}
+	sort.Slice(snapshot.Channels, func(i, j int) bool {
+		return snapshot.Channels[i].ID < snapshot.Channels[j].ID
+	})
+
+	for _, user := range c.Users {
+		s := &UserSnapshot{
+			Session:           user.Session,
+			UserID:            user.UserID,
+			Registered:        user.IsRegistered(),
+			Name:              user.Name,
+			ListeningChannels: channelIDs(user.ListeningChannels),
+			Muted:             user.Muted,
+			Deafened:          user.Deafened,
+			Suppressed:        user.Suppressed,
+			SelfMuted:         user.SelfMuted,
+			SelfDeafened:      user.SelfDeafened,
+			PrioritySpeaker:   user.PrioritySpeaker,
+			Recording:         user.Recording,
+			Comment:           user.Comment,
+			Hash:              user.Hash,
+			IdleSince:         user.IdleSince(),
+		}
+		if user.Channel != nil {
+			s.Channel = user.Channel.ID
+		}
+		snapshot.Users = append(snapshot.Users, s)
+	}
+	sort.Slice(snapshot.Users, func(i, j int) bool {
+		return snapshot.Users[i].Session < snapshot.Users[j].Session
+	})
+
+	return snapshot
+}
+
+// channelIDs returns the sorted IDs of channels.
+func channelIDs(channels Channels) []uint32 {
+	if len(channels) == 0 {
+		return nil
+	}
+	ids := make([]uint32, 0, len(channels))
+	for _, channel := range channels {
+		ids = append(ids, channel.ID)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// userSessions returns the sorted session IDs of users.
+func userSessions(users Users) []uint32 {
+	if len(users) == 0 {
+		return nil
+	}
+	sessions := make([]uint32, 0, len(users))
+	for _, user := range users {
+		sessions = append(sessions, user.Session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i] < sessions[j] })
+	return sessions
+}
@@ -0,0 +1,22 @@
+package gumble
+
+// ServerSuggestions contains the client configuration that the server
+// recommends. A nil field means that the server has made no suggestion about
+// the setting.
+//
+// Servers send their suggestions when a client connects, and can update them
+// at any time. Each update also triggers EventListener.OnServerConfig.
+type ServerSuggestions struct {
+	// The minimum client version that the server suggests.
+	Version *Version
+	// Whether the server suggests that positional audio be enabled.
+	Positional *bool
+	// Whether the server suggests that push-to-talk be used.
+	PushToTalk *bool
+}
+
+// VersionSatisfied returns true if the server has not suggested a version, or
+// if ClientVersion is at least the suggested version.
+func (s ServerSuggestions) VersionSatisfied() bool {
+	return s.Version == nil || s.Version.Version <= ClientVersion
+}
@@ -0,0 +1,97 @@
+package gumble
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Summary is an overview of the server that a client is connected to, e.g.
+// for dashboards. Like Snapshot, it does not reference the client's data
+// structures, and can be marshaled with encoding/json.
+type Summary struct {
+	// When the summary was made.
+	Time time.Time `json:"time"`
+	// The address of the server, as given in the client's config.
+	Address string `json:"address"`
+	// The server's version. nil if the server has not sent it.
+	Version *Version `json:"version,omitempty"`
+	// The server's welcome message.
+	WelcomeMessage string `json:"welcome_message,omitempty"`
+
+	// The number of connected users, and the maximum number of users allowed
+	// by the server (zero if the server has not sent it).
+	Users        int `json:"users"`
+	MaximumUsers int `json:"maximum_users,omitempty"`
+	// The number of channels.
+	Channels int `json:"channels"`
+
+	// An estimate of how long the server has been running: how long ago the
+	// longest-connected user whose stats are known connected. It is a lower
+	// bound, and zero if no stats are known (see RefreshSummary).
+	Uptime time.Duration `json:"uptime"`
+
+	// The maximum audio bitrate allowed by the server, in bits per second.
+	// Zero if the server has no limit, or has not sent it.
+	MaximumBitrate int `json:"maximum_bitrate,omitempty"`
+	// The client's audio configuration: the interval at which audio packets
+	// are sent, and the resulting bitrate (see Config.AudioDataBytes).
+	AudioInterval time.Duration `json:"audio_interval"`
+	AudioBitrate  int           `json:"audio_bitrate"`
+	// The average round trip time of TCP pings to the server, in
+	// milliseconds.
+	Ping float32 `json:"ping"`
+}
+
+// Summary returns an overview of the server, made from the client's current
+// view of it.
+func (c *Client) Summary() *Summary {
+	c.volatile.RLock()
+	defer c.volatile.RUnlock()
+
+	now := time.Now()
+	summary := &Summary{
+		Time:           now,
+		Address:        c.Config.Address,
+		WelcomeMessage: c.welcomeMessage,
+		Users:          len(c.Users),
+		MaximumUsers:   c.maximumUsers,
+		Channels:       len(c.Channels),
+		MaximumBitrate: int(atomic.LoadInt32(&c.maximumBitrate)),
+		AudioInterval:  c.Config.AudioInterval,
+		Ping:           math.Float32frombits(atomic.LoadUint32(&c.tcpPingAvg)),
+	}
+	if c.Config.AudioInterval > 0 {
+		summary.AudioBitrate = int(int64(c.Config.AudioDataBytes) * 8 * int64(time.Second) / int64(c.Config.AudioInterval))
+	}
+	if c.serverVersion != nil {
+		version := *c.serverVersion
+		summary.Version = &version
+	}
+	for _, user := range c.Users {
+		if user.Stats == nil || user.Stats.Connected.IsZero() {
+			continue
+		}
+		if uptime := now.Sub(user.Stats.Connected); uptime > summary.Uptime {
+			summary.Uptime = uptime
+		}
+	}
+	return summary
+}
+
+// RefreshSummary requests the stats of the users whose stats are not known,
+// which improve the Uptime estimate of later summaries. On large servers,
+// this sends many requests.
+func (c *Client) RefreshSummary() {
+	var users []*User
+	c.volatile.RLock()
+	for _, user := range c.Users {
+		if user.Stats == nil {
+			users = append(users, user)
+		}
+	}
+	c.volatile.RUnlock()
+	for _, user := range users {
+		user.RequestStats()
+	}
+}
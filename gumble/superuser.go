@@ -0,0 +1,73 @@
+package gumble
+
+import (
+	"strings"
+)
+
+// SuperUserName is the user name of the server's built-in administrator
+// account, whose user ID is 0. Its password is set by the server's
+// administrator (e.g. with murmurd -supw). Logging in as SuperUser is only
+// possible with the password; certificates are not used.
+const SuperUserName = "SuperUser"
+
+// IsSuperUserName returns true if name refers to the SuperUser account. The
+// server compares the name without regard to case.
+func IsSuperUserName(name string) bool {
+	return strings.EqualFold(strings.TrimSpace(name), SuperUserName)
+}
+
+// LoginAsSuperUser sets the config's Username to SuperUserName and its
+// Password to password, the SuperUser password of the server.
+func (c *Config) LoginAsSuperUser(password string) {
+	c.Username = SuperUserName
+	c.Password = password
+}
+
+// IsSuperUser returns true if the user is logged in as SuperUser.
+func (u *User) IsSuperUser() bool {
+	return u.superUser
+}
+
+// AuthResult describes how the server authenticated the client.
+type AuthResult int
+
+// Ways in which the server can authenticate the client.
+const (
+	// The client is connected as an unregistered user (with the server
+	// password, if the server has one).
+	AuthUnregistered AuthResult = iota
+	// The client is connected as a registered user, identified by its
+	// certificate or by its password.
+	AuthRegistered
+	// The client is connected as SuperUser.
+	AuthSuperUser
+)
+
+// String implements fmt.Stringer.
+func (r AuthResult) String() string {
+	switch r {
+	case AuthRegistered:
+		return "registered"
+	case AuthSuperUser:
+		return "SuperUser"
+	default:
+		return "unregistered"
+	}
+}
+
+// AuthResult returns how the server authenticated the client, once it has
+// connected.
+func (c *Client) AuthResult() AuthResult {
+	c.volatile.RLock()
+	defer c.volatile.RUnlock()
+	switch {
+	case c.Self == nil:
+		return AuthUnregistered
+	case c.Self.superUser:
+		return AuthSuperUser
+	case c.Self.IsRegistered():
+		return AuthRegistered
+	default:
+		return AuthUnregistered
+	}
+}
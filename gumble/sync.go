@@ -0,0 +1,55 @@
+package gumble
+
+// SyncEvents specifies how the events of the server's initial state are
+// delivered (see Config.SyncEvents).
+type SyncEvents int
+
+const (
+	// SyncEventsDiscard discards the events of the initial state: listeners
+	// only receive the ConnectEvent, whose Users and Channels summarize the
+	// state.
+	SyncEventsDiscard SyncEvents = iota
+
+	// SyncEventsDeferred holds the events of the initial state until it has
+	// been fully received, then delivers them, in order, before the
+	// ConnectEvent. Users and channels are in their final state when the
+	// events are delivered.
+	SyncEventsDeferred
+
+	// SyncEventsImmediate delivers the events of the initial state as it is
+	// received, before Client.Self is known and the ConnectEvent is
+	// triggered.
+	SyncEventsImmediate
+)
+
+// syncEvent delivers an event of the initial state as specified by
+// Config.SyncEvents.
+func (c *Client) syncEvent(deliver func()) {
+	switch c.Config.SyncEvents {
+	case SyncEventsDeferred:
+		c.syncEvents = append(c.syncEvents, deliver)
+	case SyncEventsImmediate:
+		deliver()
+	}
+}
+
+// flushSyncEvents delivers the deferred events of the initial state.
+func (c *Client) flushSyncEvents() {
+	events := c.syncEvents
+	c.syncEvents = nil
+	for _, deliver := range events {
+		deliver()
+	}
+}
+
+func (c *Client) syncProgress(done bool) {
+	c.volatile.RLock()
+	event := SyncProgressEvent{
+		Client:   c,
+		Channels: len(c.Channels),
+		Users:    len(c.Users),
+		Done:     done,
+	}
+	c.volatile.RUnlock()
+	c.Config.Listeners.onSyncProgress(&event)
+}
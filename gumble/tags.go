@@ -0,0 +1,93 @@
+package gumble
+
+import (
+	"sync"
+)
+
+// tags is a thread-safe store of user-defined values, keyed by name.
+type tags struct {
+	mu     sync.RWMutex
+	values map[string]interface{}
+}
+
+func (t *tags) get(key string) interface{} {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.values[key]
+}
+
+func (t *tags) set(key string, value interface{}) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if value == nil {
+		delete(t.values, key)
+		return
+	}
+	if t.values == nil {
+		t.values = make(map[string]interface{})
+	}
+	t.values[key] = value
+}
+
+func (t *tags) copy() map[string]interface{} {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if len(t.values) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{}, len(t.values))
+	for key, value := range t.values {
+		values[key] = value
+	}
+	return values
+}
+
+func (t *tags) keys() []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	keys := make([]string, 0, len(t.values))
+	for key := range t.values {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Tag returns the value that was attached to the user with SetTag, or nil.
+func (u *User) Tag(key string) interface{} {
+	return u.tags.get(key)
+}
+
+// SetTag attaches a value of the application's choosing (e.g. a warning
+// count) to the user. The value is kept for as long as the User is, i.e.
+// until the user leaves the server or the client disconnects, and is given
+// back to the user if they return (see UserReturnedEvent). A nil value
+// removes the tag.
+//
+// Tags are not sent to the server. They can be used from any goroutine.
+func (u *User) SetTag(key string, value interface{}) {
+	u.tags.set(key, value)
+}
+
+// TagKeys returns the keys of the user's tags, in no particular order.
+func (u *User) TagKeys() []string {
+	return u.tags.keys()
+}
+
+// Tag returns the value that was attached to the channel with SetTag, or nil.
+func (c *Channel) Tag(key string) interface{} {
+	return c.tags.get(key)
+}
+
+// SetTag attaches a value of the application's choosing to the channel. The
+// value is kept for as long as the Channel is, i.e. until the channel is
+// removed or the client disconnects. A nil value removes the tag.
+//
+// Tags are not sent to the server. They can be used from any goroutine.
+func (c *Channel) SetTag(key string, value interface{}) {
+	c.tags.set(key, value)
+}
+
+// TagKeys returns the keys of the channel's tags, in no particular order.
+func (c *Channel) TagKeys() []string {
+	return c.tags.keys()
+}
@@ -1,6 +1,8 @@
 package gumble
 
 import (
+	"errors"
+
 	"github.com/bmmcginty/gumble/gumble/MumbleProto"
 )
 
@@ -19,7 +21,33 @@ type TextMessage struct {
 	Message string
 }
 
+// TextMiddleware is a function that processes a text message before it is
+// sent to the server (see Config.OutgoingTextMiddleware), or before it is
+// passed to the event listeners (see Config.IncomingTextMiddleware). It can
+// modify message, e.g. to convert its markup or censor it. If it returns an
+// error, the message is dropped, and the rest of the chain is not run.
+type TextMiddleware func(message *TextMessage) error
+
+// runTextMiddleware passes message through each function of chain, in order.
+func runTextMiddleware(chain []TextMiddleware, message *TextMessage) error {
+	for _, middleware := range chain {
+		if err := middleware(message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (t *TextMessage) writeMessage(client *Client) error {
+	if chain := client.Config.OutgoingTextMiddleware; len(chain) > 0 {
+		// Run the chain on a copy, so that the caller's message is left as
+		// it is.
+		message := *t
+		if err := runTextMiddleware(chain, &message); err != nil {
+			return err
+		}
+		t = &message
+	}
 	packet := MumbleProto.TextMessage{
 		Message: &t.Message,
 	}
@@ -43,3 +71,13 @@ func (t *TextMessage) writeMessage(client *Client) error {
 	}
 	return client.Conn.WriteProto(&packet)
 }
+
+// SendTextMessage sends the text message to all of its recipients (Users,
+// Channels, and Trees) in a single packet. An error is returned if the message
+// has no recipients, or if it was dropped by Config.OutgoingTextMiddleware.
+func (c *Client) SendTextMessage(message *TextMessage) error {
+	if len(message.Users) == 0 && len(message.Channels) == 0 && len(message.Trees) == 0 {
+		return errors.New("gumble: text message has no recipients")
+	}
+	return message.writeMessage(c)
+}
@@ -0,0 +1,87 @@
+package gumble
+
+import (
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+	"github.com/golang/protobuf/proto"
+)
+
+// UploadProgress is called as a packet is written to the connection, with
+// the number of bytes of the packet that have been written so far, and the
+// size of the packet. It is called after each chunk of the packet is written,
+// the last time with written equal to total.
+//
+// It is called from the goroutine that writes to the connection, and should
+// thus return quickly.
+type UploadProgress func(written, total int)
+
+// WriteProtoProgress is like WriteProto, but calls progress as the message is
+// written to the connection. It is meant for large messages, such as textures
+// and comments of several hundred kilobytes.
+//
+// The message is written in chunks, with a write deadline per chunk, so a
+// slow connection does not time out while it is being written. The writer
+// goes back to the write queue between chunks; queued audio and pings are
+// written before the message and right after it, but not in the middle of it,
+// as Mumble packets cannot be split.
+//
+// progress is not called if the message is written as part of a Batch.
+func (c *Conn) WriteProtoProgress(message proto.Message, progress UploadProgress) error {
+	return c.writeProto(message, progress)
+}
+
+// writeChunks writes packet to the connection in chunks of
+// writeQueueBulkBytes, and calls progress, if not nil, after each chunk.
+func (c *Conn) writeChunks(packet []byte, progress UploadProgress) error {
+	for written := 0; written < len(packet); {
+		var err error
+		if written, err = c.writeChunk(packet, written, progress); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeChunk writes the chunk of packet that follows the first written bytes,
+// calls progress, if not nil, and returns the number of bytes of packet that
+// have been written.
+func (c *Conn) writeChunk(packet []byte, written int, progress UploadProgress) (int, error) {
+	chunk := packet[written:]
+	if len(chunk) > writeQueueBulkBytes {
+		chunk = chunk[:writeQueueBulkBytes]
+	}
+	if c.queue != nil {
+		c.Conn.SetWriteDeadline(time.Now().Add(c.Timeout))
+	}
+	n, err := c.Conn.Write(chunk)
+	written += n
+	if err != nil {
+		return written, err
+	}
+	if progress != nil {
+		progress(written, len(packet))
+	}
+	return written, nil
+}
+
+// SetTextureProgress is like SetTexture, but calls progress as the texture is
+// written to the connection (see Conn.WriteProtoProgress).
+func (u *User) SetTextureProgress(texture []byte, progress UploadProgress) error {
+	packet := MumbleProto.UserState{
+		Session: &u.Session,
+		Texture: texture,
+	}
+	return u.client.Conn.WriteProtoProgress(&packet, progress)
+}
+
+// SetCommentProgress is like SetComment, but calls progress as the comment is
+// written to the connection (see Conn.WriteProtoProgress).
+func (u *User) SetCommentProgress(comment string, progress UploadProgress) error {
+	u.sentComment.Store(comment)
+	packet := MumbleProto.UserState{
+		Session: &u.Session,
+		Comment: &comment,
+	}
+	return u.client.Conn.WriteProtoProgress(&packet, progress)
+}
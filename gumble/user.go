@@ -1,6 +1,12 @@
 package gumble
 
 import (
+	"bytes"
+	"crypto/sha1"
+	"math"
+	"sync/atomic"
+	"time"
+
 	"github.com/golang/protobuf/proto"
 	"github.com/bmmcginty/gumble/gumble/MumbleProto"
 "github.com/bmmcginty/go-openal/openal"
@@ -32,12 +38,21 @@ type User struct {
 	// Is the user recording audio?
 	Recording bool
 
+	// The channels that the user is listening to.
+	ListeningChannels Channels
+	// The volume adjustments that the user has applied to the channels they
+	// are listening to, keyed by channel ID. nil if no adjustments are known.
+	ListeningVolumes map[uint32]float32
+
 	// The user's comment. Contains the empty string if the user does not have a
 	// comment, or if the comment needs to be requested.
 	Comment string
 	// The user's comment hash. nil if User.Comment has been populated.
 	CommentHash []byte
-	// The hash of the user's certificate (can be empty).
+	// The hash of the user's certificate (can be empty), as a lowercase hex
+	// encoded SHA-1 hash of the DER certificate. The certificate itself is
+	// part of the user's stats (see RequestStats and
+	// UserStats.Certificates).
 	Hash string
 	// The user's texture (avatar). nil if the user does not have a
 	// texture, or if the texture needs to be requested.
@@ -50,6 +65,31 @@ type User struct {
 
 	client  *Client
 	decoder AudioDecoder
+	// Is the user logged in as SuperUser (user ID 0)?
+	superUser bool
+	// When audio was last received from the user.
+	lastAudio time.Time
+	// The loudness normalization state of the user's audio; nil until
+	// needed.
+	normalizer *normalizer
+	// Reorders the user's voice packets; nil until needed.
+	reorder *reorderBuffer
+	// When the user last sent audio or a text message (Unix nanoseconds),
+	// accessed atomically.
+	lastActivity int64
+	// Is the user idle (0 or 1)? Accessed atomically.
+	idle uint32
+	// The last comment set with SetComment (string), so that it is known
+	// when the server only sends its hash back.
+	sentComment atomic.Value
+	// Values attached with SetTag.
+	tags tags
+
+	// Local mute (0 or 1) and volume (math.Float32bits) preferences,
+	// accessed atomically so that they remain usable after the user has been
+	// removed from the client.
+	localMute   uint32
+	localVolume uint32
 
  AudioSource *openal.Source
 Boost uint16
@@ -60,6 +100,17 @@ func (u *User) GetClient() *Client {
 return u.client
 }
 
+// Version returns the version of the user's client, or nil if it is not known.
+// The version is part of the user's stats, so it is only known once they have
+// been received (see RequestStats).
+func (u *User) Version() *Version {
+	if u.Stats == nil || (u.Stats.Version.Version == 0 && u.Stats.Version.Release == "") {
+		return nil
+	}
+	version := u.Stats.Version
+	return &version
+}
+
 // SetTexture sets the user's texture.
 func (u *User) SetTexture(texture []byte) {
 	packet := MumbleProto.UserState{
@@ -94,7 +145,8 @@ func (u *User) IsRegistered() bool {
 }
 
 // Register will register the user with the server. If the client has
-// permission to do so, the user will shortly be given a UserID.
+// permission to do so, the user will shortly be given a UserID. See
+// RegisterWait to wait for the server's reply.
 func (u *User) Register() {
 	packet := MumbleProto.UserState{
 		Session: &u.Session,
@@ -103,9 +155,16 @@ func (u *User) Register() {
 	u.client.Conn.WriteProto(&packet)
 }
 
-// SetComment will set the user's comment to the given string. The user's
-// comment will be erased if the comment is set to the empty string.
+// SetComment will set the user's comment to the given string (which can
+// contain HTML). The user's comment will be erased if the comment is set to
+// the empty string. Setting the comment of another user requires the Move
+// permission in the root channel.
+//
+// Servers only send the hash of long comments. If that hash is the one of
+// comment, User.Comment is populated with comment instead, and a
+// UserChangeEvent with the UserChangeComment type is triggered as usual.
 func (u *User) SetComment(comment string) {
+	u.sentComment.Store(comment)
 	packet := MumbleProto.UserState{
 		Session: &u.Session,
 		Comment: &comment,
@@ -195,11 +254,13 @@ func (u *User) RequestStats() {
 	packet := MumbleProto.UserStats{
 		Session: &u.Session,
 	}
+	u.client.requestSent(RequestTypeUserStats, u.Session)
 	u.client.Conn.WriteProto(&packet)
 }
 
 // RequestTexture requests that the user's actual texture (i.e. non-hashed) be
-// sent to the client.
+// sent to the client. Textures in the client's BlobCache are used without
+// being requested, so TextureHash is only set if the texture is not cached.
 func (u *User) RequestTexture() {
 	packet := MumbleProto.RequestBlob{
 		SessionTexture: []uint32{u.Session},
@@ -207,8 +268,32 @@ func (u *User) RequestTexture() {
 	u.client.Conn.WriteProto(&packet)
 }
 
+// commentForHash returns the comment whose hash is hash, if the client knows
+// it: either the user's current comment, the one set with SetComment, or one
+// in the client's BlobCache.
+func (u *User) commentForHash(hash []byte) (string, bool) {
+	candidates := []string{u.Comment}
+	if sent, ok := u.sentComment.Load().(string); ok {
+		candidates = append(candidates, sent)
+	}
+	for _, comment := range candidates {
+		if comment == "" {
+			continue
+		}
+		sum := sha1.Sum([]byte(comment))
+		if bytes.Equal(sum[:], hash) {
+			return comment, true
+		}
+	}
+	if blob, ok := u.client.cachedBlob(hash); ok {
+		return string(blob), true
+	}
+	return "", false
+}
+
 // RequestComment requests that the user's actual comment (i.e. non-hashed) be
-// sent to the client.
+// sent to the client. Comments in the client's BlobCache are used without
+// being requested, so CommentHash is only set if the comment is not cached.
 func (u *User) RequestComment() {
 	packet := MumbleProto.RequestBlob{
 		SessionComment: []uint32{u.Session},
@@ -225,6 +310,140 @@ func (u *User) Send(message string) {
 	u.client.Send(&textMessage)
 }
 
+// ListenTo starts listening to the given channels. Audio spoken in a listened
+// channel is received as if the user were in the channel.
+//
+// This method should only be called on Client.Self().
+func (u *User) ListenTo(channels ...*Channel) {
+	packet := MumbleProto.UserState{
+		Session:             &u.Session,
+		ListeningChannelAdd: make([]uint32, len(channels)),
+	}
+	for i, channel := range channels {
+		packet.ListeningChannelAdd[i] = channel.ID
+	}
+	u.client.Conn.WriteProto(&packet)
+}
+
+// StopListeningTo stops listening to the given channels. If no arguments are
+// passed, the user stops listening to all channels.
+//
+// This method should only be called on Client.Self().
+func (u *User) StopListeningTo(channels ...*Channel) {
+	packet := MumbleProto.UserState{
+		Session: &u.Session,
+	}
+	if len(channels) == 0 {
+		packet.ListeningChannelRemove = make([]uint32, 0, len(u.ListeningChannels))
+		for channelID := range u.ListeningChannels {
+			packet.ListeningChannelRemove = append(packet.ListeningChannelRemove, channelID)
+		}
+	} else {
+		packet.ListeningChannelRemove = make([]uint32, len(channels))
+		for i, channel := range channels {
+			packet.ListeningChannelRemove[i] = channel.ID
+		}
+	}
+	u.client.Conn.WriteProto(&packet)
+}
+
+// SetListeningVolume sets the volume adjustment (a multiplier, where 1 is the
+// original volume) applied to the audio received from the given listened
+// channel.
+//
+// The adjustment is applied to the audio that the client receives through the
+// listened channel, i.e. from users in the channel while the client is in
+// another one.
+//
+// This method should only be called on Client.Self().
+func (u *User) SetListeningVolume(channel *Channel, volume float32) {
+	u.client.volatile.Lock()
+	if u.ListeningVolumes == nil {
+		u.ListeningVolumes = make(map[uint32]float32)
+	}
+	u.ListeningVolumes[channel.ID] = volume
+	u.client.volatile.Unlock()
+	packet := MumbleProto.UserState{
+		Session: &u.Session,
+		ListeningVolumeAdjustment: []*MumbleProto.UserState_VolumeAdjustment{
+			{
+				ListeningChannel: &channel.ID,
+				VolumeAdjustment: &volume,
+			},
+		},
+	}
+	u.client.Conn.WriteProto(&packet)
+}
+
+// ListeningVolume returns the volume adjustment applied to the audio received
+// from the given listened channel.
+func (u *User) ListeningVolume(channel *Channel) float32 {
+	if c := u.client; c != nil {
+		c.volatile.RLock()
+		defer c.volatile.RUnlock()
+	}
+	return u.listeningVolume(channel)
+}
+
+func (u *User) listeningVolume(channel *Channel) float32 {
+	if volume, ok := u.ListeningVolumes[channel.ID]; ok {
+		return volume
+	}
+	return 1
+}
+
+// SetLocalMute sets if the user's audio is muted locally. Audio from a locally
+// muted user is not delivered to any AudioListener. The server and other
+// users are not notified.
+func (u *User) SetLocalMute(mute bool) {
+	var value uint32
+	if mute {
+		value = 1
+	}
+	atomic.StoreUint32(&u.localMute, value)
+}
+
+// LocalMute returns if the user's audio is muted locally.
+func (u *User) LocalMute() bool {
+	return atomic.LoadUint32(&u.localMute) == 1
+}
+
+// SetLocalVolume sets the volume multiplier (where 1 is the original volume)
+// that is applied to the user's decoded audio before it is delivered to
+// AudioListeners. Encoded audio (see AudioListeners.AttachEncoded) is passed
+// through unmodified. The server and other users are not notified.
+func (u *User) SetLocalVolume(volume float32) {
+	if volume < 0 {
+		volume = 0
+	}
+	atomic.StoreUint32(&u.localVolume, math.Float32bits(volume))
+}
+
+// LocalVolume returns the user's local volume multiplier.
+func (u *User) LocalVolume() float32 {
+	return math.Float32frombits(atomic.LoadUint32(&u.localVolume))
+}
+
+// IdleSince returns when the user last sent audio or a text message. If the
+// user has done neither, the time at which the user became known to the
+// client is returned.
+func (u *User) IdleSince() time.Time {
+	return time.Unix(0, atomic.LoadInt64(&u.lastActivity))
+}
+
+// IsIdle returns true if the user has been idle for longer than
+// Config.UserIdleTimeout.
+func (u *User) IsIdle() bool {
+	return atomic.LoadUint32(&u.idle) == 1
+}
+
+// active records that the user has sent audio or a text message. It returns
+// true if the user was idle.
+func (u *User) active(now time.Time) bool {
+	atomic.StoreInt64(&u.lastActivity, now.UnixNano())
+	return atomic.CompareAndSwapUint32(&u.idle, 1, 0)
+}
+
 // SetPlugin sets the user's plugin data.
 //
 // Plugins are currently only used for positional audio. Clients will receive
@@ -1,5 +1,8 @@
 package gumble
 import(
+	"math"
+	"sort"
+	"time"
 //	"github.com/bmmcginty/go-openal/openal"
 )
 // Users is a map of server users.
@@ -14,7 +17,10 @@ type Users map[uint32]*User
 // with the given session already exists, it is overwritten.
 func (u Users) create(session uint32) *User {
 	user := &User{
-		Session: session,
+		Session:           session,
+		ListeningChannels: Channels{},
+		localVolume:       math.Float32bits(1),
+		lastActivity:      time.Now().UnixNano(),
 	}
 	u[session] = user
 	return user
@@ -30,3 +36,13 @@ func (u Users) Find(name string) *User {
 	}
 	return nil
 }
+
+// sorted returns the users of u, sorted by session.
+func (u Users) sorted() []*User {
+	users := make([]*User, 0, len(u))
+	for _, user := range u {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Session < users[j].Session })
+	return users
+}
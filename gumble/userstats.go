@@ -1,7 +1,10 @@
 package gumble
 
 import (
+	"crypto/sha1"
 	"crypto/x509"
+	"encoding/hex"
+	"errors"
 	"net"
 	"time"
 )
@@ -38,7 +41,9 @@ type UserStats struct {
 	Idle time.Duration
 	// How much bandwidth the user is current using.
 	Bandwidth int
-	// The user's certificate chain.
+	// The user's certificate chain, starting with the user's own
+	// certificate. The DER encoding of each certificate is available in its
+	// Raw field.
 	Certificates []*x509.Certificate
 	// Does the user have a strong certificate? A strong certificate is one that
 	// is not self signed, nor expired, etc.
@@ -60,3 +65,37 @@ type UserStatsUDP struct {
 	Lost   uint32
 	Resync uint32
 }
+
+// CertificateHash returns the hash of the user's certificate, in the same
+// format as User.Hash (the hex encoded SHA-1 hash of the DER certificate), or
+// the empty string if the user has no certificate.
+//
+// A CertificateHash that differs from User.Hash means that the certificate
+// sent by the server is not the one the user connected with.
+func (s *UserStats) CertificateHash() string {
+	if len(s.Certificates) == 0 {
+		return ""
+	}
+	sum := sha1.Sum(s.Certificates[0].Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyCertificate verifies the user's certificate chain. Unless
+// opts.Intermediates is set, the rest of the chain is used as intermediates.
+// Unless opts.KeyUsages is set, any key usage is accepted. See
+// x509.Certificate.Verify.
+func (s *UserStats) VerifyCertificate(opts x509.VerifyOptions) ([][]*x509.Certificate, error) {
+	if len(s.Certificates) == 0 {
+		return nil, errors.New("gumble: user has no certificate")
+	}
+	if opts.Intermediates == nil {
+		opts.Intermediates = x509.NewCertPool()
+		for _, cert := range s.Certificates[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+	}
+	if opts.KeyUsages == nil {
+		opts.KeyUsages = []x509.ExtKeyUsage{x509.ExtKeyUsageAny}
+	}
+	return s.Certificates[0].Verify(opts)
+}
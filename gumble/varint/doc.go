@@ -1 +1,19 @@
+// Package varint encodes and decodes the variable-length integers of Mumble
+// voice packets.
+//
+// The first byte of a varint determines its length and form:
+//
+//  0xxxxxxx                     7-bit positive number
+//  10xxxxxx + 1 byte            14-bit positive number
+//  110xxxxx + 2 bytes           21-bit positive number
+//  1110xxxx + 3 bytes           28-bit positive number
+//  111100__ + int (32-bit)      32-bit positive number
+//  111101__ + long (64-bit)     64-bit number
+//  111110__ + varint            negative number: the bitwise negation (~) of
+//                               the varint that follows
+//  111111xx                     negative two bit number (~xx, i.e. -1 to -4)
+//
+// Multi-byte numbers are big-endian. As in the official implementation, a
+// negative number is only encoded in the negated forms when its negation fits
+// in 32 bits; smaller numbers use the 64-bit form.
 package varint // import "github.com/bmmcginty/gumble/gumble/varint"
@@ -39,7 +39,7 @@ func Decode(b []byte) (int64, int) {
 	// 111110__ + varint Negative recursive varint
 	if b[0]&0xFC == 0xF8 {
 		if v, n := Decode(b[1:]); n > 0 {
-			return -v, n + 1
+			return ^v, n + 1
 		}
 		return 0, 0
 	}
@@ -1,6 +1,10 @@
 package varint // import "github.com/bmmcginty/gumble/gumble/varint"
 
-import "testing"
+import (
+	"bytes"
+	"math"
+	"testing"
+)
 
 func TestRange(t *testing.T) {
 
@@ -29,4 +33,65 @@ func TestRange(t *testing.T) {
 	fn(134342525)
 	fn(10282934828342)
 	fn(1028293482834200000)
+	fn(-1028293482834200000)
+	fn(math.MaxInt64)
+	fn(math.MinInt64)
+}
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		Value   int64
+		Encoded []byte
+	}{
+		{0, []byte{0x00}},
+		{0x7F, []byte{0x7F}},
+		{0x80, []byte{0x80, 0x80}},
+		{0x3FFF, []byte{0xBF, 0xFF}},
+		{0x4000, []byte{0xC0, 0x40, 0x00}},
+		{0x1FFFFF, []byte{0xDF, 0xFF, 0xFF}},
+		{0x200000, []byte{0xE0, 0x20, 0x00, 0x00}},
+		{0xFFFFFFF, []byte{0xEF, 0xFF, 0xFF, 0xFF}},
+		{0x10000000, []byte{0xF0, 0x10, 0x00, 0x00, 0x00}},
+		{0xFFFFFFFF, []byte{0xF0, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{0x100000000, []byte{0xF4, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00}},
+		{math.MaxInt64, []byte{0xF4, 0x7F, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{-1, []byte{0xFC}},
+		{-4, []byte{0xFF}},
+		// ~4
+		{-5, []byte{0xF8, 0x04}},
+		// ~0x80
+		{-0x81, []byte{0xF8, 0x80, 0x80}},
+		// ~0xFFFFFFFF
+		{-0x100000000, []byte{0xF8, 0xF0, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{-0x100000001, []byte{0xF4, 0xFF, 0xFF, 0xFF, 0xFE, 0xFF, 0xFF, 0xFF, 0xFF}},
+		{math.MinInt64, []byte{0xF4, 0x80, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00}},
+	}
+	for _, test := range tests {
+		if size := Size(test.Value); size != len(test.Encoded) {
+			t.Errorf("Size(%d) = %d, expected %d\n", test.Value, size, len(test.Encoded))
+		}
+		if encoded := Append(nil, test.Value); !bytes.Equal(encoded, test.Encoded) {
+			t.Errorf("%d encoded to %x, expected %x\n", test.Value, encoded, test.Encoded)
+		}
+		if value, n := Decode(test.Encoded); value != test.Value || n != len(test.Encoded) {
+			t.Errorf("%x decoded to (%d, %d), expected (%d, %d)\n", test.Encoded, value, n, test.Value, len(test.Encoded))
+		}
+		if n := Encode(make([]byte, len(test.Encoded)-1), test.Value); n != 0 {
+			t.Errorf("Encode of %d to a short buffer returned %d\n", test.Value, n)
+		}
+		if _, n := Decode(test.Encoded[:len(test.Encoded)-1]); n != 0 {
+			t.Errorf("Decode of truncated %x returned %d\n", test.Encoded, n)
+		}
+	}
+}
+
+func TestDecodeRecursive(t *testing.T) {
+	// ~(~5)
+	if value, n := Decode([]byte{0xF8, 0xF8, 0x05}); value != 5 || n != 3 {
+		t.Errorf("recursive negation decoded to (%d, %d)\n", value, n)
+	}
+	// ~(-1)
+	if value, n := Decode([]byte{0xF8, 0xFC}); value != 0 || n != 2 {
+		t.Errorf("negated two bit number decoded to (%d, %d)\n", value, n)
+	}
 }
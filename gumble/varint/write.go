@@ -2,63 +2,106 @@ package varint
 
 import (
 	"encoding/binary"
-	"math"
 )
 
 // MaxVarintLen is the maximum number of bytes required to encode a varint
 // number.
 const MaxVarintLen = 10
 
-// Encode encodes the given value to varint format.
-func Encode(b []byte, value int64) int {
-	// 111111xx Byte-inverted negative two bit number (~xx)
-	if value <= -1 && value >= -4 {
-		b[0] = 0xFC | byte(^value&0xFF)
+// Size returns the number of bytes that Encode uses to encode value.
+func Size(value int64) int {
+	if value < 0 && ^value < 0x100000000 {
+		// 111111xx Byte-inverted negative two bit number (~xx)
+		if ^value <= 0x3 {
+			return 1
+		}
+		// 111110__ + varint Negative recursive varint
+		return 1 + Size(^value)
+	}
+	switch {
+	case value < 0:
+		return 9
+	case value < 0x80:
 		return 1
+	case value < 0x4000:
+		return 2
+	case value < 0x200000:
+		return 3
+	case value < 0x10000000:
+		return 4
+	case value < 0x100000000:
+		return 5
 	}
-	// 111110__ + varint Negative recursive varint
-	if value < 0 {
+	return 9
+}
+
+// Encode encodes the given value to varint format, into b.
+//
+// The function returns the number of bytes written, or 0 if b is shorter than
+// Size(value).
+func Encode(b []byte, value int64) int {
+	n := Size(value)
+	if len(b) < n {
+		return 0
+	}
+	put(b, value)
+	return n
+}
+
+// Append appends the varint encoding of value to b, and returns the extended
+// buffer.
+func Append(b []byte, value int64) []byte {
+	var buf [MaxVarintLen]byte
+	n := Encode(buf[:], value)
+	return append(b, buf[:n]...)
+}
+
+// put encodes value into b, which must have room for Size(value) bytes.
+func put(b []byte, value int64) {
+	if value < 0 && ^value < 0x100000000 {
+		// 111111xx Byte-inverted negative two bit number (~xx)
+		if ^value <= 0x3 {
+			b[0] = 0xFC | byte(^value)
+			return
+		}
+		// 111110__ + varint Negative recursive varint
 		b[0] = 0xF8
-		return 1 + Encode(b[1:], -value)
+		put(b[1:], ^value)
+		return
 	}
-	// 0xxxxxxx 7-bit positive number
-	if value <= 0x7F {
+	switch {
+	case value < 0:
+		// Negative numbers whose negation does not fit in 32 bits use the
+		// 64-bit form below.
+	case value < 0x80:
+		// 0xxxxxxx 7-bit positive number
 		b[0] = byte(value)
-		return 1
-	}
-	// 10xxxxxx + 1 byte 14-bit positive number
-	if value <= 0x3FFF {
+		return
+	case value < 0x4000:
+		// 10xxxxxx + 1 byte 14-bit positive number
 		b[0] = byte(((value >> 8) & 0x3F) | 0x80)
 		b[1] = byte(value & 0xFF)
-		return 2
-	}
-	// 110xxxxx + 2 bytes 21-bit positive number
-	if value <= 0x1FFFFF {
+		return
+	case value < 0x200000:
+		// 110xxxxx + 2 bytes 21-bit positive number
 		b[0] = byte((value>>16)&0x1F | 0xC0)
 		b[1] = byte((value >> 8) & 0xFF)
 		b[2] = byte(value & 0xFF)
-		return 3
-	}
-	// 1110xxxx + 3 bytes 28-bit positive number
-	if value <= 0xFFFFFFF {
+		return
+	case value < 0x10000000:
+		// 1110xxxx + 3 bytes 28-bit positive number
 		b[0] = byte((value>>24)&0xF | 0xE0)
 		b[1] = byte((value >> 16) & 0xFF)
 		b[2] = byte((value >> 8) & 0xFF)
 		b[3] = byte(value & 0xFF)
-		return 4
-	}
-	// 111100__ + int (32-bit) 32-bit positive number
-	if value <= math.MaxInt32 {
+		return
+	case value < 0x100000000:
+		// 111100__ + int (32-bit) 32-bit positive number
 		b[0] = 0xF0
 		binary.BigEndian.PutUint32(b[1:], uint32(value))
-		return 5
+		return
 	}
 	// 111101__ + long (64-bit) 64-bit number
-	if value <= math.MaxInt64 {
-		b[0] = 0xF4
-		binary.BigEndian.PutUint64(b[1:], uint64(value))
-		return 9
-	}
-
-	return 0
+	b[0] = 0xF4
+	binary.BigEndian.PutUint64(b[1:], uint64(value))
 }
@@ -1,18 +1,22 @@
 package gumble
 
+import (
+	"strconv"
+)
+
 // Version represents a Mumble client or server version.
 type Version struct {
 	// The semantic version information as a single unsigned integer.
 	//
-	// Bits 0-15 are the major version, bits 16-23 are the minor version, and
-	// bits 24-31 are the patch version.
-	Version uint32
+	// Bits 16-31 are the major version, bits 8-15 are the minor version, and
+	// bits 0-7 are the patch version (see VersionNumber).
+	Version uint32 `json:"version"`
 	// The name of the client.
-	Release string
+	Release string `json:"release"`
 	// The operating system name.
-	OS string
+	OS string `json:"os"`
 	// The operating system version.
-	OSVersion string
+	OSVersion string `json:"os_version"`
 }
 
 // SemanticVersion returns the version's semantic version components.
@@ -22,3 +26,39 @@ func (v *Version) SemanticVersion() (major uint16, minor, patch uint8) {
 	patch = uint8(v.Version) & 0xFF
 	return
 }
+
+// VersionNumber returns the single integer form of a semantic version, as used
+// in Version.Version.
+func VersionNumber(major uint16, minor, patch uint8) uint32 {
+	return uint32(major)<<16 | uint32(minor)<<8 | uint32(patch)
+}
+
+// Compare returns -1, 0 or 1 if the version is respectively older than, the
+// same as, or newer than the given semantic version.
+func (v *Version) Compare(major uint16, minor, patch uint8) int {
+	other := VersionNumber(major, minor, patch)
+	switch {
+	case v.Version < other:
+		return -1
+	case v.Version > other:
+		return 1
+	}
+	return 0
+}
+
+// AtLeast returns true if the version is the same as, or newer than, the given
+// semantic version.
+func (v *Version) AtLeast(major uint16, minor, patch uint8) bool {
+	return v.Compare(major, minor, patch) >= 0
+}
+
+// String returns the version's semantic version (e.g. "1.4.0"), followed by
+// its release name, if it has one.
+func (v *Version) String() string {
+	major, minor, patch := v.SemanticVersion()
+	s := strconv.Itoa(int(major)) + "." + strconv.Itoa(int(minor)) + "." + strconv.Itoa(int(patch))
+	if v.Release != "" {
+		s += " (" + v.Release + ")"
+	}
+	return s
+}
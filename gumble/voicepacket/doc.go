@@ -0,0 +1,15 @@
+// Package voicepacket encodes and decodes Mumble voice packets.
+//
+// Voice packets are sent through the control channel inside of UDPTunnel
+// messages (and, in the official client, directly over UDP). Each packet has
+// the following layout:
+//
+//  header   1 byte; the top 3 bits are the codec type, the bottom 5 the target
+//  session  varint; only present in packets sent by the server
+//  sequence varint
+//  length   varint; the 14th bit (0x2000) marks the final packet of a stream
+//  data     length bytes of encoded audio
+//  position optional; three little-endian float32 values (X, Y, Z)
+//
+// Only the Opus framing is supported.
+package voicepacket // import "github.com/bmmcginty/gumble/gumble/voicepacket"
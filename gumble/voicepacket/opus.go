@@ -0,0 +1,54 @@
+package voicepacket
+
+// OpusMaxPacketSamples is the largest number of samples (per channel, at
+// 48 kHz) that an Opus packet can contain: 120ms of audio.
+const OpusMaxPacketSamples = 48000 / 1000 * 120
+
+// opusFrameSamples is the number of samples in a frame of each Opus
+// configuration (the top 5 bits of the TOC byte), at 48 kHz. See RFC 6716,
+// section 3.1.
+var opusFrameSamples = [32]int{
+	// SILK-only: 10, 20, 40 and 60ms
+	480, 960, 1920, 2880,
+	480, 960, 1920, 2880,
+	480, 960, 1920, 2880,
+	// Hybrid: 10 and 20ms
+	480, 960,
+	480, 960,
+	// CELT-only: 2.5, 5, 10 and 20ms
+	120, 240, 480, 960,
+	120, 240, 480, 960,
+	120, 240, 480, 960,
+	120, 240, 480, 960,
+}
+
+// OpusFrames returns the number of frames that the Opus packet data contains
+// (senders may put several frames in a packet, e.g. to reduce overhead), and
+// the number of samples (per channel, at 48 kHz) to which each frame decodes.
+// All the frames of an Opus packet have the same duration.
+//
+// An empty packet, which clients send to signal a gap in the audio, contains
+// no frames. ErrInvalidPacket is returned if the packet's table of contents
+// is invalid.
+func OpusFrames(data []byte) (frames, frameSamples int, err error) {
+	if len(data) == 0 {
+		return 0, 0, nil
+	}
+	toc := data[0]
+	frameSamples = opusFrameSamples[toc>>3]
+	switch toc & 0x3 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(data) < 2 {
+			return 0, 0, ErrInvalidPacket
+		}
+		frames = int(data[1] & 0x3F)
+		if frames == 0 || frames*frameSamples > OpusMaxPacketSamples {
+			return 0, 0, ErrInvalidPacket
+		}
+	}
+	return frames, frameSamples, nil
+}
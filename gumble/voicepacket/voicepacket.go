@@ -0,0 +1,184 @@
+package voicepacket
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+
+	"github.com/bmmcginty/gumble/gumble/varint"
+)
+
+// Voice packet codec types.
+const (
+	TypeCELTAlpha = 0
+	TypePing      = 1
+	TypeSpeex     = 2
+	TypeCELTBeta  = 3
+	TypeOpus      = 4
+)
+
+// Special voice packet targets.
+const (
+	// TargetNormal is the target used for regular talking.
+	TargetNormal = 0
+	// TargetLoopback is the target that causes the server to send the audio
+	// back to its sender.
+	TargetLoopback = 31
+)
+
+const (
+	// MaxTarget is the largest valid target value.
+	MaxTarget = 0x1F
+	// MaxDataLength is the largest number of audio bytes that a single Opus
+	// voice packet can contain.
+	MaxDataLength = 0x1FFF
+
+	terminator = 0x2000
+
+	positionalBytes = 3 * 4
+)
+
+var (
+	// ErrShortPacket is returned when a packet is too short to be decoded.
+	ErrShortPacket = errors.New("voicepacket: packet too short")
+	// ErrInvalidPacket is returned when a packet contains invalid data.
+	ErrInvalidPacket = errors.New("voicepacket: invalid packet")
+	// ErrUnsupportedType is returned when handling a packet whose codec type
+	// is not supported.
+	ErrUnsupportedType = errors.New("voicepacket: unsupported packet type")
+)
+
+// Packet is a single voice packet.
+type Packet struct {
+	// Codec type of the packet (e.g. TypeOpus).
+	Type byte
+	// Voice target of the packet. Must be in the range [0, MaxTarget].
+	Target byte
+	// Session of the user that spoke the audio. Only encoded or decoded when
+	// the withSession argument of Encode and Decode is true.
+	Session uint32
+	// Sequence number of the packet.
+	Sequence int64
+	// Encoded audio data.
+	Data []byte
+	// Is this the final packet of the audio stream?
+	Final bool
+
+	// Does the packet contain positional audio information?
+	HasPosition bool
+	X, Y, Z     float32
+}
+
+// Encode returns the encoded form of p. If withSession is true, p.Session is
+// written to the packet; this is the form of packets that are sent from the
+// server to a client.
+func Encode(p *Packet, withSession bool) ([]byte, error) {
+	if p.Type != TypeOpus {
+		return nil, ErrUnsupportedType
+	}
+	if p.Target > MaxTarget || len(p.Data) > MaxDataLength {
+		return nil, ErrInvalidPacket
+	}
+
+	size := 1 + varint.MaxVarintLen*3 + len(p.Data)
+	if p.HasPosition {
+		size += positionalBytes
+	}
+	b := make([]byte, size)
+
+	b[0] = p.Type<<5 | p.Target
+	n := 1
+	if withSession {
+		m := varint.Encode(b[n:], int64(p.Session))
+		if m == 0 {
+			return nil, ErrInvalidPacket
+		}
+		n += m
+	}
+	m := varint.Encode(b[n:], p.Sequence)
+	if m == 0 {
+		return nil, ErrInvalidPacket
+	}
+	n += m
+	length := int64(len(p.Data))
+	if p.Final {
+		length |= terminator
+	}
+	m = varint.Encode(b[n:], length)
+	if m == 0 {
+		return nil, ErrInvalidPacket
+	}
+	n += m
+	n += copy(b[n:], p.Data)
+
+	if p.HasPosition {
+		binary.LittleEndian.PutUint32(b[n:], math.Float32bits(p.X))
+		binary.LittleEndian.PutUint32(b[n+4:], math.Float32bits(p.Y))
+		binary.LittleEndian.PutUint32(b[n+8:], math.Float32bits(p.Z))
+		n += positionalBytes
+	}
+
+	return b[:n], nil
+}
+
+// Decode decodes the voice packet contained in b. If withSession is true, the
+// packet is expected to contain the session of the user that spoke the audio.
+//
+// The Data field of the returned packet references b.
+func Decode(b []byte, withSession bool) (*Packet, error) {
+	if len(b) < 1 {
+		return nil, ErrShortPacket
+	}
+	p := &Packet{
+		Type:   (b[0] >> 5) & 0x7,
+		Target: b[0] & 0x1F,
+	}
+	if p.Type != TypeOpus {
+		return nil, ErrUnsupportedType
+	}
+	b = b[1:]
+
+	if withSession {
+		session, n := varint.Decode(b)
+		if n <= 0 {
+			return nil, ErrShortPacket
+		}
+		if session < 0 || session > math.MaxUint32 {
+			return nil, ErrInvalidPacket
+		}
+		p.Session = uint32(session)
+		b = b[n:]
+	}
+
+	sequence, n := varint.Decode(b)
+	if n <= 0 {
+		return nil, ErrShortPacket
+	}
+	p.Sequence = sequence
+	b = b[n:]
+
+	length, n := varint.Decode(b)
+	if n <= 0 {
+		return nil, ErrShortPacket
+	}
+	if length < 0 || length > terminator|MaxDataLength {
+		return nil, ErrInvalidPacket
+	}
+	b = b[n:]
+	p.Final = length&terminator != 0
+	dataLength := int(length & MaxDataLength)
+	if dataLength > len(b) {
+		return nil, ErrShortPacket
+	}
+	p.Data = b[:dataLength]
+	b = b[dataLength:]
+
+	if len(b) == positionalBytes {
+		p.X = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		p.Y = math.Float32frombits(binary.LittleEndian.Uint32(b[4:]))
+		p.Z = math.Float32frombits(binary.LittleEndian.Uint32(b[8:]))
+		p.HasPosition = true
+	}
+
+	return p, nil
+}
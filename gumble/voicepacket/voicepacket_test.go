@@ -0,0 +1,160 @@
+package voicepacket // import "github.com/bmmcginty/gumble/gumble/voicepacket"
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestRoundTrip(t *testing.T) {
+	packets := []*Packet{
+		{
+			Type: TypeOpus,
+		},
+		{
+			Type:     TypeOpus,
+			Target:   TargetLoopback,
+			Session:  3,
+			Sequence: 1234,
+			Data:     []byte{1, 2, 3, 4, 5},
+		},
+		{
+			Type:     TypeOpus,
+			Target:   7,
+			Session:  0xFFFFFFFF,
+			Sequence: 0x7FFFFFFF,
+			Data:     bytes.Repeat([]byte{0xAB}, MaxDataLength),
+			Final:    true,
+		},
+		{
+			Type:        TypeOpus,
+			Session:     200,
+			Sequence:    -2,
+			Data:        []byte{9, 9},
+			HasPosition: true,
+			X:           1.5,
+			Y:           -2.25,
+			Z:           1000,
+		},
+	}
+
+	for _, withSession := range []bool{false, true} {
+		for i, p := range packets {
+			b, err := Encode(p, withSession)
+			if err != nil {
+				t.Fatalf("packet %d: Encode returned %s\n", i, err)
+			}
+			d, err := Decode(b, withSession)
+			if err != nil {
+				t.Fatalf("packet %d: Decode returned %s\n", i, err)
+			}
+			expected := *p
+			if !withSession {
+				expected.Session = 0
+			}
+			if !bytes.Equal(d.Data, expected.Data) {
+				t.Errorf("packet %d: decoded data %v, expected %v\n", i, d.Data, expected.Data)
+			}
+			d.Data, expected.Data = nil, nil
+			if !reflect.DeepEqual(*d, expected) {
+				t.Errorf("packet %d: decoded %+v, expected %+v\n", i, *d, expected)
+			}
+		}
+	}
+}
+
+func TestEncodeBytes(t *testing.T) {
+	p := &Packet{
+		Type:     TypeOpus,
+		Target:   2,
+		Session:  5,
+		Sequence: 300,
+		Data:     []byte{0xAA, 0xBB},
+		Final:    true,
+	}
+	b, err := Encode(p, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []byte{
+		0x82,       // opus, target 2
+		0x05,       // session
+		0x81, 0x2C, // sequence 300
+		0xA0, 0x02, // length 2 | terminator
+		0xAA, 0xBB,
+	}
+	if !bytes.Equal(b, expected) {
+		t.Errorf("encoded %v, expected %v\n", b, expected)
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		Data        []byte
+		WithSession bool
+		Err         error
+	}{
+		{nil, false, ErrShortPacket},
+		{[]byte{0x20}, false, ErrUnsupportedType},
+		{[]byte{0x80}, false, ErrShortPacket},
+		{[]byte{0x80, 0x01}, true, ErrShortPacket},
+		{[]byte{0x80, 0x01, 0x05, 0x01}, false, ErrShortPacket},
+		{[]byte{0x80, 0x01, 0xFC}, false, ErrInvalidPacket},
+		{[]byte{0x80, 0x01, 0xC0, 0x40, 0x00}, false, ErrInvalidPacket},
+		{[]byte{0x80, 0xFC, 0x00, 0x00}, true, ErrInvalidPacket},
+	}
+	for i, test := range tests {
+		if _, err := Decode(test.Data, test.WithSession); err != test.Err {
+			t.Errorf("test %d: Decode returned %v, expected %v\n", i, err, test.Err)
+		}
+	}
+}
+
+func TestEncodeErrors(t *testing.T) {
+	tests := []struct {
+		Packet *Packet
+		Err    error
+	}{
+		{&Packet{Type: TypeCELTAlpha}, ErrUnsupportedType},
+		{&Packet{Type: TypeOpus, Target: MaxTarget + 1}, ErrInvalidPacket},
+		{&Packet{Type: TypeOpus, Data: make([]byte, MaxDataLength+1)}, ErrInvalidPacket},
+	}
+	for i, test := range tests {
+		if _, err := Encode(test.Packet, false); err != test.Err {
+			t.Errorf("test %d: Encode returned %v, expected %v\n", i, err, test.Err)
+		}
+	}
+}
+
+func TestOpusFrames(t *testing.T) {
+	tests := []struct {
+		Data         []byte
+		Frames       int
+		FrameSamples int
+		Err          error
+	}{
+		{nil, 0, 0, nil},
+		// one 20ms CELT frame
+		{[]byte{31<<3 | 0, 0xAA}, 1, 960, nil},
+		// two equal 10ms SILK frames
+		{[]byte{0<<3 | 1, 0xAA, 0xBB}, 2, 480, nil},
+		// two 20ms hybrid frames of different sizes
+		{[]byte{13<<3 | 2, 0x01, 0xAA, 0xBB}, 2, 960, nil},
+		// three 40ms SILK frames (120ms)
+		{[]byte{2<<3 | 3, 0x03, 0xAA, 0xBB, 0xCC}, 3, 1920, nil},
+		// six 20ms CELT frames, with padding and variable sizes
+		{[]byte{31<<3 | 3, 0xC6, 0x00, 0x01, 0x01, 0x01, 0x01, 0x01, 0xAA}, 6, 960, nil},
+		// four 60ms SILK frames (240ms) is too long
+		{[]byte{3<<3 | 3, 0x04, 0xAA}, 0, 0, ErrInvalidPacket},
+		// frame count missing
+		{[]byte{31<<3 | 3}, 0, 0, ErrInvalidPacket},
+		// zero frames
+		{[]byte{31<<3 | 3, 0x00}, 0, 0, ErrInvalidPacket},
+	}
+	for i, test := range tests {
+		frames, frameSamples, err := OpusFrames(test.Data)
+		if frames != test.Frames || frameSamples != test.FrameSamples || err != test.Err {
+			t.Errorf("test %d: OpusFrames returned (%d, %d, %v), expected (%d, %d, %v)\n", i, frames, frameSamples, err, test.Frames, test.FrameSamples, test.Err)
+		}
+	}
+}
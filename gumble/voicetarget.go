@@ -50,6 +50,35 @@ func (v *VoiceTarget) AddChannel(channel *Channel, recursive, links bool, group
 	})
 }
 
+// Users returns the users that are part of the voice target.
+func (v *VoiceTarget) Users() []*User {
+	users := make([]*User, len(v.users))
+	copy(users, v.users)
+	return users
+}
+
+// VoiceTargetChannel is a channel that is part of a VoiceTarget. See
+// VoiceTarget.AddChannel for a description of its fields.
+type VoiceTargetChannel struct {
+	Channel          *Channel
+	Recursive, Links bool
+	Group            string
+}
+
+// Channels returns the channels that are part of the voice target.
+func (v *VoiceTarget) Channels() []VoiceTargetChannel {
+	channels := make([]VoiceTargetChannel, len(v.channels))
+	for i, vtChannel := range v.channels {
+		channels[i] = VoiceTargetChannel{
+			Channel:   vtChannel.channel,
+			Recursive: vtChannel.recursive,
+			Links:     vtChannel.links,
+			Group:     vtChannel.group,
+		}
+	}
+	return channels
+}
+
 func (v *VoiceTarget) writeMessage(client *Client) error {
 	packet := MumbleProto.VoiceTarget{
 		Id:      &v.ID,
@@ -0,0 +1,85 @@
+package gumble
+
+import (
+	"context"
+	"errors"
+)
+
+// WaitForSync waits until the client has received the server's initial state
+// (i.e. its state is StateSynced). An error is returned if ctx is done, or the
+// client disconnects, first.
+//
+// Like the other Wait functions, WaitForSync must not be called from an event
+// listener, or from inside of Client.Do, as the events it waits for would
+// never be processed.
+func (c *Client) WaitForSync(ctx context.Context) error {
+	return c.waitFor(ctx, c.isSynced)
+}
+
+// WaitForUser waits until a user with the given name is connected to the
+// server, and returns it. See WaitForSync.
+func (c *Client) WaitForUser(ctx context.Context, name string) (*User, error) {
+	var user *User
+	err := c.waitFor(ctx, func() bool {
+		user = c.Users.Find(name)
+		return user != nil
+	})
+	return user, err
+}
+
+// WaitForChannel waits until the channel with the given path (by channel name)
+// from the root channel exists, and returns it (see Channels.Find). See
+// WaitForSync.
+func (c *Client) WaitForChannel(ctx context.Context, names ...string) (*Channel, error) {
+	var channel *Channel
+	err := c.waitFor(ctx, func() bool {
+		channel = c.Channels.Find(names...)
+		return channel != nil
+	})
+	return channel, err
+}
+
+// waitFor calls check, with the volatile lock held, each time an event is
+// triggered, until check returns true.
+func (c *Client) waitFor(ctx context.Context, check func() bool) error {
+	for {
+		changed := c.changed()
+		c.volatile.RLock()
+		ok := check()
+		c.volatile.RUnlock()
+		if ok {
+			return nil
+		}
+		if c.State() == StateDisconnected {
+			return errors.New("gumble: client disconnected")
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-c.end:
+			return errors.New("gumble: client disconnected")
+		case <-changed:
+		}
+	}
+}
+
+// changed returns a channel that is closed the next time an event is
+// triggered.
+func (c *Client) changed() <-chan struct{} {
+	c.waitLock.Lock()
+	defer c.waitLock.Unlock()
+	if c.waitChanged == nil {
+		c.waitChanged = make(chan struct{})
+	}
+	return c.waitChanged
+}
+
+// notifyWaiters wakes up the calls to waitFor.
+func (c *Client) notifyWaiters() {
+	c.waitLock.Lock()
+	if c.waitChanged != nil {
+		close(c.waitChanged)
+		c.waitChanged = nil
+	}
+	c.waitLock.Unlock()
+}
@@ -0,0 +1,254 @@
+package gumble
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"time"
+)
+
+// ErrWriteQueueFull is returned by Conn's write functions when an audio or
+// ping packet is refused, as more than Conn.MaximumQueuedBytes of audio and
+// ping packets are waiting to be written to the connection.
+var ErrWriteQueueFull = errors.New("gumble: write queue is full")
+
+var errWriteQueueClosed = errors.New("gumble: connection is closed")
+
+// writeQueueFlushTimeout is how long closing a Conn waits for the queued
+// packets to be written.
+const writeQueueFlushTimeout = time.Second
+
+// writeQueueBulkBytes is the number of bytes of control packets that the
+// writer writes at once, before it checks for priority packets again. Larger
+// packets are written alone, in chunks of this size (see writeChunk).
+const writeQueueBulkBytes = 16 * 1024
+
+// queuedPacket is a control packet of a writeQueue.
+type queuedPacket struct {
+	data     []byte
+	progress UploadProgress
+}
+
+// large returns true if the packet must be written alone, in chunks.
+func (p *queuedPacket) large() bool {
+	return len(p.data) > writeQueueBulkBytes || p.progress != nil
+}
+
+// writeQueue holds the packets that are waiting to be written to a Conn by
+// its writer goroutine.
+//
+// Tunneled audio and pings are queued apart from the other (control)
+// packets, and are written first, so that bulk control traffic (e.g. an ACL,
+// or a texture) does not delay them. The order of the packets of each queue
+// is kept.
+type writeQueue struct {
+	mu      sync.Mutex
+	cond    sync.Cond
+	packets []queuedPacket
+	// The queued audio and ping packets.
+	priority      net.Buffers
+	priorityBytes int
+	closing       bool
+	err           error
+	// While a Batch is collected, the packets are appended to batch instead
+	// of being queued.
+	batching bool
+	batch    []byte
+
+	done chan struct{}
+}
+
+// startWriter makes the writes to c asynchronous: packets are queued, and
+// written to the connection by a new goroutine, so that a slow connection
+// does not block the goroutines that send packets (e.g. the client's read
+// goroutine, from which event listeners are called).
+func (c *Conn) startWriter() {
+	q := &writeQueue{
+		done: make(chan struct{}),
+	}
+	q.cond.L = &q.mu
+	c.queue = q
+	go c.writeRoutine()
+}
+
+// isPriorityPacket returns true if packets of the given type are written
+// before the control packets: tunneled audio (UDPTunnel) and pings.
+func isPriorityPacket(ptype uint16) bool {
+	return ptype == 1 || ptype == 3
+}
+
+// queuePacket adds a packet of the given type, consisting of its header and
+// data, to the write queue. progress, if not nil, is called as the packet is
+// written (see UploadProgress).
+//
+// Conn.MaximumQueuedBytes limits the bytes of the queued priority packets,
+// which are dropped when the connection cannot keep up. Control packets are
+// always queued, as the client's state depends on them.
+func (c *Conn) queuePacket(ptype uint16, header, data []byte, progress UploadProgress) error {
+	q := c.queue
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.err != nil {
+		return q.err
+	}
+	if q.closing {
+		return errWriteQueueClosed
+	}
+	size := len(header) + len(data)
+	priority := isPriorityPacket(ptype) && !q.batching && progress == nil
+	if priority && c.MaximumQueuedBytes > 0 && q.priorityBytes > 0 && q.priorityBytes+size > c.MaximumQueuedBytes {
+		return ErrWriteQueueFull
+	}
+	if q.batching {
+		q.batch = append(q.batch, header...)
+		q.batch = append(q.batch, data...)
+		return nil
+	}
+	packet := make([]byte, 0, size)
+	packet = append(packet, header...)
+	packet = append(packet, data...)
+	if priority {
+		q.priority = append(q.priority, packet)
+		q.priorityBytes += size
+	} else {
+		q.packets = append(q.packets, queuedPacket{
+			data:     packet,
+			progress: progress,
+		})
+	}
+	q.cond.Signal()
+	return nil
+}
+
+// beginBatch starts collecting the queued packets into a batch, which
+// endBatch queues as a single buffer. If the writes to c are not queued,
+// packets are written as they are sent.
+func (c *Conn) beginBatch() {
+	if q := c.queue; q != nil {
+		q.mu.Lock()
+		q.batching = true
+		q.mu.Unlock()
+	}
+}
+
+// endBatch queues the packets collected since beginBatch. The batch is queued
+// with the control packets, even if it only holds priority packets, as its
+// packets must not be reordered.
+func (c *Conn) endBatch() error {
+	q := c.queue
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	batch := q.batch
+	q.batching = false
+	q.batch = nil
+	if q.err != nil {
+		return q.err
+	}
+	if q.closing {
+		return errWriteQueueClosed
+	}
+	if len(batch) > 0 {
+		q.packets = append(q.packets, queuedPacket{data: batch})
+		q.cond.Signal()
+	}
+	return nil
+}
+
+// writeRoutine writes the queued packets to the connection, until the queue
+// is closed and empty, or a write fails. A failed write closes the
+// connection.
+//
+// Each write holds all of the queued priority packets, followed by control
+// packets, up to writeQueueBulkBytes. A large control packet is written after
+// the priority packets, one chunk per write, going back to the queue between
+// chunks. As a Mumble packet cannot be split, priority packets that are queued
+// while it is written are written right after its last chunk.
+func (c *Conn) writeRoutine() {
+	q := c.queue
+	defer close(q.done)
+	var (
+		// The large packet that is being written, and the number of its
+		// bytes that have been written.
+		large   *queuedPacket
+		written int
+	)
+	for {
+		var packets net.Buffers
+		q.mu.Lock()
+		for large == nil && len(q.priority) == 0 && len(q.packets) == 0 && !q.closing {
+			q.cond.Wait()
+		}
+		if large == nil {
+			packets = q.priority
+			q.priority = nil
+			q.priorityBytes = 0
+		}
+		var n, bulk int
+		for large == nil && n < len(q.packets) {
+			packet := &q.packets[n]
+			if packet.large() {
+				if n == 0 {
+					packet := *packet
+					large = &packet
+					n++
+				}
+				break
+			}
+			if bulk+len(packet.data) > writeQueueBulkBytes {
+				break
+			}
+			packets = append(packets, packet.data)
+			bulk += len(packet.data)
+			n++
+		}
+		if q.packets = q.packets[n:]; len(q.packets) == 0 {
+			q.packets = nil
+		}
+		q.mu.Unlock()
+		if len(packets) == 0 && large == nil {
+			return
+		}
+
+		var err error
+		if len(packets) > 0 {
+			c.Conn.SetWriteDeadline(time.Now().Add(c.Timeout))
+			_, err = packets.WriteTo(c.Conn)
+		}
+		if err == nil && large != nil {
+			if written, err = c.writeChunk(large.data, written, large.progress); written == len(large.data) {
+				large = nil
+				written = 0
+			}
+		}
+		if err != nil {
+			q.mu.Lock()
+			q.err = err
+			q.packets = nil
+			q.priority = nil
+			q.priorityBytes = 0
+			q.mu.Unlock()
+			c.Conn.Close()
+			return
+		}
+	}
+}
+
+// closeWriter stops accepting packets, and waits for the queued packets to
+// be written, for at most writeQueueFlushTimeout.
+func (c *Conn) closeWriter() {
+	q := c.queue
+	q.mu.Lock()
+	q.closing = true
+	q.cond.Signal()
+	q.mu.Unlock()
+
+	timer := time.NewTimer(writeQueueFlushTimeout)
+	defer timer.Stop()
+	select {
+	case <-q.done:
+	case <-timer.C:
+	}
+}
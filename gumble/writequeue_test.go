@@ -0,0 +1,200 @@
+package gumble
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+type testPacket struct {
+	ptype uint16
+	data  []byte
+}
+
+// newTestQueuedConn returns a Conn whose writes are queued, and the other end
+// of its connection.
+func newTestQueuedConn() (*Conn, net.Conn) {
+	clientConn, serverConn := net.Pipe()
+	conn := NewConn(clientConn)
+	conn.startWriter()
+	return conn, serverConn
+}
+
+// startTestWrite queues packet, and returns once the writer has started to
+// write it. As net.Pipe is not buffered, the writer then waits for the rest
+// of the packet to be read from the returned reader.
+func startTestWrite(conn *Conn, server net.Conn, packet testPacket) io.Reader {
+	conn.WritePacket(packet.ptype, packet.data)
+	var b [1]byte
+	io.ReadFull(server, b[:])
+	return io.MultiReader(bytes.NewReader(b[:]), server)
+}
+
+// readTestPackets reads the packets from r until the connection is closed.
+func readTestPackets(t *testing.T, r io.Reader) []testPacket {
+	var packets []testPacket
+	for {
+		var header [6]byte
+		if _, err := io.ReadFull(r, header[:]); err != nil {
+			return packets
+		}
+		data := make([]byte, binary.BigEndian.Uint32(header[2:]))
+		if _, err := io.ReadFull(r, data); err != nil {
+			t.Errorf("incomplete packet: %v\n", err)
+			return packets
+		}
+		packets = append(packets, testPacket{binary.BigEndian.Uint16(header[:]), data})
+	}
+}
+
+// closeTestConn closes conn and returns the packets read from r, the other
+// end of its connection.
+func closeTestConn(t *testing.T, conn *Conn, r io.Reader) []testPacket {
+	go conn.Close()
+	return readTestPackets(t, r)
+}
+
+func checkTestPackets(t *testing.T, got []testPacket, want ...testPacket) {
+	if len(got) != len(want) {
+		t.Fatalf("got %d packets, expected %d\n", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ptype != want[i].ptype || !bytes.Equal(got[i].data, want[i].data) {
+			t.Errorf("packet %d has type %d and %d bytes, expected type %d and %d bytes\n", i, got[i].ptype, len(got[i].data), want[i].ptype, len(want[i].data))
+		}
+	}
+}
+
+func TestWriteQueuePriority(t *testing.T) {
+	conn, server := newTestQueuedConn()
+	first := testPacket{7, []byte{0}}
+	control := []testPacket{{7, []byte{1}}, {9, []byte{2}}}
+	audio := testPacket{1, []byte{3}}
+
+	r := startTestWrite(conn, server, first)
+	for _, packet := range control {
+		conn.WritePacket(packet.ptype, packet.data)
+	}
+	conn.WritePacket(audio.ptype, audio.data)
+
+	checkTestPackets(t, closeTestConn(t, conn, r), first, audio, control[0], control[1])
+}
+
+func TestWriteQueueControlNotRefused(t *testing.T) {
+	conn, server := newTestQueuedConn()
+	conn.MaximumQueuedBytes = 100
+
+	want := []testPacket{{7, bytes.Repeat([]byte{0}, 50)}}
+	r := startTestWrite(conn, server, want[0])
+	for i := 1; i < 10; i++ {
+		packet := testPacket{7, bytes.Repeat([]byte{byte(i)}, 50)}
+		if err := conn.WritePacket(packet.ptype, packet.data); err != nil {
+			t.Fatalf("control packet %d refused: %v\n", i, err)
+		}
+		want = append(want, packet)
+	}
+	audio := testPacket{1, make([]byte, 60)}
+	// The first audio packet is queued, whatever its size.
+	if err := conn.WritePacket(audio.ptype, audio.data); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WritePacket(audio.ptype, audio.data); err != ErrWriteQueueFull {
+		t.Errorf("got %v, expected ErrWriteQueueFull\n", err)
+	}
+
+	// The audio is written as soon as the first packet has been.
+	want = append([]testPacket{want[0], audio}, want[1:]...)
+	checkTestPackets(t, closeTestConn(t, conn, r), want...)
+}
+
+func TestWriteQueueLargePacket(t *testing.T) {
+	conn, server := newTestQueuedConn()
+	before := testPacket{7, []byte{1}}
+	large := testPacket{9, bytes.Repeat([]byte{2, 3, 5, 7}, writeQueueBulkBytes)}
+	after := testPacket{7, []byte{4}}
+
+	type call struct{ written, total int }
+	var calls []call
+	conn.WritePacket(before.ptype, before.data)
+	conn.writePacket(large.ptype, large.data, func(written, total int) {
+		calls = append(calls, call{written, total})
+	})
+	conn.WritePacket(after.ptype, after.data)
+
+	checkTestPackets(t, closeTestConn(t, conn, server), before, large, after)
+
+	total := 6 + len(large.data)
+	if chunks := (total + writeQueueBulkBytes - 1) / writeQueueBulkBytes; len(calls) != chunks {
+		t.Fatalf("progress called %d times, expected %d\n", len(calls), chunks)
+	}
+	for i, c := range calls {
+		if c.total != total || (i > 0 && c.written <= calls[i-1].written) {
+			t.Errorf("progress call %d: %d of %d bytes\n", i, c.written, c.total)
+		}
+	}
+	if last := calls[len(calls)-1]; last.written != total {
+		t.Errorf("last progress call: %d of %d bytes\n", last.written, total)
+	}
+}
+
+func TestWriteQueueAudioDuringLargePacket(t *testing.T) {
+	conn, server := newTestQueuedConn()
+	large := testPacket{9, make([]byte, 4*writeQueueBulkBytes)}
+	control := testPacket{7, []byte{1}}
+	audio := testPacket{1, []byte{2}}
+
+	conn.WritePacket(large.ptype, large.data)
+	// Once the first chunk has been read, the writer is writing the second
+	// one.
+	chunk := make([]byte, writeQueueBulkBytes)
+	if _, err := io.ReadFull(server, chunk); err != nil {
+		t.Fatal(err)
+	}
+	conn.WritePacket(control.ptype, control.data)
+	conn.WritePacket(audio.ptype, audio.data)
+
+	// The audio is written right after the large packet, before the control
+	// packet that was queued before it.
+	r := io.MultiReader(bytes.NewReader(chunk), server)
+	checkTestPackets(t, closeTestConn(t, conn, r), large, audio, control)
+}
+
+func TestWriteQueueCloseFlushes(t *testing.T) {
+	conn, server := newTestQueuedConn()
+	var want []testPacket
+	for i := 0; i < 5; i++ {
+		packet := testPacket{7, []byte{byte(i)}}
+		conn.WritePacket(packet.ptype, packet.data)
+		want = append(want, packet)
+	}
+	checkTestPackets(t, closeTestConn(t, conn, server), want...)
+
+	if err := conn.WritePacket(7, nil); err == nil {
+		t.Error("packet queued after Close")
+	}
+}
+
+func TestWriteQueueCloseTimeout(t *testing.T) {
+	conn, server := newTestQueuedConn()
+	defer server.Close()
+	// Nothing is read from server, so the packet cannot be written.
+	conn.WritePacket(7, []byte{1})
+
+	start := time.Now()
+	closed := make(chan struct{})
+	go func() {
+		conn.Close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+	case <-time.After(writeQueueFlushTimeout + time.Second):
+		t.Fatal("Close did not return")
+	}
+	if elapsed := time.Since(start); elapsed < writeQueueFlushTimeout {
+		t.Errorf("Close returned after %v, before the flush timeout\n", elapsed)
+	}
+}
@@ -0,0 +1,10 @@
+// Package gumblealsa is a lightweight ALSA audio backend for gumble.
+//
+// It talks to the kernel's PCM devices (/dev/snd/pcmC*D*) directly, without
+// OpenAL, PulseAudio or a C toolchain, making it suitable for headless
+// devices such as Raspberry Pi intercoms. Only hardware devices ("hw:C,D")
+// are supported; as these can only play one stream at a time, incoming audio
+// is mixed in software.
+//
+// The package is only available on Linux.
+package gumblealsa // import "github.com/bmmcginty/gumble/gumblealsa"
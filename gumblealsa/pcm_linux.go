@@ -0,0 +1,243 @@
+//go:build linux
+// +build linux
+
+package gumblealsa // import "github.com/bmmcginty/gumble/gumblealsa"
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// Kernel PCM interface definitions, from <sound/asound.h>.
+const (
+	hwParamAccess    = 0
+	hwParamFormat    = 1
+	hwParamSubformat = 2
+
+	hwParamFirstInterval = 8
+	hwParamChannels      = 10
+	hwParamRate          = 11
+	hwParamPeriodSize    = 13
+	hwParamBufferSize    = 17
+
+	accessRWInterleaved = 3
+	formatS16LE         = 2
+	subformatStd        = 0
+
+	intervalInteger = 1 << 2
+)
+
+type interval struct {
+	Min, Max uint32
+	Flags    uint32
+}
+
+type hwParams struct {
+	Flags     uint32
+	Masks     [8][8]uint32
+	Intervals [21]interval
+	Rmask     uint32
+	Cmask     uint32
+	Info      uint32
+	Msbits    uint32
+	RateNum   uint32
+	RateDen   uint32
+	FifoSize  uintptr
+	Reserved  [64]byte
+}
+
+type xferi struct {
+	Result uintptr
+	Buf    uintptr
+	Frames uintptr
+}
+
+const (
+	iocWrite = 1
+	iocRead  = 2
+)
+
+func ioc(dir, nr, size uintptr) uintptr {
+	return dir<<30 | size<<16 | 'A'<<8 | nr
+}
+
+var (
+	ioctlHwParams    = ioc(iocRead|iocWrite, 0x11, unsafe.Sizeof(hwParams{}))
+	ioctlPrepare     = ioc(0, 0x40, 0)
+	ioctlDrop        = ioc(0, 0x43, 0)
+	ioctlResume      = ioc(0, 0x47, 0)
+	ioctlWriteFrames = ioc(iocWrite, 0x50, unsafe.Sizeof(xferi{}))
+	ioctlReadFrames  = ioc(iocRead, 0x51, unsafe.Sizeof(xferi{}))
+)
+
+// pcm is an open mono, signed 16-bit PCM device.
+type pcm struct {
+	file       *os.File
+	rate       int
+	periodSize int
+	bufferSize int
+	// Number of xruns (buffer overruns or underruns) that were recovered
+	// from.
+	xruns int
+}
+
+// sampleRates are the sample rates that are tried, in order, when opening a
+// device.
+var sampleRates = []int{gumble.AudioSampleRate, 44100, 32000, 22050, 16000, 11025, 8000}
+
+// openPCM opens the given card and device for playback or capture, with the
+// requested period and buffer sizes (in frames at gumble.AudioSampleRate).
+// The first rate of sampleRates that the device supports is used.
+func openPCM(card, device int, capture bool, periodSize, bufferSize int) (*pcm, error) {
+	suffix := 'p'
+	if capture {
+		suffix = 'c'
+	}
+	name := fmt.Sprintf("/dev/snd/pcmC%dD%d%c", card, device, suffix)
+	file, err := os.OpenFile(name, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	p := &pcm{
+		file: file,
+	}
+	for _, rate := range sampleRates {
+		err = p.setParams(rate, scale(periodSize, rate), scale(bufferSize, rate))
+		if err != syscall.EINVAL {
+			break
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("gumblealsa: %s: %v", name, err)
+	}
+	if err := p.ioctl(ioctlPrepare, nil); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *pcm) ioctl(request uintptr, arg unsafe.Pointer) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, p.file.Fd(), request, uintptr(arg))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// scale returns the number of frames at rate that have the same duration as
+// frames at gumble.AudioSampleRate.
+func scale(frames, rate int) int {
+	return (frames*rate + gumble.AudioSampleRate - 1) / gumble.AudioSampleRate
+}
+
+func (p *pcm) setParams(rate, periodSize, bufferSize int) error {
+	var params hwParams
+	for i := range params.Masks {
+		for j := range params.Masks[i] {
+			params.Masks[i][j] = ^uint32(0)
+		}
+	}
+	for i := range params.Intervals {
+		params.Intervals[i].Max = ^uint32(0)
+	}
+	params.Rmask = ^uint32(0)
+
+	setMask := func(param int, value uint32) {
+		params.Masks[param] = [8]uint32{}
+		params.Masks[param][value/32] = 1 << (value % 32)
+	}
+	setInterval := func(param int, value uint32) {
+		params.Intervals[param-hwParamFirstInterval] = interval{
+			Min:   value,
+			Max:   value,
+			Flags: intervalInteger,
+		}
+	}
+	setMask(hwParamAccess, accessRWInterleaved)
+	setMask(hwParamFormat, formatS16LE)
+	setMask(hwParamSubformat, subformatStd)
+	setInterval(hwParamChannels, 1)
+	setInterval(hwParamRate, uint32(rate))
+	setInterval(hwParamPeriodSize, uint32(periodSize))
+	setInterval(hwParamBufferSize, uint32(bufferSize))
+
+	if err := p.ioctl(ioctlHwParams, unsafe.Pointer(&params)); err != nil {
+		return err
+	}
+	p.rate = rate
+	p.periodSize = int(params.Intervals[hwParamPeriodSize-hwParamFirstInterval].Min)
+	p.bufferSize = int(params.Intervals[hwParamBufferSize-hwParamFirstInterval].Min)
+	return nil
+}
+
+// recover recovers the device from an xrun (EPIPE) or suspend (ESTRPIPE). Any
+// other error is returned.
+func (p *pcm) recover(err error) error {
+	switch err {
+	case syscall.EPIPE:
+	case syscall.ESTRPIPE:
+		for {
+			err = p.ioctl(ioctlResume, nil)
+			if err != syscall.EAGAIN {
+				break
+			}
+		}
+		if err == nil {
+			p.xruns++
+			return nil
+		}
+	default:
+		return err
+	}
+	if err := p.ioctl(ioctlPrepare, nil); err != nil {
+		return err
+	}
+	p.xruns++
+	return nil
+}
+
+// transfer reads or writes all of the frames in buf, recovering from xruns.
+func (p *pcm) transfer(request uintptr, buf []int16) error {
+	for len(buf) > 0 {
+		x := xferi{
+			Buf:    uintptr(unsafe.Pointer(&buf[0])),
+			Frames: uintptr(len(buf)),
+		}
+		err := p.ioctl(request, unsafe.Pointer(&x))
+		runtime.KeepAlive(buf)
+		if err == syscall.EINTR || err == syscall.EAGAIN {
+			continue
+		}
+		if err != nil {
+			if err := p.recover(err); err != nil {
+				return err
+			}
+			continue
+		}
+		buf = buf[int(x.Result):]
+	}
+	return nil
+}
+
+// Read fills buf with captured frames.
+func (p *pcm) Read(buf []int16) error {
+	return p.transfer(ioctlReadFrames, buf)
+}
+
+// Write plays the frames in buf.
+func (p *pcm) Write(buf []int16) error {
+	return p.transfer(ioctlWriteFrames, buf)
+}
+
+// Close stops the device, discarding any pending frames, and closes it.
+func (p *pcm) Close() error {
+	p.ioctl(ioctlDrop, nil)
+	return p.file.Close()
+}
@@ -0,0 +1,310 @@
+//go:build linux
+// +build linux
+
+package gumblealsa // import "github.com/bmmcginty/gumble/gumblealsa"
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+)
+
+var (
+	ErrState  = errors.New("gumblealsa: invalid state")
+	ErrMic    = errors.New("gumblealsa: microphone disconnected or misconfigured")
+	ErrDevice = errors.New("gumblealsa: invalid device name")
+)
+
+var _ gumbleutil.AudioStream = (*Stream)(nil)
+
+// Config contains the device parameters of a Stream.
+type Config struct {
+	// The period size, in frames. If zero, 480 frames (10ms) is used.
+	PeriodSize int
+	// The buffer size, in frames. If zero, four periods are used.
+	BufferSize int
+}
+
+func (c *Config) periodSize() int {
+	if c == nil || c.PeriodSize <= 0 {
+		return gumble.AudioSampleRate / 100
+	}
+	return c.PeriodSize
+}
+
+func (c *Config) bufferSize() int {
+	if c == nil || c.BufferSize <= 0 {
+		return c.periodSize() * 4
+	}
+	return c.BufferSize
+}
+
+// Stream plays incoming audio through an ALSA playback device and sends audio
+// captured from an ALSA capture device to the server.
+type Stream struct {
+	// If not nil, applied to the captured audio, after the capture volume
+	// (see gumbleutil.AGC). It must be set before StartSource.
+	AGC *gumbleutil.AGC
+
+	client *gumble.Client
+	link   gumble.Detacher
+	config *Config
+
+	inputDevice     *string
+	source          *pcm
+	sourceResampler *gumbleutil.Resampler
+	captured        []int16
+	sourceStop      chan struct{}
+	sourceDone      chan struct{}
+	micVolume       float32
+	micVolumeLock   sync.Mutex
+
+	sink          *pcm
+	sinkBuffer    []int16
+	sinkResampler *gumbleutil.Resampler
+	sinkStop      chan struct{}
+	sinkDone      chan struct{}
+
+	mixer *gumbleutil.Mixer
+}
+
+// parseDevice parses an ALSA hardware device name of the form "hw:C,D" or
+// "hw:C". A nil or empty name refers to "hw:0,0".
+func parseDevice(name *string) (card, device int, err error) {
+	if name == nil || *name == "" {
+		return 0, 0, nil
+	}
+	if !strings.HasPrefix(*name, "hw:") {
+		return 0, 0, ErrDevice
+	}
+	spec := strings.TrimPrefix(*name, "hw:")
+	if strings.Contains(spec, ",") {
+		_, err = fmt.Sscanf(spec, "%d,%d", &card, &device)
+	} else {
+		_, err = fmt.Sscanf(spec, "%d", &card)
+	}
+	if err != nil {
+		return 0, 0, ErrDevice
+	}
+	return card, device, nil
+}
+
+// New opens the given playback device and returns a new Stream. The capture
+// device is opened when the source is started. Device names are of the form
+// "hw:C,D"; nil or empty names refer to "hw:0,0". config can be nil.
+func New(client *gumble.Client, inputDevice *string, outputDevice *string, config *Config) (*Stream, error) {
+	if _, _, err := parseDevice(inputDevice); err != nil {
+		return nil, err
+	}
+	card, device, err := parseDevice(outputDevice)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := openPCM(card, device, false, config.periodSize(), config.bufferSize())
+	if err != nil {
+		return nil, err
+	}
+	s := &Stream{
+		client:      client,
+		config:      config,
+		inputDevice: inputDevice,
+		micVolume:   1,
+		sink:        sink,
+		sinkBuffer:  make([]int16, config.periodSize()),
+		mixer:       gumbleutil.NewMixer(),
+	}
+	if sink.rate != gumble.AudioSampleRate {
+		s.sinkResampler = gumbleutil.NewResampler(gumble.AudioSampleRate, sink.rate)
+	}
+	return s, nil
+}
+
+// AttachStream starts playing the audio received by client.
+func (s *Stream) AttachStream(client *gumble.Client) {
+	s.link = client.AttachAudio(s)
+	s.sinkStop = make(chan struct{})
+	s.sinkDone = make(chan struct{})
+	go s.sinkRoutine()
+}
+
+// Destroy stops the stream and closes its devices.
+func (s *Stream) Destroy() {
+	if s.link != nil {
+		s.link.Detach()
+		s.link = nil
+	}
+	if s.sourceStop != nil {
+		s.StopSource()
+	}
+	if s.sink != nil {
+		if s.sinkStop != nil {
+			close(s.sinkStop)
+			<-s.sinkDone
+			s.sinkStop = nil
+		}
+		s.sink.Close()
+		s.sink = nil
+	}
+}
+
+// StartSource opens inputDevice (or the device passed to New, if nil) and
+// starts sending the audio captured from it to the server.
+func (s *Stream) StartSource(inputDevice *string) error {
+	if s.sourceStop != nil {
+		return ErrState
+	}
+	if inputDevice == nil {
+		inputDevice = s.inputDevice
+	}
+	card, device, err := parseDevice(inputDevice)
+	if err != nil {
+		return err
+	}
+	source, err := openPCM(card, device, true, s.config.periodSize(), s.config.bufferSize())
+	if err != nil {
+		return err
+	}
+	s.source = source
+	s.sourceResampler = nil
+	s.captured = s.captured[:0]
+	if source.rate != gumble.AudioSampleRate {
+		s.sourceResampler = gumbleutil.NewResampler(source.rate, gumble.AudioSampleRate)
+	}
+	s.sourceStop = make(chan struct{})
+	s.sourceDone = make(chan struct{})
+	go s.sourceRoutine()
+	return nil
+}
+
+// StopSource stops capturing audio and closes the capture device.
+func (s *Stream) StopSource() error {
+	if s.sourceStop == nil {
+		return ErrState
+	}
+	close(s.sourceStop)
+	<-s.sourceDone
+	s.sourceStop = nil
+	s.source.Close()
+	s.source = nil
+	return nil
+}
+
+// GetMicVolume returns the capture volume, between 0 and 1.
+func (s *Stream) GetMicVolume() float32 {
+	s.micVolumeLock.Lock()
+	defer s.micVolumeLock.Unlock()
+	return s.micVolume
+}
+
+// SetMicVolume sets the capture volume, either to change or by change if
+// relative is true. The volume is clamped between 0 and 1.
+func (s *Stream) SetMicVolume(change float32, relative bool) {
+	s.micVolumeLock.Lock()
+	defer s.micVolumeLock.Unlock()
+	val := change
+	if relative {
+		val += s.micVolume
+	}
+	if val >= 1 {
+		val = 1.0
+	}
+	if val <= 0 {
+		val = 0
+	}
+	s.micVolume = val
+}
+
+// Xruns returns the number of buffer underruns of the playback device and
+// overruns of the capture device that have been recovered from. It must not
+// be called while the stream is running.
+func (s *Stream) Xruns() (playback, capture int) {
+	if s.sink != nil {
+		playback = s.sink.xruns
+	}
+	if s.source != nil {
+		capture = s.source.xruns
+	}
+	return
+}
+
+func (s *Stream) sourceRoutine() {
+	defer close(s.sourceDone)
+
+	outgoing := s.client.AudioOutgoing()
+	defer close(outgoing)
+
+	for {
+		select {
+		case <-s.sourceStop:
+			return
+		default:
+		}
+
+		// The frame size is read every time, as it can change while the
+		// client is connected.
+		frameSize := s.client.AudioFrameSize()
+		if s.sourceResampler == nil {
+			buffer := make(gumble.AudioBuffer, frameSize)
+			if err := s.source.Read(buffer); err != nil {
+				return
+			}
+			s.sendCaptured(outgoing, buffer)
+			continue
+		}
+		buffer := make([]int16, scale(frameSize, s.source.rate))
+		if err := s.source.Read(buffer); err != nil {
+			return
+		}
+		s.captured = append(s.captured, s.sourceResampler.Process(buffer)...)
+		for len(s.captured) >= frameSize {
+			frame := make(gumble.AudioBuffer, frameSize)
+			copy(frame, s.captured)
+			s.captured = append(s.captured[:0], s.captured[frameSize:]...)
+			s.sendCaptured(outgoing, frame)
+		}
+	}
+}
+
+// sendCaptured applies the capture volume and the AGC to buffer and sends it
+// to the server.
+func (s *Stream) sendCaptured(outgoing chan<- gumble.AudioBuffer, buffer gumble.AudioBuffer) {
+	if volume := s.GetMicVolume(); volume != 1 {
+		for i, sample := range buffer {
+			buffer[i] = int16(float32(sample) * volume)
+		}
+	}
+	if s.AGC != nil {
+		s.AGC.Process(buffer)
+	}
+	outgoing <- buffer
+}
+
+// OnAudioStream implements gumble.AudioListener. Incoming audio is queued per
+// user and mixed by the playback routine.
+func (s *Stream) OnAudioStream(e *gumble.AudioStreamEvent) {
+	s.mixer.OnAudioStream(e)
+}
+
+func (s *Stream) sinkRoutine() {
+	defer close(s.sinkDone)
+
+	for {
+		select {
+		case <-s.sinkStop:
+			return
+		default:
+		}
+		s.mixer.Mix(s.sinkBuffer)
+		buffer := s.sinkBuffer
+		if s.sinkResampler != nil {
+			buffer = s.sinkResampler.Process(buffer)
+		}
+		if err := s.sink.Write(buffer); err != nil {
+			return
+		}
+	}
+}
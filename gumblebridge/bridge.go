@@ -0,0 +1,287 @@
+// Package gumblebridge exchanges the audio of a gumble client with another
+// voice service that uses 20ms, 48kHz Opus frames (e.g. Discord).
+//
+// The audio heard by the client is mixed, encoded and made available with
+// Bridge.Frames. The frames received from the other service are passed to
+// Bridge.Write, along with an identifier of their speaker. While a single
+// speaker is talking, their frames are sent to the server as they are;
+// when several are, their audio is decoded, mixed and encoded again.
+package gumblebridge // import "github.com/bmmcginty/gumble/gumblebridge"
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	"github.com/bmmcginty/gumble/opus"
+)
+
+// FrameSize is the number of samples in each Opus frame (20ms).
+const FrameSize = gumble.AudioSampleRate / 50
+
+// DefaultBitrate is the default bitrate of the frames encoded by the bridge,
+// in bits per second.
+const DefaultBitrate = 64000
+
+const frameDuration = time.Second * FrameSize / gumble.AudioSampleRate
+
+// maxQueued is the maximum number of frames queued per speaker; older frames
+// are dropped.
+const maxQueued = 10
+
+// silentFrames is the number of frame intervals without audio after which a
+// transmission ends.
+const silentFrames = 10
+
+// Bridge exchanges Opus audio between a gumble client and another voice
+// service.
+type Bridge struct {
+	// The bitrate of the frames encoded by the bridge, in bits per second.
+	// Defaults to DefaultBitrate. Cannot be changed after the bridge is
+	// started.
+	Bitrate int
+	// Called when encoding, decoding or sending audio fails. Can be nil.
+	Error func(err error)
+
+	client *gumble.Client
+	mixer  *gumbleutil.Mixer
+	frames chan []byte
+
+	mu       sync.Mutex
+	speakers map[uint32]*speaker
+	detacher gumble.Detacher
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+type speaker struct {
+	queue   [][]byte
+	decoder gumble.AudioDecoder
+}
+
+// New returns a new Bridge for client.
+func New(client *gumble.Client) *Bridge {
+	return &Bridge{
+		Bitrate:  DefaultBitrate,
+		client:   client,
+		frames:   make(chan []byte, maxQueued),
+		speakers: make(map[uint32]*speaker),
+	}
+}
+
+// Frames returns the channel on which the mixed audio heard by the client is
+// sent, as Opus frames. Frames are only sent while someone is talking; a nil
+// frame is sent at the end of each transmission. Frames are dropped if the
+// channel is not read quickly enough.
+func (b *Bridge) Frames() <-chan []byte {
+	return b.frames
+}
+
+// Write queues an Opus frame, received from the other service, to be sent to
+// the server. source identifies the frame's speaker (e.g. an RTP SSRC).
+func (b *Bridge) Write(source uint32, frame []byte) {
+	if len(frame) == 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	s := b.speakers[source]
+	if s == nil {
+		s = &speaker{}
+		b.speakers[source] = s
+	}
+	if len(s.queue) >= maxQueued {
+		s.queue = s.queue[1:]
+	}
+	s.queue = append(s.queue, append([]byte(nil), frame...))
+}
+
+// RemoveSource forgets the given speaker, e.g. after they have left the other
+// service.
+func (b *Bridge) RemoveSource(source uint32) {
+	b.mu.Lock()
+	delete(b.speakers, source)
+	b.mu.Unlock()
+}
+
+// Start starts exchanging audio.
+func (b *Bridge) Start() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.stop != nil {
+		return errors.New("gumblebridge: bridge already started")
+	}
+	bitrate := b.Bitrate
+	if bitrate <= 0 {
+		bitrate = DefaultBitrate
+	}
+	// One encoder for each direction.
+	var encoders [2]*opus.Encoder
+	for i := range encoders {
+		encoder, ok := opus.Codec.NewEncoder().(*opus.Encoder)
+		if !ok || encoder.Encoder == nil {
+			return errors.New("gumblebridge: could not create opus encoder")
+		}
+		encoder.SetBitrate(bitrate)
+		encoders[i] = encoder
+	}
+
+	b.mixer = gumbleutil.NewMixer()
+	b.client.Do(func() {
+		b.detacher = b.client.AttachAudio(b.mixer)
+	})
+	b.stop = make(chan struct{})
+	b.done = make(chan struct{})
+	go b.run(encoders[0], encoders[1], b.stop, b.done)
+	return nil
+}
+
+// Stop stops exchanging audio.
+func (b *Bridge) Stop() error {
+	b.mu.Lock()
+	if b.stop == nil {
+		b.mu.Unlock()
+		return errors.New("gumblebridge: bridge not started")
+	}
+	stop, done := b.stop, b.done
+	b.stop = nil
+	b.done = nil
+	b.mu.Unlock()
+
+	b.client.Do(func() {
+		b.detacher.Detach()
+	})
+	close(stop)
+	<-done
+	return nil
+}
+
+func (b *Bridge) fail(err error) {
+	if err != nil && b.Error != nil {
+		b.Error(err)
+	}
+}
+
+func (b *Bridge) send(frame []byte) {
+	select {
+	case b.frames <- frame:
+	default:
+	}
+}
+
+func (b *Bridge) run(encoder, mixEncoder *opus.Encoder, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(frameDuration)
+	defer ticker.Stop()
+
+	buf := make([]int16, FrameSize)
+	var outgoingSilence, incomingSilence int
+	outgoing, incoming := false, false
+	for {
+		select {
+		case <-stop:
+			if outgoing {
+				b.send(nil)
+			}
+			if incoming {
+				b.client.SendOpusFrame(nil, 0)
+			}
+			return
+		case <-ticker.C:
+		}
+
+		// Mumble to the other service.
+		if b.mixer.Buffered() > 0 {
+			outgoing = true
+			outgoingSilence = 0
+			b.mixer.Mix(buf)
+			frame, err := encoder.Encode(buf, FrameSize, 1275)
+			if err != nil {
+				b.fail(err)
+			} else {
+				b.send(frame)
+			}
+		} else if outgoing {
+			if outgoingSilence++; outgoingSilence >= silentFrames {
+				outgoing = false
+				encoder.ResetState()
+				b.send(nil)
+			}
+		}
+
+		// The other service to Mumble.
+		frame, samples := b.nextIncoming(mixEncoder)
+		if frame != nil {
+			incoming = true
+			incomingSilence = 0
+			b.fail(b.client.SendOpusFrame(frame, samples))
+		} else if incoming {
+			if incomingSilence++; incomingSilence >= silentFrames {
+				incoming = false
+				b.fail(b.client.SendOpusFrame(nil, 0))
+			}
+		}
+	}
+}
+
+// nextIncoming takes the next frame of each speaker, and returns the frame
+// that must be sent to the server, or nil if no one is speaking.
+func (b *Bridge) nextIncoming(encoder *opus.Encoder) ([]byte, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var frames [][]byte
+	var speakers []*speaker
+	for _, s := range b.speakers {
+		if len(s.queue) == 0 {
+			continue
+		}
+		frames = append(frames, s.queue[0])
+		speakers = append(speakers, s)
+		s.queue = s.queue[1:]
+	}
+
+	switch len(frames) {
+	case 0:
+		return nil, 0
+	case 1:
+		return frames[0], FrameSize
+	}
+
+	mixed := make([]int32, FrameSize)
+	for i, s := range speakers {
+		if s.decoder == nil {
+			s.decoder = opus.Codec.NewDecoder()
+		}
+		pcm, err := s.decoder.Decode(frames[i], gumble.AudioMaximumFrameSize)
+		if err != nil {
+			b.fail(err)
+			continue
+		}
+		for j := 0; j < len(pcm) && j < FrameSize; j++ {
+			mixed[j] += int32(pcm[j])
+		}
+	}
+	buf := make([]int16, FrameSize)
+	for i, sample := range mixed {
+		switch {
+		case sample > math.MaxInt16:
+			sample = math.MaxInt16
+		case sample < math.MinInt16:
+			sample = math.MinInt16
+		}
+		buf[i] = int16(sample)
+	}
+	frame, err := encoder.Encode(buf, FrameSize, 1275)
+	if err != nil {
+		b.fail(err)
+		return nil, 0
+	}
+	return frame, FrameSize
+}
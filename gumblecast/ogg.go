@@ -0,0 +1,100 @@
+package gumblecast // import "github.com/bmmcginty/gumble/gumblecast"
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// opusPreSkip is the number of samples the decoder should skip at the start of
+// the stream (the encoder's look-ahead).
+const opusPreSkip = 312
+
+var oggCRCTable = func() (table [256]uint32) {
+	for i := range table {
+		r := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if r&0x80000000 != 0 {
+				r = r<<1 ^ 0x04c11db7
+			} else {
+				r <<= 1
+			}
+		}
+		table[i] = r
+	}
+	return
+}()
+
+func oggCRC(data []byte) uint32 {
+	var crc uint32
+	for _, b := range data {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	return crc
+}
+
+// oggWriter writes a mono Ogg/Opus stream (RFC 7845), with one Opus packet per
+// page.
+type oggWriter struct {
+	w        io.Writer
+	serial   uint32
+	sequence uint32
+	granule  uint64
+}
+
+func newOggWriter(w io.Writer, serial uint32) (*oggWriter, error) {
+	o := &oggWriter{
+		w:      w,
+		serial: serial,
+	}
+
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1 // version
+	head[9] = gumble.AudioChannels
+	binary.LittleEndian.PutUint16(head[10:], opusPreSkip)
+	binary.LittleEndian.PutUint32(head[12:], gumble.AudioSampleRate)
+	if err := o.writePage(head, 0x02, 0); err != nil {
+		return nil, err
+	}
+
+	const vendor = "gumble"
+	tags := make([]byte, 8+4+len(vendor)+4)
+	copy(tags, "OpusTags")
+	binary.LittleEndian.PutUint32(tags[8:], uint32(len(vendor)))
+	copy(tags[12:], vendor)
+	if err := o.writePage(tags, 0, 0); err != nil {
+		return nil, err
+	}
+
+	o.granule = opusPreSkip
+	return o, nil
+}
+
+// WritePacket writes an Opus packet containing samples samples.
+func (o *oggWriter) WritePacket(packet []byte, samples int) error {
+	o.granule += uint64(samples)
+	return o.writePage(packet, 0, o.granule)
+}
+
+func (o *oggWriter) writePage(packet []byte, flags byte, granule uint64) error {
+	segments := len(packet)/255 + 1
+	page := make([]byte, 27+segments+len(packet))
+	copy(page, "OggS")
+	page[5] = flags
+	binary.LittleEndian.PutUint64(page[6:], granule)
+	binary.LittleEndian.PutUint32(page[14:], o.serial)
+	binary.LittleEndian.PutUint32(page[18:], o.sequence)
+	page[26] = byte(segments)
+	for i := 0; i < segments-1; i++ {
+		page[27+i] = 255
+	}
+	page[27+segments-1] = byte(len(packet) % 255)
+	copy(page[27+segments:], packet)
+	binary.LittleEndian.PutUint32(page[22:], oggCRC(page))
+
+	o.sequence++
+	_, err := o.w.Write(page)
+	return err
+}
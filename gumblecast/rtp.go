@@ -0,0 +1,77 @@
+package gumblecast // import "github.com/bmmcginty/gumble/gumblecast"
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"net"
+)
+
+// DefaultRTPPayloadType is the default RTP payload type of the Opus packets.
+// Opus has no static payload type, so the receiver must be configured (e.g.
+// with an SDP file) to use the same one.
+const DefaultRTPPayloadType = 111
+
+// RTP is an Output that sends Opus packets over RTP (RFC 7587) to a fixed UDP
+// destination.
+type RTP struct {
+	// The destination address, e.g. "239.0.0.1:5004".
+	Address string
+	// The RTP payload type. Defaults to DefaultRTPPayloadType.
+	PayloadType byte
+
+	conn      net.Conn
+	ssrc      uint32
+	sequence  uint16
+	timestamp uint32
+	buf       []byte
+}
+
+// NewRTP returns a new RTP output that sends to the given address.
+func NewRTP(address string) *RTP {
+	return &RTP{
+		Address:     address,
+		PayloadType: DefaultRTPPayloadType,
+	}
+}
+
+// Connect implements Output.
+func (r *RTP) Connect() error {
+	conn, err := net.Dial("udp", r.Address)
+	if err != nil {
+		return err
+	}
+	r.conn = conn
+	r.ssrc = rand.Uint32()
+	r.sequence = uint16(rand.Uint32())
+	r.timestamp = rand.Uint32()
+	return nil
+}
+
+// WritePacket implements Output.
+func (r *RTP) WritePacket(packet []byte, samples int) error {
+	if r.conn == nil {
+		return errNotConnected
+	}
+	r.buf = append(r.buf[:0], make([]byte, 12)...)
+	r.buf[0] = 2 << 6 // version
+	r.buf[1] = r.PayloadType & 0x7F
+	binary.BigEndian.PutUint16(r.buf[2:], r.sequence)
+	binary.BigEndian.PutUint32(r.buf[4:], r.timestamp)
+	binary.BigEndian.PutUint32(r.buf[8:], r.ssrc)
+	r.buf = append(r.buf, packet...)
+
+	r.sequence++
+	r.timestamp += uint32(samples)
+	_, err := r.conn.Write(r.buf)
+	return err
+}
+
+// Close implements Output.
+func (r *RTP) Close() error {
+	if r.conn == nil {
+		return nil
+	}
+	err := r.conn.Close()
+	r.conn = nil
+	return err
+}
@@ -0,0 +1,180 @@
+// Package gumblecast re-streams the audio heard by a gumble client, e.g. to
+// broadcast a Mumble channel as a live stream.
+//
+// A Sink mixes the incoming audio, encodes it with Opus, and writes it to an
+// Output. Outputs are provided for Icecast servers (Ogg/Opus) and for RTP
+// destinations.
+package gumblecast // import "github.com/bmmcginty/gumble/gumblecast"
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	"github.com/bmmcginty/gumble/opus"
+)
+
+// FrameSize is the number of samples in each encoded Opus packet (20ms).
+const FrameSize = gumble.AudioSampleRate / 50
+
+// DefaultBitrate is the default bitrate of the encoded stream, in bits per
+// second.
+const DefaultBitrate = 64000
+
+var errNotConnected = errors.New("gumblecast: output not connected")
+
+const (
+	reconnectMinDelay = time.Second
+	reconnectMaxDelay = 30 * time.Second
+)
+
+// Output is a destination to which a Sink writes its encoded audio.
+type Output interface {
+	// Connect establishes the connection to the destination. It is called
+	// when the Sink starts, and again after WritePacket fails.
+	Connect() error
+	// WritePacket writes an Opus packet that contains the given number of
+	// samples (per channel, at 48kHz).
+	WritePacket(packet []byte, samples int) error
+	// Close closes the connection to the destination.
+	Close() error
+}
+
+// Sink is an audio listener that mixes the audio of every user that the
+// client hears, and writes it, encoded with Opus, to an Output.
+//
+// Audio is written continuously (silence is encoded while no one is talking),
+// as live stream listeners expect. If the Output fails, it is reconnected
+// with an increasing delay; audio is discarded while it is disconnected.
+type Sink struct {
+	// The bitrate of the encoded stream, in bits per second. Defaults to
+	// DefaultBitrate. Cannot be changed after the sink is started.
+	Bitrate int
+	// Called when the output fails to connect or write. Can be nil.
+	Error func(err error)
+
+	client *gumble.Client
+	output Output
+	mixer  *gumbleutil.Mixer
+
+	mu       sync.Mutex
+	detacher gumble.Detacher
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// New returns a new Sink that sends the audio heard by client to output.
+func New(client *gumble.Client, output Output) *Sink {
+	return &Sink{
+		Bitrate: DefaultBitrate,
+		client:  client,
+		output:  output,
+	}
+}
+
+// Start starts mixing and sending audio.
+func (s *Sink) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop != nil {
+		return errors.New("gumblecast: sink already started")
+	}
+	encoder, ok := opus.Codec.NewEncoder().(*opus.Encoder)
+	if !ok || encoder.Encoder == nil {
+		return errors.New("gumblecast: could not create opus encoder")
+	}
+	bitrate := s.Bitrate
+	if bitrate <= 0 {
+		bitrate = DefaultBitrate
+	}
+	encoder.SetBitrate(bitrate)
+
+	s.mixer = gumbleutil.NewMixer()
+	s.detacher = s.client.AttachAudio(s.mixer)
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run(encoder, s.stop, s.done)
+	return nil
+}
+
+// Stop stops sending audio, and closes the output.
+func (s *Sink) Stop() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stop == nil {
+		return errors.New("gumblecast: sink not started")
+	}
+	s.client.Do(func() {
+		s.detacher.Detach()
+	})
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+	s.done = nil
+	return nil
+}
+
+func (s *Sink) fail(err error) {
+	if err != nil && s.Error != nil {
+		s.Error(err)
+	}
+}
+
+func (s *Sink) run(encoder *opus.Encoder, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+
+	interval := time.Duration(FrameSize) * time.Second / gumble.AudioSampleRate
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	buf := make([]int16, FrameSize)
+	connected := false
+	delay := reconnectMinDelay
+	var retry time.Time
+	for {
+		select {
+		case <-stop:
+			if connected {
+				s.fail(s.output.Close())
+			}
+			return
+		case <-ticker.C:
+		}
+
+		// Always drain the mixer, so that audio does not build up while the
+		// output is disconnected.
+		s.mixer.Mix(buf)
+
+		if !connected {
+			if time.Now().Before(retry) {
+				continue
+			}
+			if err := s.output.Connect(); err != nil {
+				s.fail(err)
+				retry = time.Now().Add(delay)
+				if delay *= 2; delay > reconnectMaxDelay {
+					delay = reconnectMaxDelay
+				}
+				continue
+			}
+			connected = true
+			delay = reconnectMinDelay
+		}
+
+		packet, err := encoder.Encode(buf, FrameSize, 1275)
+		if err != nil {
+			s.fail(err)
+			continue
+		}
+		if err := s.output.WritePacket(packet, FrameSize); err != nil {
+			s.fail(err)
+			s.output.Close()
+			connected = false
+			retry = time.Now().Add(delay)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package gumbleffmpeg // import "github.com/bmmcginty/gumble/gumbleffmpeg"
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"text/template"
+	"time"
+)
+
+// Default NowPlaying templates.
+const (
+	DefaultNowPlayingStart  = "Now playing: {{.Title}}"
+	DefaultNowPlayingUpdate = "Now playing: {{.Title}} ({{clock .Elapsed}})"
+	DefaultNowPlayingFinish = "Finished playing: {{.Title}} ({{clock .Elapsed}})"
+)
+
+// NowPlayingData is the data with which NowPlaying templates are executed.
+type NowPlayingData struct {
+	// The title of the track, HTML escaped.
+	Title string
+	// The amount of audio that has been played.
+	Elapsed time.Duration
+	// Has the stream stopped?
+	Finished bool
+}
+
+// NowPlaying announces the track played by a Stream, by setting the client's
+// comment and/or sending a message to the client's channel when the stream
+// starts and finishes.
+//
+// The messages are text/template templates executed with NowPlayingData.
+// The "clock" function formats a duration as m:ss (e.g. {{clock .Elapsed}}).
+type NowPlaying struct {
+	// The title of the track.
+	Title string
+
+	// Should the client's comment be set to the messages? The comment that
+	// the client had is restored when the stream finishes, unless Finish is
+	// set.
+	Comment bool
+	// Should the messages be sent to the client's channel?
+	Channel bool
+
+	// The message when the stream starts. Defaults to
+	// DefaultNowPlayingStart.
+	Start string
+	// The comment while the stream plays, updated every UpdateInterval.
+	// Defaults to DefaultNowPlayingUpdate. Only used if Comment is set and
+	// UpdateInterval is positive.
+	Update         string
+	UpdateInterval time.Duration
+	// The message when the stream finishes. If empty, nothing is sent to the
+	// channel.
+	Finish string
+}
+
+var nowPlayingFuncs = template.FuncMap{
+	"clock": func(d time.Duration) string {
+		seconds := int(d / time.Second)
+		return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+	},
+}
+
+// Play starts playing stream (see Stream.Play), and announces it. The finish
+// message is sent from a new goroutine once the stream stops.
+func (n *NowPlaying) Play(stream *Stream) error {
+	start, err := n.template("start", n.Start, DefaultNowPlayingStart)
+	if err != nil {
+		return err
+	}
+	update, err := n.template("update", n.Update, DefaultNowPlayingUpdate)
+	if err != nil {
+		return err
+	}
+	var finish *template.Template
+	if n.Finish != "" {
+		if finish, err = n.template("finish", n.Finish, ""); err != nil {
+			return err
+		}
+	}
+
+	client := stream.client
+	var previousComment string
+	client.Do(func() {
+		if client.Self != nil {
+			previousComment = client.Self.Comment
+		}
+	})
+
+	if err := stream.Play(); err != nil {
+		return err
+	}
+	n.announce(stream, start, n.Channel)
+
+	go func() {
+		done := make(chan struct{})
+		go func() {
+			stream.Wait()
+			close(done)
+		}()
+		if n.Comment && n.UpdateInterval > 0 {
+			ticker := time.NewTicker(n.UpdateInterval)
+		updates:
+			for {
+				select {
+				case <-ticker.C:
+					n.announce(stream, update, false)
+				case <-done:
+					break updates
+				}
+			}
+			ticker.Stop()
+		}
+		<-done
+
+		if finish != nil {
+			n.announce(stream, finish, n.Channel)
+		} else if n.Comment {
+			client.Do(func() {
+				if client.Self != nil {
+					client.Self.SetComment(previousComment)
+				}
+			})
+		}
+	}()
+	return nil
+}
+
+func (n *NowPlaying) template(name, text, defaultText string) (*template.Template, error) {
+	if text == "" {
+		text = defaultText
+	}
+	return template.New(name).Funcs(nowPlayingFuncs).Parse(text)
+}
+
+// announce sets the client's comment to the message of t, if n.Comment is
+// set, and sends it to the client's channel if channel is true.
+func (n *NowPlaying) announce(stream *Stream, t *template.Template, channel bool) {
+	if !n.Comment && !channel {
+		return
+	}
+	data := NowPlayingData{
+		Title:    html.EscapeString(n.Title),
+		Elapsed:  stream.Elapsed(),
+		Finished: stream.State() == StateStopped,
+	}
+	var message bytes.Buffer
+	if err := t.Execute(&message, &data); err != nil {
+		return
+	}
+
+	client := stream.client
+	client.Do(func() {
+		self := client.Self
+		if self == nil {
+			return
+		}
+		if n.Comment {
+			self.SetComment(message.String())
+		}
+		if channel && self.Channel != nil {
+			self.Channel.Send(message.String(), false)
+		}
+	})
+}
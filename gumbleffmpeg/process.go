@@ -0,0 +1,58 @@
+package gumbleffmpeg // import "github.com/bmmcginty/gumble/gumbleffmpeg"
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultKillTimeout is the default value of Stream.KillTimeout.
+const DefaultKillTimeout = 2 * time.Second
+
+// stderrLimit is the number of bytes of ffmpeg's standard error that are
+// kept for ProcessError.
+const stderrLimit = 4096
+
+// ProcessError is passed to Stream.OnError when ffmpeg exits abnormally.
+type ProcessError struct {
+	// The error returned by exec.Cmd.Wait (e.g. an *exec.ExitError).
+	Err error
+	// The end of ffmpeg's standard error output.
+	Stderr string
+}
+
+func (e *ProcessError) Error() string {
+	message := "gumbleffmpeg: ffmpeg: " + e.Err.Error()
+	if lines := strings.Split(strings.TrimSpace(e.Stderr), "\n"); lines[len(lines)-1] != "" {
+		message += ": " + strings.TrimSpace(lines[len(lines)-1])
+	}
+	return message
+}
+
+// tailBuffer is an io.Writer that keeps the last stderrLimit bytes written to
+// it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	n := len(p)
+	if len(p) > stderrLimit {
+		p = p[len(p)-stderrLimit:]
+	}
+	if excess := t.buf.Len() + len(p) - stderrLimit; excess > 0 {
+		t.buf.Next(excess)
+	}
+	t.buf.Write(p)
+	return n, nil
+}
+
+func (t *tailBuffer) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.buf.String()
+}
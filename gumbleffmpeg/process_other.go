@@ -0,0 +1,25 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package gumbleffmpeg // import "github.com/bmmcginty/gumble/gumbleffmpeg"
+
+import (
+	"os/exec"
+)
+
+// setProcessGroup does nothing: process groups are not supported on this
+// platform, so only the processes started directly by a Stream are stopped.
+func setProcessGroup(cmd *exec.Cmd) {
+}
+
+// interruptProcessGroup kills cmd.
+func interruptProcessGroup(cmd *exec.Cmd) {
+	killProcessGroup(cmd)
+}
+
+// killProcessGroup kills cmd.
+func killProcessGroup(cmd *exec.Cmd) {
+	if cmd.Process != nil {
+		cmd.Process.Kill()
+	}
+}
@@ -81,6 +81,7 @@ func (*sourceExec) arguments() []string {
 
 func (s *sourceExec) start(cmd *exec.Cmd) error {
 	s.cmd = exec.Command(s.name, s.arg...)
+	setProcessGroup(s.cmd)
 	r, err := s.cmd.StdoutPipe()
 	if err != nil {
 		return err
@@ -95,9 +96,9 @@ func (s *sourceExec) start(cmd *exec.Cmd) error {
 
 func (s *sourceExec) done() {
 	if s.cmd != nil {
-		if p := s.cmd.Process; p != nil {
-			p.Kill()
-		}
+		// The command, and any processes that it started (e.g. when it is a
+		// shell), are killed.
+		killProcessGroup(s.cmd)
 		s.cmd.Wait()
 	}
 }
@@ -1,9 +1,9 @@
 package gumbleffmpeg // import "github.com/bmmcginty/gumble/gumbleffmpeg"
 
 import (
-	"encoding/binary"
 	"errors"
 	"io"
+	"os"
 	"os/exec"
 	"strconv"
 	"sync"
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumble/pcm"
+	"github.com/bmmcginty/gumble/gumbleutil"
 )
 
 // State represents the state of a Stream.
@@ -28,6 +30,10 @@ const (
 // the server.
 //
 // A stream can only be used once; it cannot be started after it is stopped.
+//
+// ffmpeg, and the processes it starts, are run in their own process group
+// (where supported), so that they are all stopped with the stream. The stream
+// is stopped when the client disconnects.
 type Stream struct {
 	// Command to execute to play the file. Defaults to "ffmpeg".
 	Command string
@@ -37,14 +43,50 @@ type Stream struct {
 	Source Source
 	// Starting offset.
 	Offset time.Duration
+	// How long ffmpeg is given to exit once it has been asked to, before it
+	// is killed. Defaults to DefaultKillTimeout.
+	KillTimeout time.Duration
+	// How much audio is buffered before the stream starts transmitting, and
+	// again whenever ffmpeg does not provide audio fast enough (e.g. when a
+	// network source stalls). The transmission is ended while the stream
+	// refills. At most twice this amount (and at least 120ms) is read ahead
+	// from ffmpeg. Zero disables buffering: the stream waits for each frame
+	// as it is needed. Cannot be changed after the stream starts.
+	Prebuffer time.Duration
+	// Called, from the stream's goroutine, with true when the stream starts
+	// buffering (including when it starts or resumes while buffering), and
+	// with false once it has buffered enough to transmit. Can be nil.
+	OnBuffering func(buffering bool)
+	// If true, the stream is paused while the client is alone in its channel,
+	// and resumed once another user joins it, to save CPU time and bandwidth.
+	// Cannot be changed after the stream starts.
+	PauseWhenAlone bool
+	// Called with a *ProcessError, from the stream's goroutine, when ffmpeg
+	// exits abnormally (i.e. with an error, and not because the stream was
+	// stopped). Can be nil.
+	OnError func(err error)
 
 	client  *gumble.Client
 	cmd     *exec.Cmd
-	pipe    io.ReadCloser
+	pipe    *os.File
+	stderr  *tailBuffer
+	exited  chan struct{}
+	waitErr error
+	link    gumble.Detacher
 	pause   chan struct{}
 	elapsed int64
 
+	// Audio read from ffmpeg, in chunks of chunkSize samples.
+	chunks    chan []int16
+	readDone  chan struct{}
+	stopRead  chan struct{}
+	pending   []int16
+	buffering bool
+
 	state State
+	// If the stream was paused because the client was alone.
+	autoPaused bool
+	aloneLock  sync.Mutex
 
 	l  sync.Mutex
 	wg sync.WaitGroup
@@ -53,12 +95,13 @@ type Stream struct {
 // New returns a new Stream for the given gumble Client and Source.
 func New(client *gumble.Client, source Source) *Stream {
 	return &Stream{
-		client:  client,
-		Volume:  1.0,
-		Source:  source,
-		Command: "ffmpeg",
-		pause:   make(chan struct{}),
-		state:   StateInitial,
+		client:      client,
+		Volume:      1.0,
+		Source:      source,
+		Command:     "ffmpeg",
+		KillTimeout: DefaultKillTimeout,
+		pause:       make(chan struct{}),
+		state:       StateInitial,
 	}
 }
 
@@ -70,6 +113,7 @@ func (s *Stream) Play() error {
 	switch s.state {
 	case StatePaused:
 		s.state = StatePlaying
+		s.autoPaused = false
 		go s.process()
 		return nil
 	case StatePlaying:
@@ -89,25 +133,62 @@ func (s *Stream) Play() error {
 	}
 	args = append(args, "-ac", strconv.Itoa(gumble.AudioChannels), "-ar", strconv.Itoa(gumble.AudioSampleRate), "-f", "s16le", "-")
 	cmd := exec.Command(s.Command, args...)
-	var err error
-	s.pipe, err = cmd.StdoutPipe()
+	setProcessGroup(cmd)
+	// The pipe is created here, rather than with cmd.StdoutPipe, so that it
+	// is not closed by cmd.Wait while its end is still being read.
+	pipe, w, err := os.Pipe()
 	if err != nil {
 		return err
 	}
+	cmd.Stdout = w
+	s.stderr = &tailBuffer{}
+	cmd.Stderr = s.stderr
 	if err := s.Source.start(cmd); err != nil {
+		pipe.Close()
+		w.Close()
 		return err
 	}
 	if err := cmd.Start(); err != nil {
+		pipe.Close()
+		w.Close()
 		s.Source.done()
 		return err
 	}
+	w.Close()
+	s.pipe = pipe
+	s.exited = make(chan struct{})
+	go func() {
+		s.waitErr = cmd.Wait()
+		close(s.exited)
+	}()
+	listener := gumbleutil.Listener{
+		Disconnect: s.onDisconnect,
+	}
+	if s.PauseWhenAlone {
+		listener.ChannelOccupancy = func(e *gumble.ChannelOccupancyEvent) {
+			go s.updateAlone()
+		}
+	}
+	s.link = s.client.Attach(listener)
+	s.chunks = make(chan []int16, 2*s.refillChunks())
+	s.readDone = make(chan struct{})
+	s.stopRead = make(chan struct{})
+	s.buffering = s.Prebuffer > 0
+	go s.read()
 	s.wg.Add(1)
 	s.cmd = cmd
 	s.state = StatePlaying
 	go s.process()
+	if s.PauseWhenAlone {
+		go s.updateAlone()
+	}
 	return nil
 }
 
+func (s *Stream) onDisconnect(e *gumble.DisconnectEvent) {
+	go s.Stop()
+}
+
 // State returns the state of the stream.
 func (s *Stream) State() State {
 	s.l.Lock()
@@ -123,11 +204,45 @@ func (s *Stream) Pause() error {
 		return errors.New("gumbleffmpeg: stream is not playing")
 	}
 	s.state = StatePaused
+	s.autoPaused = false
 	s.l.Unlock()
 	s.pause <- struct{}{}
 	return nil
 }
 
+// updateAlone pauses the stream if the client is alone in its channel, and
+// resumes it if it was paused because of that and the client no longer is
+// (see PauseWhenAlone).
+func (s *Stream) updateAlone() {
+	s.aloneLock.Lock()
+	defer s.aloneLock.Unlock()
+	var alone bool
+	s.client.Do(func() {
+		self := s.client.Self
+		alone = self != nil && self.Channel != nil && len(self.Channel.Users) <= 1
+	})
+
+	s.l.Lock()
+	switch {
+	case alone && s.state == StatePlaying:
+		s.state = StatePaused
+		s.autoPaused = true
+		s.l.Unlock()
+		select {
+		case s.pause <- struct{}{}:
+		case <-s.stopRead:
+			// The stream ended in the meantime.
+		}
+	case !alone && s.state == StatePaused && s.autoPaused:
+		s.state = StatePlaying
+		s.autoPaused = false
+		go s.process()
+		s.l.Unlock()
+	default:
+		s.l.Unlock()
+	}
+}
+
 // Stop stops the stream.
 func (s *Stream) Stop() error {
 	s.l.Lock()
@@ -136,7 +251,7 @@ func (s *Stream) Stop() error {
 		s.l.Unlock()
 		return errors.New("gumbleffmpeg: stream is not playing nor paused")
 	}
-	s.cleanup()
+	s.cleanup(true)
 	s.Wait()
 	return nil
 }
@@ -151,34 +266,107 @@ func (s *Stream) Elapsed() time.Duration {
 	return time.Duration(atomic.LoadInt64(&s.elapsed))
 }
 
+// chunkSize is the number of samples in the chunks read from ffmpeg (10ms).
+// Frames of any valid size are a multiple of it.
+const chunkSize = gumble.AudioDefaultFrameSize
+
+// refillChunks returns the number of chunks that must be buffered before the
+// stream transmits after buffering.
+func (s *Stream) refillChunks() int {
+	chunks := int((s.Prebuffer + 10*time.Millisecond - 1) / (10 * time.Millisecond))
+	// Enough audio for the largest frame size.
+	if largest := gumble.AudioMaximumFrameSize / chunkSize; chunks < largest {
+		chunks = largest
+	}
+	return chunks
+}
+
+// read reads the audio output by ffmpeg into s.chunks, until ffmpeg stops
+// or the stream is stopped.
+func (s *Stream) read() {
+	defer close(s.readDone)
+	defer close(s.chunks)
+
+	byteBuffer := make([]byte, chunkSize*2)
+	for {
+		if _, err := io.ReadFull(s.pipe, byteBuffer); err != nil {
+			return
+		}
+		chunk := make([]int16, chunkSize)
+		pcm.Decode(chunk, byteBuffer)
+		select {
+		case s.chunks <- chunk:
+		case <-s.stopRead:
+			return
+		}
+	}
+}
+
+func (s *Stream) setBuffering(buffering bool) {
+	s.buffering = buffering
+	if s.OnBuffering != nil {
+		s.OnBuffering(buffering)
+	}
+}
+
 func (s *Stream) process() {
 	// s.state has been set to StatePlaying
 
-	interval := s.client.Config.AudioInterval
-	frameSize := s.client.Config.AudioFrameSize()
-
-	byteBuffer := make([]byte, frameSize*2)
+	interval := s.client.AudioInterval()
+	frameSize := s.client.AudioFrameSize()
 
-	outgoing := s.client.AudioOutgoing()
-	defer close(outgoing)
+	var outgoing chan<- gumble.AudioBuffer
+	defer func() {
+		if outgoing != nil {
+			close(outgoing)
+		}
+	}()
 
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
+	if s.buffering {
+		s.setBuffering(true)
+	}
+
 	for {
 		select {
 		case <-s.pause:
 			return
 		case <-ticker.C:
-			if _, err := io.ReadFull(s.pipe, byteBuffer); err != nil {
+			if s.buffering {
+				select {
+				case <-s.readDone:
+				default:
+					if len(s.chunks) < s.refillChunks() {
+						continue
+					}
+				}
+				s.setBuffering(false)
+			}
+			if !s.fill(frameSize) {
+				if s.buffering {
+					// Underrun: end the transmission while refilling.
+					if outgoing != nil {
+						close(outgoing)
+						outgoing = nil
+					}
+					continue
+				}
 				s.l.Lock()
-				s.cleanup()
+				s.cleanup(false)
 				return
 			}
 			int16Buffer := make([]int16, frameSize)
-			for i := range int16Buffer {
-				float := float32(int16(binary.LittleEndian.Uint16(byteBuffer[i*2 : (i+1)*2])))
-				int16Buffer[i] = int16(s.Volume * float)
+			copy(int16Buffer, s.pending)
+			s.pending = append(s.pending[:0], s.pending[frameSize:]...)
+			if s.Volume != 1 {
+				for i, sample := range int16Buffer {
+					int16Buffer[i] = int16(s.Volume * float32(sample))
+				}
+			}
+			if outgoing == nil {
+				outgoing = s.client.AudioOutgoing()
 			}
 			atomic.AddInt64(&s.elapsed, int64(interval))
 			outgoing <- gumble.AudioBuffer(int16Buffer)
@@ -186,18 +374,88 @@ func (s *Stream) process() {
 	}
 }
 
-func (s *Stream) cleanup() {
-	defer s.l.Unlock()
+// fill adds chunks to s.pending until it holds frameSize samples, returning
+// true if it does. false is returned once ffmpeg's output has ended, or, if
+// the stream prebuffers, when no chunk is available; the stream is then
+// buffering.
+func (s *Stream) fill(frameSize int) bool {
+	for len(s.pending) < frameSize {
+		var chunk []int16
+		var ok bool
+		if s.Prebuffer > 0 {
+			select {
+			case chunk, ok = <-s.chunks:
+			default:
+				s.setBuffering(true)
+				return false
+			}
+		} else {
+			chunk, ok = <-s.chunks
+		}
+		if !ok {
+			return false
+		}
+		s.pending = append(s.pending, chunk...)
+	}
+	return true
+}
+
+// cleanup stops ffmpeg and releases the stream's resources. If stop is
+// false, ffmpeg has reached the end of its output, and is given time to exit
+// by itself. s.l must be held; it is released.
+func (s *Stream) cleanup(stop bool) {
 	// s.l has been acquired
 	if s.state == StateStopped {
+		s.l.Unlock()
 		return
 	}
-	s.cmd.Process.Kill()
-	s.cmd.Wait()
+	err := s.terminate(stop)
+	close(s.stopRead)
+	s.pipe.Close()
 	s.Source.done()
 	for len(s.pause) > 0 {
 		<-s.pause
 	}
+	if link := s.link; link != nil {
+		s.client.Do(link.Detach)
+		s.link = nil
+	}
 	s.state = StateStopped
+	s.l.Unlock()
+
+	if err != nil && s.OnError != nil {
+		s.OnError(err)
+	}
 	s.wg.Done()
 }
+
+// terminate waits for ffmpeg to exit, asking it, and then forcing it, to do
+// so if needed. A *ProcessError is returned if ffmpeg exited abnormally by
+// itself.
+func (s *Stream) terminate(stop bool) error {
+	timeout := s.KillTimeout
+	if timeout <= 0 {
+		timeout = DefaultKillTimeout
+	}
+	if !stop {
+		select {
+		case <-s.exited:
+			if s.waitErr != nil {
+				return &ProcessError{
+					Err:    s.waitErr,
+					Stderr: s.stderr.String(),
+				}
+			}
+			return nil
+		case <-time.After(timeout):
+		}
+	}
+	interruptProcessGroup(s.cmd)
+	select {
+	case <-s.exited:
+	case <-time.After(timeout):
+		killProcessGroup(s.cmd)
+		<-s.exited
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+// Package gumblehttp serves the status of a gumble client over HTTP.
+//
+// The following endpoints are provided by Handler:
+//  /             HTML status page
+//  /status.json  connection state, ping and now playing information
+//  /tree.json    channel tree, with the users in each channel
+//  /state.json   the client's gumble.Snapshot
+package gumblehttp // import "github.com/bmmcginty/gumble/gumblehttp"
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleffmpeg"
+)
+
+// Status is the data served by the /status.json endpoint.
+type Status struct {
+	// "connected" or "disconnected".
+	State string `json:"state"`
+	// The address of the server.
+	Server string `json:"server"`
+	// The average round trip time of TCP pings to the server, in
+	// milliseconds.
+	Ping float32 `json:"ping"`
+	// The name of the client's user, and the path of the channel it is in.
+	Name    string   `json:"name,omitempty"`
+	Channel []string `json:"channel,omitempty"`
+	// The number of users connected to the server.
+	Users int `json:"users"`
+	// What is currently being played. nil if nothing is.
+	NowPlaying *NowPlaying `json:"now_playing,omitempty"`
+}
+
+// NowPlaying describes the media played by a gumbleffmpeg.Stream.
+type NowPlaying struct {
+	Title string `json:"title"`
+	// "playing", "paused" or "stopped".
+	State string `json:"state"`
+	// How much of the media has been played, in seconds.
+	Elapsed float64 `json:"elapsed"`
+}
+
+// Channel is a node in the tree served by the /tree.json endpoint.
+type Channel struct {
+	ID       uint32     `json:"id"`
+	Name     string     `json:"name"`
+	Users    []User     `json:"users,omitempty"`
+	Children []*Channel `json:"children,omitempty"`
+}
+
+// User is a user in a Channel.
+type User struct {
+	Session      uint32 `json:"session"`
+	Name         string `json:"name"`
+	Muted        bool   `json:"muted,omitempty"`
+	Deafened     bool   `json:"deafened,omitempty"`
+	SelfMuted    bool   `json:"self_muted,omitempty"`
+	SelfDeafened bool   `json:"self_deafened,omitempty"`
+}
+
+// Handler is an http.Handler that serves the status of a client.
+type Handler struct {
+	mu       sync.Mutex
+	client   *gumble.Client
+	title    string
+	stream   *gumbleffmpeg.Stream
+	mux      *http.ServeMux
+	template *template.Template
+}
+
+// New returns a new Handler that serves the status of client.
+func New(client *gumble.Client) *Handler {
+	h := &Handler{
+		client:   client,
+		mux:      http.NewServeMux(),
+		template: template.Must(template.New("status").Parse(statusTemplate)),
+	}
+	h.mux.HandleFunc("/", h.serveIndex)
+	h.mux.HandleFunc("/status.json", h.serveStatus)
+	h.mux.HandleFunc("/tree.json", h.serveTree)
+	h.mux.HandleFunc("/state.json", h.serveState)
+	return h
+}
+
+// SetClient changes the client whose status is served, e.g. after
+// reconnecting to the server.
+func (h *Handler) SetClient(client *gumble.Client) {
+	h.mu.Lock()
+	h.client = client
+	h.mu.Unlock()
+}
+
+// SetNowPlaying sets the stream whose playback is reported, and its title.
+// Passing a nil stream clears the now playing information.
+func (h *Handler) SetNowPlaying(title string, stream *gumbleffmpeg.Stream) {
+	h.mu.Lock()
+	h.title = title
+	h.stream = stream
+	h.mu.Unlock()
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// Status returns the data served by the /status.json endpoint.
+func (h *Handler) Status() *Status {
+	h.mu.Lock()
+	client, title, stream := h.client, h.title, h.stream
+	h.mu.Unlock()
+
+	snapshot := client.Snapshot()
+	status := &Status{
+		State:  "disconnected",
+		Server: snapshot.Server.Address,
+		Ping:   snapshot.Server.Ping,
+		Users:  len(snapshot.Users),
+	}
+	if snapshot.State == gumble.StateSynced {
+		status.State = "connected"
+	}
+	if self := findUser(snapshot, snapshot.Self); self != nil {
+		status.Name = self.Name
+		status.Channel = channelPath(snapshot, self.Channel)
+	}
+	if stream != nil {
+		status.NowPlaying = &NowPlaying{
+			Title:   title,
+			State:   streamState(stream.State()),
+			Elapsed: stream.Elapsed().Seconds(),
+		}
+	}
+	return status
+}
+
+// Tree returns the data served by the /tree.json endpoint. nil is returned if
+// the client has not received the root channel.
+func (h *Handler) Tree() *Channel {
+	h.mu.Lock()
+	client := h.client
+	h.mu.Unlock()
+
+	return tree(client.Snapshot())
+}
+
+func streamState(state gumbleffmpeg.State) string {
+	switch state {
+	case gumbleffmpeg.StatePlaying:
+		return "playing"
+	case gumbleffmpeg.StatePaused:
+		return "paused"
+	}
+	return "stopped"
+}
+
+func findUser(snapshot *gumble.Snapshot, session *uint32) *gumble.UserSnapshot {
+	if session == nil {
+		return nil
+	}
+	for _, user := range snapshot.Users {
+		if user.Session == *session {
+			return user
+		}
+	}
+	return nil
+}
+
+func findChannel(snapshot *gumble.Snapshot, id uint32) *gumble.ChannelSnapshot {
+	for _, channel := range snapshot.Channels {
+		if channel.ID == id {
+			return channel
+		}
+	}
+	return nil
+}
+
+// channelPath returns the names of the channels from the root channel to the
+// channel with the given ID.
+func channelPath(snapshot *gumble.Snapshot, id uint32) []string {
+	var path []string
+	for channel := findChannel(snapshot, id); channel != nil; {
+		path = append([]string{channel.Name}, path...)
+		if channel.Parent == nil {
+			break
+		}
+		channel = findChannel(snapshot, *channel.Parent)
+	}
+	return path
+}
+
+func tree(snapshot *gumble.Snapshot) *Channel {
+	channels := make(map[uint32]*gumble.ChannelSnapshot, len(snapshot.Channels))
+	for _, channel := range snapshot.Channels {
+		channels[channel.ID] = channel
+	}
+	users := make(map[uint32]*gumble.UserSnapshot, len(snapshot.Users))
+	for _, user := range snapshot.Users {
+		users[user.Session] = user
+	}
+
+	var build func(channel *gumble.ChannelSnapshot) *Channel
+	build = func(channel *gumble.ChannelSnapshot) *Channel {
+		node := &Channel{
+			ID:   channel.ID,
+			Name: channel.Name,
+		}
+		for _, session := range channel.Users {
+			if user := users[session]; user != nil {
+				node.Users = append(node.Users, User{
+					Session:      user.Session,
+					Name:         user.Name,
+					Muted:        user.Muted,
+					Deafened:     user.Deafened,
+					SelfMuted:    user.SelfMuted,
+					SelfDeafened: user.SelfDeafened,
+				})
+			}
+		}
+		for _, id := range channel.Children {
+			if child := channels[id]; child != nil {
+				node.Children = append(node.Children, build(child))
+			}
+		}
+		return node
+	}
+
+	root := channels[0]
+	if root == nil {
+		return nil
+	}
+	return build(root)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(v)
+}
+
+func (h *Handler) serveStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, h.Status())
+}
+
+func (h *Handler) serveTree(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, h.Tree())
+}
+
+func (h *Handler) serveState(w http.ResponseWriter, r *http.Request) {
+	h.mu.Lock()
+	client := h.client
+	h.mu.Unlock()
+
+	writeJSON(w, client.Snapshot())
+}
+
+func (h *Handler) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	data := struct {
+		Status *Status
+		Tree   *Channel
+		Time   time.Time
+	}{
+		Status: h.Status(),
+		Tree:   h.Tree(),
+		Time:   time.Now(),
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-cache")
+	h.template.Execute(w, data)
+}
+
+const statusTemplate = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Status.Server}}</title>
+</head>
+<body>
+<h1>{{.Status.Server}}</h1>
+<p>{{.Status.State}}{{if .Status.Name}} as {{.Status.Name}}{{end}}, ping {{printf "%.1f" .Status.Ping}} ms, {{.Status.Users}} users</p>
+{{with .Status.NowPlaying}}<p>{{.State}}: {{.Title}} ({{printf "%.0f" .Elapsed}}s)</p>{{end}}
+{{define "channel"}}<li>{{.Name}}{{if or .Users .Children}}<ul>
+{{range .Users}}<li><em>{{.Name}}</em>{{if or .Muted .SelfMuted}} (muted){{end}}{{if or .Deafened .SelfDeafened}} (deafened){{end}}</li>
+{{end}}{{range .Children}}{{template "channel" .}}{{end}}</ul>{{end}}</li>
+{{end}}{{with .Tree}}<ul>
+{{template "channel" .}}</ul>{{end}}
+<p><small>{{.Time.Format "2006-01-02 15:04:05"}}</small></p>
+</body>
+</html>
+`
@@ -0,0 +1,167 @@
+// Package gumblenull is a no-op audio backend for gumble. Incoming audio is
+// discarded and silence is sent in place of captured audio, so that
+// applications can run in containers and CI environments without any audio
+// libraries or devices.
+//
+// Applications typically select the backend at runtime:
+//
+//  var stream gumbleutil.AudioStream
+//  if gumblenull.Requested() {
+//    stream = gumblenull.New(client)
+//  } else {
+//    stream, err = gumbleopenal.New(client, &input, &output, false)
+//  }
+package gumblenull // import "github.com/bmmcginty/gumble/gumblenull"
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+)
+
+// EnvVar is the environment variable that selects the audio backend. The null
+// backend is requested when it is set to "null".
+const EnvVar = "GUMBLE_AUDIO"
+
+var ErrState = errors.New("gumblenull: invalid state")
+
+// Requested returns true if the null backend has been selected through the
+// GUMBLE_AUDIO environment variable.
+func Requested() bool {
+	return os.Getenv(EnvVar) == "null"
+}
+
+var _ gumbleutil.AudioStream = (*Stream)(nil)
+
+// Stream is a no-op audio stream.
+type Stream struct {
+	client *gumble.Client
+	link   gumble.Detacher
+
+	l          sync.Mutex
+	micVolume  float32
+	sourceStop chan struct{}
+	sourceDone chan struct{}
+
+	packets uint64
+}
+
+// New returns a new Stream for client.
+func New(client *gumble.Client) *Stream {
+	return &Stream{
+		client:    client,
+		micVolume: 1,
+	}
+}
+
+// AttachStream starts receiving (and discarding) the audio received by
+// client.
+func (s *Stream) AttachStream(client *gumble.Client) {
+	s.link = client.AttachAudio(s)
+}
+
+// Destroy stops the stream.
+func (s *Stream) Destroy() {
+	if s.link != nil {
+		s.link.Detach()
+		s.link = nil
+	}
+	s.StopSource()
+}
+
+// StartSource starts sending silence to the server, one frame every
+// AudioInterval. inputDevice is ignored.
+func (s *Stream) StartSource(inputDevice *string) error {
+	s.l.Lock()
+	defer s.l.Unlock()
+	if s.sourceStop != nil {
+		return ErrState
+	}
+	s.sourceStop = make(chan struct{})
+	s.sourceDone = make(chan struct{})
+	go s.sourceRoutine(s.sourceStop, s.sourceDone)
+	return nil
+}
+
+// StopSource stops sending silence.
+func (s *Stream) StopSource() error {
+	s.l.Lock()
+	if s.sourceStop == nil {
+		s.l.Unlock()
+		return ErrState
+	}
+	close(s.sourceStop)
+	done := s.sourceDone
+	s.sourceStop = nil
+	s.l.Unlock()
+	<-done
+	return nil
+}
+
+// GetMicVolume returns the capture volume. It has no effect on the silence
+// that is sent.
+func (s *Stream) GetMicVolume() float32 {
+	s.l.Lock()
+	defer s.l.Unlock()
+	return s.micVolume
+}
+
+// SetMicVolume sets the capture volume, either to change or by change if
+// relative is true.
+func (s *Stream) SetMicVolume(change float32, relative bool) {
+	s.l.Lock()
+	defer s.l.Unlock()
+	val := change
+	if relative {
+		val += s.micVolume
+	}
+	if val >= 1 {
+		val = 1.0
+	}
+	if val <= 0 {
+		val = 0
+	}
+	s.micVolume = val
+}
+
+// PacketsReceived returns the number of incoming audio packets that have been
+// discarded.
+func (s *Stream) PacketsReceived() uint64 {
+	return atomic.LoadUint64(&s.packets)
+}
+
+// OnAudioStream implements gumble.AudioListener.
+func (s *Stream) OnAudioStream(e *gumble.AudioStreamEvent) {
+	go func() {
+		for range e.C {
+			atomic.AddUint64(&s.packets, 1)
+		}
+	}()
+}
+
+func (s *Stream) sourceRoutine(stop, done chan struct{}) {
+	defer close(done)
+
+	outgoing := s.client.AudioOutgoing()
+	defer close(outgoing)
+
+	interval := s.client.AudioInterval()
+	frameSize := s.client.AudioFrameSize()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			outgoing <- make(gumble.AudioBuffer, frameSize)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package gumbleopenal // import "github.com/bmmcginty/gumble/gumbleopenal"
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/bmmcginty/go-openal/openal"
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+var (
+	// ErrDeviceNotFound is the reason of a DeviceError when the device does
+	// not exist or cannot be opened.
+	ErrDeviceNotFound = errors.New("device not found")
+	// ErrFormatUnsupported is the reason of a DeviceError when the device
+	// exists, but does not support mono 16-bit audio at any of the tried
+	// sample rates.
+	ErrFormatUnsupported = errors.New("format unsupported")
+	// ErrNoContext is the reason of a DeviceError when a playback context
+	// cannot be created on the output device.
+	ErrNoContext = errors.New("could not create context")
+)
+
+// DeviceError is returned by New when an audio device cannot be opened.
+//
+// errors.Is reports a DeviceError as ErrInputDevice or ErrOutputDevice,
+// depending on the device that failed.
+type DeviceError struct {
+	// The name of the device. The empty string is the default device.
+	Device string
+	// If the device is a capture (input) device.
+	Capture bool
+	// The reason the device could not be opened (ErrDeviceNotFound,
+	// ErrFormatUnsupported or ErrNoContext).
+	Err error
+}
+
+func (e *DeviceError) Error() string {
+	kind := "output"
+	if e.Capture {
+		kind = "input"
+	}
+	name := "default device"
+	if e.Device != "" {
+		name = strconv.Quote(e.Device)
+	}
+	return "gumbleopenal: " + kind + " " + name + ": " + e.Err.Error()
+}
+
+func (e *DeviceError) Unwrap() error {
+	return e.Err
+}
+
+func (e *DeviceError) Is(target error) bool {
+	if e.Capture {
+		return target == ErrInputDevice
+	}
+	return target == ErrOutputDevice
+}
+
+// Capabilities describes the audio devices found by Probe.
+type Capabilities struct {
+	// The sample rates at which the input device can capture mono 16-bit
+	// audio. Rates other than gumble.AudioSampleRate are resampled.
+	CaptureRates []int
+	// If a playback context could be created on the output device.
+	Playback bool
+}
+
+// Probe reports the capabilities of the given input and output devices,
+// without keeping them open. nil device names refer to the default devices.
+// A DeviceError is returned for a device that cannot be used by New.
+func Probe(inputDevice *string, outputDevice *string) (*Capabilities, error) {
+	caps := &Capabilities{}
+	frameSize := gumble.AudioSampleRate / 100
+
+	input := deviceName(inputDevice)
+	for _, rate := range captureSampleRates {
+		device := openal.CaptureOpenDevice(input, uint32(rate), openal.FormatMono16, uint32(deviceSamples(frameSize, rate)))
+		if device != nil {
+			device.CaptureCloseDevice()
+			caps.CaptureRates = append(caps.CaptureRates, rate)
+		}
+	}
+	if len(caps.CaptureRates) == 0 {
+		return caps, captureError(input, frameSize)
+	}
+
+	output := deviceName(outputDevice)
+	device := openal.OpenDevice(output)
+	if device == nil {
+		return caps, &DeviceError{Device: output, Err: ErrDeviceNotFound}
+	}
+	defer device.CloseDevice()
+	context := device.CreateContext()
+	if context == nil {
+		return caps, &DeviceError{Device: output, Err: ErrNoContext}
+	}
+	context.Destroy()
+	caps.Playback = true
+	return caps, nil
+}
+
+// deviceName returns the OpenAL device name for name; nil refers to the
+// default device.
+func deviceName(name *string) string {
+	if name == nil {
+		return ""
+	}
+	return *name
+}
+
+// captureError returns the DeviceError for a capture device that could not be
+// opened in mono 16-bit format at any rate.
+func captureError(name string, frameSize int) error {
+	// If the device can be opened in another format, it exists but is not
+	// usable by gumble.
+	if device := openal.CaptureOpenDevice(name, gumble.AudioSampleRate, openal.FormatStereo16, uint32(frameSize)); device != nil {
+		device.CaptureCloseDevice()
+		return &DeviceError{Device: name, Capture: true, Err: ErrFormatUnsupported}
+	}
+	return &DeviceError{Device: name, Capture: true, Err: ErrDeviceNotFound}
+}
@@ -0,0 +1,132 @@
+package gumbleopenal // import "github.com/bmmcginty/gumble/gumbleopenal"
+
+import (
+	"math"
+	"sort"
+
+	"github.com/bmmcginty/go-openal/openal"
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// SpatialLayout returns the azimuth, in degrees, at which user is placed
+// around the listener: 0 is straight ahead, positive angles are to the right,
+// and negative angles to the left. users are the users whose audio is being
+// played, sorted by session, and include user.
+type SpatialLayout func(user *gumble.User, users []*gumble.User) float64
+
+// SpreadLayout returns a SpatialLayout that spreads the users evenly over an
+// arc of width degrees, centered in front of the listener. A single user is
+// placed straight ahead.
+func SpreadLayout(width float64) SpatialLayout {
+	return func(user *gumble.User, users []*gumble.User) float64 {
+		if len(users) < 2 {
+			return 0
+		}
+		for i, u := range users {
+			if u == user {
+				return -width/2 + width*float64(i)/float64(len(users)-1)
+			}
+		}
+		return 0
+	}
+}
+
+// FixedLayout returns a SpatialLayout that places the users named in
+// azimuths at the given azimuth. The other users are placed by fallback, or
+// straight ahead if fallback is nil.
+func FixedLayout(azimuths map[string]float64, fallback SpatialLayout) SpatialLayout {
+	return func(user *gumble.User, users []*gumble.User) float64 {
+		if azimuth, ok := azimuths[user.Name]; ok {
+			return azimuth
+		}
+		if fallback != nil {
+			return fallback(user, users)
+		}
+		return 0
+	}
+}
+
+// SetSpatialLayout places the sources of the users around the listener, as
+// given by layout, so that the users can be told apart by direction. With
+// headphones, enabling OpenAL Soft's HRTF (e.g. hrtf = true in alsoft.conf)
+// renders the directions binaurally. A nil layout, the default, places every
+// user straight ahead.
+func (s *Stream) SetSpatialLayout(layout SpatialLayout) {
+	s.mixLock.Lock()
+	s.layout = layout
+	s.updatePositions()
+	s.mixLock.Unlock()
+}
+
+// SetPositionalAudio sets whether the positions that users send with their
+// audio (such as the positions of their characters in a game) are used to
+// place their sources, instead of the spatial layout. The listener is at the
+// position given to SetListenerPosition (the origin by default), and the
+// users are attenuated with their distance to it. Users that send no position
+// are still placed by the spatial layout.
+func (s *Stream) SetPositionalAudio(enabled bool) {
+	s.mixLock.Lock()
+	s.positional = enabled
+	if !enabled && len(s.positioned) > 0 {
+		s.positioned = make(map[*gumble.User]bool)
+		s.updatePositions()
+	}
+	s.mixLock.Unlock()
+}
+
+// SetListenerPosition sets the position of the listener, in the coordinates
+// of the positions that users send (see SetPositionalAudio).
+func (s *Stream) SetListenerPosition(x, y, z float32) {
+	s.lockContext()
+	openal.Listener{}.SetPosition(openal.Vector{x, y, -z})
+	unlockContext()
+}
+
+// updatePositions places the sources of the users that are not positioned
+// by their audio, following the spatial layout. s.mixLock must be held.
+func (s *Stream) updatePositions() {
+	users := make([]*gumble.User, 0, len(s.sources))
+	for user := range s.sources {
+		users = append(users, user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Session < users[j].Session })
+	s.lockContext()
+	defer unlockContext()
+	for _, user := range users {
+		if s.positioned[user] {
+			continue
+		}
+		var position openal.Vector
+		if s.layout != nil {
+			azimuth := s.layout(user, users) * math.Pi / 180
+			position = openal.Vector{float32(math.Sin(azimuth)), 0, float32(-math.Cos(azimuth))}
+		}
+		source := s.sources[user]
+		source.SetSourceRelative(true)
+		source.SetPosition(&position)
+	}
+}
+
+// positionSource places user's source at the position sent with packet, if
+// positional audio is enabled.
+func (s *Stream) positionSource(user *gumble.User, source *openal.Source, packet *gumble.AudioPacket) {
+	s.mixLock.Lock()
+	defer s.mixLock.Unlock()
+	if !s.positional {
+		return
+	}
+	if !packet.HasPosition {
+		if s.positioned[user] {
+			delete(s.positioned, user)
+			s.updatePositions()
+		}
+		return
+	}
+	s.positioned[user] = true
+	s.lockContext()
+	defer unlockContext()
+	// Mumble's coordinates are left-handed (Z points forward), and OpenAL's
+	// are right-handed (Z points backward).
+	source.SetSourceRelative(false)
+	source.SetPosition(&openal.Vector{packet.X, packet.Y, -packet.Z})
+}
@@ -4,9 +4,12 @@ import (
 	"encoding/binary"
 	"errors"
 	"os/exec"
+	"sync"
 	"time"
 
 	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumble/pcm"
+	"github.com/bmmcginty/gumble/gumbleutil"
 	"github.com/bmmcginty/go-openal/openal"
 )
 
@@ -17,6 +20,46 @@ var (
 	ErrOutputDevice = errors.New("gumbleopenal: invalid output device or parameters")
 )
 
+const (
+	// DefaultPriorityAttenuation is the default fraction by which other users
+	// are attenuated while a priority speaker is talking.
+	DefaultPriorityAttenuation = 0.5
+
+	// talkingTimeout is how long a user must be silent before they are
+	// considered to have stopped talking.
+	talkingTimeout = 250 * time.Millisecond
+
+	// captureFailureTimeout is how long the capture device must fail to
+	// return samples before it is considered disconnected.
+	captureFailureTimeout = time.Second
+	// reopenMinBackoff and reopenMaxBackoff bound the delay between attempts
+	// to re-open a disconnected capture device.
+	reopenMinBackoff = 500 * time.Millisecond
+	reopenMaxBackoff = 10 * time.Second
+)
+
+// OpenAL has a single current context per process, which the sources and
+// buffers that are used belong to. contextLock is held while the context of a
+// Stream is used, so that Streams playing to different output devices can be
+// used at the same time. It is acquired after Stream.mixLock.
+var (
+	contextLock    sync.Mutex
+	currentContext *openal.Context
+)
+
+// lockContext acquires contextLock, and makes the Stream's context current.
+func (s *Stream) lockContext() {
+	contextLock.Lock()
+	if currentContext != s.contextSink {
+		s.contextSink.Activate()
+		currentContext = s.contextSink
+	}
+}
+
+func unlockContext() {
+	contextLock.Unlock()
+}
+
 func beep() {
 	cmd := exec.Command("beep")
 	cmdout, err := cmd.Output()
@@ -27,80 +70,244 @@ func beep() {
 	}
 }
 
+var _ gumbleutil.AudioStream = (*Stream)(nil)
+
 type Stream struct {
-	client *gumble.Client
-	link   gumble.Detacher
+	// If not nil, applied to the captured audio, after the capture volume
+	// (see gumbleutil.AGC). It must be set before StartSource.
+	AGC *gumbleutil.AGC
+
+	// Called with ErrMic when the capture device stops returning audio (e.g.
+	// a USB microphone was unplugged). The device is then re-opened
+	// periodically until it returns. Can be nil.
+	MicError func(err error)
+	// Called when a capture device that previously failed has been opened
+	// again. Can be nil.
+	MicRestored func()
 
+	client    *gumble.Client
+	link      gumble.Detacher
+	eventLink gumble.Detacher
+
+	// sourceLock protects the capture device, which the source routine
+	// re-opens when it fails, and the state that depends on it.
+	sourceLock      sync.Mutex
 	deviceSource    *openal.CaptureDevice
+	inputDevice     *string
 	sourceFrameSize int
+	sourceRate      int
+	resampler       *gumbleutil.Resampler
+	captured        []int16
 	micVolume       float32
 	sourceStop      chan bool
+	sourceChange    chan *gumble.AudioConfigEvent
 
 	deviceSink  *openal.Device
 	contextSink *openal.Context
+
+	mixLock             sync.Mutex
+	sources             map[*gumble.User]*openal.Source
+	talking             map[*gumble.User]bool
+	priorityAttenuation float32
+	// The placement of the sources (see SetSpatialLayout and
+	// SetPositionalAudio), and the users placed by their audio's position.
+	layout     SpatialLayout
+	positional bool
+	positioned map[*gumble.User]bool
 }
 
+// New opens the given input and output devices and returns a new Stream. nil
+// device names refer to the default devices. If a device cannot be opened, a
+// *DeviceError describing the failure is returned.
+//
+// If test is true, the devices are only checked and then closed, and a nil
+// Stream is returned; Probe gives more detail about the devices.
 func New(client *gumble.Client, inputDevice *string, outputDevice *string, test bool) (*Stream, error) {
-frmsz := 480
-if !test {
-frmsz = client.Config.AudioFrameSize()
-}
+	frmsz := gumble.AudioSampleRate / 100
+	if !test {
+		frmsz = client.AudioFrameSize()
+	}
 
-idev := openal.CaptureOpenDevice(*inputDevice, gumble.AudioSampleRate, openal.FormatMono16, uint32(frmsz))
+	input := deviceName(inputDevice)
+	idev, rate := openCaptureDevice(input, frmsz)
 	if idev == nil {
-return nil,ErrInputDevice
+		return nil, captureError(input, frmsz)
 	}
 
-odev := openal.OpenDevice(*outputDevice)
-if odev==nil {
-idev.CaptureCloseDevice()
-return nil,ErrOutputDevice
-}
+	output := deviceName(outputDevice)
+	odev := openal.OpenDevice(output)
+	if odev == nil {
+		idev.CaptureCloseDevice()
+		return nil, &DeviceError{Device: output, Err: ErrDeviceNotFound}
+	}
 
-if test {
-idev.CaptureCloseDevice()
-odev.CloseDevice()
-return nil,nil
-}
+	context := odev.CreateContext()
+	if context == nil {
+		idev.CaptureCloseDevice()
+		odev.CloseDevice()
+		return nil, &DeviceError{Device: output, Err: ErrNoContext}
+	}
+
+	if test {
+		context.Destroy()
+		idev.CaptureCloseDevice()
+		odev.CloseDevice()
+		return nil, nil
+	}
 
 	s := &Stream{
 		client:          client,
+		deviceSource:    idev,
 		sourceFrameSize: frmsz,
+		sourceRate:      rate,
+		inputDevice:     &input,
+		sourceChange:    make(chan *gumble.AudioConfigEvent, 1),
+
+		deviceSink:  odev,
+		contextSink: context,
+
+		sources:             make(map[*gumble.User]*openal.Source),
+		talking:             make(map[*gumble.User]bool),
+		priorityAttenuation: DefaultPriorityAttenuation,
+		positioned:          make(map[*gumble.User]bool),
 	}
+	s.setResampler()
+	s.lockContext()
+	unlockContext()
 
-	s.deviceSource = idev
-if s.deviceSource==nil {
-return nil,ErrInputDevice
+	return s, nil
 }
 
-	s.deviceSink = odev
-if s.deviceSink==nil {
-return nil,ErrOutputDevice
+// AttachStream attaches the Stream to client, so that it plays the audio of
+// every user, and follows the events that affect it (see AttachEvents).
+func (s *Stream) AttachStream(client *gumble.Client) {
+	s.link = client.AttachAudio(s)
+	s.AttachEvents(client)
 }
-	s.contextSink = s.deviceSink.CreateContext()
-if s.contextSink == nil {
-s.Destroy();
-return nil,ErrOutputDevice
+
+// AttachEvents only attaches the event listeners of the Stream to client,
+// which update the capture when the audio configuration changes, and the
+// gains when priority speakers change. It is used instead of AttachStream
+// when the Stream plays the audio of some users only, e.g. as the listener of
+// a gumbleutil.AudioRoute.
+func (s *Stream) AttachEvents(client *gumble.Client) {
+	s.eventLink = client.Attach(gumbleutil.Listener{
+		AudioConfig: s.onAudioConfig,
+		UserChange:  s.onUserChange,
+	})
 }
-	s.contextSink.Activate()
 
-	return s, nil
+// onUserChange re-applies the source gains when a user's priority speaker
+// flag changes.
+func (s *Stream) onUserChange(e *gumble.UserChangeEvent) {
+	if e.Type.Has(gumble.UserChangePrioritySpeaker) {
+		s.mixLock.Lock()
+		s.updateGains()
+		s.mixLock.Unlock()
+	}
 }
 
-func (s *Stream) AttachStream(client *gumble.Client) {
-	s.link = client.Config.AttachAudio(s)
+// GetPriorityAttenuation returns the fraction by which other users are
+// attenuated while a priority speaker is talking.
+func (s *Stream) GetPriorityAttenuation() float32 {
+	s.mixLock.Lock()
+	defer s.mixLock.Unlock()
+	return s.priorityAttenuation
+}
+
+// SetPriorityAttenuation sets the fraction (between 0 and 1) by which other
+// users' gains are reduced while a priority speaker is talking. 0 disables
+// the attenuation; 1 silences other users completely.
+func (s *Stream) SetPriorityAttenuation(attenuation float32) {
+	if attenuation < 0 {
+		attenuation = 0
+	}
+	if attenuation > 1 {
+		attenuation = 1
+	}
+	s.mixLock.Lock()
+	s.priorityAttenuation = attenuation
+	s.updateGains()
+	s.mixLock.Unlock()
+}
+
+// setTalking records if user is currently talking, updating the gains of all
+// sources if the set of talking priority speakers changed.
+func (s *Stream) setTalking(user *gumble.User, talking bool) {
+	s.mixLock.Lock()
+	defer s.mixLock.Unlock()
+	if s.talking[user] == talking {
+		return
+	}
+	if talking {
+		s.talking[user] = true
+	} else {
+		delete(s.talking, user)
+	}
+	if user.PrioritySpeaker {
+		s.updateGains()
+	}
+}
+
+// updateGains sets the gain of each source, attenuating users that are not
+// priority speakers while a priority speaker is talking. s.mixLock must be
+// held.
+func (s *Stream) updateGains() {
+	attenuate := false
+	for user := range s.talking {
+		if user.PrioritySpeaker {
+			attenuate = true
+			break
+		}
+	}
+	s.lockContext()
+	defer unlockContext()
+	for user, source := range s.sources {
+		gain := user.Volume
+		if attenuate && !user.PrioritySpeaker {
+			gain *= 1 - s.priorityAttenuation
+		}
+		source.SetGain(gain)
+	}
+}
+
+// onAudioConfig passes the client's new audio interval and frame size to the
+// source routine. A change that the routine has not received yet is replaced.
+func (s *Stream) onAudioConfig(e *gumble.AudioConfigEvent) {
+	for {
+		select {
+		case s.sourceChange <- e:
+			return
+		default:
+		}
+		select {
+		case <-s.sourceChange:
+		default:
+		}
+	}
 }
 
 func (s *Stream) Destroy() {
 if(s.link!=nil) {
 	s.link.Detach()
 }
+	if s.eventLink != nil {
+		s.eventLink.Detach()
+		s.eventLink = nil
+	}
+	s.StopSource()
+	s.sourceLock.Lock()
 	if s.deviceSource != nil {
-			s.StopSource()
 			s.deviceSource.CaptureCloseDevice()
 		s.deviceSource = nil
 	}
+	s.sourceLock.Unlock()
 	if s.deviceSink != nil {
+		contextLock.Lock()
+		if currentContext == s.contextSink {
+			currentContext = nil
+		}
+		contextLock.Unlock()
 		s.contextSink.Destroy()
 		s.deviceSink.CloseDevice()
 		s.contextSink = nil
@@ -112,26 +319,33 @@ func (s *Stream) StartSource(inputDevice *string) error {
 	if s.sourceStop != nil {
 		return ErrState
 	}
+	if inputDevice == nil {
+		inputDevice = s.inputDevice
+	}
+	s.sourceLock.Lock()
+	defer s.sourceLock.Unlock()
 	if s.deviceSource == nil {
 		return ErrMic
 	} else {
 		s.deviceSource.CaptureStart()
 		s.sourceStop = make(chan bool)
-		go s.sourceRoutine(inputDevice)
+		go s.sourceRoutine(inputDevice, s.sourceStop)
 	}
 	return nil
 }
 
 func (s *Stream) StopSource() error {
-	if s.deviceSource == nil {
-		return ErrMic
-	}
-	s.deviceSource.CaptureStop()
 	if s.sourceStop == nil {
 		return ErrState
 	}
 	close(s.sourceStop)
 	s.sourceStop = nil
+	s.sourceLock.Lock()
+	defer s.sourceLock.Unlock()
+	if s.deviceSource == nil {
+		return ErrMic
+	}
+	s.deviceSource.CaptureStop()
 	return nil
 }
 
@@ -158,11 +372,17 @@ func (s *Stream) SetMicVolume(change float32, relative bool) {
 
 func (s *Stream) OnAudioStream(e *gumble.AudioStreamEvent) {
 	go func(e *gumble.AudioStreamEvent) {
+		s.lockContext()
 		var source = openal.NewSource()
+		emptyBufs := openal.NewBuffers(8)
+		unlockContext()
 		e.User.AudioSource = &source
-		e.User.AudioSource.SetGain(e.User.Volume)
+		s.mixLock.Lock()
+		s.sources[e.User] = &source
+		s.updateGains()
+		s.updatePositions()
+		s.mixLock.Unlock()
 		//source := e.User.AudioSource
-		emptyBufs := openal.NewBuffers(8)
 		reclaim := func() {
 			if n := source.BuffersProcessed(); n > 0 {
 				reclaimedBufs := make(openal.Buffers, n)
@@ -171,18 +391,45 @@ func (s *Stream) OnAudioStream(e *gumble.AudioStreamEvent) {
 			}
 		}
 		var raw [gumble.AudioMaximumFrameSize * 2]byte
-		for packet := range e.C {
+		talkingTimer := time.NewTimer(talkingTimeout)
+		talkingTimer.Stop()
+		for {
+			var packet *gumble.AudioPacket
+			select {
+			case packet = <-e.C:
+			case <-talkingTimer.C:
+				s.setTalking(e.User, false)
+				continue
+			}
+			if packet == nil {
+				break
+			}
+			s.setTalking(e.User, true)
+			if !talkingTimer.Stop() {
+				select {
+				case <-talkingTimer.C:
+				default:
+				}
+			}
+			talkingTimer.Reset(talkingTimeout)
 var boost uint16 = uint16(1)
 			samples := len(packet.AudioBuffer)
 			if samples > cap(raw) {
 				continue
 			}
 boost=e.User.Boost
-			for i, value := range packet.AudioBuffer {
-				binary.LittleEndian.PutUint16(raw[i*2:], uint16(value)*boost)
+			if boost == 1 {
+				pcm.Encode(raw[:], packet.AudioBuffer)
+			} else {
+				for i, value := range packet.AudioBuffer {
+					binary.LittleEndian.PutUint16(raw[i*2:], uint16(value)*boost)
+				}
 			}
+			s.positionSource(e.User, &source, packet)
+			s.lockContext()
 			reclaim()
 			if len(emptyBufs) == 0 {
+				unlockContext()
 				continue
 			}
 			last := len(emptyBufs) - 1
@@ -193,45 +440,241 @@ boost=e.User.Boost
 			if source.State() != openal.Playing {
 				source.Play()
 			}
+			unlockContext()
+		}
+		talkingTimer.Stop()
+		s.setTalking(e.User, false)
+		s.mixLock.Lock()
+		delete(s.sources, e.User)
+		delete(s.positioned, e.User)
+		s.updatePositions()
+		if e.User.AudioSource == &source {
+			e.User.AudioSource = nil
 		}
+		s.mixLock.Unlock()
+		s.lockContext()
 		reclaim()
 		emptyBufs.Delete()
 		source.Delete()
+		unlockContext()
 	}(e)
 }
 
-func (s *Stream) sourceRoutine(inputDevice *string) {
-	interval := s.client.Config.AudioInterval
-	frameSize := s.client.Config.AudioFrameSize()
+// sourceRoutine sends the captured audio to the server, until stop is closed.
+// It re-opens the capture device when it fails, or when the frame size
+// changes; the device is only accessed with sourceLock held.
+func (s *Stream) sourceRoutine(inputDevice *string, stop chan bool) {
+	interval := s.client.AudioInterval()
+	frameSize := s.client.AudioFrameSize()
 
+	s.sourceLock.Lock()
 	if frameSize != s.sourceFrameSize {
-		s.deviceSource.CaptureCloseDevice()
-		s.sourceFrameSize = frameSize
-		s.deviceSource = openal.CaptureOpenDevice(*inputDevice, gumble.AudioSampleRate, openal.FormatMono16, uint32(s.sourceFrameSize))
+		if err := s.reopenSource(inputDevice, frameSize); err != nil {
+			s.sourceLock.Unlock()
+			return
+		}
 	}
+	s.sourceLock.Unlock()
 
 	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
-
-	stop := s.sourceStop
+	defer func() {
+		ticker.Stop()
+	}()
 
 	outgoing := s.client.AudioOutgoing()
 	defer close(outgoing)
 
+	var (
+		failingSince time.Time
+		reopen       <-chan time.Time
+		backoff      time.Duration
+	)
+
 	for {
 		select {
 		case <-stop:
 			return
-		case <-ticker.C:
-			buff := s.deviceSource.CaptureSamples(uint32(frameSize))
-			if len(buff) != frameSize*2 {
+		case <-reopen:
+			s.sourceLock.Lock()
+			reopened := !stopped(stop) && s.tryReopenSource(inputDevice, frameSize)
+			s.sourceLock.Unlock()
+			if reopened {
+				reopen = nil
+				failingSince = time.Time{}
+				if s.MicRestored != nil {
+					s.MicRestored()
+				}
+				continue
+			}
+			backoff *= 2
+			if backoff > reopenMaxBackoff {
+				backoff = reopenMaxBackoff
+			}
+			reopen = time.After(backoff)
+		case e := <-s.sourceChange:
+			ticker.Stop()
+			interval = e.AudioInterval
+			frameSize = e.AudioFrameSize
+			s.sourceLock.Lock()
+			var err error
+			if frameSize != s.sourceFrameSize && s.deviceSource != nil && !stopped(stop) {
+				err = s.reopenSource(inputDevice, frameSize)
+			}
+			s.sourceLock.Unlock()
+			if err != nil {
+				return
+			}
+			ticker = time.NewTicker(interval)
+		case now := <-ticker.C:
+			s.sourceLock.Lock()
+			if s.deviceSource == nil {
+				s.sourceLock.Unlock()
 				continue
 			}
-			int16Buffer := make([]int16, frameSize)
-			for i := range int16Buffer {
-				int16Buffer[i] = int16(binary.LittleEndian.Uint16(buff[i*2 : (i+1)*2]))
+			frames, ok := s.captureFrames(frameSize)
+			failed := false
+			if ok {
+				failingSince = time.Time{}
+			} else if failingSince.IsZero() {
+				failingSince = now
+			} else if now.Sub(failingSince) >= captureFailureTimeout {
+				s.closeFailedSource()
+				failed = true
 			}
-			outgoing <- gumble.AudioBuffer(int16Buffer)
+			s.sourceLock.Unlock()
+			if failed {
+				if s.MicError != nil {
+					s.MicError(ErrMic)
+				}
+				backoff = reopenMinBackoff
+				reopen = time.After(backoff)
+			}
+			for _, frame := range frames {
+				s.sendCaptured(outgoing, frame)
+			}
+		}
+	}
+}
+
+// stopped returns true if stop has been closed, so that the source routine
+// does not start a capture device after StopSource has stopped it.
+func stopped(stop chan bool) bool {
+	select {
+	case <-stop:
+		return true
+	default:
+		return false
+	}
+}
+
+// captureFrames reads the audio that the capture device has captured, and
+// returns the frames of frameSize samples that are ready. ok is false if the
+// device has not captured enough audio. sourceLock must be held.
+func (s *Stream) captureFrames(frameSize int) (frames []gumble.AudioBuffer, ok bool) {
+	if s.deviceSource.CapturedSamples() < uint32(deviceSamples(frameSize, s.sourceRate)) {
+		return nil, false
+	}
+	if s.resampler == nil {
+		buff := s.deviceSource.CaptureSamples(uint32(frameSize))
+		if len(buff) != frameSize*2 {
+			return nil, true
+		}
+		return []gumble.AudioBuffer{gumble.AudioBuffer(pcm.DecodeAll(buff))}, true
+	}
+	deviceFrameSize := deviceSamples(frameSize, s.sourceRate)
+	buff := s.deviceSource.CaptureSamples(uint32(deviceFrameSize))
+	if len(buff) != deviceFrameSize*2 {
+		return nil, true
+	}
+	s.captured = append(s.captured, s.resampler.Process(pcm.DecodeAll(buff))...)
+	for len(s.captured) >= frameSize {
+		frame := make(gumble.AudioBuffer, frameSize)
+		copy(frame, s.captured)
+		s.captured = append(s.captured[:0], s.captured[frameSize:]...)
+		frames = append(frames, frame)
+	}
+	return frames, true
+}
+
+// sendCaptured applies the AGC, if any, to buffer and sends it to the server.
+func (s *Stream) sendCaptured(outgoing chan<- gumble.AudioBuffer, buffer gumble.AudioBuffer) {
+	if s.AGC != nil {
+		s.AGC.Process(buffer)
+	}
+	outgoing <- buffer
+}
+
+// reopenSource re-opens the capture device with a buffer large enough for
+// frameSize samples. sourceLock must be held, as for closeFailedSource and
+// tryReopenSource.
+func (s *Stream) reopenSource(inputDevice *string, frameSize int) error {
+	s.deviceSource.CaptureStop()
+	s.deviceSource.CaptureCloseDevice()
+	s.sourceFrameSize = frameSize
+	name := deviceName(inputDevice)
+	s.deviceSource, s.sourceRate = openCaptureDevice(name, frameSize)
+	if s.deviceSource == nil {
+		return captureError(name, frameSize)
+	}
+	s.deviceSource.CaptureStart()
+	s.setResampler()
+	return nil
+}
+
+// closeFailedSource closes a capture device that has stopped returning
+// samples.
+func (s *Stream) closeFailedSource() {
+	s.deviceSource.CaptureStop()
+	s.deviceSource.CaptureCloseDevice()
+	s.deviceSource = nil
+}
+
+// tryReopenSource attempts to re-open a capture device that previously
+// failed, returning true if it succeeded.
+func (s *Stream) tryReopenSource(inputDevice *string, frameSize int) bool {
+	device, rate := openCaptureDevice(deviceName(inputDevice), frameSize)
+	if device == nil {
+		return false
+	}
+	s.deviceSource = device
+	s.sourceRate = rate
+	s.sourceFrameSize = frameSize
+	s.setResampler()
+	s.deviceSource.CaptureStart()
+	return true
+}
+
+// captureSampleRates are the sample rates that are tried, in order, when
+// opening a capture device. Audio captured at a rate other than
+// gumble.AudioSampleRate is resampled.
+var captureSampleRates = []int{gumble.AudioSampleRate, 44100, 32000, 22050, 16000, 11025, 8000}
+
+// openCaptureDevice opens the named capture device at the first supported
+// rate of captureSampleRates, with a buffer of frameSize samples at
+// gumble.AudioSampleRate. nil is returned if the device cannot be opened.
+func openCaptureDevice(name string, frameSize int) (*openal.CaptureDevice, int) {
+	for _, rate := range captureSampleRates {
+		device := openal.CaptureOpenDevice(name, uint32(rate), openal.FormatMono16, uint32(deviceSamples(frameSize, rate)))
+		if device != nil {
+			return device, rate
 		}
 	}
+	return nil, 0
+}
+
+// deviceSamples returns the number of samples at rate that have the same
+// duration as samples at gumble.AudioSampleRate.
+func deviceSamples(samples, rate int) int {
+	return (samples*rate + gumble.AudioSampleRate - 1) / gumble.AudioSampleRate
+}
+
+// setResampler creates the resampler for the capture device's sample rate, if
+// it differs from gumble.AudioSampleRate.
+func (s *Stream) setResampler() {
+	s.captured = s.captured[:0]
+	if s.sourceRate == gumble.AudioSampleRate {
+		s.resampler = nil
+		return
+	}
+	s.resampler = gumbleutil.NewResampler(s.sourceRate, gumble.AudioSampleRate)
 }
@@ -0,0 +1,339 @@
+// Package gumbleportaudio is a PortAudio audio backend for gumble. It is an
+// alternative to gumbleopenal for platforms where OpenAL is hard to install,
+// such as Windows (where PortAudio can use WASAPI).
+package gumbleportaudio // import "github.com/bmmcginty/gumble/gumbleportaudio"
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	"github.com/gordonklaus/portaudio"
+)
+
+var (
+	ErrState        = errors.New("gumbleportaudio: invalid state")
+	ErrMic          = errors.New("gumbleportaudio: microphone disconnected or misconfigured")
+	ErrInputDevice  = errors.New("gumbleportaudio: invalid input device or parameters")
+	ErrOutputDevice = errors.New("gumbleportaudio: invalid output device or parameters")
+)
+
+const (
+	// playbackFrameSize is the number of samples mixed and written to the
+	// output device at a time (10ms).
+	playbackFrameSize = gumble.AudioSampleRate / 100
+)
+
+var _ gumbleutil.AudioStream = (*Stream)(nil)
+
+// Stream plays incoming audio through a PortAudio output device and sends
+// audio captured from a PortAudio input device to the server.
+type Stream struct {
+	// If not nil, applied to the captured audio, after the capture volume
+	// (see gumbleutil.AGC). It must be set before StartSource.
+	AGC *gumbleutil.AGC
+
+	client    *gumble.Client
+	link      gumble.Detacher
+	eventLink gumble.Detacher
+
+	inputDevice   *portaudio.DeviceInfo
+	source        *portaudio.Stream
+	sourceBuffer  []int16
+	sourceStop    chan struct{}
+	sourceDone    chan struct{}
+	sourceChange  chan *gumble.AudioConfigEvent
+	micVolume     float32
+	micVolumeLock sync.Mutex
+
+	sink       *portaudio.Stream
+	sinkBuffer []int16
+	sinkStop   chan struct{}
+	sinkDone   chan struct{}
+
+	mixer *gumbleutil.Mixer
+}
+
+// New opens the given input and output devices, by name, and returns a new
+// Stream. A nil or empty name selects the system's default device.
+func New(client *gumble.Client, inputDevice *string, outputDevice *string) (*Stream, error) {
+	if err := portaudio.Initialize(); err != nil {
+		return nil, err
+	}
+
+	idev, err := findDevice(inputDevice, true)
+	if err != nil {
+		portaudio.Terminate()
+		return nil, err
+	}
+	odev, err := findDevice(outputDevice, false)
+	if err != nil {
+		portaudio.Terminate()
+		return nil, err
+	}
+
+	s := &Stream{
+		client:       client,
+		inputDevice:  idev,
+		sourceChange: make(chan *gumble.AudioConfigEvent, 1),
+		micVolume:    1,
+		sinkBuffer:   make([]int16, playbackFrameSize),
+		mixer:        gumbleutil.NewMixer(),
+	}
+
+	params := portaudio.HighLatencyParameters(nil, odev)
+	params.Output.Channels = 1
+	params.SampleRate = gumble.AudioSampleRate
+	params.FramesPerBuffer = playbackFrameSize
+	s.sink, err = portaudio.OpenStream(params, &s.sinkBuffer)
+	if err != nil {
+		portaudio.Terminate()
+		return nil, ErrOutputDevice
+	}
+	return s, nil
+}
+
+// findDevice returns the device with the given name, or the default device if
+// name is nil or empty.
+func findDevice(name *string, input bool) (*portaudio.DeviceInfo, error) {
+	if name == nil || *name == "" {
+		var (
+			device *portaudio.DeviceInfo
+			err    error
+		)
+		if input {
+			device, err = portaudio.DefaultInputDevice()
+		} else {
+			device, err = portaudio.DefaultOutputDevice()
+		}
+		if err != nil {
+			if input {
+				return nil, ErrInputDevice
+			}
+			return nil, ErrOutputDevice
+		}
+		return device, nil
+	}
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		if device.Name != *name {
+			continue
+		}
+		if input && device.MaxInputChannels > 0 {
+			return device, nil
+		}
+		if !input && device.MaxOutputChannels > 0 {
+			return device, nil
+		}
+	}
+	if input {
+		return nil, ErrInputDevice
+	}
+	return nil, ErrOutputDevice
+}
+
+// AttachStream starts playing the audio received by client.
+func (s *Stream) AttachStream(client *gumble.Client) {
+	s.link = client.AttachAudio(s)
+	s.eventLink = client.Attach(gumbleutil.Listener{
+		AudioConfig: s.onAudioConfig,
+	})
+
+	if err := s.sink.Start(); err != nil {
+		return
+	}
+	s.sinkStop = make(chan struct{})
+	s.sinkDone = make(chan struct{})
+	go s.sinkRoutine()
+}
+
+// onAudioConfig passes the client's new audio interval and frame size to the
+// source routine. A change that the routine has not received yet is replaced.
+func (s *Stream) onAudioConfig(e *gumble.AudioConfigEvent) {
+	for {
+		select {
+		case s.sourceChange <- e:
+			return
+		default:
+		}
+		select {
+		case <-s.sourceChange:
+		default:
+		}
+	}
+}
+
+// Destroy stops the stream and closes its devices.
+func (s *Stream) Destroy() {
+	if s.link != nil {
+		s.link.Detach()
+		s.link = nil
+	}
+	if s.eventLink != nil {
+		s.eventLink.Detach()
+		s.eventLink = nil
+	}
+	if s.sourceStop != nil {
+		s.StopSource()
+	}
+	if s.sink != nil {
+		if s.sinkStop != nil {
+			close(s.sinkStop)
+			<-s.sinkDone
+			s.sinkStop = nil
+			s.sink.Stop()
+		}
+		s.sink.Close()
+		s.sink = nil
+		portaudio.Terminate()
+	}
+}
+
+// StartSource starts capturing audio from inputDevice (or the device passed
+// to New, if nil) and sending it to the server.
+func (s *Stream) StartSource(inputDevice *string) error {
+	if s.sourceStop != nil {
+		return ErrState
+	}
+	if inputDevice != nil {
+		device, err := findDevice(inputDevice, true)
+		if err != nil {
+			return err
+		}
+		s.inputDevice = device
+	}
+	if err := s.openSource(s.client.AudioFrameSize()); err != nil {
+		return err
+	}
+	s.sourceStop = make(chan struct{})
+	s.sourceDone = make(chan struct{})
+	go s.sourceRoutine()
+	return nil
+}
+
+// StopSource stops capturing audio.
+func (s *Stream) StopSource() error {
+	if s.sourceStop == nil {
+		return ErrState
+	}
+	close(s.sourceStop)
+	<-s.sourceDone
+	s.sourceStop = nil
+	s.closeSource()
+	return nil
+}
+
+// openSource opens and starts the capture stream, reading frameSize samples
+// at a time.
+func (s *Stream) openSource(frameSize int) error {
+	if s.inputDevice == nil {
+		return ErrMic
+	}
+	s.sourceBuffer = make([]int16, frameSize)
+	params := portaudio.LowLatencyParameters(s.inputDevice, nil)
+	params.Input.Channels = 1
+	params.SampleRate = gumble.AudioSampleRate
+	params.FramesPerBuffer = frameSize
+	source, err := portaudio.OpenStream(params, &s.sourceBuffer)
+	if err != nil {
+		return ErrInputDevice
+	}
+	if err := source.Start(); err != nil {
+		source.Close()
+		return ErrMic
+	}
+	s.source = source
+	return nil
+}
+
+func (s *Stream) closeSource() {
+	if s.source != nil {
+		s.source.Stop()
+		s.source.Close()
+		s.source = nil
+	}
+}
+
+// GetMicVolume returns the capture volume, between 0 and 1.
+func (s *Stream) GetMicVolume() float32 {
+	s.micVolumeLock.Lock()
+	defer s.micVolumeLock.Unlock()
+	return s.micVolume
+}
+
+// SetMicVolume sets the capture volume, either to change or by change if
+// relative is true. The volume is clamped between 0 and 1.
+func (s *Stream) SetMicVolume(change float32, relative bool) {
+	s.micVolumeLock.Lock()
+	defer s.micVolumeLock.Unlock()
+	val := change
+	if relative {
+		val += s.micVolume
+	}
+	if val >= 1 {
+		val = 1.0
+	}
+	if val <= 0 {
+		val = 0
+	}
+	s.micVolume = val
+}
+
+func (s *Stream) sourceRoutine() {
+	defer close(s.sourceDone)
+
+	outgoing := s.client.AudioOutgoing()
+	defer close(outgoing)
+
+	for {
+		select {
+		case <-s.sourceStop:
+			return
+		case e := <-s.sourceChange:
+			s.closeSource()
+			if err := s.openSource(e.AudioFrameSize); err != nil {
+				return
+			}
+		default:
+		}
+
+		if err := s.source.Read(); err != nil && err != portaudio.InputOverflowed {
+			continue
+		}
+		volume := s.GetMicVolume()
+		buffer := make(gumble.AudioBuffer, len(s.sourceBuffer))
+		for i, sample := range s.sourceBuffer {
+			buffer[i] = int16(float32(sample) * volume)
+		}
+		if s.AGC != nil {
+			s.AGC.Process(buffer)
+		}
+		outgoing <- buffer
+	}
+}
+
+// OnAudioStream implements gumble.AudioListener. Incoming audio is queued per
+// user and mixed by the playback routine.
+func (s *Stream) OnAudioStream(e *gumble.AudioStreamEvent) {
+	s.mixer.OnAudioStream(e)
+}
+
+func (s *Stream) sinkRoutine() {
+	defer close(s.sinkDone)
+
+	for {
+		select {
+		case <-s.sinkStop:
+			return
+		default:
+		}
+		s.mixer.Mix(s.sinkBuffer)
+		if err := s.sink.Write(); err != nil && err != portaudio.OutputUnderflowed {
+			continue
+		}
+	}
+}
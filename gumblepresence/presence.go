@@ -0,0 +1,143 @@
+// Package gumblepresence records when users join, leave and move between the
+// channels of a server, so that bots can answer questions such as "when was
+// X last online" or "who was in channel Y at time T".
+//
+// Users are identified by their gumble.User.StableID, so their history
+// continues across reconnects. Users without a stable identity are not
+// recorded.
+//
+// The history is kept in a Store: MemoryStore keeps it in memory, and
+// SQLStore in a database/sql database. Other databases (e.g. bbolt) can be
+// used by implementing Store.
+package gumblepresence // import "github.com/bmmcginty/gumble/gumblepresence"
+
+import (
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+)
+
+// EventKind is the kind of a presence Event.
+type EventKind int
+
+// Presence event kinds.
+const (
+	// The user connected to the server.
+	Join EventKind = iota
+	// The user disconnected from the server.
+	Leave
+	// The user moved to another channel.
+	Move
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case Join:
+		return "join"
+	case Leave:
+		return "leave"
+	case Move:
+		return "move"
+	}
+	return "unknown"
+}
+
+// Event is a change in the presence of a user.
+type Event struct {
+	Time time.Time
+	Kind EventKind
+	// The user's gumble.User.StableID, and name.
+	ID   string
+	Name string
+	// The channel that the user is in after the event; for Leave events, the
+	// channel that the user left from.
+	Channel     uint32
+	ChannelName string
+	// Unobserved is set on the events that only reflect the recorder's own
+	// connection: the joins of the users who were already online when the
+	// client connected, and the leaves of the users who were still online
+	// when it disconnected.
+	Unobserved bool
+}
+
+// Recorder is a gumble.EventListener that records the presence events of a
+// client's server to a Store.
+type Recorder struct {
+	gumbleutil.Listener
+
+	// The store to which events are recorded.
+	Store Store
+	// Called when an event cannot be recorded. Can be nil.
+	Error func(err error)
+}
+
+// NewRecorder returns a new Recorder that records events to store. The
+// recorder needs to be attached to a client (e.g. with
+// gumble.Config.Attach).
+func NewRecorder(store Store) *Recorder {
+	r := &Recorder{
+		Store: store,
+	}
+	r.Listener.Connect = r.onConnect
+	r.Listener.Disconnect = r.onDisconnect
+	r.Listener.UserChange = r.onUserChange
+	return r
+}
+
+func (r *Recorder) onConnect(e *gumble.ConnectEvent) {
+	now := time.Now()
+	for _, user := range e.Users {
+		r.record(now, Join, user, true)
+	}
+}
+
+func (r *Recorder) onDisconnect(e *gumble.DisconnectEvent) {
+	now := time.Now()
+	var users []*gumble.User
+	e.Client.Do(func() {
+		for _, user := range e.Client.Users {
+			users = append(users, user)
+		}
+	})
+	for _, user := range users {
+		r.record(now, Leave, user, true)
+	}
+}
+
+func (r *Recorder) onUserChange(e *gumble.UserChangeEvent) {
+	if e.Initial {
+		// Recorded from the ConnectEvent.
+		return
+	}
+	now := time.Now()
+	switch {
+	case e.Type.Has(gumble.UserChangeConnected):
+		r.record(now, Join, e.User, false)
+	case e.Type.Has(gumble.UserChangeDisconnected):
+		r.record(now, Leave, e.User, false)
+	case e.Type.Has(gumble.UserChangeChannel):
+		r.record(now, Move, e.User, false)
+	}
+}
+
+func (r *Recorder) record(now time.Time, kind EventKind, user *gumble.User, unobserved bool) {
+	id := user.StableID()
+	if id == "" {
+		return
+	}
+	event := Event{
+		Time:       now,
+		Kind:       kind,
+		ID:         id,
+		Name:       user.Name,
+		Unobserved: unobserved,
+	}
+	if user.Channel != nil {
+		event.Channel = user.Channel.ID
+		event.ChannelName = user.Channel.Name
+	}
+	if err := r.Store.Add(event); err != nil && r.Error != nil {
+		r.Error(err)
+	}
+}
@@ -0,0 +1,53 @@
+package gumblepresence
+
+import (
+	"sort"
+	"time"
+)
+
+// LastSeen returns the last recorded event of the user with the given ID,
+// and whether the user is online according to it (i.e. it is not a Leave).
+// ok is false if nothing has been recorded for the user.
+//
+// The time at which a user was last online is the event's Time if the user
+// is not online.
+func LastSeen(store Store, id string) (event Event, online, ok bool, err error) {
+	events, err := store.Events(Query{
+		ID:     id,
+		Limit:  1,
+		Newest: true,
+	})
+	if err != nil || len(events) == 0 {
+		return Event{}, false, false, err
+	}
+	event = events[0]
+	return event, event.Kind != Leave, true, nil
+}
+
+// Occupants returns the users who were in the channel with the given ID at
+// time t, as the latest event of each user before t, sorted by name.
+func Occupants(store Store, channel uint32, t time.Time) ([]Event, error) {
+	events, err := store.Events(Query{
+		To: t.Add(1),
+	})
+	if err != nil {
+		return nil, err
+	}
+	latest := make(map[string]Event)
+	for _, event := range events {
+		latest[event.ID] = event
+	}
+	var occupants []Event
+	for _, event := range latest {
+		if event.Kind != Leave && event.Channel == channel {
+			occupants = append(occupants, event)
+		}
+	}
+	sort.Slice(occupants, func(i, j int) bool {
+		if occupants[i].Name != occupants[j].Name {
+			return occupants[i].Name < occupants[j].Name
+		}
+		return occupants[i].ID < occupants[j].ID
+	})
+	return occupants, nil
+}
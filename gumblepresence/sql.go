@@ -0,0 +1,129 @@
+package gumblepresence
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SQLStore is a Store that keeps events in a table of an SQL database.
+//
+// The table, which can be created with CreateTable, has the columns:
+//  at           BIGINT   the event's time, in Unix nanoseconds
+//  kind         INTEGER  the EventKind
+//  identity     TEXT     the user's ID
+//  name         TEXT
+//  channel      BIGINT
+//  channel_name TEXT
+//  unobserved   BOOLEAN
+// An index on (identity, at) speeds up LastSeen.
+type SQLStore struct {
+	DB *sql.DB
+	// The name of the table, which is inserted into the statements as is.
+	Table string
+	// Placeholder returns the placeholder for the nth argument of a
+	// statement, starting from 1. If nil, "?" is used; PostgreSQL needs
+	// DollarPlaceholder.
+	Placeholder func(n int) string
+}
+
+// NewSQLStore returns a new SQLStore that uses the given table of db.
+func NewSQLStore(db *sql.DB, table string) *SQLStore {
+	return &SQLStore{
+		DB:    db,
+		Table: table,
+	}
+}
+
+// DollarPlaceholder returns "$n", the placeholders used by PostgreSQL.
+func DollarPlaceholder(n int) string {
+	return "$" + strconv.Itoa(n)
+}
+
+// CreateTable creates the store's table, if it does not exist.
+func (s *SQLStore) CreateTable() error {
+	_, err := s.DB.Exec("CREATE TABLE IF NOT EXISTS " + s.Table + ` (
+	at BIGINT NOT NULL,
+	kind INTEGER NOT NULL,
+	identity TEXT NOT NULL,
+	name TEXT NOT NULL,
+	channel BIGINT NOT NULL,
+	channel_name TEXT NOT NULL,
+	unobserved BOOLEAN NOT NULL
+)`)
+	return err
+}
+
+func (s *SQLStore) placeholder(n int) string {
+	if s.Placeholder != nil {
+		return s.Placeholder(n)
+	}
+	return "?"
+}
+
+// Add implements Store.
+func (s *SQLStore) Add(event Event) error {
+	placeholders := make([]string, 7)
+	for i := range placeholders {
+		placeholders[i] = s.placeholder(i + 1)
+	}
+	_, err := s.DB.Exec("INSERT INTO "+s.Table+" (at, kind, identity, name, channel, channel_name, unobserved) VALUES ("+strings.Join(placeholders, ", ")+")",
+		event.Time.UnixNano(), int(event.Kind), event.ID, event.Name, int64(event.Channel), event.ChannelName, event.Unobserved)
+	return err
+}
+
+// Events implements Store.
+func (s *SQLStore) Events(query Query) ([]Event, error) {
+	var conditions []string
+	var args []interface{}
+	condition := func(format string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, format+s.placeholder(len(args)))
+	}
+	if query.ID != "" {
+		condition("identity = ", query.ID)
+	}
+	if !query.From.IsZero() {
+		condition("at >= ", query.From.UnixNano())
+	}
+	if !query.To.IsZero() {
+		condition("at < ", query.To.UnixNano())
+	}
+
+	statement := "SELECT at, kind, identity, name, channel, channel_name, unobserved FROM " + s.Table
+	if len(conditions) > 0 {
+		statement += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	if query.Newest {
+		statement += " ORDER BY at DESC"
+	} else {
+		statement += " ORDER BY at"
+	}
+	if query.Limit > 0 {
+		statement += " LIMIT " + strconv.Itoa(query.Limit)
+	}
+
+	rows, err := s.DB.Query(statement, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var events []Event
+	for rows.Next() {
+		var (
+			event   Event
+			at      int64
+			kind    int
+			channel int64
+		)
+		if err := rows.Scan(&at, &kind, &event.ID, &event.Name, &channel, &event.ChannelName, &event.Unobserved); err != nil {
+			return nil, err
+		}
+		event.Time = time.Unix(0, at)
+		event.Kind = EventKind(kind)
+		event.Channel = uint32(channel)
+		events = append(events, event)
+	}
+	return events, rows.Err()
+}
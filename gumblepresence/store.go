@@ -0,0 +1,97 @@
+package gumblepresence
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Store stores presence events.
+type Store interface {
+	// Add records an event.
+	Add(event Event) error
+	// Events returns the recorded events that match query, in chronological
+	// order (or reverse chronological order if query.Newest is set).
+	Events(query Query) ([]Event, error)
+}
+
+// Query selects events from a Store.
+type Query struct {
+	// If not empty, only the events of the user with this ID.
+	ID string
+	// If not zero, only the events that happened at or after From, and
+	// before To.
+	From, To time.Time
+	// If positive, the maximum number of events to return.
+	Limit int
+	// Return the newest events first? Combined with Limit, selects the
+	// latest events.
+	Newest bool
+}
+
+func (q *Query) match(event *Event) bool {
+	if q.ID != "" && event.ID != q.ID {
+		return false
+	}
+	if !q.From.IsZero() && event.Time.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !event.Time.Before(q.To) {
+		return false
+	}
+	return true
+}
+
+// MemoryStore is a Store that keeps events in memory. It is safe for
+// concurrent use.
+type MemoryStore struct {
+	// If positive, the maximum number of events that are kept. The oldest
+	// events are discarded first.
+	MaxEvents int
+
+	mu     sync.RWMutex
+	events []Event
+}
+
+// NewMemoryStore returns a new MemoryStore that keeps at most maxEvents
+// events (no limit if maxEvents is zero).
+func NewMemoryStore(maxEvents int) *MemoryStore {
+	return &MemoryStore{
+		MaxEvents: maxEvents,
+	}
+}
+
+// Add implements Store.
+func (s *MemoryStore) Add(event Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	i := sort.Search(len(s.events), func(i int) bool { return event.Time.Before(s.events[i].Time) })
+	s.events = append(s.events, Event{})
+	copy(s.events[i+1:], s.events[i:])
+	s.events[i] = event
+	if s.MaxEvents > 0 && len(s.events) > s.MaxEvents {
+		s.events = append(s.events[:0], s.events[len(s.events)-s.MaxEvents:]...)
+	}
+	return nil
+}
+
+// Events implements Store.
+func (s *MemoryStore) Events(query Query) ([]Event, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var events []Event
+	for i := range s.events {
+		index := i
+		if query.Newest {
+			index = len(s.events) - 1 - i
+		}
+		if !query.match(&s.events[index]) {
+			continue
+		}
+		events = append(events, s.events[index])
+		if query.Limit > 0 && len(events) >= query.Limit {
+			break
+		}
+	}
+	return events, nil
+}
@@ -0,0 +1,201 @@
+package gumbleprovision // import "github.com/bmmcginty/gumble/gumbleprovision"
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Export returns the description of the server the client is connected to:
+// its channel tree, the channels' descriptions, positions, maximum users and
+// links, and the ACLs that the client is allowed to read. Converging another
+// server to the returned Spec copies the channel tree to it.
+//
+// Temporary channels are not exported. The exported channels are not pruned,
+// so that converging keeps the channels that are not part of the
+// description. The users in ACL groups and rules are identified by their ID,
+// which is specific to the server.
+//
+// Export must not be called from an event listener, or from inside of
+// Client.Do.
+func Export(ctx context.Context, client *gumble.Client) (*Spec, error) {
+	if err := fetchDescriptions(ctx, client); err != nil {
+		return nil, err
+	}
+	acls, err := gumbleutil.FetchACLs(ctx, client, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	spec := &Spec{}
+	client.Do(func() {
+		if root := client.Channels[0]; root != nil {
+			spec.Channels = exportChannel(root, acls)
+		}
+	})
+	if spec.Channels == nil {
+		spec.Channels = &gumbleutil.ChannelTree{}
+	}
+	return spec, nil
+}
+
+// Marshal returns the YAML encoding of s, which Parse reads. A Spec can also
+// be encoded with encoding/json.
+func (s *Spec) Marshal() ([]byte, error) {
+	return yaml.Marshal(s)
+}
+
+// fetchDescriptions requests the descriptions of the channels that only have
+// a description hash, and waits until the server has sent them.
+func fetchDescriptions(ctx context.Context, client *gumble.Client) error {
+	changed := make(chan struct{}, 1)
+	detacher := client.Attach(gumbleutil.Listener{
+		ChannelChange: func(e *gumble.ChannelChangeEvent) {
+			select {
+			case changed <- struct{}{}:
+			default:
+			}
+		},
+	})
+	defer detacher.Detach()
+
+	requested := make(map[*gumble.Channel]bool)
+	for {
+		pending := false
+		client.Do(func() {
+			for _, channel := range client.Channels {
+				if channel.DescriptionHash == nil {
+					continue
+				}
+				pending = true
+				if !requested[channel] {
+					requested[channel] = true
+					channel.RequestDescription()
+				}
+			}
+		})
+		if !pending {
+			return nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func optionalFalse(b bool) *bool {
+	if b {
+		return nil
+	}
+	return &b
+}
+
+func userIDs(users map[uint32]*gumble.ACLUser) []uint32 {
+	if len(users) == 0 {
+		return nil
+	}
+	ids := make([]uint32, 0, len(users))
+	for id := range users {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// exportACL returns the groups and rules defined in the ACL's channel.
+func exportACL(acl *gumble.ACL) *gumbleutil.ACLSummary {
+	summary := &gumbleutil.ACLSummary{
+		Inherit: optionalFalse(acl.Inherits),
+	}
+	for _, group := range acl.Groups {
+		if group.Inherited {
+			continue
+		}
+		summary.Groups = append(summary.Groups, gumbleutil.ACLGroupSummary{
+			Name:        group.Name,
+			Inherit:     optionalFalse(group.InheritUsers),
+			Inheritable: optionalFalse(group.Inheritable),
+			Add:         userIDs(group.UsersAdd),
+			Remove:      userIDs(group.UsersRemove),
+		})
+	}
+	for _, rule := range acl.Rules {
+		if rule.Inherited {
+			continue
+		}
+		r := gumbleutil.ACLRuleSummary{
+			Here: optionalFalse(rule.AppliesCurrent),
+			Subs: optionalFalse(rule.AppliesChildren),
+		}
+		if rule.Granted != gumble.PermissionNone {
+			r.Grant = rule.Granted.String()
+		}
+		if rule.Denied != gumble.PermissionNone {
+			r.Deny = rule.Denied.String()
+		}
+		if rule.User != nil {
+			id := rule.User.UserID
+			r.UserID = &id
+		} else if rule.Group != nil {
+			r.Group = rule.Group.Name
+		} else {
+			continue
+		}
+		summary.Rules = append(summary.Rules, r)
+	}
+	return summary
+}
+
+func channelPath(channel *gumble.Channel) string {
+	var names []string
+	for ; channel != nil && !channel.IsRoot(); channel = channel.Parent {
+		names = append([]string{channel.Name}, names...)
+	}
+	return strings.Join(names, "/")
+}
+
+func exportChannel(channel *gumble.Channel, acls map[*gumble.Channel]*gumble.ACL) *gumbleutil.ChannelTree {
+	position := channel.Position
+	tree := &gumbleutil.ChannelTree{
+		Name:     channel.Name,
+		Position: &position,
+	}
+	if channel.Description != "" {
+		description := channel.Description
+		tree.Description = &description
+	}
+	if channel.MaxUsers != 0 {
+		maxUsers := channel.MaxUsers
+		tree.MaxUsers = &maxUsers
+	}
+	for _, linked := range channel.Links {
+		tree.Links = append(tree.Links, channelPath(linked))
+	}
+	sort.Strings(tree.Links)
+	if acl := acls[channel]; acl != nil {
+		tree.ACL = exportACL(acl)
+	}
+
+	children := make([]*gumble.Channel, 0, len(channel.Children))
+	for _, child := range channel.Children {
+		if !child.Temporary {
+			children = append(children, child)
+		}
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].Position != children[j].Position {
+			return children[i].Position < children[j].Position
+		}
+		return children[i].Name < children[j].Name
+	})
+	for _, child := range children {
+		tree.Children = append(tree.Children, exportChannel(child, acls))
+	}
+	return tree
+}
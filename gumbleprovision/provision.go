@@ -0,0 +1,131 @@
+// Package gumbleprovision converges a Mumble server's channels, groups and
+// ACLs to a declarative description, read from YAML.
+//
+// Example description:
+//  channels:
+//    acl:
+//      groups:
+//        - name: admins
+//          add: [2, 5]
+//      rules:
+//        - group: admins
+//          grant: Write|Move|MuteDeafen
+//    children:
+//      - name: Lobby
+//        description: Welcome!
+//        position: 0
+//      - name: Games
+//        prune: true
+//        children:
+//          - name: Chess
+//            max_users: 2
+//            links: [Lobby]
+//          - name: Private
+//            acl:
+//              inherit: false
+//              rules:
+//                - group: all
+//                  deny: Enter
+//                - group: "#secret"
+//                  grant: Enter|Speak
+//
+// The channels description is a gumbleutil.ChannelTree, which describes the
+// root channel; see its documentation for the meaning of each field.
+package gumbleprovision // import "github.com/bmmcginty/gumble/gumbleprovision"
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is the description of a server.
+type Spec struct {
+	// The root channel, and the channel tree below it.
+	Channels *gumbleutil.ChannelTree `yaml:"channels" json:"channels"`
+}
+
+// Parse parses a YAML (or JSON) description. Unknown fields are an error.
+func Parse(data []byte) (*Spec, error) {
+	var spec Spec
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&spec); err != nil && err != io.EOF {
+		return nil, err
+	}
+	if spec.Channels == nil {
+		return nil, errors.New("gumbleprovision: description has no channels")
+	}
+	return &spec, nil
+}
+
+// LoadFile reads and parses the description in the given file.
+func LoadFile(filename string) (*Spec, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Plan returns the changes that make the server match spec. The ACLs of the
+// existing channels that spec gives an ACL are fetched first, so that only
+// those that differ are replaced.
+//
+// Plan must not be called from an event listener, or from inside of
+// Client.Do.
+func Plan(ctx context.Context, client *gumble.Client, spec *Spec) ([]*gumbleutil.TreeChange, error) {
+	var channels []*gumble.Channel
+	client.Do(func() {
+		var walk func(channel *gumble.Channel, tree *gumbleutil.ChannelTree)
+		walk = func(channel *gumble.Channel, tree *gumbleutil.ChannelTree) {
+			if tree.ACL != nil {
+				channels = append(channels, channel)
+			}
+			for _, child := range tree.Children {
+				if live := channel.Find(child.Name); live != nil {
+					walk(live, child)
+				}
+			}
+		}
+		if root := client.Channels[0]; root != nil {
+			walk(root, spec.Channels)
+		}
+	})
+
+	var acls map[*gumble.Channel]*gumble.ACL
+	if len(channels) > 0 {
+		var err error
+		if acls, err = gumbleutil.FetchACLs(ctx, client, channels); err != nil {
+			return nil, err
+		}
+	}
+	return gumbleutil.DiffChannelTree(client, spec.Channels, acls)
+}
+
+// Converge plans the changes that make the server match spec, and writes them
+// to w (if it is not nil), one per line. Unless dryRun is true, the changes
+// are then applied with gumbleutil.ApplyChannelTree. The planned changes are
+// returned.
+func Converge(ctx context.Context, client *gumble.Client, spec *Spec, dryRun bool, w io.Writer) ([]*gumbleutil.TreeChange, error) {
+	changes, err := Plan(ctx, client, spec)
+	if err != nil {
+		return nil, err
+	}
+	if w != nil {
+		for _, change := range changes {
+			fmt.Fprintln(w, change)
+		}
+	}
+	if dryRun || len(changes) == 0 {
+		return changes, nil
+	}
+	return changes, gumbleutil.ApplyChannelTree(ctx, client, changes)
+}
@@ -0,0 +1,102 @@
+package gumbleprovision
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+	"github.com/golang/protobuf/proto"
+)
+
+const testSpec = `
+channels:
+  children:
+    - name: Lobby
+      description: Welcome!
+      position: 0
+    - name: Games
+      prune: true
+      children:
+        - name: Chess
+          max_users: 2
+          links: [Lobby]
+`
+
+func TestParse(t *testing.T) {
+	spec, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	children := spec.Channels.Children
+	if len(children) != 2 || children[0].Name != "Lobby" || children[1].Name != "Games" {
+		t.Fatalf("got channels %+v\n", children)
+	}
+	if d := children[0].Description; d == nil || *d != "Welcome!" {
+		t.Errorf("got description %v, expected \"Welcome!\"\n", d)
+	}
+	if p := children[0].Position; p == nil || *p != 0 {
+		t.Errorf("got position %v, expected 0\n", p)
+	}
+	if !children[1].Prune || children[0].Prune {
+		t.Error("only Games should be pruned")
+	}
+	chess := children[1].Children[0]
+	if chess.MaxUsers == nil || *chess.MaxUsers != 2 || len(chess.Links) != 1 || chess.Links[0] != "Lobby" {
+		t.Errorf("got Chess %+v\n", chess)
+	}
+
+	invalid := map[string]string{
+		"unknown field": "channels:\n  children:\n    - name: Lobby\n      colour: red\n",
+		"no channels":   "# nothing\n",
+		"not YAML":      "channels: [",
+	}
+	for name, data := range invalid {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: no error\n", name)
+		}
+	}
+}
+
+func TestConvergeDryRun(t *testing.T) {
+	client, server := gumble.NewReplayClient(gumble.NewConfig())
+	defer server.Close()
+	packets := []*MumbleProto.ChannelState{
+		{ChannelId: proto.Uint32(0), Name: proto.String("Root")},
+		{ChannelId: proto.Uint32(1), Parent: proto.Uint32(0), Name: proto.String("Lobby"), Description: proto.String("Welcome!")},
+		{ChannelId: proto.Uint32(2), Parent: proto.Uint32(0), Name: proto.String("Games")},
+		{ChannelId: proto.Uint32(3), Parent: proto.Uint32(2), Name: proto.String("Poker")},
+	}
+	for _, packet := range packets {
+		if err := server.WriteProto(packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The previous packet has been handled once the next one is read.
+	server.WriteProto(&MumbleProto.Ping{})
+
+	spec, err := Parse([]byte(testSpec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	changes, err := Converge(context.Background(), client, spec, true, &b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const want = "create /Games/Chess\nupdate /Games/Chess (links)\nremove /Games/Poker\n"
+	if b.String() != want {
+		t.Errorf("got plan:\n%sexpected:\n%s", b.String(), want)
+	}
+	if len(changes) != 3 {
+		t.Errorf("got %d changes, expected 3\n", len(changes))
+	}
+
+	// Nothing is applied in a dry run.
+	client.Do(func() {
+		if client.Channels.Find("Games", "Chess") != nil || client.Channels.Find("Games", "Poker") == nil {
+			t.Error("the channel tree changed")
+		}
+	})
+}
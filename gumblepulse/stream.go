@@ -0,0 +1,426 @@
+// Package gumblepulse is a native PulseAudio (and PipeWire, through
+// pipewire-pulse) audio backend for gumble.
+//
+// Each user that talks gets their own named playback stream, so that they
+// appear individually in mixers such as pavucontrol and can have their volume
+// changed by the sound server. Streams that are not bound to a specific
+// device follow the server's default device when it changes.
+package gumblepulse // import "github.com/bmmcginty/gumble/gumblepulse"
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	"github.com/jfreymuth/pulse"
+	"github.com/jfreymuth/pulse/proto"
+)
+
+var (
+	ErrState        = errors.New("gumblepulse: invalid state")
+	ErrMic          = errors.New("gumblepulse: microphone disconnected or misconfigured")
+	ErrInputDevice  = errors.New("gumblepulse: invalid input device or parameters")
+	ErrOutputDevice = errors.New("gumblepulse: invalid output device or parameters")
+)
+
+const (
+	// DefaultName is the application name used if none is given to New.
+	DefaultName = "Mumble bot"
+
+	// maxBufferedSamples is the maximum number of samples buffered per user
+	// before the oldest samples are dropped (500ms).
+	maxBufferedSamples = gumble.AudioSampleRate / 2
+
+	// reconnectInterval is how often the connection to the sound server is
+	// checked, and re-established if it was lost.
+	reconnectInterval = time.Second
+)
+
+var _ gumbleutil.AudioStream = (*Stream)(nil)
+
+// Stream plays incoming audio through PulseAudio and sends audio recorded
+// from PulseAudio to the server.
+type Stream struct {
+	// If not nil, applied to the captured audio, after the capture volume
+	// (see gumbleutil.AGC). It must be set before StartSource.
+	AGC *gumbleutil.AGC
+
+	client   *gumble.Client
+	link     gumble.Detacher
+	name     string
+	monitor  chan struct{}
+	sourcing bool
+
+	inputDevice  *string
+	outputDevice *string
+
+	lock   sync.Mutex
+	pulse  *pulse.Client
+	record *pulse.RecordStream
+	users  map[*gumble.User]*userStream
+
+	// captureLock guards the fields below. It is separate from lock as
+	// writeRecord is called from the pulse client's read loop, which must not
+	// block while a request is made on the client.
+	captureLock sync.Mutex
+	captured    []int16
+	outgoing    chan<- gumble.AudioBuffer
+	micVolume   float32
+}
+
+// userStream is the playback stream of a single user.
+type userStream struct {
+	playback *pulse.PlaybackStream
+	volume   float64 // < 0 if the volume has not been set by the application
+
+	lock    sync.Mutex
+	pending []int16
+}
+
+// read implements pulse.Int16Reader. Silence is played when no audio is
+// buffered, which keeps the stream (and its mixer entry) alive.
+func (u *userStream) read(buf []int16) (int, error) {
+	u.lock.Lock()
+	n := copy(buf, u.pending)
+	u.pending = u.pending[n:]
+	u.lock.Unlock()
+	for i := n; i < len(buf); i++ {
+		buf[i] = 0
+	}
+	return len(buf), nil
+}
+
+// New connects to the sound server and returns a new Stream. name is shown as
+// the application and stream name in the sound server's mixer; DefaultName is
+// used if it is empty. inputDevice and outputDevice are PulseAudio source and
+// sink names; if nil or empty, the server's default devices are used and
+// followed when they change.
+func New(client *gumble.Client, name string, inputDevice *string, outputDevice *string) (*Stream, error) {
+	if name == "" {
+		name = DefaultName
+	}
+	s := &Stream{
+		client:       client,
+		name:         name,
+		micVolume:    1,
+		inputDevice:  inputDevice,
+		outputDevice: outputDevice,
+		users:        make(map[*gumble.User]*userStream),
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect connects to the sound server and (re-)creates the record stream and
+// the playback streams of all known users. s.lock must be held.
+func (s *Stream) connect() error {
+	c, err := pulse.NewClient(pulse.ClientApplicationName(s.name))
+	if err != nil {
+		return err
+	}
+	s.pulse = c
+	if err := s.openRecord(); err != nil {
+		c.Close()
+		s.pulse = nil
+		return err
+	}
+	for user, us := range s.users {
+		if err := s.openPlayback(user, us); err != nil {
+			delete(s.users, user)
+		}
+	}
+	if s.sourcing {
+		s.record.Start()
+	}
+	return nil
+}
+
+// disconnect closes all streams and the connection to the sound server.
+// s.lock must be held.
+func (s *Stream) disconnect() {
+	if s.pulse == nil {
+		return
+	}
+	for _, us := range s.users {
+		if us.playback != nil {
+			us.playback.Close()
+			us.playback = nil
+		}
+	}
+	if s.record != nil {
+		s.record.Close()
+		s.record = nil
+	}
+	s.pulse.Close()
+	s.pulse = nil
+}
+
+// openRecord creates the record stream. s.lock must be held.
+func (s *Stream) openRecord() error {
+	opts := []pulse.RecordOption{
+		pulse.RecordMono,
+		pulse.RecordSampleRate(gumble.AudioSampleRate),
+		pulse.RecordMediaName(s.name),
+		pulse.RecordLatency(s.client.AudioInterval().Seconds()),
+	}
+	if s.inputDevice != nil && *s.inputDevice != "" {
+		source, err := s.pulse.SourceByID(*s.inputDevice)
+		if err != nil {
+			return ErrInputDevice
+		}
+		opts = append(opts, pulse.RecordSource(source))
+	}
+	record, err := s.pulse.NewRecord(pulse.Int16Writer(s.writeRecord), opts...)
+	if err != nil {
+		return ErrInputDevice
+	}
+	s.record = record
+	return nil
+}
+
+// openPlayback creates and starts the playback stream of user. s.lock must
+// be held.
+func (s *Stream) openPlayback(user *gumble.User, us *userStream) error {
+	opts := []pulse.PlaybackOption{
+		pulse.PlaybackMono,
+		pulse.PlaybackSampleRate(gumble.AudioSampleRate),
+		pulse.PlaybackMediaName(s.name + ": " + user.Name),
+		pulse.PlaybackLatency(0.06),
+	}
+	if s.outputDevice != nil && *s.outputDevice != "" {
+		sink, err := s.pulse.SinkByID(*s.outputDevice)
+		if err != nil {
+			return ErrOutputDevice
+		}
+		opts = append(opts, pulse.PlaybackSink(sink))
+	}
+	playback, err := s.pulse.NewPlayback(pulse.Int16Reader(us.read), opts...)
+	if err != nil {
+		return ErrOutputDevice
+	}
+	us.playback = playback
+	if us.volume >= 0 {
+		playback.SetVolume(proto.ChannelVolumes{proto.NormVolume(us.volume)})
+	}
+	playback.Start()
+	return nil
+}
+
+// AttachStream starts playing the audio received by client.
+func (s *Stream) AttachStream(client *gumble.Client) {
+	s.link = client.AttachAudio(s)
+	s.lock.Lock()
+	s.monitor = make(chan struct{})
+	go s.monitorRoutine(s.monitor)
+	s.lock.Unlock()
+}
+
+// monitorRoutine re-connects to the sound server if the connection is lost
+// (e.g. when PulseAudio or PipeWire is restarted).
+func (s *Stream) monitorRoutine(stop chan struct{}) {
+	ticker := time.NewTicker(reconnectInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		s.lock.Lock()
+		if s.pulse == nil || s.record == nil || s.record.Closed() {
+			s.disconnect()
+			s.connect()
+		}
+		s.lock.Unlock()
+	}
+}
+
+// Destroy stops the stream, closes all of its PulseAudio streams and
+// disconnects from the sound server.
+func (s *Stream) Destroy() {
+	if s.link != nil {
+		s.link.Detach()
+		s.link = nil
+	}
+	s.StopSource()
+	s.lock.Lock()
+	if s.monitor != nil {
+		close(s.monitor)
+		s.monitor = nil
+	}
+	s.disconnect()
+	s.lock.Unlock()
+}
+
+// StartSource starts recording audio from inputDevice (or the device passed
+// to New, if nil) and sending it to the server.
+func (s *Stream) StartSource(inputDevice *string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.sourcing {
+		return ErrState
+	}
+	if s.pulse == nil {
+		return ErrMic
+	}
+	if inputDevice != nil && (s.inputDevice == nil || *inputDevice != *s.inputDevice) {
+		previous := s.inputDevice
+		s.inputDevice = inputDevice
+		oldRecord := s.record
+		if err := s.openRecord(); err != nil {
+			s.inputDevice = previous
+			return err
+		}
+		oldRecord.Close()
+	}
+	s.sourcing = true
+	s.captureLock.Lock()
+	s.captured = s.captured[:0]
+	s.outgoing = s.client.AudioOutgoing()
+	s.captureLock.Unlock()
+	s.record.Start()
+	return nil
+}
+
+// StopSource stops recording audio.
+func (s *Stream) StopSource() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.sourcing {
+		return ErrState
+	}
+	s.sourcing = false
+	s.captureLock.Lock()
+	close(s.outgoing)
+	s.outgoing = nil
+	s.captureLock.Unlock()
+	if s.record != nil && !s.record.Closed() {
+		s.record.Stop()
+	}
+	return nil
+}
+
+// writeRecord implements pulse.Int16Writer. Recorded samples are collected
+// into frames of the client's current audio frame size.
+func (s *Stream) writeRecord(buf []int16) (int, error) {
+	s.captureLock.Lock()
+	defer s.captureLock.Unlock()
+	if s.outgoing == nil {
+		return len(buf), nil
+	}
+	s.captured = append(s.captured, buf...)
+	frameSize := s.client.AudioFrameSize()
+	for len(s.captured) >= frameSize {
+		frame := make(gumble.AudioBuffer, frameSize)
+		for i, sample := range s.captured[:frameSize] {
+			frame[i] = int16(float32(sample) * s.micVolume)
+		}
+		s.captured = s.captured[frameSize:]
+		if s.AGC != nil {
+			s.AGC.Process(frame)
+		}
+		s.outgoing <- frame
+	}
+	return len(buf), nil
+}
+
+// GetMicVolume returns the capture volume, between 0 and 1.
+func (s *Stream) GetMicVolume() float32 {
+	s.captureLock.Lock()
+	defer s.captureLock.Unlock()
+	return s.micVolume
+}
+
+// SetMicVolume sets the capture volume, either to change or by change if
+// relative is true. The volume is clamped between 0 and 1.
+func (s *Stream) SetMicVolume(change float32, relative bool) {
+	s.captureLock.Lock()
+	defer s.captureLock.Unlock()
+	val := change
+	if relative {
+		val += s.micVolume
+	}
+	if val >= 1 {
+		val = 1.0
+	}
+	if val <= 0 {
+		val = 0
+	}
+	s.micVolume = val
+}
+
+// SetUserVolume sets the sound server's volume of user's playback stream,
+// where 1 is 100%. The volume is re-applied if the connection to the sound
+// server is re-established.
+func (s *Stream) SetUserVolume(user *gumble.User, volume float64) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	us := s.users[user]
+	if us == nil || us.playback == nil {
+		return ErrState
+	}
+	us.volume = volume
+	return us.playback.SetVolume(proto.ChannelVolumes{proto.NormVolume(volume)})
+}
+
+// UserVolume returns the sound server's volume of user's playback stream,
+// including changes made by the user in the system mixer.
+func (s *Stream) UserVolume(user *gumble.User) (float64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	us := s.users[user]
+	if us == nil || us.playback == nil {
+		return 0, ErrState
+	}
+	volumes, err := us.playback.Volume()
+	if err != nil {
+		return 0, err
+	}
+	if len(volumes) == 0 {
+		return 0, nil
+	}
+	var sum float64
+	for _, volume := range volumes {
+		sum += volume.Norm()
+	}
+	return sum / float64(len(volumes)), nil
+}
+
+// OnAudioStream implements gumble.AudioListener. A playback stream is created
+// for the user, which is closed when the audio stream ends.
+func (s *Stream) OnAudioStream(e *gumble.AudioStreamEvent) {
+	us := &userStream{
+		volume: -1,
+	}
+	s.lock.Lock()
+	s.users[e.User] = us
+	if s.pulse != nil {
+		s.openPlayback(e.User, us)
+	}
+	s.lock.Unlock()
+
+	go func() {
+		for packet := range e.C {
+			us.lock.Lock()
+			pending := append(us.pending, packet.AudioBuffer...)
+			if over := len(pending) - maxBufferedSamples; over > 0 {
+				pending = pending[over:]
+			}
+			us.pending = pending
+			us.lock.Unlock()
+		}
+		s.lock.Lock()
+		if us.playback != nil {
+			us.playback.Close()
+			us.playback = nil
+		}
+		delete(s.users, e.User)
+		s.lock.Unlock()
+	}()
+}
@@ -0,0 +1,274 @@
+// Package gumblertc bridges the audio of a gumble client to web browsers using
+// WebRTC.
+//
+// Each browser is a Peer of a Gateway. The Opus audio of the users speaking
+// on the server is forwarded, without transcoding, to the peer's audio
+// tracks, and audio from the peer's microphone can be sent to the server.
+//
+// Signaling is left to the application: the browser's offer is passed to
+// Gateway.Answer (or POSTed to the Gateway, which is an http.Handler), and
+// the returned answer is passed back to the browser. The offer must contain
+// an audio transceiver for each of the Gateway's tracks, and a data channel
+// negotiated out-of-band with the ID 0, on which the gateway announces which
+// user is heard on each track, e.g. in JavaScript:
+//
+//  const pc = new RTCPeerConnection();
+//  for (let i = 0; i < 4; i++) pc.addTransceiver("audio", {direction: "recvonly"});
+//  const events = pc.createDataChannel("gumble", {negotiated: true, id: 0});
+//
+// The announcements are JSON objects, such as:
+//  {"track": "gumble-0", "session": 12, "name": "Alice"}
+//  {"track": "gumble-0", "session": 0, "name": ""}
+package gumblertc // import "github.com/bmmcginty/gumble/gumblertc"
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+)
+
+// DefaultTracks is the default number of audio tracks per peer.
+const DefaultTracks = 4
+
+// trackIdleTimeout is how long a track must go without audio before it can be
+// used for another user.
+const trackIdleTimeout = time.Second
+
+// floorTimeout is how long a peer's microphone must be silent before another
+// peer can talk.
+const floorTimeout = 200 * time.Millisecond
+
+// ErrClosed is returned by Gateway.Answer after the gateway has been closed.
+var ErrClosed = errors.New("gumblertc: gateway closed")
+
+// Gateway bridges the audio of a gumble client to WebRTC peers.
+type Gateway struct {
+	// The configuration of the peer connections (e.g. ICE servers).
+	Configuration webrtc.Configuration
+	// The number of audio tracks per peer, i.e. the number of users that a
+	// peer can hear at the same time. Defaults to DefaultTracks.
+	Tracks int
+	// Should the audio from peers' microphones be sent to the server? Only
+	// one peer can talk at a time; the others are ignored until the talking
+	// peer is silent.
+	Transmit bool
+
+	client   *gumble.Client
+	detacher gumble.Detacher
+
+	mu          sync.Mutex
+	peers       map[*Peer]struct{}
+	talker      *Peer
+	talkerUntil time.Time
+	closed      bool
+}
+
+// New returns a new Gateway for client. The gateway starts forwarding audio
+// immediately, and keeps doing so until Close is called.
+func New(client *gumble.Client) *Gateway {
+	g := &Gateway{
+		Tracks: DefaultTracks,
+		client: client,
+		peers:  make(map[*Peer]struct{}),
+	}
+	g.detacher = client.AttachAudioEncoded(g)
+	return g
+}
+
+// Answer creates a new Peer from the browser's offer, and returns the answer
+// that must be passed back to the browser. ICE candidates are gathered before
+// Answer returns, so the answer contains all of them.
+func (g *Gateway) Answer(offer webrtc.SessionDescription) (*Peer, *webrtc.SessionDescription, error) {
+	g.mu.Lock()
+	closed := g.closed
+	tracks := g.Tracks
+	g.mu.Unlock()
+	if closed {
+		return nil, nil, ErrClosed
+	}
+	if tracks <= 0 {
+		tracks = DefaultTracks
+	}
+
+	pc, err := webrtc.NewPeerConnection(g.Configuration)
+	if err != nil {
+		return nil, nil, err
+	}
+	p := &Peer{
+		gateway: g,
+		pc:      pc,
+	}
+	fail := func(err error) (*Peer, *webrtc.SessionDescription, error) {
+		pc.Close()
+		return nil, nil, err
+	}
+
+	if err := pc.SetRemoteDescription(offer); err != nil {
+		return fail(err)
+	}
+	for i := 0; i < tracks; i++ {
+		id := "gumble-" + strconv.Itoa(i)
+		track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{
+			MimeType:  webrtc.MimeTypeOpus,
+			ClockRate: 48000,
+			Channels:  2,
+		}, id, id)
+		if err != nil {
+			return fail(err)
+		}
+		if _, err := pc.AddTrack(track); err != nil {
+			return fail(err)
+		}
+		p.tracks = append(p.tracks, &peerTrack{
+			id:    id,
+			track: track,
+		})
+	}
+	negotiated := true
+	var channelID uint16
+	events, err := pc.CreateDataChannel("gumble", &webrtc.DataChannelInit{
+		Negotiated: &negotiated,
+		ID:         &channelID,
+	})
+	if err != nil {
+		return fail(err)
+	}
+	p.events = events
+	pc.OnTrack(p.onTrack)
+	pc.OnConnectionStateChange(func(state webrtc.PeerConnectionState) {
+		switch state {
+		case webrtc.PeerConnectionStateFailed, webrtc.PeerConnectionStateClosed:
+			p.Close()
+		}
+	})
+
+	answer, err := pc.CreateAnswer(nil)
+	if err != nil {
+		return fail(err)
+	}
+	gathered := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(answer); err != nil {
+		return fail(err)
+	}
+	<-gathered
+
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return fail(ErrClosed)
+	}
+	g.peers[p] = struct{}{}
+	g.mu.Unlock()
+
+	return p, pc.LocalDescription(), nil
+}
+
+// ServeHTTP implements http.Handler. The request body must be the browser's
+// offer as JSON (i.e. RTCSessionDescription), and the answer is written to
+// the response as JSON.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var offer webrtc.SessionDescription
+	if err := json.NewDecoder(r.Body).Decode(&offer); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	_, answer, err := g.Answer(offer)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(answer)
+}
+
+// Peers returns the gateway's connected peers.
+func (g *Gateway) Peers() []*Peer {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	peers := make([]*Peer, 0, len(g.peers))
+	for p := range g.peers {
+		peers = append(peers, p)
+	}
+	return peers
+}
+
+// Close stops forwarding audio, and closes every peer.
+func (g *Gateway) Close() error {
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return nil
+	}
+	g.closed = true
+	peers := g.peers
+	g.peers = make(map[*Peer]struct{})
+	g.mu.Unlock()
+
+	g.client.Do(func() {
+		g.detacher.Detach()
+	})
+	for p := range peers {
+		p.Close()
+	}
+	return nil
+}
+
+// OnAudioStream implements gumble.AudioListener.
+func (g *Gateway) OnAudioStream(e *gumble.AudioStreamEvent) {
+	go func() {
+		for packet := range e.C {
+			if len(packet.Encoded) == 0 {
+				continue
+			}
+			sample := media.Sample{
+				Data:     packet.Encoded,
+				Duration: time.Duration(len(packet.AudioBuffer)) * time.Second / gumble.AudioSampleRate,
+			}
+			for _, p := range g.Peers() {
+				p.writeUser(e.User, sample)
+			}
+		}
+		for _, p := range g.Peers() {
+			p.releaseUser(e.User)
+		}
+	}()
+}
+
+// takeFloor returns true if p may send audio to the server.
+func (g *Gateway) takeFloor(p *Peer, now time.Time) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.Transmit || g.closed {
+		return false
+	}
+	if g.talker != nil && g.talker != p && now.Before(g.talkerUntil) {
+		return false
+	}
+	g.talker = p
+	g.talkerUntil = now.Add(floorTimeout)
+	return true
+}
+
+func (g *Gateway) removePeer(p *Peer) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.peers, p)
+	if g.talker == p {
+		g.talker = nil
+	}
+}
@@ -0,0 +1,195 @@
+package gumblertc // import "github.com/bmmcginty/gumble/gumblertc"
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/pion/webrtc/v3"
+	"github.com/pion/webrtc/v3/pkg/media"
+)
+
+// Peer is a browser connected to a Gateway.
+type Peer struct {
+	gateway *Gateway
+	pc      *webrtc.PeerConnection
+	events  *webrtc.DataChannel
+
+	mu     sync.Mutex
+	tracks []*peerTrack
+	closed bool
+}
+
+type peerTrack struct {
+	id    string
+	track *webrtc.TrackLocalStaticSample
+	user  *gumble.User
+	last  time.Time
+}
+
+// trackEvent is sent on the peer's data channel when the user heard on a track
+// changes.
+type trackEvent struct {
+	Track   string `json:"track"`
+	Session uint32 `json:"session"`
+	Name    string `json:"name"`
+}
+
+// PeerConnection returns the peer's underlying connection.
+func (p *Peer) PeerConnection() *webrtc.PeerConnection {
+	return p.pc
+}
+
+// Close closes the connection to the peer.
+func (p *Peer) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	p.gateway.removePeer(p)
+	return p.pc.Close()
+}
+
+// announce sends the user heard on track to the peer. p.mu must be held.
+func (p *Peer) announce(track *peerTrack) {
+	if p.events.ReadyState() != webrtc.DataChannelStateOpen {
+		return
+	}
+	event := trackEvent{
+		Track: track.id,
+	}
+	if track.user != nil {
+		event.Session = track.user.Session
+		event.Name = track.user.Name
+	}
+	data, err := json.Marshal(&event)
+	if err != nil {
+		return
+	}
+	p.events.SendText(string(data))
+}
+
+// writeUser sends a sample of user's audio to the peer, assigning a track to
+// the user if needed. The sample is dropped if every track is in use.
+func (p *Peer) writeUser(user *gumble.User, sample media.Sample) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	var track, free *peerTrack
+	for _, t := range p.tracks {
+		if t.user == user {
+			track = t
+			break
+		}
+		if free == nil && (t.user == nil || now.Sub(t.last) >= trackIdleTimeout) {
+			free = t
+		}
+	}
+	if track == nil {
+		if free == nil {
+			p.mu.Unlock()
+			return
+		}
+		track = free
+		track.user = user
+		p.announce(track)
+	}
+	track.last = now
+	p.mu.Unlock()
+
+	track.track.WriteSample(sample)
+}
+
+// releaseUser frees the track used by user, if any.
+func (p *Peer) releaseUser(user *gumble.User) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, t := range p.tracks {
+		if t.user == user {
+			t.user = nil
+			if !p.closed {
+				p.announce(t)
+			}
+		}
+	}
+}
+
+// onTrack forwards the peer's microphone to the server.
+func (p *Peer) onTrack(remote *webrtc.TrackRemote, receiver *webrtc.RTPReceiver) {
+	if !strings.EqualFold(remote.Codec().MimeType, webrtc.MimeTypeOpus) {
+		return
+	}
+	client := p.gateway.client
+	talking := false
+	for {
+		packet, _, err := remote.ReadRTP()
+		if err != nil {
+			break
+		}
+		samples := opusSamples(packet.Payload)
+		if samples == 0 {
+			continue
+		}
+		if !p.gateway.takeFloor(p, time.Now()) {
+			if talking {
+				client.SendOpusFrame(nil, 0)
+				talking = false
+			}
+			continue
+		}
+		if client.SendOpusFrame(packet.Payload, samples) == nil {
+			talking = true
+		}
+	}
+	if talking {
+		client.SendOpusFrame(nil, 0)
+	}
+}
+
+// opusSamples returns the number of samples (per channel, at 48kHz) in the
+// given Opus packet, or 0 if the packet is invalid (see RFC 6716, section 3.1).
+func opusSamples(packet []byte) int {
+	if len(packet) == 0 {
+		return 0
+	}
+	toc := packet[0]
+	config := int(toc >> 3)
+
+	var frame int
+	switch {
+	case config < 12: // SILK: 10, 20, 40 or 60ms
+		frame = []int{480, 960, 1920, 2880}[config%4]
+	case config < 16: // Hybrid: 10 or 20ms
+		frame = []int{480, 960}[config%2]
+	default: // CELT: 2.5, 5, 10 or 20ms
+		frame = []int{120, 240, 480, 960}[config%4]
+	}
+
+	var frames int
+	switch toc & 0x3 {
+	case 0:
+		frames = 1
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0
+		}
+		frames = int(packet[1] & 0x3F)
+	}
+	samples := frame * frames
+	if samples > gumble.AudioMaximumFrameSize {
+		return 0
+	}
+	return samples
+}
@@ -0,0 +1,346 @@
+// Package gumblescript lets gumble bots be customized with Lua scripts.
+//
+// A Script is a gumble.EventListener that runs Lua code (using gopher-lua).
+// Scripts register event handlers, and use the client, through the global
+// gumble table:
+//
+//  gumble.on("text_message", function(e)
+//    if e.message == "!hello" then
+//      gumble.send_user(e.sender.session, "Hello, " .. e.sender.name .. "!")
+//    end
+//  end)
+//
+// The following functions are available:
+//  gumble.on(event, function)            -- registers an event handler
+//  gumble.self()                         -- returns the client's user
+//  gumble.users()                        -- returns the connected users
+//  gumble.channels()                     -- returns the server's channels
+//  gumble.send(message)                  -- sends a message to Self's channel
+//  gumble.send_user(session, message)    -- sends a message to a user
+//  gumble.send_channel(id, message)      -- sends a message to a channel
+//  gumble.move(session, channel_id)      -- moves a user to a channel
+//  gumble.play(filename)                 -- plays a file with ffmpeg
+//  gumble.stop()                         -- stops playing the file
+//
+// The events are "connect", "disconnect", "text_message", "user_change" and
+// "channel_change". Each handler receives a table describing the event; users
+// are tables with session, name, user_id (if registered) and channel fields,
+// and channels are tables with id, name, parent and temporary fields.
+//
+// Lua code is only run while the client's state cannot change (i.e. from
+// event listeners, or inside client.Do), so scripts see a consistent view of
+// the server.
+package gumblescript // import "github.com/bmmcginty/gumble/gumblescript"
+
+import (
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleffmpeg"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	lua "github.com/yuin/gopher-lua"
+)
+
+// Script is a Lua interpreter bound to a gumble client.
+type Script struct {
+	gumbleutil.Listener
+
+	// Called when a script, or one of its event handlers, fails. Can be nil.
+	Error func(err error)
+
+	client *gumble.Client
+
+	mu       sync.Mutex
+	state    *lua.LState
+	handlers map[string][]*lua.LFunction
+	stream   *gumbleffmpeg.Stream
+}
+
+// New returns a new Script for client. Attach it to the client's config for
+// its event handlers to be called.
+func New(client *gumble.Client) *Script {
+	s := &Script{
+		client:   client,
+		state:    lua.NewState(),
+		handlers: make(map[string][]*lua.LFunction),
+	}
+	s.Listener = gumbleutil.Listener{
+		Connect:       s.onConnect,
+		Disconnect:    s.onDisconnect,
+		TextMessage:   s.onTextMessage,
+		UserChange:    s.onUserChange,
+		ChannelChange: s.onChannelChange,
+	}
+	s.state.SetGlobal("gumble", s.state.SetFuncs(s.state.NewTable(), map[string]lua.LGFunction{
+		"on":           s.luaOn,
+		"self":         s.luaSelf,
+		"users":        s.luaUsers,
+		"channels":     s.luaChannels,
+		"send":         s.luaSend,
+		"send_user":    s.luaSendUser,
+		"send_channel": s.luaSendChannel,
+		"move":         s.luaMove,
+		"play":         s.luaPlay,
+		"stop":         s.luaStop,
+	}))
+	return s
+}
+
+// DoString runs the given Lua code.
+func (s *Script) DoString(code string) error {
+	var err error
+	s.client.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		err = s.state.DoString(code)
+	})
+	return err
+}
+
+// DoFile runs the Lua script in the named file.
+func (s *Script) DoFile(filename string) error {
+	var err error
+	s.client.Do(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		err = s.state.DoFile(filename)
+	})
+	return err
+}
+
+// Close stops the file being played, if any, and closes the interpreter.
+func (s *Script) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stream != nil {
+		s.stream.Stop()
+		s.stream = nil
+	}
+	s.state.Close()
+	s.handlers = make(map[string][]*lua.LFunction)
+}
+
+// emit calls the handlers of the given event with the table built by event.
+func (s *Script) emit(name string, event func(L *lua.LState) *lua.LTable) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	handlers := s.handlers[name]
+	if len(handlers) == 0 {
+		return
+	}
+	table := event(s.state)
+	for _, fn := range handlers {
+		err := s.state.CallByParam(lua.P{
+			Fn:      fn,
+			NRet:    0,
+			Protect: true,
+		}, table)
+		if err != nil && s.Error != nil {
+			s.Error(err)
+		}
+	}
+}
+
+func (s *Script) onConnect(e *gumble.ConnectEvent) {
+	s.emit("connect", func(L *lua.LState) *lua.LTable {
+		t := L.NewTable()
+		if e.WelcomeMessage != nil {
+			t.RawSetString("welcome_message", lua.LString(*e.WelcomeMessage))
+		}
+		return t
+	})
+}
+
+func (s *Script) onDisconnect(e *gumble.DisconnectEvent) {
+	s.emit("disconnect", func(L *lua.LState) *lua.LTable {
+		t := L.NewTable()
+		t.RawSetString("type", lua.LNumber(e.Type))
+		t.RawSetString("reason", lua.LString(e.String))
+		return t
+	})
+}
+
+func (s *Script) onTextMessage(e *gumble.TextMessageEvent) {
+	s.emit("text_message", func(L *lua.LState) *lua.LTable {
+		t := L.NewTable()
+		if e.Sender != nil {
+			t.RawSetString("sender", userTable(L, e.Sender))
+		}
+		t.RawSetString("message", lua.LString(gumbleutil.PlainText(&e.TextMessage)))
+		t.RawSetString("html", lua.LString(e.Message))
+		users := L.NewTable()
+		for _, user := range e.Users {
+			users.Append(userTable(L, user))
+		}
+		t.RawSetString("users", users)
+		channels := L.NewTable()
+		for _, channel := range e.Channels {
+			channels.Append(channelTable(L, channel))
+		}
+		t.RawSetString("channels", channels)
+		trees := L.NewTable()
+		for _, channel := range e.Trees {
+			trees.Append(channelTable(L, channel))
+		}
+		t.RawSetString("trees", trees)
+		return t
+	})
+}
+
+func (s *Script) onUserChange(e *gumble.UserChangeEvent) {
+	s.emit("user_change", func(L *lua.LState) *lua.LTable {
+		t := L.NewTable()
+		t.RawSetString("user", userTable(L, e.User))
+		if e.Actor != nil {
+			t.RawSetString("actor", userTable(L, e.Actor))
+		}
+		t.RawSetString("type", lua.LNumber(e.Type))
+		t.RawSetString("connected", lua.LBool(e.Type.Has(gumble.UserChangeConnected)))
+		t.RawSetString("disconnected", lua.LBool(e.Type.Has(gumble.UserChangeDisconnected)))
+		t.RawSetString("kicked", lua.LBool(e.Type.Has(gumble.UserChangeKicked)))
+		t.RawSetString("banned", lua.LBool(e.Type.Has(gumble.UserChangeBanned)))
+		t.RawSetString("channel", lua.LBool(e.Type.Has(gumble.UserChangeChannel)))
+		t.RawSetString("name", lua.LBool(e.Type.Has(gumble.UserChangeName)))
+		t.RawSetString("comment", lua.LBool(e.Type.Has(gumble.UserChangeComment)))
+		t.RawSetString("audio", lua.LBool(e.Type.Has(gumble.UserChangeAudio)))
+		t.RawSetString("reason", lua.LString(e.String))
+		return t
+	})
+}
+
+func (s *Script) onChannelChange(e *gumble.ChannelChangeEvent) {
+	s.emit("channel_change", func(L *lua.LState) *lua.LTable {
+		t := L.NewTable()
+		t.RawSetString("channel", channelTable(L, e.Channel))
+		t.RawSetString("type", lua.LNumber(e.Type))
+		t.RawSetString("created", lua.LBool(e.Type.Has(gumble.ChannelChangeCreated)))
+		t.RawSetString("removed", lua.LBool(e.Type.Has(gumble.ChannelChangeRemoved)))
+		return t
+	})
+}
+
+func (s *Script) luaOn(L *lua.LState) int {
+	name := L.CheckString(1)
+	fn := L.CheckFunction(2)
+	s.handlers[name] = append(s.handlers[name], fn)
+	return 0
+}
+
+func (s *Script) luaSelf(L *lua.LState) int {
+	if s.client.Self == nil {
+		L.Push(lua.LNil)
+	} else {
+		L.Push(userTable(L, s.client.Self))
+	}
+	return 1
+}
+
+func (s *Script) luaUsers(L *lua.LState) int {
+	t := L.NewTable()
+	for _, user := range s.client.Users {
+		t.Append(userTable(L, user))
+	}
+	L.Push(t)
+	return 1
+}
+
+func (s *Script) luaChannels(L *lua.LState) int {
+	t := L.NewTable()
+	for _, channel := range s.client.Channels {
+		t.Append(channelTable(L, channel))
+	}
+	L.Push(t)
+	return 1
+}
+
+func (s *Script) luaSend(L *lua.LState) int {
+	message := L.CheckString(1)
+	if s.client.Self != nil && s.client.Self.Channel != nil {
+		s.client.Self.Channel.Send(message, false)
+	}
+	return 0
+}
+
+func (s *Script) luaSendUser(L *lua.LState) int {
+	user := s.client.Users[uint32(L.CheckInt(1))]
+	message := L.CheckString(2)
+	if user == nil {
+		L.ArgError(1, "unknown user")
+		return 0
+	}
+	user.Send(message)
+	return 0
+}
+
+func (s *Script) luaSendChannel(L *lua.LState) int {
+	channel := s.client.Channels[uint32(L.CheckInt(1))]
+	message := L.CheckString(2)
+	if channel == nil {
+		L.ArgError(1, "unknown channel")
+		return 0
+	}
+	channel.Send(message, false)
+	return 0
+}
+
+func (s *Script) luaMove(L *lua.LState) int {
+	user := s.client.Users[uint32(L.CheckInt(1))]
+	channel := s.client.Channels[uint32(L.CheckInt(2))]
+	if user == nil {
+		L.ArgError(1, "unknown user")
+		return 0
+	}
+	if channel == nil {
+		L.ArgError(2, "unknown channel")
+		return 0
+	}
+	user.Move(channel)
+	return 0
+}
+
+func (s *Script) luaPlay(L *lua.LState) int {
+	filename := L.CheckString(1)
+	if s.stream != nil {
+		s.stream.Stop()
+	}
+	s.stream = gumbleffmpeg.New(s.client, gumbleffmpeg.SourceFile(filename))
+	if err := s.stream.Play(); err != nil {
+		s.stream = nil
+		L.RaiseError("%s", err)
+	}
+	return 0
+}
+
+func (s *Script) luaStop(L *lua.LState) int {
+	if s.stream != nil {
+		s.stream.Stop()
+		s.stream = nil
+	}
+	return 0
+}
+
+func userTable(L *lua.LState, user *gumble.User) *lua.LTable {
+	t := L.NewTable()
+	t.RawSetString("session", lua.LNumber(user.Session))
+	t.RawSetString("name", lua.LString(user.Name))
+	if user.IsRegistered() {
+		t.RawSetString("user_id", lua.LNumber(user.UserID))
+	}
+	if user.Channel != nil {
+		t.RawSetString("channel", lua.LNumber(user.Channel.ID))
+	}
+	return t
+}
+
+func channelTable(L *lua.LState, channel *gumble.Channel) *lua.LTable {
+	t := L.NewTable()
+	t.RawSetString("id", lua.LNumber(channel.ID))
+	t.RawSetString("name", lua.LString(channel.Name))
+	if channel.Parent != nil {
+		t.RawSetString("parent", lua.LNumber(channel.Parent.ID))
+	}
+	t.RawSetString("temporary", lua.LBool(channel.Temporary))
+	return t
+}
@@ -0,0 +1,78 @@
+package gumblesip // import "github.com/bmmcginty/gumble/gumblesip"
+
+// G.711 μ-law and A-law companding (ITU-T G.711).
+
+const (
+	ulawBias = 0x84
+	ulawClip = 32635
+)
+
+func encodeULaw(sample int16) byte {
+	s := int(sample)
+	sign := 0
+	if s < 0 {
+		s = -s
+		sign = 0x80
+	}
+	if s > ulawClip {
+		s = ulawClip
+	}
+	s += ulawBias
+	exponent := 7
+	for mask := 0x4000; s&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (s >> uint(exponent+3)) & 0x0F
+	return ^byte(sign | exponent<<4 | mantissa)
+}
+
+func decodeULaw(b byte) int16 {
+	b = ^b
+	exponent := int(b>>4) & 0x07
+	mantissa := int(b) & 0x0F
+	s := ((mantissa << 3) + ulawBias) << uint(exponent)
+	s -= ulawBias
+	if b&0x80 != 0 {
+		return int16(-s)
+	}
+	return int16(s)
+}
+
+func encodeALaw(sample int16) byte {
+	s := int(sample) >> 3
+	mask := 0xD5
+	if s < 0 {
+		mask = 0x55
+		s = -s - 1
+	}
+	segment := 0
+	for end := 0x1F; segment < 8 && s > end; end = end<<1 | 1 {
+		segment++
+	}
+	if segment >= 8 {
+		return byte(0x7F ^ mask)
+	}
+	b := segment << 4
+	if segment < 2 {
+		b |= (s >> 1) & 0x0F
+	} else {
+		b |= (s >> uint(segment)) & 0x0F
+	}
+	return byte(b ^ mask)
+}
+
+func decodeALaw(b byte) int16 {
+	b ^= 0x55
+	exponent := int(b>>4) & 0x07
+	mantissa := int(b) & 0x0F
+	var s int
+	if exponent == 0 {
+		s = mantissa<<4 + 8
+	} else {
+		s = (mantissa<<4 + 0x108) << uint(exponent-1)
+	}
+	if b&0x80 == 0 {
+		return int16(-s)
+	}
+	return int16(s)
+}
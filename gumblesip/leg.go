@@ -0,0 +1,450 @@
+// Package gumblesip provides the media side of a SIP/telephone gateway for
+// gumble.
+//
+// A Leg is an RTP session with a phone (e.g. a SIP softphone, or an Asterisk
+// trunk): the audio heard by the client is mixed and sent to the phone, and
+// the phone's audio is sent to the server. DTMF digits (RFC 4733 telephone
+// events) can be mapped to commands.
+//
+// SIP signaling is not handled by the package. The application (or a PBX in
+// front of it) negotiates the call, and uses Leg.LocalAddr and Leg.SDP to
+// describe the leg's media in its SDP.
+package gumblesip // import "github.com/bmmcginty/gumble/gumblesip"
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumbleutil"
+	"github.com/bmmcginty/gumble/opus"
+)
+
+// Codec is the audio codec of a Leg.
+type Codec int
+
+// Supported codecs.
+const (
+	// G.711 μ-law, 8kHz (static payload type 0).
+	CodecPCMU Codec = iota
+	// G.711 A-law, 8kHz (static payload type 8).
+	CodecPCMA
+	// Opus, 48kHz (dynamic payload type, see Leg.PayloadType).
+	CodecOpus
+)
+
+// Default dynamic payload types.
+const (
+	DefaultOpusPayloadType = 111
+	DefaultDTMFPayloadType = 101
+)
+
+// packetDuration is the duration of the audio in each sent RTP packet.
+const packetDuration = 20 * time.Millisecond
+
+// talkTimeout is how long the phone must be silent before the transmission to
+// the server is ended.
+const talkTimeout = 200 * time.Millisecond
+
+// dtmfEvents are the DTMF digits, indexed by RFC 4733 event code.
+const dtmfEvents = "0123456789*#ABCD"
+
+// Leg is an RTP session between a gumble client and a phone.
+type Leg struct {
+	// The payload type of Opus packets, if the leg's codec is CodecOpus.
+	// Defaults to DefaultOpusPayloadType.
+	PayloadType byte
+	// The payload type of telephone events. Defaults to
+	// DefaultDTMFPayloadType.
+	DTMFPayloadType byte
+	// The peak amplitude below which the phone's audio is considered silent
+	// and is not sent to the server. Zero sends all of the phone's audio.
+	Threshold int16
+
+	// Called for each DTMF digit ('0'-'9', '*', '#', 'A'-'D') received from
+	// the phone. Can be nil.
+	DTMF func(digit rune)
+	// Commands run when the phone dials their key followed by '#'. Dialing
+	// '*' clears the digits dialed so far. Can be nil.
+	Commands map[string]func()
+	// Called when sending or receiving fails. Can be nil.
+	Error func(err error)
+
+	client *gumble.Client
+	codec  Codec
+	conn   *net.UDPConn
+	mixer  *gumbleutil.Mixer
+
+	detacher gumble.Detacher
+	closed   chan struct{}
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	remote   *net.UDPAddr
+	outgoing chan<- gumble.AudioBuffer
+	lastTalk time.Time
+	pending  []int16 // 48kHz audio from the phone, not yet sent
+	digits   []rune
+	lastDTMF uint32 // RTP timestamp of the last DTMF event
+	gotDTMF  bool
+}
+
+// Listen returns a new Leg for client that receives RTP on the given local
+// UDP address (e.g. ":10000"). Audio is sent to the phone once its address is
+// known, either from SetRemote, or from the first packet received from it.
+func Listen(client *gumble.Client, address string, codec Codec) (*Leg, error) {
+	if codec < CodecPCMU || codec > CodecOpus {
+		return nil, errors.New("gumblesip: invalid codec")
+	}
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &Leg{
+		PayloadType:     DefaultOpusPayloadType,
+		DTMFPayloadType: DefaultDTMFPayloadType,
+
+		client: client,
+		codec:  codec,
+		conn:   conn,
+		mixer:  gumbleutil.NewMixer(),
+		closed: make(chan struct{}),
+	}
+	client.Do(func() {
+		l.detacher = client.AttachAudio(l.mixer)
+	})
+
+	l.wg.Add(2)
+	go l.sendRoutine()
+	go l.receiveRoutine()
+	return l, nil
+}
+
+// LocalAddr returns the local address on which the leg receives RTP.
+func (l *Leg) LocalAddr() *net.UDPAddr {
+	return l.conn.LocalAddr().(*net.UDPAddr)
+}
+
+// SetRemote sets the address to which RTP is sent.
+func (l *Leg) SetRemote(address string) error {
+	addr, err := net.ResolveUDPAddr("udp", address)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	l.remote = addr
+	l.mu.Unlock()
+	return nil
+}
+
+// SDP returns the SDP media description of the leg (an "m=audio" line and its
+// attributes), for use in an offer or answer.
+func (l *Leg) SDP() string {
+	port := l.LocalAddr().Port
+	switch l.codec {
+	case CodecPCMA:
+		return fmt.Sprintf("m=audio %d RTP/AVP 8 %d\r\na=rtpmap:8 PCMA/8000\r\na=rtpmap:%[2]d telephone-event/8000\r\na=fmtp:%[2]d 0-15\r\na=ptime:20\r\n", port, l.DTMFPayloadType)
+	case CodecOpus:
+		return fmt.Sprintf("m=audio %d RTP/AVP %d %d\r\na=rtpmap:%[2]d opus/48000/2\r\na=rtpmap:%[3]d telephone-event/48000\r\na=fmtp:%[3]d 0-15\r\na=ptime:20\r\n", port, l.PayloadType, l.DTMFPayloadType)
+	}
+	return fmt.Sprintf("m=audio %d RTP/AVP 0 %d\r\na=rtpmap:0 PCMU/8000\r\na=rtpmap:%[2]d telephone-event/8000\r\na=fmtp:%[2]d 0-15\r\na=ptime:20\r\n", port, l.DTMFPayloadType)
+}
+
+// Close ends the leg.
+func (l *Leg) Close() error {
+	select {
+	case <-l.closed:
+		return nil
+	default:
+	}
+	close(l.closed)
+	err := l.conn.Close()
+	l.wg.Wait()
+
+	l.client.Do(func() {
+		l.detacher.Detach()
+	})
+	l.mu.Lock()
+	if l.outgoing != nil {
+		close(l.outgoing)
+		l.outgoing = nil
+	}
+	l.mu.Unlock()
+	return err
+}
+
+func (l *Leg) fail(err error) {
+	if err != nil && l.Error != nil {
+		l.Error(err)
+	}
+}
+
+// clockRate returns the RTP clock rate of the leg's codec.
+func (l *Leg) clockRate() int {
+	if l.codec == CodecOpus {
+		return gumble.AudioSampleRate
+	}
+	return 8000
+}
+
+// sendRoutine mixes the audio heard by the client and sends it to the phone.
+func (l *Leg) sendRoutine() {
+	defer l.wg.Done()
+
+	var encoder *opus.Encoder
+	var resampler *gumbleutil.Resampler
+	payloadType := byte(0)
+	switch l.codec {
+	case CodecOpus:
+		encoder, _ = opus.Codec.NewEncoder().(*opus.Encoder)
+		if encoder == nil || encoder.Encoder == nil {
+			l.fail(errors.New("gumblesip: could not create opus encoder"))
+			return
+		}
+		encoder.SetBitrate(32000)
+		payloadType = l.PayloadType
+	case CodecPCMA:
+		payloadType = 8
+		fallthrough
+	default:
+		resampler = gumbleutil.NewResampler(gumble.AudioSampleRate, 8000)
+	}
+
+	frameSize := int(packetDuration / (time.Second / gumble.AudioSampleRate))
+	rtpFrame := l.clockRate() * int(packetDuration/time.Millisecond) / 1000
+	buf := make([]int16, frameSize)
+	var samples []int16
+
+	ssrc := rand.Uint32()
+	sequence := uint16(rand.Uint32())
+	timestamp := rand.Uint32()
+	packet := make([]byte, 12, 12+1275)
+
+	ticker := time.NewTicker(packetDuration)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.closed:
+			return
+		case <-ticker.C:
+		}
+		l.endIdleTalk()
+
+		l.mixer.Mix(buf)
+		l.mu.Lock()
+		remote := l.remote
+		l.mu.Unlock()
+		if remote == nil {
+			continue
+		}
+
+		var payloads [][]byte
+		if encoder != nil {
+			data, err := encoder.Encode(buf, frameSize, 1275)
+			if err != nil {
+				l.fail(err)
+				continue
+			}
+			payloads = append(payloads, data)
+		} else {
+			samples = append(samples, resampler.Process(buf)...)
+			for len(samples) >= rtpFrame {
+				data := make([]byte, rtpFrame)
+				for i, sample := range samples[:rtpFrame] {
+					if l.codec == CodecPCMA {
+						data[i] = encodeALaw(sample)
+					} else {
+						data[i] = encodeULaw(sample)
+					}
+				}
+				samples = samples[rtpFrame:]
+				payloads = append(payloads, data)
+			}
+		}
+
+		for _, data := range payloads {
+			packet = packet[:12]
+			packet[0] = 2 << 6 // version
+			packet[1] = payloadType & 0x7F
+			binary.BigEndian.PutUint16(packet[2:], sequence)
+			binary.BigEndian.PutUint32(packet[4:], timestamp)
+			binary.BigEndian.PutUint32(packet[8:], ssrc)
+			packet = append(packet, data...)
+			sequence++
+			timestamp += uint32(rtpFrame)
+			if _, err := l.conn.WriteToUDP(packet, remote); err != nil {
+				l.fail(err)
+			}
+		}
+	}
+}
+
+// receiveRoutine receives RTP from the phone.
+func (l *Leg) receiveRoutine() {
+	defer l.wg.Done()
+
+	var decoder gumble.AudioDecoder
+	var resampler *gumbleutil.Resampler
+	if l.codec == CodecOpus {
+		decoder = opus.Codec.NewDecoder()
+	} else {
+		resampler = gumbleutil.NewResampler(8000, gumble.AudioSampleRate)
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := l.conn.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-l.closed:
+				return
+			default:
+			}
+			l.fail(err)
+			continue
+		}
+		packet := buf[:n]
+		if len(packet) < 12 || packet[0]>>6 != 2 {
+			continue
+		}
+
+		l.mu.Lock()
+		if l.remote == nil {
+			l.remote = addr
+		}
+		l.mu.Unlock()
+
+		// Skip the RTP header, CSRCs and extension.
+		offset := 12 + int(packet[0]&0x0F)*4
+		if packet[0]&0x10 != 0 {
+			if len(packet) < offset+4 {
+				continue
+			}
+			offset += 4 + int(binary.BigEndian.Uint16(packet[offset+2:]))*4
+		}
+		end := len(packet)
+		if packet[0]&0x20 != 0 && end > 0 {
+			end -= int(packet[end-1])
+		}
+		if offset > end {
+			continue
+		}
+		payloadType := packet[1] & 0x7F
+		timestamp := binary.BigEndian.Uint32(packet[4:])
+		payload := packet[offset:end]
+
+		switch {
+		case payloadType == l.DTMFPayloadType:
+			l.handleDTMF(timestamp, payload)
+		case l.codec == CodecOpus && payloadType == l.PayloadType:
+			pcm, err := decoder.Decode(payload, gumble.AudioMaximumFrameSize)
+			if err != nil {
+				continue
+			}
+			l.handleAudio(pcm)
+		case l.codec == CodecPCMU && payloadType == 0, l.codec == CodecPCMA && payloadType == 8:
+			pcm := make([]int16, len(payload))
+			for i, b := range payload {
+				if l.codec == CodecPCMA {
+					pcm[i] = decodeALaw(b)
+				} else {
+					pcm[i] = decodeULaw(b)
+				}
+			}
+			l.handleAudio(resampler.Process(pcm))
+		}
+	}
+}
+
+// handleAudio sends 48kHz audio received from the phone to the server.
+func (l *Leg) handleAudio(pcm []int16) {
+	threshold := int(l.Threshold)
+	loud := threshold == 0
+	for _, sample := range pcm {
+		if s := int(sample); s >= threshold || -s >= threshold {
+			loud = true
+			break
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if loud {
+		l.lastTalk = now
+	} else if l.outgoing == nil {
+		return
+	}
+	if l.outgoing == nil {
+		l.outgoing = l.client.AudioOutgoing()
+		l.pending = l.pending[:0]
+	}
+
+	frameSize := l.client.AudioFrameSize()
+	l.pending = append(l.pending, pcm...)
+	for len(l.pending) >= frameSize {
+		frame := make(gumble.AudioBuffer, frameSize)
+		copy(frame, l.pending)
+		l.pending = l.pending[frameSize:]
+		l.outgoing <- frame
+	}
+}
+
+// endIdleTalk ends the transmission to the server once the phone has been
+// silent for talkTimeout.
+func (l *Leg) endIdleTalk() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.outgoing != nil && time.Since(l.lastTalk) >= talkTimeout {
+		close(l.outgoing)
+		l.outgoing = nil
+	}
+}
+
+// handleDTMF handles an RFC 4733 telephone event. Each digit is reported once,
+// when its end packet is first received.
+func (l *Leg) handleDTMF(timestamp uint32, payload []byte) {
+	if len(payload) < 4 || payload[1]&0x80 == 0 || int(payload[0]) >= len(dtmfEvents) {
+		return
+	}
+
+	l.mu.Lock()
+	if l.gotDTMF && l.lastDTMF == timestamp {
+		l.mu.Unlock()
+		return
+	}
+	l.gotDTMF = true
+	l.lastDTMF = timestamp
+
+	digit := rune(dtmfEvents[payload[0]])
+	var command func()
+	switch digit {
+	case '*':
+		l.digits = l.digits[:0]
+	case '#':
+		command = l.Commands[string(l.digits)]
+		l.digits = l.digits[:0]
+	default:
+		l.digits = append(l.digits, digit)
+	}
+	l.mu.Unlock()
+
+	if l.DTMF != nil {
+		l.DTMF(digit)
+	}
+	if command != nil {
+		command()
+	}
+}
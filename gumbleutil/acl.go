@@ -39,7 +39,7 @@ func UserGroups(client *gumble.Client, user *gumble.User, channel *gumble.Channe
 			}
 			var names []string
 			for _, g := range e.ACL.Groups {
-				if (g.UsersAdd[user.UserID] != nil || g.UsersInherited[user.UserID] != nil) && g.UsersRemove[user.UserID] == nil {
+				if g.HasUser(user.UserID) {
 					names = append(names, g.Name)
 				}
 			}
@@ -48,7 +48,7 @@ func UserGroups(client *gumble.Client, user *gumble.User, channel *gumble.Channe
 			close(ch)
 		},
 	}
-	detacher = client.Config.Attach(&listener)
+	detacher = client.Attach(&listener)
 	channel.RequestACL()
 
 	return ch
@@ -0,0 +1,106 @@
+package gumbleutil
+
+import (
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// The functions below return an event listener that only handles one type of
+// event, e.g.:
+//  client.Attach(gumbleutil.OnTextMessage(func(e *gumble.TextMessageEvent) {
+//    // ...
+//  }))
+
+// OnConnect returns an event listener that calls fn for each ConnectEvent.
+func OnConnect(fn func(e *gumble.ConnectEvent)) gumble.EventListener {
+	return Listener{Connect: fn}
+}
+
+// OnDisconnect returns an event listener that calls fn for each DisconnectEvent.
+func OnDisconnect(fn func(e *gumble.DisconnectEvent)) gumble.EventListener {
+	return Listener{Disconnect: fn}
+}
+
+// OnTextMessage returns an event listener that calls fn for each TextMessageEvent.
+func OnTextMessage(fn func(e *gumble.TextMessageEvent)) gumble.EventListener {
+	return Listener{TextMessage: fn}
+}
+
+// OnUserChange returns an event listener that calls fn for each UserChangeEvent.
+func OnUserChange(fn func(e *gumble.UserChangeEvent)) gumble.EventListener {
+	return Listener{UserChange: fn}
+}
+
+// OnChannelChange returns an event listener that calls fn for each ChannelChangeEvent.
+func OnChannelChange(fn func(e *gumble.ChannelChangeEvent)) gumble.EventListener {
+	return Listener{ChannelChange: fn}
+}
+
+// OnPermissionDenied returns an event listener that calls fn for each PermissionDeniedEvent.
+func OnPermissionDenied(fn func(e *gumble.PermissionDeniedEvent)) gumble.EventListener {
+	return Listener{PermissionDenied: fn}
+}
+
+// OnUserList returns an event listener that calls fn for each UserListEvent.
+func OnUserList(fn func(e *gumble.UserListEvent)) gumble.EventListener {
+	return Listener{UserList: fn}
+}
+
+// OnACL returns an event listener that calls fn for each ACLEvent.
+func OnACL(fn func(e *gumble.ACLEvent)) gumble.EventListener {
+	return Listener{ACL: fn}
+}
+
+// OnBanList returns an event listener that calls fn for each BanListEvent.
+func OnBanList(fn func(e *gumble.BanListEvent)) gumble.EventListener {
+	return Listener{BanList: fn}
+}
+
+// OnContextActionChange returns an event listener that calls fn for each ContextActionChangeEvent.
+func OnContextActionChange(fn func(e *gumble.ContextActionChangeEvent)) gumble.EventListener {
+	return Listener{ContextActionChange: fn}
+}
+
+// OnServerConfig returns an event listener that calls fn for each ServerConfigEvent.
+func OnServerConfig(fn func(e *gumble.ServerConfigEvent)) gumble.EventListener {
+	return Listener{ServerConfig: fn}
+}
+
+// OnAudioConfig returns an event listener that calls fn for each AudioConfigEvent.
+func OnAudioConfig(fn func(e *gumble.AudioConfigEvent)) gumble.EventListener {
+	return Listener{AudioConfig: fn}
+}
+
+// OnSyncProgress returns an event listener that calls fn for each SyncProgressEvent.
+func OnSyncProgress(fn func(e *gumble.SyncProgressEvent)) gumble.EventListener {
+	return Listener{SyncProgress: fn}
+}
+
+// OnStateChange returns an event listener that calls fn for each StateChangeEvent.
+func OnStateChange(fn func(e *gumble.StateChangeEvent)) gumble.EventListener {
+	return Listener{StateChange: fn}
+}
+
+// OnUserReturned returns an event listener that calls fn for each UserReturnedEvent.
+func OnUserReturned(fn func(e *gumble.UserReturnedEvent)) gumble.EventListener {
+	return Listener{UserReturned: fn}
+}
+
+// OnChannelOccupancyChange returns an event listener that calls fn for each ChannelOccupancyEvent.
+func OnChannelOccupancyChange(fn func(e *gumble.ChannelOccupancyEvent)) gumble.EventListener {
+	return Listener{ChannelOccupancy: fn}
+}
+
+// OnChannelActivityChange returns an event listener that calls fn for each ChannelActivityEvent.
+func OnChannelActivityChange(fn func(e *gumble.ChannelActivityEvent)) gumble.EventListener {
+	return Listener{ChannelActivity: fn}
+}
+
+// OnChannelLost returns an event listener that calls fn for each ChannelLostEvent.
+func OnChannelLost(fn func(e *gumble.ChannelLostEvent)) gumble.EventListener {
+	return Listener{ChannelLost: fn}
+}
+
+// OnResourceLimit returns an event listener that calls fn for each ResourceLimitEvent.
+func OnResourceLimit(fn func(e *gumble.ResourceLimitEvent)) gumble.EventListener {
+	return Listener{ResourceLimit: fn}
+}
@@ -0,0 +1,166 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// StatsTimeout is how long KickIdleUsers waits for the server to send the
+// users' stats before giving up on the users who have not been reported.
+var StatsTimeout = 10 * time.Second
+
+// MuteChannel sets the server mute state of every user in the given channel
+// (and, if recursive is true, its sub-channels), except the client's own
+// user. Users that are already in the requested state are skipped. The users
+// for which a request was sent are returned.
+//
+// The client must have the mute/deafen permission in the channels for the
+// requests to succeed.
+func MuteChannel(client *gumble.Client, channel *gumble.Channel, muted, recursive bool) []*gumble.User {
+	var users []*gumble.User
+	client.Do(func() {
+		var walk func(channel *gumble.Channel)
+		walk = func(channel *gumble.Channel) {
+			for _, user := range channel.Users {
+				if user != client.Self && user.Muted != muted {
+					users = append(users, user)
+				}
+			}
+			if recursive {
+				for _, child := range channel.Children {
+					walk(child)
+				}
+			}
+		}
+		walk(channel)
+		for _, user := range users {
+			user.SetMuted(muted)
+		}
+	})
+	return users
+}
+
+// PurgeTemporaryChannels removes every temporary channel on the server. Users
+// in a removed channel are moved to its parent channel by the server.
+// Temporary channels below a removed channel are removed along with it, and
+// are not requested separately. The channels for which a request was sent
+// are returned.
+//
+// The client must have the write permission in the channels for the requests
+// to succeed.
+func PurgeTemporaryChannels(client *gumble.Client) []*gumble.Channel {
+	var channels []*gumble.Channel
+	client.Do(func() {
+		var walk func(channel *gumble.Channel)
+		walk = func(channel *gumble.Channel) {
+			if channel.Temporary {
+				channels = append(channels, channel)
+				return
+			}
+			for _, child := range channel.Children {
+				walk(child)
+			}
+		}
+		if root := client.Channels[0]; root != nil {
+			walk(root)
+		}
+		for _, channel := range channels {
+			channel.Remove()
+		}
+	})
+	return channels
+}
+
+// KickIdleUsers requests the stats of every user on the server (except the
+// client's own user), and kicks, with the given reason, the users that have
+// been idle for at least idle. The slice of kicked users is sent via the
+// returned channel once every user's stats have been received, StatsTimeout
+// has elapsed, or the client has disconnected.
+//
+// The client must have the kick permission, and permission to view the
+// users' stats, for the requests to succeed.
+func KickIdleUsers(client *gumble.Client, idle time.Duration, reason string) <-chan []*gumble.User {
+	ch := make(chan []*gumble.User, 1)
+
+	var (
+		lock     sync.Mutex
+		pending  = make(map[*gumble.User]bool)
+		kicked   []*gumble.User
+		done     bool
+		detacher gumble.Detacher
+		timer    *time.Timer
+	)
+
+	// must be called with lock held and the client's state locked
+	finish := func() {
+		if done {
+			return
+		}
+		done = true
+		if detacher != nil {
+			detacher.Detach()
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		ch <- kicked
+		close(ch)
+	}
+
+	listener := Listener{
+		Disconnect: func(e *gumble.DisconnectEvent) {
+			lock.Lock()
+			defer lock.Unlock()
+			finish()
+		},
+		UserChange: func(e *gumble.UserChangeEvent) {
+			lock.Lock()
+			defer lock.Unlock()
+			if done || !pending[e.User] {
+				return
+			}
+			if e.Type.Has(gumble.UserChangeDisconnected) {
+				delete(pending, e.User)
+			} else if e.Type.Has(gumble.UserChangeStats) {
+				delete(pending, e.User)
+				if e.User.Stats != nil && e.User.Stats.Idle >= idle {
+					e.User.Kick(reason)
+					kicked = append(kicked, e.User)
+				}
+			}
+			if len(pending) == 0 {
+				finish()
+			}
+		},
+	}
+
+	client.Do(func() {
+		lock.Lock()
+		defer lock.Unlock()
+
+		for _, user := range client.Users {
+			if user != client.Self {
+				pending[user] = true
+			}
+		}
+		if len(pending) == 0 {
+			finish()
+			return
+		}
+		detacher = client.Attach(&listener)
+		timer = time.AfterFunc(StatsTimeout, func() {
+			client.Do(func() {
+				lock.Lock()
+				defer lock.Unlock()
+				finish()
+			})
+		})
+		for user := range pending {
+			user.RequestStats()
+		}
+	})
+
+	return ch
+}
@@ -0,0 +1,148 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// AFKMover is a gumble.EventListener that moves idle users into an AFK
+// channel, and optionally moves them back to the channel they were in once
+// they become active again.
+//
+// Users are considered idle using the client's idle tracking, so
+// Config.UserIdleTimeout must be set for the mover to do anything. The client
+// must have the move permission for the moves to succeed.
+type AFKMover struct {
+	Listener
+
+	// The ID of the channel into which idle users are moved.
+	Channel uint32
+	// Should users be moved back to their previous channel when they become
+	// active again?
+	Return bool
+	// The IDs of channels whose users are never moved.
+	ExcludeChannels []uint32
+	// The groups whose members are never moved. The groups are evaluated with
+	// the root channel's ACL, which is requested when the client connects.
+	// Built-in groups (e.g. "auth") are supported. See gumble.ACL.IsMember.
+	ExcludeGroups []string
+
+	mu       sync.Mutex
+	rootACL  *gumble.ACL
+	previous map[uint32]uint32 // user session -> channel ID
+}
+
+// NewAFKMover returns a new AFKMover that moves idle users into the channel
+// with the given ID.
+func NewAFKMover(channel uint32) *AFKMover {
+	m := &AFKMover{
+		Channel: channel,
+	}
+	m.Listener = Listener{
+		Connect:    m.onConnect,
+		Disconnect: m.onDisconnect,
+		UserChange: m.onUserChange,
+		ACL:        m.onACL,
+	}
+	return m
+}
+
+// excluded returns true if user must not be moved. m.mu must be held.
+func (m *AFKMover) excluded(client *gumble.Client, user *gumble.User) bool {
+	if user == client.Self || user.Channel == nil {
+		return true
+	}
+	for _, id := range m.ExcludeChannels {
+		if user.Channel.ID == id {
+			return true
+		}
+	}
+	if len(m.ExcludeGroups) > 0 {
+		acl := m.rootACL
+		if acl == nil {
+			acl = &gumble.ACL{
+				Channel: client.Channels[0],
+			}
+		}
+		for _, group := range m.ExcludeGroups {
+			if acl.IsMember(group, user) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (m *AFKMover) onConnect(e *gumble.ConnectEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rootACL = nil
+	m.previous = make(map[uint32]uint32)
+	if root := e.Client.Channels[0]; root != nil && len(m.ExcludeGroups) > 0 {
+		root.RequestACL()
+	}
+}
+
+func (m *AFKMover) onDisconnect(e *gumble.DisconnectEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rootACL = nil
+	m.previous = nil
+}
+
+func (m *AFKMover) onACL(e *gumble.ACLEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if e.ACL.Channel != nil && e.ACL.Channel.IsRoot() {
+		m.rootACL = e.ACL
+	}
+}
+
+func (m *AFKMover) onUserChange(e *gumble.UserChangeEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.previous == nil {
+		return
+	}
+	user := e.User
+	switch {
+	case e.Type.Has(gumble.UserChangeDisconnected):
+		delete(m.previous, user.Session)
+
+	case e.Type.Has(gumble.UserChangeChannel):
+		// The user was moved by someone else, or has moved on their own.
+		if user.Channel == nil || user.Channel.ID != m.Channel {
+			delete(m.previous, user.Session)
+		}
+
+	case e.Type.Has(gumble.UserChangeIdle):
+		afk := e.Client.Channels[m.Channel]
+		if afk == nil {
+			return
+		}
+		if user.IsIdle() {
+			if user.Channel == afk || m.excluded(e.Client, user) {
+				return
+			}
+			m.previous[user.Session] = user.Channel.ID
+			user.Move(afk)
+			return
+		}
+		id, ok := m.previous[user.Session]
+		if !ok {
+			return
+		}
+		delete(m.previous, user.Session)
+		if !m.Return || user.Channel != afk {
+			return
+		}
+		if channel := e.Client.Channels[id]; channel != nil {
+			user.Move(channel)
+		}
+	}
+}
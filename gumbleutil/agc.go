@@ -0,0 +1,138 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// Default AGC settings.
+const (
+	AGCDefaultTargetRMS  = 0.1
+	AGCDefaultMaxGain    = 10
+	AGCDefaultAttack     = 20 * time.Millisecond
+	AGCDefaultRelease    = 2 * time.Second
+	AGCDefaultNoiseFloor = 0.002
+)
+
+// AGC is an automatic gain control stage for captured audio. It measures the
+// loudness (RMS) of each buffer, and smoothly adjusts its gain so that speech
+// reaches the target loudness: the gain is lowered within Attack when the
+// audio is too loud, and raised within Release when it is too quiet.
+//
+// The gain that the AGC has learned is kept between buffers and streams, so
+// one AGC should be used per microphone. It can be saved with Gain and
+// restored with SetGain, e.g. between runs of a program.
+//
+// The settings can be changed while the AGC is in use.
+type AGC struct {
+	// The loudness to reach, as a fraction of full scale (e.g. 0.1 is
+	// -20 dBFS).
+	TargetRMS float64
+	// The largest gain that is applied. Gains are never lower than 1/MaxGain.
+	MaxGain float64
+	// How quickly the gain is lowered and raised.
+	Attack, Release time.Duration
+	// Buffers quieter than NoiseFloor (as a fraction of full scale) are
+	// considered silent, and do not change the gain, so that background noise
+	// is not amplified between words.
+	NoiseFloor float64
+
+	mu   sync.Mutex
+	gain float64
+}
+
+// NewAGC returns a new AGC with the default settings and a gain of 1.
+func NewAGC() *AGC {
+	return &AGC{
+		TargetRMS:  AGCDefaultTargetRMS,
+		MaxGain:    AGCDefaultMaxGain,
+		Attack:     AGCDefaultAttack,
+		Release:    AGCDefaultRelease,
+		NoiseFloor: AGCDefaultNoiseFloor,
+		gain:       1,
+	}
+}
+
+// Gain returns the gain that is currently applied.
+func (a *AGC) Gain() float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.gain
+}
+
+// SetGain sets the gain that is applied, e.g. to restore a previously learned
+// gain.
+func (a *AGC) SetGain(gain float64) {
+	a.mu.Lock()
+	a.gain = a.clampGain(gain)
+	a.mu.Unlock()
+}
+
+// Reset sets the gain back to 1.
+func (a *AGC) Reset() {
+	a.SetGain(1)
+}
+
+// Process applies the gain to buffer, in place, and updates the gain from the
+// loudness of buffer.
+func (a *AGC) Process(buffer gumble.AudioBuffer) {
+	if len(buffer) == 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var sum float64
+	for _, sample := range buffer {
+		value := float64(sample) / math.MaxInt16
+		sum += value * value
+	}
+	rms := math.Sqrt(sum / float64(len(buffer)))
+
+	start := a.gain
+	if rms > a.NoiseFloor && rms > 0 {
+		desired := a.clampGain(a.TargetRMS / rms)
+		timeConstant := a.Release
+		if desired < a.gain {
+			timeConstant = a.Attack
+		}
+		duration := time.Duration(len(buffer)) * time.Second / gumble.AudioSampleRate
+		coefficient := 1.0
+		if timeConstant > 0 {
+			coefficient = 1 - math.Exp(-float64(duration)/float64(timeConstant))
+		}
+		a.gain += (desired - a.gain) * coefficient
+	}
+
+	// Ramp from the previous gain to the new one over the buffer, to avoid
+	// steps in the output.
+	step := (a.gain - start) / float64(len(buffer))
+	gain := start
+	for i, sample := range buffer {
+		gain += step
+		value := float64(sample) * gain
+		switch {
+		case value > math.MaxInt16:
+			value = math.MaxInt16
+		case value < math.MinInt16:
+			value = math.MinInt16
+		}
+		buffer[i] = int16(value)
+	}
+}
+
+func (a *AGC) clampGain(gain float64) float64 {
+	if a.MaxGain <= 0 {
+		return gain
+	}
+	if gain > a.MaxGain {
+		return a.MaxGain
+	}
+	if gain < 1/a.MaxGain {
+		return 1 / a.MaxGain
+	}
+	return gain
+}
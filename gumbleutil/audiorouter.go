@@ -0,0 +1,119 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// AudioRoute is an entry of an AudioRouter's routing table. A user matches
+// the route if their name is in Users, if the name of their channel is in
+// Channels, or if Match returns true.
+type AudioRoute struct {
+	Users    []string
+	Channels []string
+	Match    func(user *gumble.User) bool
+
+	// The listener that receives the audio of the matching users, e.g. an
+	// audio stream playing to a specific output device. nil drops the audio.
+	Listener gumble.AudioListener
+}
+
+func (r *AudioRoute) matches(user *gumble.User) bool {
+	for _, name := range r.Users {
+		if user.Name == name {
+			return true
+		}
+	}
+	if user.Channel != nil {
+		for _, name := range r.Channels {
+			if user.Channel.Name == name {
+				return true
+			}
+		}
+	}
+	return r.Match != nil && r.Match(user)
+}
+
+// AudioRouter is an AudioListener that directs the audio of each user to the
+// listener of the first route that the user matches, or to a default
+// listener. For example, moderators can be routed to a headset, and everyone
+// else to the speakers, by giving each a stream that plays to a different
+// output device.
+//
+// The route of a user is chosen for each packet, so a user that moves to
+// another channel, or a change of the routes, moves the user's audio to
+// another listener: the audio stream of the previous listener is then
+// closed, and a new one is started on the next.
+type AudioRouter struct {
+	mu       sync.Mutex
+	routes   []AudioRoute
+	fallback gumble.AudioListener
+}
+
+// NewAudioRouter returns a new AudioRouter that sends the audio of users that
+// match none of its routes to fallback. A nil fallback drops their audio.
+func NewAudioRouter(fallback gumble.AudioListener, routes ...AudioRoute) *AudioRouter {
+	return &AudioRouter{
+		routes:   append([]AudioRoute(nil), routes...),
+		fallback: fallback,
+	}
+}
+
+// Routes returns a copy of the routing table.
+func (r *AudioRouter) Routes() []AudioRoute {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]AudioRoute(nil), r.routes...)
+}
+
+// SetRoutes replaces the routing table. Routes are tried in order.
+func (r *AudioRouter) SetRoutes(routes ...AudioRoute) {
+	r.mu.Lock()
+	r.routes = append([]AudioRoute(nil), routes...)
+	r.mu.Unlock()
+}
+
+// Route returns the listener that receives user's audio.
+func (r *AudioRouter) Route(user *gumble.User) gumble.AudioListener {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.routes {
+		if r.routes[i].matches(user) {
+			return r.routes[i].Listener
+		}
+	}
+	return r.fallback
+}
+
+// OnAudioStream implements gumble.AudioListener.
+func (r *AudioRouter) OnAudioStream(e *gumble.AudioStreamEvent) {
+	go func() {
+		var (
+			listener gumble.AudioListener
+			ch       chan *gumble.AudioPacket
+		)
+		for packet := range e.C {
+			if route := r.Route(e.User); ch == nil || route != listener {
+				if ch != nil {
+					close(ch)
+					ch = nil
+				}
+				listener = route
+				if listener == nil {
+					continue
+				}
+				ch = make(chan *gumble.AudioPacket)
+				listener.OnAudioStream(&gumble.AudioStreamEvent{
+					Client: e.Client,
+					User:   e.User,
+					C:      ch,
+				})
+			}
+			ch <- packet
+		}
+		if ch != nil {
+			close(ch)
+		}
+	}()
+}
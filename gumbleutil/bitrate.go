@@ -10,10 +10,10 @@ var autoBitrate = &Listener{
 	Connect: func(e *gumble.ConnectEvent) {
 		if e.MaximumBitrate != nil {
 			const safety = 5
-			interval := e.Client.Config.AudioInterval
+			interval := e.Client.AudioInterval()
 			dataBytes := (*e.MaximumBitrate / (8 * (int(time.Second/interval) + safety))) - 32 - 10
 
-			e.Client.Config.AudioDataBytes = dataBytes
+			e.Client.SetAudioDataBytes(dataBytes)
 		}
 	},
 }
@@ -0,0 +1,716 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// TreeChangeTimeout is how long ApplyChannelTree waits for the server to
+// confirm each change.
+var TreeChangeTimeout = 10 * time.Second
+
+// ChannelTree describes the desired state of a channel and of its
+// sub-channels, which DiffChannelTree compares with the server's channel
+// tree. Channels are identified by their path (by channel name) from the
+// root channel; renaming a channel is not supported.
+//
+// The fields that are nil (or empty, for Links) are left as they are on the
+// server.
+type ChannelTree struct {
+	// The name of the channel. Ignored for the root channel.
+	Name        string  `yaml:"name" json:"name"`
+	Description *string `yaml:"description,omitempty" json:"description,omitempty"`
+	Position    *int32  `yaml:"position,omitempty" json:"position,omitempty"`
+	MaxUsers    *uint32 `yaml:"max_users,omitempty" json:"max_users,omitempty"`
+	// The paths of the channels linked to the channel, with the names
+	// separated by "/" (e.g. "Games/Chess").
+	Links []string    `yaml:"links,omitempty" json:"links,omitempty"`
+	ACL   *ACLSummary `yaml:"acl,omitempty" json:"acl,omitempty"`
+
+	Children []*ChannelTree `yaml:"children,omitempty" json:"children,omitempty"`
+	// Remove the sub-channels that are not in Children?
+	Prune bool `yaml:"prune,omitempty" json:"prune,omitempty"`
+}
+
+// ACLSummary describes the groups and rules of a channel's ACL. The groups
+// and rules inherited from the parent channels are not part of it.
+type ACLSummary struct {
+	// Does the channel inherit the rules of its parent? nil means true.
+	Inherit *bool            `yaml:"inherit,omitempty" json:"inherit,omitempty"`
+	Rules   []ACLRuleSummary `yaml:"rules,omitempty" json:"rules,omitempty"`
+	// The groups defined in the channel. If nil, the channel's groups are
+	// left as they are on the server.
+	Groups []ACLGroupSummary `yaml:"groups,omitempty" json:"groups,omitempty"`
+}
+
+// ACLGroupSummary describes a group of an ACLSummary.
+type ACLGroupSummary struct {
+	Name string `yaml:"name" json:"name"`
+	// Does the group inherit the members of the parent channel's group with
+	// the same name, and can it be inherited by the sub-channels? nil means
+	// true.
+	Inherit     *bool `yaml:"inherit,omitempty" json:"inherit,omitempty"`
+	Inheritable *bool `yaml:"inheritable,omitempty" json:"inheritable,omitempty"`
+	// The IDs of the registered users who are added to, and removed from,
+	// the group.
+	Add    []uint32 `yaml:"add,omitempty" json:"add,omitempty"`
+	Remove []uint32 `yaml:"remove,omitempty" json:"remove,omitempty"`
+}
+
+func aclUsers(ids []uint32) map[uint32]*gumble.ACLUser {
+	if len(ids) == 0 {
+		return nil
+	}
+	users := make(map[uint32]*gumble.ACLUser, len(ids))
+	for _, id := range ids {
+		users[id] = &gumble.ACLUser{
+			UserID: id,
+		}
+	}
+	return users
+}
+
+// groups returns the groups of s, as gumble.ACLGroups.
+func (s *ACLSummary) groups() []*gumble.ACLGroup {
+	groups := make([]*gumble.ACLGroup, 0, len(s.Groups))
+	for _, g := range s.Groups {
+		groups = append(groups, &gumble.ACLGroup{
+			Name:         g.Name,
+			InheritUsers: boolOrTrue(g.Inherit),
+			Inheritable:  boolOrTrue(g.Inheritable),
+			UsersAdd:     aclUsers(g.Add),
+			UsersRemove:  aclUsers(g.Remove),
+		})
+	}
+	return groups
+}
+
+func aclUsersEqual(a, b map[uint32]*gumble.ACLUser) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for id := range a {
+		if b[id] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// groupsMatch returns true if the groups defined in acl are those of s.
+func groupsMatch(acl *gumble.ACL, s *ACLSummary) bool {
+	want := s.groups()
+	i := 0
+	for _, group := range acl.Groups {
+		if group.Inherited {
+			continue
+		}
+		if i >= len(want) {
+			return false
+		}
+		w := want[i]
+		if group.Name != w.Name || group.InheritUsers != w.InheritUsers || group.Inheritable != w.Inheritable || !aclUsersEqual(group.UsersAdd, w.UsersAdd) || !aclUsersEqual(group.UsersRemove, w.UsersRemove) {
+			return false
+		}
+		i++
+	}
+	return i == len(want)
+}
+
+// ACLRuleSummary describes a single rule of an ACLSummary.
+type ACLRuleSummary struct {
+	// The group the rule applies to, which can use the syntax listed in
+	// gumble.ACL.IsMember (e.g. "#token"), or the ID of the registered user
+	// it applies to.
+	Group  string  `yaml:"group,omitempty" json:"group,omitempty"`
+	UserID *uint32 `yaml:"user_id,omitempty" json:"user_id,omitempty"`
+	// Does the rule apply to the channel, and to its sub-channels? nil means
+	// true.
+	Here *bool `yaml:"here,omitempty" json:"here,omitempty"`
+	Subs *bool `yaml:"subs,omitempty" json:"subs,omitempty"`
+	// The granted and denied permissions, in the format of
+	// gumble.ParsePermission (e.g. "Enter|Speak").
+	Grant string `yaml:"grant,omitempty" json:"grant,omitempty"`
+	Deny  string `yaml:"deny,omitempty" json:"deny,omitempty"`
+}
+
+func boolOrTrue(b *bool) bool {
+	return b == nil || *b
+}
+
+// rules returns the rules of s, as gumble.ACLRules.
+func (s *ACLSummary) rules() ([]*gumble.ACLRule, error) {
+	rules := make([]*gumble.ACLRule, 0, len(s.Rules))
+	for _, r := range s.Rules {
+		granted, err := gumble.ParsePermission(r.Grant)
+		if err != nil {
+			return nil, err
+		}
+		denied, err := gumble.ParsePermission(r.Deny)
+		if err != nil {
+			return nil, err
+		}
+		rule := &gumble.ACLRule{
+			AppliesCurrent:  boolOrTrue(r.Here),
+			AppliesChildren: boolOrTrue(r.Subs),
+			Granted:         granted,
+			Denied:          denied,
+		}
+		if r.UserID != nil {
+			rule.User = &gumble.ACLUser{
+				UserID: *r.UserID,
+			}
+		} else if r.Group != "" {
+			rule.Group = &gumble.ACLGroup{
+				Name: r.Group,
+			}
+		} else {
+			return nil, errors.New("gumbleutil: ACL rule has neither a group nor a user")
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+func aclRuleEqual(a, b *gumble.ACLRule) bool {
+	if a.AppliesCurrent != b.AppliesCurrent || a.AppliesChildren != b.AppliesChildren || a.Granted != b.Granted || a.Denied != b.Denied {
+		return false
+	}
+	if (a.User == nil) != (b.User == nil) || (a.Group == nil) != (b.Group == nil) {
+		return false
+	}
+	if a.User != nil && a.User.UserID != b.User.UserID {
+		return false
+	}
+	return a.Group == nil || a.Group.Name == b.Group.Name
+}
+
+// aclMatches returns true if the channel's own groups and rules in acl are
+// those of s.
+func aclMatches(acl *gumble.ACL, s *ACLSummary) (bool, error) {
+	rules, err := s.rules()
+	if err != nil {
+		return false, err
+	}
+	if acl.Inherits != boolOrTrue(s.Inherit) {
+		return false, nil
+	}
+	if s.Groups != nil && !groupsMatch(acl, s) {
+		return false, nil
+	}
+	i := 0
+	for _, rule := range acl.Rules {
+		if rule.Inherited {
+			continue
+		}
+		if i >= len(rules) || !aclRuleEqual(rule, rules[i]) {
+			return false, nil
+		}
+		i++
+	}
+	return i == len(rules), nil
+}
+
+// TreeChangeType is the type of a TreeChange.
+type TreeChangeType int
+
+// Tree change types.
+const (
+	TreeChangeCreate TreeChangeType = iota
+	TreeChangeUpdate
+	TreeChangeSetACL
+	TreeChangeRemove
+)
+
+// TreeChange is a change that makes the server's channel tree closer to a
+// ChannelTree.
+type TreeChange struct {
+	Type TreeChangeType
+	// The path of the channel (by channel name) from the root channel.
+	Path []string
+	// The channel, if it exists when the change is computed.
+	Channel *gumble.Channel
+	// The desired state of the channel. nil for TreeChangeRemove.
+	Spec *ChannelTree
+	// The fields that differ, for TreeChangeUpdate: "description",
+	// "position", "max_users" and "links".
+	Fields []string
+	// The channel's current ACL, for TreeChangeSetACL. Its groups are kept
+	// when the rules are replaced, unless the ACLSummary has groups. nil if
+	// the channel is created, or its ACL could not be read.
+	ACL *gumble.ACL
+}
+
+// String returns a description of the change, e.g. "update Games/Chess
+// (description, position)".
+func (c *TreeChange) String() string {
+	path := "/" + strings.Join(c.Path, "/")
+	switch c.Type {
+	case TreeChangeCreate:
+		return "create " + path
+	case TreeChangeUpdate:
+		return "update " + path + " (" + strings.Join(c.Fields, ", ") + ")"
+	case TreeChangeSetACL:
+		return "set ACL of " + path
+	case TreeChangeRemove:
+		return "remove " + path
+	}
+	return "unknown change to " + path
+}
+
+func descriptionMatches(channel *gumble.Channel, description string) bool {
+	if channel.DescriptionHash != nil {
+		hash := sha1.Sum([]byte(description))
+		return bytes.Equal(channel.DescriptionHash, hash[:])
+	}
+	return channel.Description == description
+}
+
+func channelPath(channel *gumble.Channel) []string {
+	var path []string
+	for ; channel != nil && !channel.IsRoot(); channel = channel.Parent {
+		path = append([]string{channel.Name}, path...)
+	}
+	return path
+}
+
+func splitChannelPath(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, "/") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// linksMatch returns true if channel is linked to exactly the given paths.
+func linksMatch(channel *gumble.Channel, links []string) bool {
+	want := make(map[string]bool, len(links))
+	for _, link := range links {
+		want[strings.Join(splitChannelPath(link), "/")] = true
+	}
+	if len(want) != len(channel.Links) {
+		return false
+	}
+	for _, linked := range channel.Links {
+		if !want[strings.Join(channelPath(linked), "/")] {
+			return false
+		}
+	}
+	return true
+}
+
+// updatedFields returns the fields of channel that differ from spec.
+func updatedFields(channel *gumble.Channel, spec *ChannelTree) []string {
+	var fields []string
+	if spec.Description != nil && !descriptionMatches(channel, *spec.Description) {
+		fields = append(fields, "description")
+	}
+	if spec.Position != nil && channel.Position != *spec.Position {
+		fields = append(fields, "position")
+	}
+	if spec.MaxUsers != nil && channel.MaxUsers != *spec.MaxUsers {
+		fields = append(fields, "max_users")
+	}
+	return fields
+}
+
+// sortedChildren returns the sub-channels of channel, by position and name.
+func sortedChildren(channel *gumble.Channel) []*gumble.Channel {
+	children := make([]*gumble.Channel, 0, len(channel.Children))
+	for _, child := range channel.Children {
+		children = append(children, child)
+	}
+	sort.Slice(children, func(i, j int) bool {
+		if children[i].Position != children[j].Position {
+			return children[i].Position < children[j].Position
+		}
+		return children[i].Name < children[j].Name
+	})
+	return children
+}
+
+// channelTreeDiff holds the changes computed by DiffChannelTree.
+type channelTreeDiff struct {
+	acls    map[*gumble.Channel]*gumble.ACL
+	changes []*TreeChange
+	links   []*TreeChange
+	removes []*TreeChange
+}
+
+func (d *channelTreeDiff) walk(channel *gumble.Channel, spec *ChannelTree, path []string) error {
+	if channel == nil {
+		d.changes = append(d.changes, &TreeChange{
+			Type: TreeChangeCreate,
+			Path: path,
+			Spec: spec,
+		})
+	} else if fields := updatedFields(channel, spec); len(fields) > 0 {
+		d.changes = append(d.changes, &TreeChange{
+			Type:    TreeChangeUpdate,
+			Path:    path,
+			Channel: channel,
+			Spec:    spec,
+			Fields:  fields,
+		})
+	}
+
+	if spec.Links != nil && (channel == nil || !linksMatch(channel, spec.Links)) {
+		d.links = append(d.links, &TreeChange{
+			Type:    TreeChangeUpdate,
+			Path:    path,
+			Channel: channel,
+			Spec:    spec,
+			Fields:  []string{"links"},
+		})
+	}
+
+	if spec.ACL != nil {
+		var acl *gumble.ACL
+		match := false
+		if channel != nil {
+			acl = d.acls[channel]
+		}
+		if acl != nil {
+			var err error
+			if match, err = aclMatches(acl, spec.ACL); err != nil {
+				return err
+			}
+		} else if _, err := spec.ACL.rules(); err != nil {
+			return err
+		}
+		if !match {
+			d.changes = append(d.changes, &TreeChange{
+				Type:    TreeChangeSetACL,
+				Path:    path,
+				Channel: channel,
+				Spec:    spec,
+				ACL:     acl,
+			})
+		}
+	}
+
+	wanted := make(map[string]bool, len(spec.Children))
+	for _, child := range spec.Children {
+		if child.Name == "" || strings.Contains(child.Name, "/") {
+			return errors.New("gumbleutil: invalid channel name " + strconv.Quote(child.Name))
+		}
+		if wanted[child.Name] {
+			return errors.New("gumbleutil: duplicate channel " + strconv.Quote(child.Name))
+		}
+		wanted[child.Name] = true
+
+		var live *gumble.Channel
+		if channel != nil {
+			live = channel.Find(child.Name)
+		}
+		childPath := append(append([]string(nil), path...), child.Name)
+		if err := d.walk(live, child, childPath); err != nil {
+			return err
+		}
+	}
+
+	if spec.Prune && channel != nil {
+		for _, child := range sortedChildren(channel) {
+			if !wanted[child.Name] {
+				d.removes = append(d.removes, &TreeChange{
+					Type:    TreeChangeRemove,
+					Path:    append(append([]string(nil), path...), child.Name),
+					Channel: child,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// DiffChannelTree returns the changes that make the server's channel tree
+// match spec, which describes the root channel. Channels are created and
+// updated first (parents before their sub-channels), then the links are
+// changed, then the pruned channels are removed.
+//
+// acls contains the ACLs of the existing channels, as fetched by FetchACLs.
+// The ACL of a channel that has an ACLSummary is replaced if it is not in
+// acls.
+func DiffChannelTree(client *gumble.Client, spec *ChannelTree, acls map[*gumble.Channel]*gumble.ACL) ([]*TreeChange, error) {
+	d := channelTreeDiff{
+		acls: acls,
+	}
+	var err error
+	client.Do(func() {
+		root := client.Channels[0]
+		if root == nil {
+			err = errors.New("gumbleutil: root channel is unknown")
+			return
+		}
+		err = d.walk(root, spec, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+	changes := append(d.changes, d.links...)
+	return append(changes, d.removes...), nil
+}
+
+// Step returns a Sequence step that applies the change. The step is confirmed
+// once the server's channel tree reflects the change, except for ACL changes,
+// which the server does not acknowledge.
+func (c *TreeChange) Step() Step {
+	step := Step{
+		Name: c.String(),
+	}
+	find := func(client *gumble.Client) (*gumble.Channel, error) {
+		var channel *gumble.Channel
+		client.Do(func() {
+			channel = client.Channels.Find(c.Path...)
+		})
+		if channel == nil {
+			return nil, errors.New("channel does not exist")
+		}
+		return channel, nil
+	}
+
+	switch c.Type {
+	case TreeChangeCreate:
+		step.Do = func(client *gumble.Client) error {
+			var parent *gumble.Channel
+			client.Do(func() {
+				parent = client.Channels.Find(c.Path[:len(c.Path)-1]...)
+			})
+			if parent == nil {
+				return errors.New("parent channel does not exist")
+			}
+			spec := gumble.ChannelSpec{
+				Name:   c.Spec.Name,
+				Parent: parent,
+			}
+			if c.Spec.Description != nil {
+				spec.Description = *c.Spec.Description
+			}
+			if c.Spec.Position != nil {
+				spec.Position = *c.Spec.Position
+			}
+			if c.Spec.MaxUsers != nil {
+				spec.MaxUsers = *c.Spec.MaxUsers
+			}
+			return client.CreateChannel(spec)
+		}
+		step.Confirm = func(client *gumble.Client) bool {
+			return client.Channels.Find(c.Path...) != nil
+		}
+
+	case TreeChangeUpdate:
+		step.Do = func(client *gumble.Client) error {
+			channel, err := find(client)
+			if err != nil {
+				return err
+			}
+			client.Do(func() {
+				err = applyFields(client, channel, c.Spec, c.Fields)
+			})
+			return err
+		}
+		step.Confirm = func(client *gumble.Client) bool {
+			channel := client.Channels.Find(c.Path...)
+			if channel == nil {
+				return false
+			}
+			for _, field := range c.Fields {
+				if field == "links" && !linksMatch(channel, c.Spec.Links) {
+					return false
+				}
+			}
+			return len(updatedFields(channel, c.Spec)) == 0
+		}
+
+	case TreeChangeSetACL:
+		step.Do = func(client *gumble.Client) error {
+			channel, err := find(client)
+			if err != nil {
+				return err
+			}
+			rules, err := c.Spec.ACL.rules()
+			if err != nil {
+				return err
+			}
+			acl := &gumble.ACL{
+				Channel:  channel,
+				Rules:    rules,
+				Inherits: boolOrTrue(c.Spec.ACL.Inherit),
+			}
+			if c.Spec.ACL.Groups != nil {
+				acl.Groups = c.Spec.ACL.groups()
+			} else if c.ACL != nil {
+				for _, group := range c.ACL.Groups {
+					if !group.Inherited {
+						acl.Groups = append(acl.Groups, group)
+					}
+				}
+			}
+			client.Send(acl)
+			return nil
+		}
+
+	case TreeChangeRemove:
+		step.Do = func(client *gumble.Client) error {
+			channel, err := find(client)
+			if err != nil {
+				return err
+			}
+			channel.Remove()
+			return nil
+		}
+		step.Confirm = func(client *gumble.Client) bool {
+			return client.Channels.Find(c.Path...) == nil
+		}
+	}
+	return step
+}
+
+// applyFields sends the requests that set the given fields of channel to
+// their value in spec.
+func applyFields(client *gumble.Client, channel *gumble.Channel, spec *ChannelTree, fields []string) error {
+	for _, field := range fields {
+		switch field {
+		case "description":
+			channel.SetDescription(*spec.Description)
+		case "position":
+			channel.SetPosition(*spec.Position)
+		case "max_users":
+			channel.SetMaxUsers(*spec.MaxUsers)
+		case "links":
+			want := make(map[*gumble.Channel]bool)
+			for _, link := range spec.Links {
+				linked := client.Channels.Find(splitChannelPath(link)...)
+				if linked == nil {
+					return errors.New("linked channel " + strconv.Quote(link) + " does not exist")
+				}
+				want[linked] = true
+			}
+			var add, remove []*gumble.Channel
+			for linked := range want {
+				if channel.Links[linked.ID] == nil {
+					add = append(add, linked)
+				}
+			}
+			for _, linked := range channel.Links {
+				if !want[linked] {
+					remove = append(remove, linked)
+				}
+			}
+			if len(add) > 0 {
+				channel.Link(add...)
+			}
+			if len(remove) > 0 {
+				channel.Unlink(remove...)
+			}
+		}
+	}
+	return nil
+}
+
+// ApplyChannelTree applies the changes returned by DiffChannelTree, in order,
+// with a Sequence. It returns a *SequenceError if a change fails, or is not
+// confirmed within TreeChangeTimeout. The changes that were applied before
+// are kept.
+//
+// ApplyChannelTree must not be called from an event listener, or from inside
+// of Client.Do.
+func ApplyChannelTree(ctx context.Context, client *gumble.Client, changes []*TreeChange) error {
+	sequence := Sequence{
+		Steps:   make([]Step, len(changes)),
+		Timeout: TreeChangeTimeout,
+	}
+	for i, change := range changes {
+		sequence.Steps[i] = change.Step()
+	}
+	return sequence.Run(ctx, client)
+}
+
+// FetchACLs requests the ACLs of the given channels (or of every channel, if
+// channels is empty), and waits until the server has sent them. The channels
+// whose ACL the client is not allowed to read are not in the returned map. If
+// ctx is done first, the ACLs received so far are returned with ctx's error.
+//
+// FetchACLs must not be called from an event listener, or from inside of
+// Client.Do.
+func FetchACLs(ctx context.Context, client *gumble.Client, channels []*gumble.Channel) (map[*gumble.Channel]*gumble.ACL, error) {
+	if len(channels) == 0 {
+		client.Do(func() {
+			for _, channel := range client.Channels {
+				channels = append(channels, channel)
+			}
+		})
+	}
+
+	var (
+		lock    sync.Mutex
+		acls    = make(map[*gumble.Channel]*gumble.ACL, len(channels))
+		pending = make(map[*gumble.Channel]bool, len(channels))
+		done    = make(chan struct{})
+	)
+	for _, channel := range channels {
+		pending[channel] = true
+	}
+	answered := func(channel *gumble.Channel, acl *gumble.ACL) {
+		lock.Lock()
+		defer lock.Unlock()
+		if !pending[channel] {
+			return
+		}
+		delete(pending, channel)
+		if acl != nil {
+			acls[channel] = acl
+		}
+		if len(pending) == 0 {
+			close(done)
+		}
+	}
+	detacher := client.Attach(&Listener{
+		ACL: func(e *gumble.ACLEvent) {
+			answered(e.ACL.Channel, e.ACL)
+		},
+		PermissionDenied: func(e *gumble.PermissionDeniedEvent) {
+			if e.Channel != nil && e.Type == gumble.PermissionDeniedPermission {
+				answered(e.Channel, nil)
+			}
+		},
+		ChannelChange: func(e *gumble.ChannelChangeEvent) {
+			if e.Type.Has(gumble.ChannelChangeRemoved) {
+				answered(e.Channel, nil)
+			}
+		},
+	})
+	defer detacher.Detach()
+
+	if len(channels) == 0 {
+		return acls, nil
+	}
+	for _, channel := range channels {
+		channel.RequestACL()
+	}
+
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	result := make(map[*gumble.Channel]*gumble.ACL, len(acls))
+	for channel, acl := range acls {
+		result[channel] = acl
+	}
+	return result, err
+}
@@ -0,0 +1,233 @@
+package gumbleutil
+
+import (
+	"crypto/sha1"
+	"strings"
+	"testing"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumble/MumbleProto"
+	"github.com/golang/protobuf/proto"
+)
+
+// newTestChannelTree returns a client that knows the following channels:
+//
+//	Root
+//	  Lobby  (position 0, description "Welcome", linked to Games)
+//	  Games  (position 1)
+//	    Chess
+//	    Go
+//	  AFK    (position 2, with only the hash of its description, "Away")
+func newTestChannelTree(t *testing.T) *gumble.Client {
+	client, server := gumble.NewReplayClient(gumble.NewConfig())
+	t.Cleanup(func() { server.Close() })
+
+	awayHash := sha1.Sum([]byte("Away"))
+	channels := []*MumbleProto.ChannelState{
+		{ChannelId: proto.Uint32(0), Name: proto.String("Root")},
+		{ChannelId: proto.Uint32(1), Parent: proto.Uint32(0), Name: proto.String("Lobby"), Position: proto.Int32(0), Description: proto.String("Welcome")},
+		{ChannelId: proto.Uint32(2), Parent: proto.Uint32(0), Name: proto.String("Games"), Position: proto.Int32(1)},
+		{ChannelId: proto.Uint32(3), Parent: proto.Uint32(2), Name: proto.String("Chess")},
+		{ChannelId: proto.Uint32(4), Parent: proto.Uint32(2), Name: proto.String("Go")},
+		{ChannelId: proto.Uint32(5), Parent: proto.Uint32(0), Name: proto.String("AFK"), Position: proto.Int32(2), DescriptionHash: awayHash[:]},
+		{ChannelId: proto.Uint32(1), Links: []uint32{2}},
+	}
+	for _, channel := range channels {
+		if err := server.WriteProto(channel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The previous packet has been handled once the next one is read.
+	server.WriteProto(&MumbleProto.Ping{})
+	return client
+}
+
+func stringPtr(s string) *string { return &s }
+func int32Ptr(i int32) *int32    { return &i }
+func boolPtr(b bool) *bool       { return &b }
+
+func diffTestChannelTree(t *testing.T, client *gumble.Client, spec *ChannelTree, acls map[*gumble.Channel]*gumble.ACL) []string {
+	changes, err := DiffChannelTree(client, spec, acls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plan := make([]string, len(changes))
+	for i, change := range changes {
+		plan[i] = change.String()
+	}
+	return plan
+}
+
+func checkTestPlan(t *testing.T, plan []string, want ...string) {
+	if strings.Join(plan, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got plan:\n  %s\nexpected:\n  %s\n", strings.Join(plan, "\n  "), strings.Join(want, "\n  "))
+	}
+}
+
+func TestDiffChannelTreeUnchanged(t *testing.T) {
+	client := newTestChannelTree(t)
+	spec := &ChannelTree{
+		Children: []*ChannelTree{
+			{Name: "Lobby", Description: stringPtr("Welcome"), Position: int32Ptr(0), Links: []string{"Games"}},
+			{Name: "Games", Position: int32Ptr(1), Prune: true, Children: []*ChannelTree{
+				{Name: "Chess"},
+				{Name: "Go"},
+			}},
+			{Name: "AFK", Description: stringPtr("Away")},
+		},
+	}
+	checkTestPlan(t, diffTestChannelTree(t, client, spec, nil))
+}
+
+func TestDiffChannelTreePlan(t *testing.T) {
+	client := newTestChannelTree(t)
+	spec := &ChannelTree{
+		Children: []*ChannelTree{
+			{Name: "Lobby", Description: stringPtr("Hello"), Links: []string{"/Games/Chess"}, ACL: &ACLSummary{
+				Rules: []ACLRuleSummary{{Group: "all", Deny: "Speak"}},
+			}},
+			{Name: "Games", Position: int32Ptr(5), Prune: true, Children: []*ChannelTree{
+				{Name: "Chess"},
+				{Name: "Poker", Children: []*ChannelTree{
+					{Name: "Hold'em"},
+				}},
+			}},
+			{Name: "AFK", Description: stringPtr("Back")},
+		},
+	}
+	// Creations and updates come first, parents before their
+	// sub-channels, then the links, then the removals.
+	checkTestPlan(t, diffTestChannelTree(t, client, spec, nil),
+		"update /Lobby (description)",
+		"set ACL of /Lobby",
+		"update /Games (position)",
+		"create /Games/Poker",
+		"create /Games/Poker/Hold'em",
+		"update /AFK (description)",
+		"update /Lobby (links)",
+		"remove /Games/Go",
+	)
+}
+
+func TestDiffChannelTreeCreatedChannelLinks(t *testing.T) {
+	client := newTestChannelTree(t)
+	spec := &ChannelTree{
+		Children: []*ChannelTree{
+			{Name: "Radio", Links: []string{"Lobby"}, ACL: &ACLSummary{}},
+		},
+	}
+	// The links of a created channel are set once every channel exists.
+	checkTestPlan(t, diffTestChannelTree(t, client, spec, nil),
+		"create /Radio",
+		"set ACL of /Radio",
+		"update /Radio (links)",
+	)
+}
+
+func TestDiffChannelTreeACL(t *testing.T) {
+	client := newTestChannelTree(t)
+	var lobby *gumble.Channel
+	client.Do(func() {
+		lobby = client.Channels.Find("Lobby")
+	})
+	acls := map[*gumble.Channel]*gumble.ACL{
+		lobby: {
+			Channel:  lobby,
+			Inherits: true,
+			Groups: []*gumble.ACLGroup{
+				{Name: "admin", Inherited: true, InheritUsers: true, Inheritable: true},
+				{Name: "mods", InheritUsers: true, Inheritable: true, UsersAdd: map[uint32]*gumble.ACLUser{7: {UserID: 7}}},
+			},
+			Rules: []*gumble.ACLRule{
+				{Inherited: true, AppliesCurrent: true, AppliesChildren: true, Group: &gumble.ACLGroup{Name: "admin"}, Granted: gumble.PermissionWrite},
+				{AppliesCurrent: true, AppliesChildren: true, Group: &gumble.ACLGroup{Name: "all"}, Denied: gumble.PermissionSpeak},
+			},
+		},
+	}
+	lobbyACL := func(acl *ACLSummary) *ChannelTree {
+		return &ChannelTree{
+			Children: []*ChannelTree{{Name: "Lobby", ACL: acl}},
+		}
+	}
+
+	tests := []struct {
+		name string
+		acl  *ACLSummary
+		want []string
+	}{
+		{
+			name: "matching rules",
+			acl:  &ACLSummary{Rules: []ACLRuleSummary{{Group: "all", Deny: "Speak"}}},
+		},
+		{
+			name: "matching rules and groups",
+			acl: &ACLSummary{
+				Rules:  []ACLRuleSummary{{Group: "all", Deny: "Speak"}},
+				Groups: []ACLGroupSummary{{Name: "mods", Add: []uint32{7}}},
+			},
+		},
+		{
+			name: "different rule",
+			acl:  &ACLSummary{Rules: []ACLRuleSummary{{Group: "all", Deny: "Enter"}}},
+			want: []string{"set ACL of /Lobby"},
+		},
+		{
+			name: "different rule scope",
+			acl:  &ACLSummary{Rules: []ACLRuleSummary{{Group: "all", Deny: "Speak", Subs: boolPtr(false)}}},
+			want: []string{"set ACL of /Lobby"},
+		},
+		{
+			name: "different groups",
+			acl: &ACLSummary{
+				Rules:  []ACLRuleSummary{{Group: "all", Deny: "Speak"}},
+				Groups: []ACLGroupSummary{{Name: "mods", Add: []uint32{8}}},
+			},
+			want: []string{"set ACL of /Lobby"},
+		},
+		{
+			name: "not inherited",
+			acl: &ACLSummary{
+				Inherit: boolPtr(false),
+				Rules:   []ACLRuleSummary{{Group: "all", Deny: "Speak"}},
+			},
+			want: []string{"set ACL of /Lobby"},
+		},
+	}
+	for _, test := range tests {
+		plan := diffTestChannelTree(t, client, lobbyACL(test.acl), acls)
+		if strings.Join(plan, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%s: got plan %q, expected %q\n", test.name, plan, test.want)
+		}
+	}
+
+	changes, err := DiffChannelTree(client, lobbyACL(tests[2].acl), acls)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changes[0].ACL != acls[lobby] {
+		t.Error("the change does not hold the channel's current ACL")
+	}
+}
+
+func TestDiffChannelTreeErrors(t *testing.T) {
+	client := newTestChannelTree(t)
+	tests := map[string]*ChannelTree{
+		"empty name":        {Children: []*ChannelTree{{Name: ""}}},
+		"name with a slash": {Children: []*ChannelTree{{Name: "Games/Chess"}}},
+		"duplicate name":    {Children: []*ChannelTree{{Name: "Lobby"}, {Name: "Lobby"}}},
+		"duplicate nested name": {Children: []*ChannelTree{
+			{Name: "Games", Children: []*ChannelTree{{Name: "Chess"}, {Name: "Chess"}}},
+		}},
+		"invalid permission": {Children: []*ChannelTree{
+			{Name: "Lobby", ACL: &ACLSummary{Rules: []ACLRuleSummary{{Group: "all", Grant: "Fly"}}}},
+		}},
+		"rule without group or user": {Children: []*ChannelTree{
+			{Name: "Radio", ACL: &ACLSummary{Rules: []ACLRuleSummary{{Grant: "Speak"}}}},
+		}},
+	}
+	for name, spec := range tests {
+		if _, err := DiffChannelTree(client, spec, nil); err == nil {
+			t.Errorf("%s: no error\n", name)
+		}
+	}
+}
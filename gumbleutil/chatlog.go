@@ -0,0 +1,197 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// ChatLogEntry is a text message, as written by ChatLogger.
+type ChatLogEntry struct {
+	// When the message was received or sent.
+	Time time.Time `json:"time"`
+	// The user who sent the message. nil if the message was sent by the
+	// server.
+	Sender *ChatLogUser `json:"sender,omitempty"`
+	// The recipients of the message.
+	Users    []ChatLogUser    `json:"users,omitempty"`
+	Channels []ChatLogChannel `json:"channels,omitempty"`
+	Trees    []ChatLogChannel `json:"trees,omitempty"`
+	// Was the message only sent to users (i.e. not to a channel)?
+	Private bool `json:"private,omitempty"`
+	// The message, without HTML tags or entities.
+	Message string `json:"message"`
+	// The message as it was sent. Only set if ChatLogger.HTML is true.
+	HTML string `json:"html,omitempty"`
+}
+
+// ChatLogUser identifies a user in a ChatLogEntry.
+type ChatLogUser struct {
+	Session uint32 `json:"session"`
+	// The user's ID, if they are registered.
+	UserID *uint32 `json:"user_id,omitempty"`
+	Name   string  `json:"name"`
+}
+
+// ChatLogChannel identifies a channel in a ChatLogEntry.
+type ChatLogChannel struct {
+	ID   uint32 `json:"id"`
+	Name string `json:"name"`
+}
+
+// ChatLogger is a gumble.EventListener that writes the text messages received
+// by the client as JSON lines (one ChatLogEntry per line).
+//
+// Private messages are not logged unless Private is set. Messages sent by the
+// client itself are not received by it; they can be logged with Log.
+type ChatLogger struct {
+	Listener
+
+	// Should private messages (sent to users, rather than channels) be
+	// logged?
+	Private bool
+	// Should the message be logged as it was sent (with HTML), in addition to
+	// plain text?
+	HTML bool
+	// If not nil, only messages for which Filter returns true are logged.
+	Filter func(message *gumble.TextMessage) bool
+	// Called when writing an entry fails. Can be nil.
+	Error func(err error)
+
+	mu sync.Mutex
+	w  io.Writer
+	// Used when logging to files.
+	pattern  string
+	filename string
+	file     *os.File
+}
+
+// NewChatLogger returns a new ChatLogger that writes to w.
+func NewChatLogger(w io.Writer) *ChatLogger {
+	l := &ChatLogger{
+		w: w,
+	}
+	l.Listener.TextMessage = l.onTextMessage
+	return l
+}
+
+// NewChatFileLogger returns a new ChatLogger that appends to files. The name
+// of the file is pattern formatted with the entry's time (see time.Format), so
+// that the log is rotated as the name changes, e.g. daily with
+// "chat-2006-01-02.jsonl".
+func NewChatFileLogger(pattern string) *ChatLogger {
+	l := NewChatLogger(nil)
+	l.pattern = pattern
+	return l
+}
+
+// Log writes message to the log, subject to the logger's filters. It can be
+// used to log the messages sent by the client.
+func (l *ChatLogger) Log(message *gumble.TextMessage) {
+	private := len(message.Users) > 0 && len(message.Channels) == 0 && len(message.Trees) == 0
+	if private && !l.Private {
+		return
+	}
+	if l.Filter != nil && !l.Filter(message) {
+		return
+	}
+
+	entry := ChatLogEntry{
+		Time:    time.Now(),
+		Private: private,
+		Message: PlainText(message),
+	}
+	if l.HTML {
+		entry.HTML = message.Message
+	}
+	if message.Sender != nil {
+		sender := chatLogUser(message.Sender)
+		entry.Sender = &sender
+	}
+	for _, user := range message.Users {
+		entry.Users = append(entry.Users, chatLogUser(user))
+	}
+	for _, channel := range message.Channels {
+		entry.Channels = append(entry.Channels, ChatLogChannel{ID: channel.ID, Name: channel.Name})
+	}
+	for _, channel := range message.Trees {
+		entry.Trees = append(entry.Trees, ChatLogChannel{ID: channel.ID, Name: channel.Name})
+	}
+
+	l.write(&entry)
+}
+
+// Close closes the logger's current file, if it logs to files. A new file is
+// opened if another entry is logged.
+func (l *ChatLogger) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	l.filename = ""
+	return err
+}
+
+func (l *ChatLogger) onTextMessage(e *gumble.TextMessageEvent) {
+	l.Log(&e.TextMessage)
+}
+
+func (l *ChatLogger) write(entry *ChatLogEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		l.fail(err)
+		return
+	}
+	data = append(data, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	w := l.w
+	if l.pattern != "" {
+		filename := entry.Time.Format(l.pattern)
+		if l.file == nil || filename != l.filename {
+			if l.file != nil {
+				l.file.Close()
+				l.file = nil
+			}
+			file, err := os.OpenFile(filename, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+			if err != nil {
+				l.fail(err)
+				return
+			}
+			l.file = file
+			l.filename = filename
+		}
+		w = l.file
+	}
+	if _, err := w.Write(data); err != nil {
+		l.fail(err)
+	}
+}
+
+func (l *ChatLogger) fail(err error) {
+	if l.Error != nil {
+		l.Error(err)
+	}
+}
+
+func chatLogUser(user *gumble.User) ChatLogUser {
+	u := ChatLogUser{
+		Session: user.Session,
+		Name:    user.Name,
+	}
+	if user.IsRegistered() {
+		id := user.UserID
+		u.UserID = &id
+	}
+	return u
+}
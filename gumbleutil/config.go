@@ -0,0 +1,291 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"io"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"gopkg.in/yaml.v3"
+)
+
+// MainConfig is the configuration of a program that uses Main. It is loaded by
+// LoadMainConfig from the following sources, each overriding the previous
+// ones:
+//  the default values of Main's flags
+//  a YAML file, given with --config or $GUMBLE_CONFIG
+//  the environment variables listed below
+//  the flags given on the command line
+//
+// For example, the following file:
+//  server: mumble.example.com:64738
+//  username: my-bot
+//  certificate: /etc/my-bot/cert.pem
+//  channel: Games/Chess
+//  command_prefix: "!"
+//  audio:
+//    interval: 20ms
+type MainConfig struct {
+	// $GUMBLE_SERVER, --server
+	Server string `yaml:"server"`
+	// $GUMBLE_USERNAME, --username
+	Username string `yaml:"username"`
+	// Log in as SuperUser, with Password as the SuperUser password, instead of
+	// as Username (see gumble.SuperUserName). $GUMBLE_SUPERUSER, --superuser
+	SuperUser bool `yaml:"superuser"`
+	// $GUMBLE_PASSWORD, --password
+	Password string `yaml:"password"`
+	// If Password is empty, the secret providers from which the password is
+	// read, e.g. "keyring,prompt" (see SecretProviderByName). The password's
+	// name is "username@server" (with SuperUser as the username if SuperUser
+	// is set). $GUMBLE_PASSWORD_SOURCE, --password-source
+	PasswordSource string `yaml:"password_source"`
+	// Skip the verification of the server's certificate? $GUMBLE_INSECURE,
+	// --insecure
+	Insecure bool `yaml:"insecure"`
+	// The user certificate and key files (PEM). $GUMBLE_CERTIFICATE,
+	// $GUMBLE_KEY, --certificate, --key
+	Certificate string `yaml:"certificate"`
+	Key         string `yaml:"key"`
+	// Access tokens sent to the server. $GUMBLE_TOKENS (comma-separated)
+	Tokens []string `yaml:"tokens"`
+	// The channel joined once connected (see gumble.Config.JoinChannel).
+	// $GUMBLE_CHANNEL, --channel
+	Channel string `yaml:"channel"`
+	// The prefix of the program's text message commands. It is not used by
+	// gumbleutil; programs read it from the config. $GUMBLE_COMMAND_PREFIX,
+	// --command-prefix
+	CommandPrefix string `yaml:"command_prefix"`
+
+	Audio MainAudioConfig `yaml:"audio"`
+}
+
+// MainAudioConfig contains the audio settings of a MainConfig.
+type MainAudioConfig struct {
+	// See gumble.Config.AudioInterval. $GUMBLE_AUDIO_INTERVAL,
+	// --audio-interval
+	Interval time.Duration `yaml:"interval"`
+	// See gumble.Config.AudioDataBytes. Zero means that it is set from the
+	// server's maximum bitrate (see AutoBitrate). $GUMBLE_AUDIO_DATA_BYTES
+	DataBytes int `yaml:"data_bytes"`
+}
+
+// mainFlags are the flags defined by LoadMainConfig.
+var mainFlags struct {
+	once                       sync.Once
+	config                     *string
+	server, username, password *string
+	passwordSource             *string
+	superUser, insecure        *bool
+	certificate, key           *string
+	channel, commandPrefix     *string
+	audioInterval              *time.Duration
+}
+
+func defineMainFlags() {
+	f := &mainFlags
+	f.config = flag.String("config", "", "configuration file (YAML)")
+	f.server = flag.String("server", "localhost:64738", "Mumble server address")
+	f.username = flag.String("username", "gumble-bot", "client username")
+	f.password = flag.String("password", "", "client password")
+	f.passwordSource = flag.String("password-source", "", "secret providers from which the password is read (e.g. keyring,prompt)")
+	f.superUser = flag.Bool("superuser", false, "log in as SuperUser, with --password as the SuperUser password")
+	f.insecure = flag.Bool("insecure", false, "skip server certificate verification")
+	f.certificate = flag.String("certificate", "", "user certificate file (PEM)")
+	f.key = flag.String("key", "", "user certificate key file (PEM)")
+	f.channel = flag.String("channel", "", "channel to join once connected (ID or path, e.g. Games/Chess)")
+	f.commandPrefix = flag.String("command-prefix", "!", "prefix of text message commands")
+	f.audioInterval = flag.Duration("audio-interval", gumble.AudioDefaultInterval, "interval at which audio packets are sent")
+}
+
+// LoadMainConfig defines Main's flags (once), parses the command line if it
+// has not been parsed yet, and returns the resulting configuration. See
+// MainConfig.
+func LoadMainConfig() (*MainConfig, error) {
+	f := &mainFlags
+	f.once.Do(defineMainFlags)
+	if !flag.Parsed() {
+		flag.Parse()
+	}
+
+	config := &MainConfig{
+		Server:         *f.server,
+		Username:       *f.username,
+		Password:       *f.password,
+		PasswordSource: *f.passwordSource,
+		SuperUser:      *f.superUser,
+		Insecure:       *f.insecure,
+		Certificate:    *f.certificate,
+		Key:            *f.key,
+		Channel:        *f.channel,
+		CommandPrefix:  *f.commandPrefix,
+		Audio: MainAudioConfig{
+			Interval: *f.audioInterval,
+		},
+	}
+
+	filename := *f.config
+	if filename == "" {
+		filename = os.Getenv("GUMBLE_CONFIG")
+	}
+	if filename != "" {
+		data, err := ioutil.ReadFile(filename)
+		if err != nil {
+			return nil, err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(config); err != nil && err != io.EOF {
+			return nil, err
+		}
+	}
+
+	if err := config.loadEnvironment(); err != nil {
+		return nil, err
+	}
+
+	flag.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "server":
+			config.Server = *f.server
+		case "username":
+			config.Username = *f.username
+		case "password":
+			config.Password = *f.password
+		case "password-source":
+			config.PasswordSource = *f.passwordSource
+		case "superuser":
+			config.SuperUser = *f.superUser
+		case "insecure":
+			config.Insecure = *f.insecure
+		case "certificate":
+			config.Certificate = *f.certificate
+		case "key":
+			config.Key = *f.key
+		case "channel":
+			config.Channel = *f.channel
+		case "command-prefix":
+			config.CommandPrefix = *f.commandPrefix
+		case "audio-interval":
+			config.Audio.Interval = *f.audioInterval
+		}
+	})
+	return config, nil
+}
+
+func (c *MainConfig) loadEnvironment() error {
+	values := map[string]*string{
+		"GUMBLE_SERVER":          &c.Server,
+		"GUMBLE_USERNAME":        &c.Username,
+		"GUMBLE_PASSWORD":        &c.Password,
+		"GUMBLE_PASSWORD_SOURCE": &c.PasswordSource,
+		"GUMBLE_CERTIFICATE":     &c.Certificate,
+		"GUMBLE_KEY":             &c.Key,
+		"GUMBLE_CHANNEL":         &c.Channel,
+		"GUMBLE_COMMAND_PREFIX":  &c.CommandPrefix,
+	}
+	for name, value := range values {
+		if env, ok := os.LookupEnv(name); ok {
+			*value = env
+		}
+	}
+	if env, ok := os.LookupEnv("GUMBLE_TOKENS"); ok {
+		c.Tokens = splitTokens(env)
+	}
+	if env, ok := os.LookupEnv("GUMBLE_SUPERUSER"); ok {
+		superUser, err := strconv.ParseBool(env)
+		if err != nil {
+			return envError("GUMBLE_SUPERUSER", err)
+		}
+		c.SuperUser = superUser
+	}
+	if env, ok := os.LookupEnv("GUMBLE_INSECURE"); ok {
+		insecure, err := strconv.ParseBool(env)
+		if err != nil {
+			return envError("GUMBLE_INSECURE", err)
+		}
+		c.Insecure = insecure
+	}
+	if env, ok := os.LookupEnv("GUMBLE_AUDIO_INTERVAL"); ok {
+		interval, err := time.ParseDuration(env)
+		if err != nil {
+			return envError("GUMBLE_AUDIO_INTERVAL", err)
+		}
+		c.Audio.Interval = interval
+	}
+	if env, ok := os.LookupEnv("GUMBLE_AUDIO_DATA_BYTES"); ok {
+		dataBytes, err := strconv.Atoi(env)
+		if err != nil {
+			return envError("GUMBLE_AUDIO_DATA_BYTES", err)
+		}
+		c.Audio.DataBytes = dataBytes
+	}
+	return nil
+}
+
+// ReadPassword sets c.Password from c.PasswordSource, if it is not already
+// set. The password is left empty if none of the providers have it.
+func (c *MainConfig) ReadPassword() error {
+	if c.Password != "" || c.PasswordSource == "" {
+		return nil
+	}
+	provider, err := SecretProviderByName(c.PasswordSource)
+	if err != nil {
+		return err
+	}
+	password, err := provider.Secret(c.username() + "@" + c.Server)
+	if err == ErrSecretNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	c.Password = password
+	return nil
+}
+
+// GumbleConfig returns a new gumble.Config with the connection, credential
+// and audio settings of c.
+func (c *MainConfig) GumbleConfig() *gumble.Config {
+	config := gumble.NewConfig()
+	config.Username = c.username()
+	config.Password = c.Password
+	config.Tokens = gumble.AccessTokens(c.Tokens)
+	config.JoinChannel = c.Channel
+	if c.Audio.Interval > 0 {
+		config.AudioInterval = c.Audio.Interval
+	}
+	if c.Audio.DataBytes > 0 {
+		config.AudioDataBytes = c.Audio.DataBytes
+	}
+	return config
+}
+
+// username returns the name that the client logs in with.
+func (c *MainConfig) username() string {
+	if c.SuperUser {
+		return gumble.SuperUserName
+	}
+	return c.Username
+}
+
+func splitTokens(s string) []string {
+	var tokens []string
+	for _, token := range strings.Split(s, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens
+}
+
+func envError(name string, err error) error {
+	return errors.New("gumbleutil: invalid $" + name + ": " + err.Error())
+}
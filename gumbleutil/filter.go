@@ -0,0 +1,307 @@
+package gumbleutil
+
+import (
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// FilterByChannel returns an event listener that forwards to l only the
+// events that relate to one of the given channels. Events that do not relate
+// to any channel (e.g. ConnectEvent, or a text message that was sent directly
+// to users) are always forwarded.
+//
+// A text message relates to a channel if it was sent to the channel, or to a
+// tree that contains the channel. A user change relates to the channel that
+// the user is in.
+func FilterByChannel(l gumble.EventListener, channels ...*gumble.Channel) gumble.EventListener {
+	match := func(channel *gumble.Channel) bool {
+		for _, c := range channels {
+			if c == channel {
+				return true
+			}
+		}
+		return false
+	}
+	return &filterListener{
+		EventListener: l,
+		keep: func(e interface{}) bool {
+			switch e := e.(type) {
+			case *gumble.TextMessageEvent:
+				if len(e.Channels) == 0 && len(e.Trees) == 0 {
+					return true
+				}
+				for _, channel := range e.Channels {
+					if match(channel) {
+						return true
+					}
+				}
+				for _, tree := range e.Trees {
+					for _, c := range channels {
+						for ; c != nil; c = c.Parent {
+							if c == tree {
+								return true
+							}
+						}
+					}
+				}
+				return false
+			case *gumble.UserChangeEvent:
+				return e.User == nil || e.User.Channel == nil || match(e.User.Channel)
+			case *gumble.ChannelChangeEvent:
+				return match(e.Channel)
+			case *gumble.PermissionDeniedEvent:
+				return e.Channel == nil || match(e.Channel)
+			case *gumble.ACLEvent:
+				return e.ACL == nil || e.ACL.Channel == nil || match(e.ACL.Channel)
+			case *gumble.ChannelOccupancyEvent:
+				return match(e.Channel)
+			case *gumble.ChannelActivityEvent:
+				return match(e.Channel)
+			case *gumble.ChannelLostEvent:
+				return match(e.Channel)
+			case *gumble.ResourceLimitEvent:
+				if e.User != nil {
+					return e.User.Channel == nil || match(e.User.Channel)
+				}
+				return match(e.Channel)
+			}
+			return true
+		},
+	}
+}
+
+// FilterBySender returns an event listener that forwards to l only the text
+// messages that were sent by a user with one of the given names. Other events
+// are always forwarded.
+func FilterBySender(l gumble.EventListener, names ...string) gumble.EventListener {
+	return &filterListener{
+		EventListener: l,
+		keep: func(e interface{}) bool {
+			textMessage, ok := e.(*gumble.TextMessageEvent)
+			if !ok {
+				return true
+			}
+			if textMessage.Sender == nil {
+				return false
+			}
+			for _, name := range names {
+				if textMessage.Sender.Name == name {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// FilterSelf returns an event listener that forwards to l every event except
+// the text messages that were sent by the client itself.
+func FilterSelf(l gumble.EventListener) gumble.EventListener {
+	return &filterListener{
+		EventListener: l,
+		keep: func(e interface{}) bool {
+			textMessage, ok := e.(*gumble.TextMessageEvent)
+			if !ok || textMessage.Sender == nil {
+				return true
+			}
+			return textMessage.Sender != textMessage.Client.Self
+		},
+	}
+}
+
+// Debounce returns an event listener that forwards to l the first event of a
+// burst, and drops the events of the same type that follow it less than d
+// apart. Each event type (e.g. *gumble.UserChangeEvent) is debounced
+// separately.
+//
+// ConnectEvents and DisconnectEvents are never dropped.
+func Debounce(l gumble.EventListener, d time.Duration) gumble.EventListener {
+	var (
+		lock sync.Mutex
+		last = make(map[reflect.Type]time.Time)
+	)
+	return &filterListener{
+		EventListener: l,
+		keep: func(e interface{}) bool {
+			switch e.(type) {
+			case *gumble.ConnectEvent, *gumble.DisconnectEvent:
+				return true
+			}
+			now := time.Now()
+			t := reflect.TypeOf(e)
+			lock.Lock()
+			defer lock.Unlock()
+			previous, ok := last[t]
+			last[t] = now
+			return !ok || now.Sub(previous) >= d
+		},
+	}
+}
+
+// Once returns an event listener that forwards to l at most one event of each
+// type. For example, the following listener is only called for the first text
+// message the client receives:
+//  gumbleutil.Once(gumbleutil.OnTextMessage(func(e *gumble.TextMessageEvent) {
+//    // ...
+//  }))
+func Once(l gumble.EventListener) gumble.EventListener {
+	var (
+		lock sync.Mutex
+		seen = make(map[reflect.Type]bool)
+	)
+	return &filterListener{
+		EventListener: l,
+		keep: func(e interface{}) bool {
+			t := reflect.TypeOf(e)
+			lock.Lock()
+			defer lock.Unlock()
+			if seen[t] {
+				return false
+			}
+			seen[t] = true
+			return true
+		},
+	}
+}
+
+// filterListener forwards the events for which keep returns true to the
+// embedded listener.
+type filterListener struct {
+	gumble.EventListener
+	keep func(e interface{}) bool
+}
+
+var _ gumble.EventListener = (*filterListener)(nil)
+
+func (f *filterListener) OnConnect(e *gumble.ConnectEvent) {
+	if f.keep(e) {
+		f.EventListener.OnConnect(e)
+	}
+}
+
+func (f *filterListener) OnDisconnect(e *gumble.DisconnectEvent) {
+	if f.keep(e) {
+		f.EventListener.OnDisconnect(e)
+	}
+}
+
+func (f *filterListener) OnTextMessage(e *gumble.TextMessageEvent) {
+	if f.keep(e) {
+		f.EventListener.OnTextMessage(e)
+	}
+}
+
+func (f *filterListener) OnUserChange(e *gumble.UserChangeEvent) {
+	if f.keep(e) {
+		f.EventListener.OnUserChange(e)
+	}
+}
+
+func (f *filterListener) OnChannelChange(e *gumble.ChannelChangeEvent) {
+	if f.keep(e) {
+		f.EventListener.OnChannelChange(e)
+	}
+}
+
+func (f *filterListener) OnPermissionDenied(e *gumble.PermissionDeniedEvent) {
+	if f.keep(e) {
+		f.EventListener.OnPermissionDenied(e)
+	}
+}
+
+func (f *filterListener) OnUserList(e *gumble.UserListEvent) {
+	if f.keep(e) {
+		f.EventListener.OnUserList(e)
+	}
+}
+
+func (f *filterListener) OnACL(e *gumble.ACLEvent) {
+	if f.keep(e) {
+		f.EventListener.OnACL(e)
+	}
+}
+
+func (f *filterListener) OnBanList(e *gumble.BanListEvent) {
+	if f.keep(e) {
+		f.EventListener.OnBanList(e)
+	}
+}
+
+func (f *filterListener) OnContextActionChange(e *gumble.ContextActionChangeEvent) {
+	if f.keep(e) {
+		f.EventListener.OnContextActionChange(e)
+	}
+}
+
+func (f *filterListener) OnServerConfig(e *gumble.ServerConfigEvent) {
+	if f.keep(e) {
+		f.EventListener.OnServerConfig(e)
+	}
+}
+
+func (f *filterListener) OnAudioConfig(e *gumble.AudioConfigEvent) {
+	if f.keep(e) {
+		if l, ok := f.EventListener.(gumble.AudioConfigListener); ok {
+			l.OnAudioConfig(e)
+		}
+	}
+}
+
+func (f *filterListener) OnSyncProgress(e *gumble.SyncProgressEvent) {
+	if f.keep(e) {
+		if l, ok := f.EventListener.(gumble.SyncProgressListener); ok {
+			l.OnSyncProgress(e)
+		}
+	}
+}
+
+func (f *filterListener) OnStateChange(e *gumble.StateChangeEvent) {
+	if f.keep(e) {
+		if l, ok := f.EventListener.(gumble.StateChangeListener); ok {
+			l.OnStateChange(e)
+		}
+	}
+}
+
+func (f *filterListener) OnUserReturned(e *gumble.UserReturnedEvent) {
+	if f.keep(e) {
+		if l, ok := f.EventListener.(gumble.UserReturnedListener); ok {
+			l.OnUserReturned(e)
+		}
+	}
+}
+
+func (f *filterListener) OnChannelOccupancyChange(e *gumble.ChannelOccupancyEvent) {
+	if f.keep(e) {
+		if l, ok := f.EventListener.(gumble.ChannelOccupancyListener); ok {
+			l.OnChannelOccupancyChange(e)
+		}
+	}
+}
+
+func (f *filterListener) OnChannelActivityChange(e *gumble.ChannelActivityEvent) {
+	if f.keep(e) {
+		if l, ok := f.EventListener.(gumble.ChannelActivityListener); ok {
+			l.OnChannelActivityChange(e)
+		}
+	}
+}
+
+func (f *filterListener) OnChannelLost(e *gumble.ChannelLostEvent) {
+	if f.keep(e) {
+		if l, ok := f.EventListener.(gumble.ChannelLostListener); ok {
+			l.OnChannelLost(e)
+		}
+	}
+}
+
+func (f *filterListener) OnResourceLimit(e *gumble.ResourceLimitEvent) {
+	if f.keep(e) {
+		if l, ok := f.EventListener.(gumble.ResourceLimitListener); ok {
+			l.OnResourceLimit(e)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"bytes"
+	htmltemplate "html/template"
+	"sync"
+	"text/template"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// GreeterData is the data passed to the Greeter templates.
+type GreeterData struct {
+	// The name of the user who connected.
+	User string
+	// The name of the channel the user connected into.
+	Channel string
+	// The name of the server (see Greeter.Server).
+	Server string
+}
+
+// Greeter is a gumble.EventListener that sends a private message to users when
+// they connect to the server, and optionally announces them in a log channel.
+//
+// Messages are Go templates (see text/template) that are executed with a
+// GreeterData, e.g.:
+//  Welcome to {{.Server}}, {{.User}}!
+//
+// If HTML is true, the templates are HTML (see html/template) and the values
+// that are inserted are escaped. Otherwise, the executed template is escaped,
+// so it is displayed as plain text.
+type Greeter struct {
+	Listener
+
+	// The message sent to users. Can be empty.
+	Message string
+	// Messages sent to users who connect into the channel with the given ID,
+	// instead of Message.
+	ChannelMessages map[uint32]string
+	// Are the templates HTML?
+	HTML bool
+
+	// The ID of the channel in which connections are announced.
+	LogChannel uint32
+	// The message sent to the log channel. If empty, connections are not
+	// announced.
+	Announcement string
+
+	// The name of the server used in the templates. If empty, the server
+	// address in the client's config is used.
+	Server string
+	// Called when a template cannot be executed. Can be nil.
+	Error func(err error)
+
+	mu    sync.Mutex
+	cache map[string]func(data *GreeterData) (string, error)
+}
+
+// NewGreeter returns a new Greeter that sends the given message.
+func NewGreeter(message string) *Greeter {
+	g := &Greeter{
+		Message: message,
+	}
+	g.Listener = Listener{
+		UserChange: g.onUserChange,
+	}
+	return g
+}
+
+// execute executes the template text with data. g.mu must be held.
+func (g *Greeter) execute(text string, data *GreeterData) (string, error) {
+	key := text
+	if g.HTML {
+		key = "html:" + text
+	}
+	if exec := g.cache[key]; exec != nil {
+		return exec(data)
+	}
+
+	var exec func(data *GreeterData) (string, error)
+	if g.HTML {
+		tmpl, err := htmltemplate.New("greeter").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		exec = func(data *GreeterData) (string, error) {
+			var buf bytes.Buffer
+			err := tmpl.Execute(&buf, data)
+			return buf.String(), err
+		}
+	} else {
+		tmpl, err := template.New("greeter").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		exec = func(data *GreeterData) (string, error) {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, data); err != nil {
+				return "", err
+			}
+			return htmltemplate.HTMLEscapeString(buf.String()), nil
+		}
+	}
+	if g.cache == nil {
+		g.cache = make(map[string]func(data *GreeterData) (string, error))
+	}
+	g.cache[key] = exec
+	return exec(data)
+}
+
+func (g *Greeter) send(text string, data *GreeterData, send func(message string)) {
+	if text == "" {
+		return
+	}
+	message, err := g.execute(text, data)
+	if err != nil {
+		if g.Error != nil {
+			g.Error(err)
+		}
+		return
+	}
+	send(message)
+}
+
+func (g *Greeter) onUserChange(e *gumble.UserChangeEvent) {
+	if !e.Type.Has(gumble.UserChangeConnected) || e.User == e.Client.Self {
+		return
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	data := &GreeterData{
+		User:   e.User.Name,
+		Server: g.Server,
+	}
+	if data.Server == "" {
+		data.Server = e.Client.Config.Address
+	}
+	message := g.Message
+	if e.User.Channel != nil {
+		data.Channel = e.User.Channel.Name
+		if m, ok := g.ChannelMessages[e.User.Channel.ID]; ok {
+			message = m
+		}
+	}
+
+	g.send(message, data, e.User.Send)
+	if log := e.Client.Channels[g.LogChannel]; log != nil {
+		g.send(g.Announcement, data, func(message string) {
+			log.Send(message, false)
+		})
+	}
+}
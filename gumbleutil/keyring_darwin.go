@@ -0,0 +1,29 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// errKeyringSetUnsupported is returned by Keyring.SetSecret on macOS:
+// security only accepts the secret as a command-line argument, where other
+// processes can see it.
+var errKeyringSetUnsupported = errors.New("gumbleutil: storing secrets in the keychain is not supported; add them with Keychain Access or security add-generic-password")
+
+func keyringSecret(service, name string) (string, error) {
+	cmd := exec.Command("security", "find-generic-password", "-s", service, "-a", name, "-w")
+	out, err := cmd.Output()
+	if err != nil {
+		// security exits with 44 if the item is not found.
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 44 {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func keyringSetSecret(service, name, secret string) error {
+	return errKeyringSetUnsupported
+}
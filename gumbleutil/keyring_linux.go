@@ -0,0 +1,26 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"bytes"
+	"os/exec"
+	"strings"
+)
+
+func keyringSecret(service, name string) (string, error) {
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", name)
+	out, err := cmd.Output()
+	if err != nil {
+		// secret-tool exits with 1, and no output, if the secret is not found.
+		if exitErr, ok := err.(*exec.ExitError); ok && exitErr.ExitCode() == 1 && len(bytes.TrimSpace(exitErr.Stderr)) == 0 {
+			return "", ErrSecretNotFound
+		}
+		return "", err
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+func keyringSetSecret(service, name, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", service+" "+name, "service", service, "account", name)
+	cmd.Stdin = strings.NewReader(secret)
+	return cmd.Run()
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !darwin
+// +build !linux,!darwin
+
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import "errors"
+
+var errKeyringUnsupported = errors.New("gumbleutil: keyring not supported on this platform")
+
+func keyringSecret(service, name string) (string, error) {
+	return "", errKeyringUnsupported
+}
+
+func keyringSetSecret(service, name, secret string) error {
+	return errKeyringUnsupported
+}
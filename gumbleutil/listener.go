@@ -18,9 +18,27 @@ type Listener struct {
 	BanList             func(e *gumble.BanListEvent)
 	ContextActionChange func(e *gumble.ContextActionChangeEvent)
 	ServerConfig        func(e *gumble.ServerConfigEvent)
+	AudioConfig         func(e *gumble.AudioConfigEvent)
+	SyncProgress        func(e *gumble.SyncProgressEvent)
+	StateChange         func(e *gumble.StateChangeEvent)
+	UserReturned        func(e *gumble.UserReturnedEvent)
+	ChannelOccupancy    func(e *gumble.ChannelOccupancyEvent)
+	ChannelActivity     func(e *gumble.ChannelActivityEvent)
+	ChannelLost         func(e *gumble.ChannelLostEvent)
+	ResourceLimit       func(e *gumble.ResourceLimitEvent)
 }
 
-var _ gumble.EventListener = (*Listener)(nil)
+var (
+	_ gumble.EventListener            = (*Listener)(nil)
+	_ gumble.AudioConfigListener      = (*Listener)(nil)
+	_ gumble.SyncProgressListener     = (*Listener)(nil)
+	_ gumble.StateChangeListener      = (*Listener)(nil)
+	_ gumble.UserReturnedListener     = (*Listener)(nil)
+	_ gumble.ChannelOccupancyListener = (*Listener)(nil)
+	_ gumble.ChannelActivityListener  = (*Listener)(nil)
+	_ gumble.ChannelLostListener      = (*Listener)(nil)
+	_ gumble.ResourceLimitListener    = (*Listener)(nil)
+)
 
 // OnConnect implements gumble.EventListener.OnConnect.
 func (l Listener) OnConnect(e *gumble.ConnectEvent) {
@@ -98,3 +116,59 @@ func (l Listener) OnServerConfig(e *gumble.ServerConfigEvent) {
 		l.ServerConfig(e)
 	}
 }
+
+// OnAudioConfig implements gumble.AudioConfigListener.
+func (l Listener) OnAudioConfig(e *gumble.AudioConfigEvent) {
+	if l.AudioConfig != nil {
+		l.AudioConfig(e)
+	}
+}
+
+// OnSyncProgress implements gumble.SyncProgressListener.
+func (l Listener) OnSyncProgress(e *gumble.SyncProgressEvent) {
+	if l.SyncProgress != nil {
+		l.SyncProgress(e)
+	}
+}
+
+// OnStateChange implements gumble.StateChangeListener.
+func (l Listener) OnStateChange(e *gumble.StateChangeEvent) {
+	if l.StateChange != nil {
+		l.StateChange(e)
+	}
+}
+
+// OnUserReturned implements gumble.UserReturnedListener.
+func (l Listener) OnUserReturned(e *gumble.UserReturnedEvent) {
+	if l.UserReturned != nil {
+		l.UserReturned(e)
+	}
+}
+
+// OnChannelOccupancyChange implements gumble.ChannelOccupancyListener.
+func (l Listener) OnChannelOccupancyChange(e *gumble.ChannelOccupancyEvent) {
+	if l.ChannelOccupancy != nil {
+		l.ChannelOccupancy(e)
+	}
+}
+
+// OnChannelActivityChange implements gumble.ChannelActivityListener.
+func (l Listener) OnChannelActivityChange(e *gumble.ChannelActivityEvent) {
+	if l.ChannelActivity != nil {
+		l.ChannelActivity(e)
+	}
+}
+
+// OnChannelLost implements gumble.ChannelLostListener.
+func (l Listener) OnChannelLost(e *gumble.ChannelLostEvent) {
+	if l.ChannelLost != nil {
+		l.ChannelLost(e)
+	}
+}
+
+// OnResourceLimit implements gumble.ResourceLimitListener.
+func (l Listener) OnResourceLimit(e *gumble.ResourceLimitEvent) {
+	if l.ResourceLimit != nil {
+		l.ResourceLimit(e)
+	}
+}
@@ -22,7 +22,17 @@ import (
 //  client.Attach(gumbleutil.ListenerFunc(handler))
 type ListenerFunc func(e interface{})
 
-var _ gumble.EventListener = ListenerFunc(nil)
+var (
+	_ gumble.EventListener            = ListenerFunc(nil)
+	_ gumble.AudioConfigListener      = ListenerFunc(nil)
+	_ gumble.SyncProgressListener     = ListenerFunc(nil)
+	_ gumble.StateChangeListener      = ListenerFunc(nil)
+	_ gumble.UserReturnedListener     = ListenerFunc(nil)
+	_ gumble.ChannelOccupancyListener = ListenerFunc(nil)
+	_ gumble.ChannelActivityListener  = ListenerFunc(nil)
+	_ gumble.ChannelLostListener      = ListenerFunc(nil)
+	_ gumble.ResourceLimitListener    = ListenerFunc(nil)
+)
 
 // OnConnect implements gumble.EventListener.OnConnect.
 func (lf ListenerFunc) OnConnect(e *gumble.ConnectEvent) {
@@ -78,3 +88,43 @@ func (lf ListenerFunc) OnContextActionChange(e *gumble.ContextActionChangeEvent)
 func (lf ListenerFunc) OnServerConfig(e *gumble.ServerConfigEvent) {
 	lf(e)
 }
+
+// OnAudioConfig implements gumble.AudioConfigListener.
+func (lf ListenerFunc) OnAudioConfig(e *gumble.AudioConfigEvent) {
+	lf(e)
+}
+
+// OnSyncProgress implements gumble.SyncProgressListener.
+func (lf ListenerFunc) OnSyncProgress(e *gumble.SyncProgressEvent) {
+	lf(e)
+}
+
+// OnStateChange implements gumble.StateChangeListener.
+func (lf ListenerFunc) OnStateChange(e *gumble.StateChangeEvent) {
+	lf(e)
+}
+
+// OnUserReturned implements gumble.UserReturnedListener.
+func (lf ListenerFunc) OnUserReturned(e *gumble.UserReturnedEvent) {
+	lf(e)
+}
+
+// OnChannelOccupancyChange implements gumble.ChannelOccupancyListener.
+func (lf ListenerFunc) OnChannelOccupancyChange(e *gumble.ChannelOccupancyEvent) {
+	lf(e)
+}
+
+// OnChannelActivityChange implements gumble.ChannelActivityListener.
+func (lf ListenerFunc) OnChannelActivityChange(e *gumble.ChannelActivityEvent) {
+	lf(e)
+}
+
+// OnChannelLost implements gumble.ChannelLostListener.
+func (lf ListenerFunc) OnChannelLost(e *gumble.ChannelLostEvent) {
+	lf(e)
+}
+
+// OnResourceLimit implements gumble.ResourceLimitListener.
+func (lf ListenerFunc) OnResourceLimit(e *gumble.ResourceLimitEvent) {
+	lf(e)
+}
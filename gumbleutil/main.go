@@ -2,7 +2,6 @@ package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
 
 import (
 	"crypto/tls"
-	"flag"
 	"fmt"
 	"net"
 	"os"
@@ -11,56 +10,69 @@ import (
 	"github.com/bmmcginty/gumble/gumble"
 )
 
-// Main aids in the creation of a basic command line gumble bot. It accepts the
-// following flag arguments:
+// Main aids in the creation of a basic command line gumble bot. It loads its
+// configuration with LoadMainConfig, which accepts the following flag
+// arguments, and then calls MainWithConfig:
+//  --config
 //  --server
 //  --username
 //  --password
+//  --password-source
+//  --superuser
 //  --insecure
 //  --certificate
 //  --key
+//  --channel
+//  --command-prefix
+//  --audio-interval
 func Main(listeners ...gumble.EventListener) {
-	server := flag.String("server", "localhost:64738", "Mumble server address")
-	username := flag.String("username", "gumble-bot", "client username")
-	password := flag.String("password", "", "client password")
-	insecure := flag.Bool("insecure", false, "skip server certificate verification")
-	certificateFile := flag.String("certificate", "", "user certificate file (PEM)")
-	keyFile := flag.String("key", "", "user certificate key file (PEM)")
+	mainConfig, err := LoadMainConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+		os.Exit(1)
+	}
+	MainWithConfig(mainConfig, listeners...)
+}
 
-	if !flag.Parsed() {
-		flag.Parse()
+// MainWithConfig is like Main, but uses the given configuration rather than
+// loading it.
+func MainWithConfig(mainConfig *MainConfig, listeners ...gumble.EventListener) {
+	if err := mainConfig.ReadPassword(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
+		os.Exit(1)
 	}
 
-	host, port, err := net.SplitHostPort(*server)
+	host, port, err := net.SplitHostPort(mainConfig.Server)
 	if err != nil {
-		host = *server
+		host = mainConfig.Server
 		port = strconv.Itoa(gumble.DefaultPort)
 	}
 
 	keepAlive := make(chan bool)
 
-	config := gumble.NewConfig()
-	config.Username = *username
-	config.Password = *password
+	config := mainConfig.GumbleConfig()
 	config.Address = net.JoinHostPort(host, port)
 
 	var tlsConfig tls.Config
 
-	if *insecure {
+	if mainConfig.Insecure {
 		tlsConfig.InsecureSkipVerify = true
 	}
-	if *certificateFile != "" {
-		if *keyFile == "" {
-			keyFile = certificateFile
+	if mainConfig.Certificate != "" {
+		keyFile := mainConfig.Key
+		if keyFile == "" {
+			keyFile = mainConfig.Certificate
 		}
-		if certificate, err := tls.LoadX509KeyPair(*certificateFile, *keyFile); err != nil {
+		if certificate, err := tls.LoadX509KeyPair(mainConfig.Certificate, keyFile); err != nil {
 			fmt.Fprintf(os.Stderr, "%s: %s\n", os.Args[0], err)
 			os.Exit(1)
 		} else {
 			tlsConfig.Certificates = append(tlsConfig.Certificates, certificate)
 		}
 	}
-	config.Attach(AutoBitrate)
+	if mainConfig.Audio.DataBytes <= 0 {
+		config.Attach(AutoBitrate)
+	}
 	for _, listener := range listeners {
 		config.Attach(listener)
 	}
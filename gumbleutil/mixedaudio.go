@@ -0,0 +1,143 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+	"github.com/bmmcginty/gumble/gumble/pcm"
+)
+
+// DefaultMixedAudioBuffer is the default amount of mixed audio that a
+// MixedAudio buffers for its reader.
+const DefaultMixedAudioBuffer = time.Second
+
+// mixedAudioInterval is how often a MixedAudio mixes the received audio.
+const mixedAudioInterval = 10 * time.Millisecond
+
+// MixedAudio is an AudioListener that mixes the audio of every user into a
+// single, continuous stream of mono 16-bit little-endian PCM, at a chosen
+// sample rate, which is read with Read. Silence is produced while nobody is
+// talking, so the stream can be piped into programs that expect a live
+// source, such as OBS or ffmpeg:
+//  mixed := gumbleutil.NewMixedAudio(44100)
+//  client.Config.AttachAudio(mixed)
+//  go io.Copy(ffmpegStdin, mixed)
+//
+// If the reader falls behind by more than MaxBuffered, the oldest audio is
+// dropped, so that the stream stays live.
+type MixedAudio struct {
+	// The amount of mixed audio that is buffered for the reader. It must be
+	// set before the first call to Read.
+	MaxBuffered time.Duration
+
+	mixer     *Mixer
+	rate      int
+	resampler *Resampler
+	stop      chan struct{}
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []byte
+	closed  bool
+}
+
+// NewMixedAudio returns a new MixedAudio that produces audio at the given
+// sample rate (in hertz), and starts mixing.
+func NewMixedAudio(rate int) *MixedAudio {
+	m := &MixedAudio{
+		MaxBuffered: DefaultMixedAudioBuffer,
+		mixer:       NewMixer(),
+		rate:        rate,
+		stop:        make(chan struct{}),
+	}
+	if rate != gumble.AudioSampleRate {
+		m.resampler = NewResampler(gumble.AudioSampleRate, rate)
+	}
+	m.cond = sync.NewCond(&m.mu)
+	go m.mixRoutine()
+	return m
+}
+
+// Rate returns the sample rate of the mixed audio.
+func (m *MixedAudio) Rate() int {
+	return m.rate
+}
+
+// OnAudioStream implements gumble.AudioListener.
+func (m *MixedAudio) OnAudioStream(e *gumble.AudioStreamEvent) {
+	m.mixer.OnAudioStream(e)
+}
+
+// Read reads mixed audio into p, waiting until some is available. io.EOF is
+// returned once the MixedAudio has been closed.
+func (m *MixedAudio) Read(p []byte) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.pending) == 0 && !m.closed {
+		m.cond.Wait()
+	}
+	if len(m.pending) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, m.pending)
+	m.pending = m.pending[n:]
+	return n, nil
+}
+
+// Close stops the mixing. The audio that is still buffered can be read
+// before Read returns io.EOF. The MixedAudio should also be detached from the
+// client.
+func (m *MixedAudio) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.closed {
+		m.closed = true
+		close(m.stop)
+		m.cond.Broadcast()
+	}
+	return nil
+}
+
+// mixRoutine mixes the received audio in real time, until m is closed.
+func (m *MixedAudio) mixRoutine() {
+	ticker := time.NewTicker(mixedAudioInterval)
+	defer ticker.Stop()
+
+	start := time.Now()
+	var produced int64
+	for {
+		select {
+		case <-m.stop:
+			return
+		case now := <-ticker.C:
+			// The number of samples is derived from the elapsed time, so that
+			// late ticks do not make the stream drift.
+			due := int64(now.Sub(start))*gumble.AudioSampleRate/int64(time.Second) - produced
+			if due <= 0 {
+				continue
+			}
+			produced += due
+			buffer := make([]int16, due)
+			m.mixer.Mix(buffer)
+			if m.resampler != nil {
+				buffer = m.resampler.Process(buffer)
+			}
+			m.write(pcm.EncodeAll(buffer))
+		}
+	}
+}
+
+func (m *MixedAudio) write(data []byte) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = append(m.pending, data...)
+	max := int(int64(m.MaxBuffered) * int64(m.rate) / int64(time.Second) * 2)
+	if over := len(m.pending) - max; max > 0 && over > 0 {
+		// Drop whole samples.
+		over += over % 2
+		m.pending = append(m.pending[:0], m.pending[over:]...)
+	}
+	m.cond.Broadcast()
+}
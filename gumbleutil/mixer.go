@@ -0,0 +1,95 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"math"
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// DefaultMixerBuffer is the default maximum number of samples a Mixer buffers
+// per user (500ms).
+const DefaultMixerBuffer = gumble.AudioSampleRate / 2
+
+// Mixer is an AudioListener that buffers the incoming audio of each user so
+// that it can be mixed into a single stream. It is used by audio backends
+// whose output device can only play one stream at a time.
+type Mixer struct {
+	// The maximum number of samples buffered per user; once reached, the
+	// oldest samples are dropped.
+	MaxBuffered int
+
+	lock    sync.Mutex
+	pending map[*gumble.User][]int16
+}
+
+// NewMixer returns a new Mixer.
+func NewMixer() *Mixer {
+	return &Mixer{
+		MaxBuffered: DefaultMixerBuffer,
+		pending:     make(map[*gumble.User][]int16),
+	}
+}
+
+// OnAudioStream implements gumble.AudioListener.
+func (m *Mixer) OnAudioStream(e *gumble.AudioStreamEvent) {
+	go func() {
+		for packet := range e.C {
+			m.lock.Lock()
+			pending := append(m.pending[e.User], packet.AudioBuffer...)
+			if over := len(pending) - m.MaxBuffered; over > 0 {
+				pending = pending[over:]
+			}
+			m.pending[e.User] = pending
+			m.lock.Unlock()
+		}
+		m.lock.Lock()
+		delete(m.pending, e.User)
+		m.lock.Unlock()
+	}()
+}
+
+// Buffered returns the largest number of samples that are buffered for any
+// user. Zero means that no one is talking.
+func (m *Mixer) Buffered() int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var max int
+	for _, pending := range m.pending {
+		if len(pending) > max {
+			max = len(pending)
+		}
+	}
+	return max
+}
+
+// Mix fills buf with the sum of the buffered audio of all users, clipping the
+// result to the range of an int16. Silence is written for the part of buf for
+// which no audio is buffered.
+func (m *Mixer) Mix(buf []int16) {
+	mixed := make([]int32, len(buf))
+
+	m.lock.Lock()
+	for user, pending := range m.pending {
+		n := len(pending)
+		if n > len(buf) {
+			n = len(buf)
+		}
+		for i := 0; i < n; i++ {
+			mixed[i] += int32(pending[i])
+		}
+		m.pending[user] = pending[n:]
+	}
+	m.lock.Unlock()
+
+	for i, sample := range mixed {
+		switch {
+		case sample > math.MaxInt16:
+			sample = math.MaxInt16
+		case sample < math.MinInt16:
+			sample = math.MinInt16
+		}
+		buf[i] = int16(sample)
+	}
+}
@@ -0,0 +1,268 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"errors"
+	"html"
+	"plugin"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// Plugin is a bundle of features (e.g. event listeners) that can be loaded
+// into and unloaded from a client at runtime with a PluginManager.
+type Plugin interface {
+	// Name returns the name of the plugin.
+	Name() string
+	// Attach starts the plugin for client. It is called from inside of
+	// client.Do, so it can attach listeners to client.Config directly.
+	Attach(client *gumble.Client) error
+	// Detach stops the plugin, detaching everything that Attach attached. It
+	// is called from inside of client.Do.
+	Detach() error
+}
+
+// PluginFactory returns a new instance of a plugin.
+type PluginFactory func() Plugin
+
+// PluginSymbol is the name of the function that Go plugins loaded with
+// PluginManager.LoadFile must export. It must be of type func() Plugin.
+const PluginSymbol = "NewPlugin"
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]PluginFactory)
+)
+
+// RegisterPlugin makes a plugin available to every PluginManager under the
+// given name. It is typically called from an init function. RegisterPlugin
+// panics if a plugin is already registered with the name.
+func RegisterPlugin(name string, factory PluginFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, ok := registry[name]; ok {
+		panic("gumbleutil: plugin " + name + " already registered")
+	}
+	registry[name] = factory
+}
+
+// Plugin errors.
+var (
+	ErrPluginUnknown       = errors.New("gumbleutil: unknown plugin")
+	ErrPluginLoaded        = errors.New("gumbleutil: plugin already loaded")
+	ErrPluginNotLoaded     = errors.New("gumbleutil: plugin not loaded")
+	ErrPluginInvalidSymbol = errors.New("gumbleutil: plugin does not export func " + PluginSymbol + "() Plugin")
+)
+
+// PluginManager loads and unloads plugins into a client.
+//
+// The manager is also a gumble.EventListener. If it is attached to the
+// client, and Command is set, users for whom Allowed returns true can manage
+// plugins with text messages:
+//  !plugin list
+//  !plugin load <name>
+//  !plugin unload <name>
+// Only plugins registered with RegisterPlugin or PluginManager.Register can
+// be loaded with text messages.
+type PluginManager struct {
+	Listener
+
+	// The text message command that manages plugins (e.g. "!plugin"). Empty
+	// disables the command.
+	Command string
+	// Returns true if user can use the command. If nil, no one can.
+	Allowed func(user *gumble.User) bool
+
+	client *gumble.Client
+
+	mu        sync.Mutex
+	factories map[string]PluginFactory
+	loaded    map[string]Plugin
+}
+
+// NewPluginManager returns a new PluginManager for client.
+func NewPluginManager(client *gumble.Client) *PluginManager {
+	m := &PluginManager{
+		client:    client,
+		factories: make(map[string]PluginFactory),
+		loaded:    make(map[string]Plugin),
+	}
+	m.Listener.TextMessage = m.onTextMessage
+	return m
+}
+
+// Register makes a plugin available to the manager under the given name,
+// replacing any plugin previously registered with the name.
+func (m *PluginManager) Register(name string, factory PluginFactory) {
+	m.mu.Lock()
+	m.factories[name] = factory
+	m.mu.Unlock()
+}
+
+// Available returns the names of the plugins that can be loaded with Load,
+// sorted.
+func (m *PluginManager) Available() []string {
+	names := make(map[string]struct{})
+	registryMu.Lock()
+	for name := range registry {
+		names[name] = struct{}{}
+	}
+	registryMu.Unlock()
+	m.mu.Lock()
+	for name := range m.factories {
+		names[name] = struct{}{}
+	}
+	m.mu.Unlock()
+	return sortedNames(names)
+}
+
+// Loaded returns the names of the loaded plugins, sorted.
+func (m *PluginManager) Loaded() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make(map[string]struct{}, len(m.loaded))
+	for name := range m.loaded {
+		names[name] = struct{}{}
+	}
+	return sortedNames(names)
+}
+
+// Load creates the plugin registered under the given name, and attaches it to
+// the client.
+func (m *PluginManager) Load(name string) error {
+	m.mu.Lock()
+	factory := m.factories[name]
+	m.mu.Unlock()
+	if factory == nil {
+		registryMu.Lock()
+		factory = registry[name]
+		registryMu.Unlock()
+	}
+	if factory == nil {
+		return ErrPluginUnknown
+	}
+	return m.attach(name, factory())
+}
+
+// LoadFile opens the Go plugin (see package plugin) at path, creates a plugin
+// with its PluginSymbol function, and attaches it to the client. The plugin
+// is loaded under the name returned by its Name method, which is returned.
+//
+// Go plugins cannot be removed from memory: unloading one only detaches it,
+// and loading the same file again creates a new instance of its plugin.
+func (m *PluginManager) LoadFile(path string) (string, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return "", err
+	}
+	symbol, err := p.Lookup(PluginSymbol)
+	if err != nil {
+		return "", err
+	}
+	factory, ok := symbol.(func() Plugin)
+	if !ok {
+		return "", ErrPluginInvalidSymbol
+	}
+	instance := factory()
+	name := instance.Name()
+	return name, m.attach(name, instance)
+}
+
+// Add attaches an existing plugin to the client, under the name returned by
+// its Name method.
+func (m *PluginManager) Add(p Plugin) error {
+	return m.attach(p.Name(), p)
+}
+
+// Unload detaches the plugin loaded under the given name.
+func (m *PluginManager) Unload(name string) error {
+	m.mu.Lock()
+	p := m.loaded[name]
+	delete(m.loaded, name)
+	m.mu.Unlock()
+	if p == nil {
+		return ErrPluginNotLoaded
+	}
+	var err error
+	m.client.Do(func() {
+		err = p.Detach()
+	})
+	return err
+}
+
+// UnloadAll detaches every loaded plugin. The first error that occurs is
+// returned.
+func (m *PluginManager) UnloadAll() error {
+	var first error
+	for _, name := range m.Loaded() {
+		if err := m.Unload(name); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (m *PluginManager) attach(name string, p Plugin) error {
+	m.mu.Lock()
+	if _, ok := m.loaded[name]; ok {
+		m.mu.Unlock()
+		return ErrPluginLoaded
+	}
+	// Reserve the name while the plugin is being attached.
+	m.loaded[name] = p
+	m.mu.Unlock()
+
+	var err error
+	m.client.Do(func() {
+		err = p.Attach(m.client)
+	})
+	if err != nil {
+		m.mu.Lock()
+		delete(m.loaded, name)
+		m.mu.Unlock()
+	}
+	return err
+}
+
+func (m *PluginManager) onTextMessage(e *gumble.TextMessageEvent) {
+	if m.Command == "" || e.Sender == nil || m.Allowed == nil {
+		return
+	}
+	fields := strings.Fields(PlainText(&e.TextMessage))
+	if len(fields) == 0 || fields[0] != m.Command || !m.Allowed(e.Sender) {
+		return
+	}
+
+	var reply string
+	switch {
+	case len(fields) == 2 && fields[1] == "list":
+		reply = "Loaded: " + html.EscapeString(strings.Join(m.Loaded(), ", ")) + "<br>Available: " + html.EscapeString(strings.Join(m.Available(), ", "))
+	case len(fields) == 3 && fields[1] == "load":
+		reply = pluginReply(fields[2], "loaded", m.Load(fields[2]))
+	case len(fields) == 3 && fields[1] == "unload":
+		reply = pluginReply(fields[2], "unloaded", m.Unload(fields[2]))
+	default:
+		reply = "Usage: " + m.Command + " list | load &lt;name&gt; | unload &lt;name&gt;"
+	}
+	e.Sender.Send(reply)
+}
+
+func pluginReply(name, action string, err error) string {
+	if err != nil {
+		return html.EscapeString(name + ": " + err.Error())
+	}
+	return html.EscapeString(name + " " + action)
+}
+
+func sortedNames(set map[string]struct{}) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,234 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// recordMagic starts every recording.
+var recordMagic = [8]byte{'g', 'u', 'm', 'b', 'l', 'e', 'r', '1'}
+
+// ErrInvalidRecording is returned by Replayer when the recording is not in the
+// format written by Recorder.
+var ErrInvalidRecording = errors.New("gumbleutil: invalid recording")
+
+// Recorder is a gumble.PacketRecorder that writes the packets read by a
+// client, along with the time at which they were received, so that they can
+// be replayed later with Replayer.
+//
+// Set the client's Config.PacketRecorder to the Recorder before connecting.
+type Recorder struct {
+	// Called when writing a packet fails. Once an error has occurred, no
+	// further packets are written. Can be nil.
+	Error func(err error)
+
+	mu     sync.Mutex
+	w      *bufio.Writer
+	closer io.Closer
+	err    error
+}
+
+// NewRecorder returns a new Recorder that writes to w.
+func NewRecorder(w io.Writer) *Recorder {
+	r := &Recorder{
+		w: bufio.NewWriter(w),
+	}
+	_, r.err = r.w.Write(recordMagic[:])
+	return r
+}
+
+// CreateRecorder returns a new Recorder that writes to the named file,
+// truncating it if it already exists.
+func CreateRecorder(filename string) (*Recorder, error) {
+	file, err := os.Create(filename)
+	if err != nil {
+		return nil, err
+	}
+	r := NewRecorder(file)
+	r.closer = file
+	return r, nil
+}
+
+// RecordPacket implements gumble.PacketRecorder.
+func (r *Recorder) RecordPacket(pType uint16, data []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return
+	}
+	var header [14]byte
+	binary.BigEndian.PutUint64(header[:], uint64(time.Now().UnixNano()))
+	binary.BigEndian.PutUint16(header[8:], pType)
+	binary.BigEndian.PutUint32(header[10:], uint32(len(data)))
+	if _, err := r.w.Write(header[:]); err != nil {
+		r.fail(err)
+		return
+	}
+	if _, err := r.w.Write(data); err != nil {
+		r.fail(err)
+		return
+	}
+	// Flush each packet, so that the recording is usable even if the
+	// program does not exit cleanly.
+	if err := r.w.Flush(); err != nil {
+		r.fail(err)
+	}
+}
+
+// fail records err. r.mu must be held.
+func (r *Recorder) fail(err error) {
+	r.err = err
+	if r.Error != nil {
+		r.Error(err)
+	}
+}
+
+// Close flushes the recording and, if the Recorder was created with
+// CreateRecorder, closes the file.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.err
+	if err == nil {
+		err = r.w.Flush()
+	}
+	if r.err == nil {
+		r.err = errors.New("gumbleutil: recorder closed")
+	}
+	if r.closer != nil {
+		if cerr := r.closer.Close(); err == nil {
+			err = cerr
+		}
+		r.closer = nil
+	}
+	return err
+}
+
+// RecordedPacket is a packet that was written by a Recorder.
+type RecordedPacket struct {
+	// When the packet was received.
+	Time time.Time
+	// The packet's type.
+	Type uint16
+	// The packet's data.
+	Data []byte
+}
+
+// Replayer reads a recording written by Recorder, and feeds it into a client
+// created with gumble.NewReplayClient.
+type Replayer struct {
+	// Should the time between packets be reproduced? If false, packets are
+	// replayed as fast as the client handles them.
+	RealTime bool
+
+	r      *bufio.Reader
+	closer io.Closer
+	magic  bool
+}
+
+// NewReplayer returns a new Replayer that reads the recording from r.
+func NewReplayer(r io.Reader) *Replayer {
+	return &Replayer{
+		r: bufio.NewReader(r),
+	}
+}
+
+// OpenReplayer returns a new Replayer that reads the recording from the named
+// file. The file is closed once the recording has been replayed, or when
+// Close is called.
+func OpenReplayer(filename string) (*Replayer, error) {
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	r := NewReplayer(file)
+	r.closer = file
+	return r, nil
+}
+
+// Next returns the next packet in the recording. io.EOF is returned at the end
+// of the recording.
+func (r *Replayer) Next() (*RecordedPacket, error) {
+	if !r.magic {
+		var magic [8]byte
+		if _, err := io.ReadFull(r.r, magic[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil, ErrInvalidRecording
+			}
+			return nil, err
+		}
+		if magic != recordMagic {
+			return nil, ErrInvalidRecording
+		}
+		r.magic = true
+	}
+
+	var header [14]byte
+	if _, err := io.ReadFull(r.r, header[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidRecording
+		}
+		return nil, err
+	}
+	packet := &RecordedPacket{
+		Time: time.Unix(0, int64(binary.BigEndian.Uint64(header[:]))),
+		Type: binary.BigEndian.Uint16(header[8:]),
+		Data: make([]byte, binary.BigEndian.Uint32(header[10:])),
+	}
+	if _, err := io.ReadFull(r.r, packet.Data); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil, ErrInvalidRecording
+		}
+		return nil, err
+	}
+	return packet, nil
+}
+
+// Replay writes every remaining packet of the recording to conn, which must
+// be the Conn returned by gumble.NewReplayClient, and then closes conn, which
+// disconnects the client. The DisconnectEvent can be used to wait for the
+// client to finish handling the replayed packets.
+func (r *Replayer) Replay(conn *gumble.Conn) error {
+	defer r.Close()
+	defer conn.Close()
+
+	var last time.Time
+	for {
+		packet, err := r.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if r.RealTime && !last.IsZero() {
+			if delay := packet.Time.Sub(last); delay > 0 {
+				time.Sleep(delay)
+			}
+		}
+		last = packet.Time
+		if err := conn.WritePacket(packet.Type, packet.Data); err != nil {
+			return err
+		}
+	}
+}
+
+// Close closes the recording file, if the Replayer was created with
+// OpenReplayer.
+func (r *Replayer) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	err := r.closer.Close()
+	r.closer = nil
+	return err
+}
@@ -0,0 +1,129 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"math"
+)
+
+// resamplerZeroCrossings is the number of zero crossings of the sinc filter
+// on each side of its center. Higher values give a sharper cutoff at the cost
+// of CPU time.
+const resamplerZeroCrossings = 16
+
+// Resampler converts a stream of mono PCM samples from one sample rate to
+// another, using a windowed sinc filter. It can be used to convert between a
+// device's sample rate and gumble.AudioSampleRate.
+//
+// A Resampler keeps state between calls to Process, so a separate Resampler
+// must be used for each stream.
+type Resampler struct {
+	inRate, outRate int
+
+	step      float64 // input samples advanced per output sample
+	cutoff    float64 // filter cutoff, as a fraction of the input Nyquist rate
+	halfWidth int     // filter half width, in input samples
+
+	history []float64
+	// The position of the next output sample in history, in 1/outRate
+	// input samples, so that it does not drift from rounding errors.
+	pos int
+}
+
+// NewResampler returns a Resampler that converts from inRate to outRate
+// (in hertz).
+func NewResampler(inRate, outRate int) *Resampler {
+	r := &Resampler{
+		inRate:  inRate,
+		outRate: outRate,
+		step:    float64(inRate) / float64(outRate),
+		cutoff:  1,
+	}
+	if outRate < inRate {
+		// Remove frequencies above the output's Nyquist rate to prevent
+		// aliasing.
+		r.cutoff = float64(outRate) / float64(inRate)
+	}
+	r.halfWidth = int(math.Ceil(resamplerZeroCrossings / r.cutoff))
+	r.Reset()
+	return r
+}
+
+// Reset clears the resampler's state, so that it can be used for a new
+// stream.
+func (r *Resampler) Reset() {
+	r.history = make([]float64, r.halfWidth)
+	r.pos = 0
+}
+
+// InRate returns the sample rate of the resampler's input.
+func (r *Resampler) InRate() int {
+	return r.inRate
+}
+
+// OutRate returns the sample rate of the resampler's output.
+func (r *Resampler) OutRate() int {
+	return r.outRate
+}
+
+// Process resamples in and returns the resulting samples. The number of
+// returned samples varies between calls, as samples near the end of in are
+// kept until enough following samples are known.
+func (r *Resampler) Process(in []int16) []int16 {
+	if r.inRate == r.outRate {
+		out := make([]int16, len(in))
+		copy(out, in)
+		return out
+	}
+
+	for _, sample := range in {
+		r.history = append(r.history, float64(sample))
+	}
+
+	out := make([]int16, 0, int(float64(len(in))/r.step)+1)
+	for r.pos/r.outRate+r.halfWidth < len(r.history) {
+		out = append(out, r.sample(float64(r.pos)/float64(r.outRate)))
+		r.pos += r.inRate
+	}
+
+	if drop := r.pos/r.outRate - r.halfWidth; drop > 0 {
+		r.history = append(r.history[:0], r.history[drop:]...)
+		r.pos -= drop * r.outRate
+	}
+	return out
+}
+
+// sample returns the filtered value of the input at position pos.
+func (r *Resampler) sample(pos float64) int16 {
+	center := int(pos)
+	var sum float64
+	for i := center - r.halfWidth + 1; i <= center+r.halfWidth; i++ {
+		if i < 0 || i >= len(r.history) {
+			continue
+		}
+		x := pos - float64(i)
+		sum += r.history[i] * r.cutoff * sinc(r.cutoff*x) * blackman(x/float64(r.halfWidth))
+	}
+	switch {
+	case sum > math.MaxInt16:
+		sum = math.MaxInt16
+	case sum < math.MinInt16:
+		sum = math.MinInt16
+	}
+	return int16(math.Round(sum))
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+// blackman returns the value of a Blackman window, centered on 0, at x (which
+// ranges from -1 to 1).
+func blackman(x float64) float64 {
+	if x <= -1 || x >= 1 {
+		return 0
+	}
+	return 0.42 + 0.5*math.Cos(math.Pi*x) + 0.08*math.Cos(2*math.Pi*x)
+}
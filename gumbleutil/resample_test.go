@@ -0,0 +1,127 @@
+package gumbleutil
+
+import (
+	"math"
+	"testing"
+)
+
+// testSine returns n samples of a sine wave with the given frequency and
+// amplitude, sampled at rate.
+func testSine(n, rate int, frequency, amplitude float64) []int16 {
+	samples := make([]int16, n)
+	for i := range samples {
+		samples[i] = int16(amplitude * math.Sin(2*math.Pi*frequency*float64(i)/float64(rate)))
+	}
+	return samples
+}
+
+// resampleTestChunks resamples in, passing chunk samples at a time to
+// Process.
+func resampleTestChunks(r *Resampler, in []int16, chunk int) []int16 {
+	var out []int16
+	for len(in) > 0 {
+		n := chunk
+		if n > len(in) {
+			n = len(in)
+		}
+		out = append(out, r.Process(in[:n])...)
+		in = in[n:]
+	}
+	return out
+}
+
+func TestResamplerLength(t *testing.T) {
+	tests := []struct {
+		inRate, outRate int
+	}{
+		{44100, 48000},
+		{48000, 44100},
+		{16000, 48000},
+		{48000, 8000},
+	}
+	for _, test := range tests {
+		r := NewResampler(test.inRate, test.outRate)
+		// One second of audio, in 10ms chunks.
+		in := make([]int16, test.inRate)
+		out := resampleTestChunks(r, in, test.inRate/100)
+
+		// The samples near the end of the input are kept until more
+		// samples are known.
+		held := int(math.Ceil(float64(r.halfWidth)/r.step)) + 1
+		if len(out) > test.outRate || len(out) < test.outRate-held {
+			t.Errorf("%d to %d Hz: got %d samples, expected between %d and %d\n", test.inRate, test.outRate, len(out), test.outRate-held, test.outRate)
+		}
+	}
+}
+
+func TestResamplerSameRate(t *testing.T) {
+	r := NewResampler(48000, 48000)
+	in := testSine(480, 48000, 1000, 10000)
+	out := r.Process(in)
+	if len(out) != len(in) {
+		t.Fatalf("got %d samples, expected %d\n", len(out), len(in))
+	}
+	for i := range in {
+		if out[i] != in[i] {
+			t.Fatalf("sample %d is %d, expected %d\n", i, out[i], in[i])
+		}
+	}
+}
+
+func TestResamplerChunkSize(t *testing.T) {
+	in := testSine(44100/2, 44100, 440, 12000)
+	want := resampleTestChunks(NewResampler(44100, 48000), in, len(in))
+	for _, chunk := range []int{1, 100, 441, 1000} {
+		got := resampleTestChunks(NewResampler(44100, 48000), in, chunk)
+		if len(got) != len(want) {
+			t.Errorf("chunks of %d samples: got %d samples, expected %d\n", chunk, len(got), len(want))
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("chunks of %d samples: sample %d is %d, expected %d\n", chunk, i, got[i], want[i])
+				break
+			}
+		}
+	}
+}
+
+func TestResamplerStability(t *testing.T) {
+	const amplitude = 12000
+	r := NewResampler(44100, 48000)
+	// Ten seconds of a 1kHz tone.
+	in := testSine(10*44100, 44100, 1000, amplitude)
+	out := resampleTestChunks(r, in, 441)
+
+	// The output is the same tone, with the same amplitude, from start to
+	// end. The first samples are skipped, as the filter starts on silence.
+	skip := 2 * r.halfWidth
+	for second := 0; second < 10; second++ {
+		start := second * 48000
+		if start < skip {
+			start = skip
+		}
+		end := (second + 1) * 48000
+		if end > len(out) {
+			end = len(out)
+		}
+		var peak float64
+		var sum, expected float64
+		for i := start; i < end; i++ {
+			s := float64(out[i])
+			peak = math.Max(peak, math.Abs(s))
+			// Output sample i is at input sample i*step, and the
+			// input is preceded by halfWidth samples of silence.
+			x := float64(i)*r.step - float64(r.halfWidth)
+			e := amplitude * math.Sin(2*math.Pi*1000*x/44100)
+			sum += (s - e) * (s - e)
+			expected += e * e
+		}
+		if math.Abs(peak-amplitude) > amplitude/100 {
+			t.Errorf("second %d: peak of %.0f, expected %d\n", second, peak, amplitude)
+		}
+		if snr := 10 * math.Log10(expected/sum); snr < 30 {
+			t.Errorf("second %d: signal-to-noise ratio of %.1fdB\n", second, snr)
+		}
+	}
+}
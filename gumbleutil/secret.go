@@ -0,0 +1,149 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// SecretProvider returns secrets, such as server passwords, so that they do
+// not have to be stored in plain text in a program's configuration.
+type SecretProvider interface {
+	// Secret returns the secret with the given name. ErrSecretNotFound is
+	// returned if the provider does not have it.
+	Secret(name string) (string, error)
+}
+
+// SecretFunc is a function that implements SecretProvider.
+type SecretFunc func(name string) (string, error)
+
+// Secret implements SecretProvider.
+func (f SecretFunc) Secret(name string) (string, error) {
+	return f(name)
+}
+
+// Secret errors.
+var (
+	ErrSecretNotFound = errors.New("gumbleutil: secret not found")
+	ErrNotTerminal    = errors.New("gumbleutil: cannot prompt for secret: not a terminal")
+)
+
+// SecretChain is a SecretProvider that tries each of its providers in order,
+// until one of them has the secret.
+type SecretChain []SecretProvider
+
+// Secret implements SecretProvider.
+func (c SecretChain) Secret(name string) (string, error) {
+	for _, provider := range c {
+		secret, err := provider.Secret(name)
+		if err != ErrSecretNotFound {
+			return secret, err
+		}
+	}
+	return "", ErrSecretNotFound
+}
+
+// Keyring is a SecretProvider that reads secrets from the operating system's
+// keyring: the Secret Service (using secret-tool) on Linux, and the login
+// keychain (using security) on macOS. Secrets are looked up by service and
+// account, the account being the secret's name.
+type Keyring struct {
+	// The service under which secrets are stored. Defaults to "gumble".
+	Service string
+}
+
+func (k Keyring) service() string {
+	if k.Service == "" {
+		return "gumble"
+	}
+	return k.Service
+}
+
+// Secret implements SecretProvider.
+func (k Keyring) Secret(name string) (string, error) {
+	return keyringSecret(k.service(), name)
+}
+
+// SetSecret stores secret in the keyring under the given name. It is not
+// supported on macOS, where the secret would have to be passed to security on
+// its command line, visible to other processes; add secrets with Keychain
+// Access, or by running security add-generic-password -s service -a name -w,
+// which prompts for the secret.
+func (k Keyring) SetSecret(name, secret string) error {
+	return keyringSetSecret(k.service(), name, secret)
+}
+
+// Prompt is a SecretProvider that asks the user for secrets on a terminal,
+// without echoing what they type.
+type Prompt struct {
+	// The terminal from which the secret is read. Defaults to os.Stdin.
+	In *os.File
+	// Where the prompt is written. Defaults to os.Stderr.
+	Out io.Writer
+}
+
+// Secret implements SecretProvider.
+func (p Prompt) Secret(name string) (string, error) {
+	in, out := p.In, p.Out
+	if in == nil {
+		in = os.Stdin
+	}
+	if out == nil {
+		out = os.Stderr
+	}
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return "", ErrNotTerminal
+	}
+	fmt.Fprintf(out, "%s: ", name)
+	secret, err := term.ReadPassword(fd)
+	fmt.Fprintln(out)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+var (
+	secretProvidersMu sync.Mutex
+	secretProviders   = map[string]SecretProvider{
+		"keyring": Keyring{},
+		"prompt":  Prompt{},
+	}
+)
+
+// RegisterSecretProvider makes a SecretProvider usable in
+// MainConfig.PasswordSource under the given name. The "keyring" (Keyring) and
+// "prompt" (Prompt) providers are registered by default.
+func RegisterSecretProvider(name string, provider SecretProvider) {
+	secretProvidersMu.Lock()
+	secretProviders[name] = provider
+	secretProvidersMu.Unlock()
+}
+
+// SecretProviderByName returns the SecretProvider for a comma-separated list of
+// registered provider names (e.g. "keyring,prompt"), which are tried in
+// order.
+func SecretProviderByName(names string) (SecretProvider, error) {
+	secretProvidersMu.Lock()
+	defer secretProvidersMu.Unlock()
+
+	var chain SecretChain
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		provider := secretProviders[name]
+		if provider == nil {
+			return nil, errors.New("gumbleutil: unknown secret provider " + name)
+		}
+		chain = append(chain, provider)
+	}
+	return chain, nil
+}
@@ -0,0 +1,221 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// ErrStepTimeout is the error of a SequenceError when a step has not been
+// confirmed within Sequence.Timeout.
+var ErrStepTimeout = errors.New("gumbleutil: timed out waiting for step confirmation")
+
+// Step is a single action of a Sequence.
+type Step struct {
+	// The name of the step, used in errors.
+	Name string
+	// Do performs the step, usually by sending one or more requests to the
+	// server. It is called again when the step is retried.
+	Do func(client *gumble.Client) error
+	// Confirm returns true once the server has applied the step (e.g. the
+	// created channel exists). It is called inside of Client.Do, each time
+	// an event is received. If nil, the step is considered done as soon as
+	// Do returns.
+	Confirm func(client *gumble.Client) bool
+	// Rollback undoes the step. It is called, in reverse order, for each
+	// completed step when a later step fails. It may be nil.
+	Rollback func(client *gumble.Client) error
+}
+
+// Sequence runs a series of steps one after the other, waiting for each step
+// to be confirmed by the server before starting the next one.
+//
+// Example:
+//  sequence := gumbleutil.Sequence{
+//    Steps: []gumbleutil.Step{
+//      {
+//        Name: "create channel",
+//        Do: func(client *gumble.Client) error {
+//          client.Channels[0].Add("Games", false)
+//          return nil
+//        },
+//        Confirm: func(client *gumble.Client) bool {
+//          return client.Channels.Find("Games") != nil
+//        },
+//        Rollback: func(client *gumble.Client) error {
+//          client.Channels.Find("Games").Remove()
+//          return nil
+//        },
+//      },
+//      // ...
+//    },
+//  }
+//  err := sequence.Run(ctx, client)
+//
+// Run must not be called from an event listener, or from inside of
+// Client.Do, as the server's replies would never be processed.
+type Sequence struct {
+	Steps []Step
+
+	// How long to wait for a step to be confirmed. If zero, there is no
+	// limit other than the context passed to Run.
+	Timeout time.Duration
+	// The number of times a step is retried after a transient permission
+	// denial, and how long to wait before each retry. This gives the server
+	// time to process a change the step depends on (e.g. an access token that
+	// was just added).
+	Retries    int
+	RetryDelay time.Duration
+	// Transient returns true if a permission denial that happens while a
+	// step is running should be retried. If nil, PermissionDeniedPermission
+	// and PermissionDeniedChannelFull denials are retried.
+	Transient func(e *gumble.PermissionDeniedEvent) bool
+}
+
+// SequenceError is the error returned by Sequence.Run when a step fails.
+type SequenceError struct {
+	// The index and name of the step that failed.
+	Step int
+	Name string
+	Err  error
+	// The permission denial that made the step fail, if any.
+	Denied *gumble.PermissionDeniedEvent
+	// The errors returned by the rollback of the completed steps.
+	RollbackErrors []error
+}
+
+// Error implements error.
+func (e *SequenceError) Error() string {
+	name := e.Name
+	if name == "" {
+		name = "#" + strconv.Itoa(e.Step)
+	}
+	msg := "gumbleutil: step " + name + " failed: " + e.Err.Error()
+	if len(e.RollbackErrors) > 0 {
+		msg += " (rollback: " + strconv.Itoa(len(e.RollbackErrors)) + " errors)"
+	}
+	return msg
+}
+
+// sequenceRun holds the events received while a Sequence runs.
+type sequenceRun struct {
+	mu           sync.Mutex
+	denied       *gumble.PermissionDeniedEvent
+	disconnected bool
+	changed      chan struct{}
+}
+
+func (r *sequenceRun) event(e interface{}) {
+	r.mu.Lock()
+	switch e := e.(type) {
+	case *gumble.PermissionDeniedEvent:
+		r.denied = e
+	case *gumble.DisconnectEvent:
+		r.disconnected = true
+	}
+	r.mu.Unlock()
+	select {
+	case r.changed <- struct{}{}:
+	default:
+	}
+}
+
+// Run runs the steps of the sequence. If a step fails, the completed steps
+// are rolled back, and a *SequenceError is returned.
+func (s *Sequence) Run(ctx context.Context, client *gumble.Client) error {
+	run := &sequenceRun{
+		changed: make(chan struct{}, 1),
+	}
+	detacher := client.Attach(ListenerFunc(run.event))
+	defer detacher.Detach()
+
+	for i := range s.Steps {
+		step := &s.Steps[i]
+		denied, err := s.runStep(ctx, client, run, step)
+		if err == nil {
+			continue
+		}
+		seqErr := &SequenceError{
+			Step:   i,
+			Name:   step.Name,
+			Err:    err,
+			Denied: denied,
+		}
+		for j := i - 1; j >= 0; j-- {
+			if rollback := s.Steps[j].Rollback; rollback != nil {
+				if err := rollback(client); err != nil {
+					seqErr.RollbackErrors = append(seqErr.RollbackErrors, err)
+				}
+			}
+		}
+		return seqErr
+	}
+	return nil
+}
+
+func (s *Sequence) runStep(ctx context.Context, client *gumble.Client, run *sequenceRun, step *Step) (*gumble.PermissionDeniedEvent, error) {
+	var timeout <-chan time.Time
+	if s.Timeout > 0 {
+		timer := time.NewTimer(s.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for attempt := 0; ; attempt++ {
+		run.mu.Lock()
+		run.denied = nil
+		run.mu.Unlock()
+		if err := step.Do(client); err != nil {
+			return nil, err
+		}
+		if step.Confirm == nil {
+			return nil, nil
+		}
+
+	wait:
+		for {
+			var confirmed bool
+			client.Do(func() {
+				confirmed = step.Confirm(client)
+			})
+			if confirmed {
+				return nil, nil
+			}
+			run.mu.Lock()
+			denied, disconnected := run.denied, run.disconnected
+			run.mu.Unlock()
+			if disconnected {
+				return nil, errors.New("client disconnected")
+			}
+			if denied != nil {
+				if attempt >= s.Retries || !s.transient(denied) {
+					return denied, errors.New("permission denied: " + denied.String)
+				}
+				select {
+				case <-time.After(s.RetryDelay):
+				case <-ctx.Done():
+					return denied, ctx.Err()
+				}
+				break wait
+			}
+			select {
+			case <-run.changed:
+			case <-timeout:
+				return nil, ErrStepTimeout
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+}
+
+func (s *Sequence) transient(e *gumble.PermissionDeniedEvent) bool {
+	if s.Transient != nil {
+		return s.Transient(e)
+	}
+	return e.Type == gumble.PermissionDeniedPermission || e.Type == gumble.PermissionDeniedChannelFull
+}
@@ -0,0 +1,318 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// ClientState is the local client state that is kept between connections to
+// a server.
+type ClientState struct {
+	// Path (see ChannelPath) of the channel the client was last in.
+	LastChannel []string `json:"last_channel,omitempty"`
+	// Per-user preferences, keyed by UserKey.
+	Users map[string]*UserPreferences `json:"users,omitempty"`
+	// Voice targets that were registered with the server.
+	VoiceTargets map[uint32]*VoiceTargetState `json:"voice_targets,omitempty"`
+}
+
+// UserPreferences are the local preferences for a single user.
+type UserPreferences struct {
+	Volume float32 `json:"volume"`
+	Muted  bool    `json:"muted,omitempty"`
+}
+
+// VoiceTargetState is the persisted form of a gumble.VoiceTarget.
+type VoiceTargetState struct {
+	// Names of the targeted users.
+	Users []string `json:"users,omitempty"`
+	// The targeted channels.
+	Channels []VoiceTargetChannelState `json:"channels,omitempty"`
+}
+
+// VoiceTargetChannelState is the persisted form of a
+// gumble.VoiceTargetChannel.
+type VoiceTargetChannelState struct {
+	Path      []string `json:"path"`
+	Recursive bool     `json:"recursive,omitempty"`
+	Links     bool     `json:"links,omitempty"`
+	Group     string   `json:"group,omitempty"`
+}
+
+// StateStore loads and saves ClientStates. Implementations must be safe for
+// concurrent use.
+type StateStore interface {
+	// LoadState returns the state saved for the given server, or nil and nil
+	// if no state has been saved.
+	LoadState(server string) (*ClientState, error)
+	// SaveState saves the state for the given server.
+	SaveState(server string, state *ClientState) error
+}
+
+// UserKey returns the key that is used to identify the given user in
+// ClientState.Users. Users with a certificate are identified by its hash;
+// other users are identified by their name.
+func UserKey(user *gumble.User) string {
+	if user.Hash != "" {
+		return "hash:" + user.Hash
+	}
+	return "name:" + user.Name
+}
+
+// StatePersister is a gumble.EventListener that saves the client's local state
+// to a StateStore, and re-applies it when the client connects to the same
+// server again.
+//
+// The following state is persisted:
+//  - the channel the client is in
+//  - each user's local volume and mute preference (User.LocalVolume and
+//    User.LocalMute)
+//  - voice targets passed to StatePersister.SendVoiceTarget
+type StatePersister struct {
+	Listener
+
+	// The store in which the state is saved.
+	Store StateStore
+	// The key of the server in the store. If empty, the server address in the
+	// client's config is used.
+	Server string
+	// Called when loading or saving the state fails. Can be nil.
+	Error func(err error)
+
+	mu     sync.Mutex
+	client *gumble.Client
+	state  *ClientState
+}
+
+// NewStatePersister returns a new StatePersister that uses the given store.
+func NewStatePersister(store StateStore) *StatePersister {
+	p := &StatePersister{
+		Store: store,
+	}
+	p.Listener = Listener{
+		Connect:    p.onConnect,
+		Disconnect: p.onDisconnect,
+		UserChange: p.onUserChange,
+	}
+	return p
+}
+
+func (p *StatePersister) server(client *gumble.Client) string {
+	if p.Server != "" {
+		return p.Server
+	}
+	return client.Config.Address
+}
+
+func (p *StatePersister) fail(err error) {
+	if err != nil && p.Error != nil {
+		p.Error(err)
+	}
+}
+
+// save writes the state to the store. p.mu must be held.
+func (p *StatePersister) save() {
+	if p.client == nil || p.state == nil {
+		return
+	}
+	p.fail(p.Store.SaveState(p.server(p.client), p.state))
+}
+
+// rememberUser records the preferences of user. p.mu must be held.
+func (p *StatePersister) rememberUser(user *gumble.User) {
+	if user == p.client.Self {
+		return
+	}
+	key := UserKey(user)
+	prefs := &UserPreferences{
+		Volume: user.LocalVolume(),
+		Muted:  user.LocalMute(),
+	}
+	if prefs.Volume == 1 && !prefs.Muted {
+		delete(p.state.Users, key)
+		return
+	}
+	if p.state.Users == nil {
+		p.state.Users = make(map[string]*UserPreferences)
+	}
+	p.state.Users[key] = prefs
+}
+
+// applyUser applies the saved preferences to user. p.mu must be held.
+func (p *StatePersister) applyUser(user *gumble.User) {
+	if prefs := p.state.Users[UserKey(user)]; prefs != nil {
+		user.SetLocalVolume(prefs.Volume)
+		user.SetLocalMute(prefs.Muted)
+	}
+}
+
+func (p *StatePersister) onConnect(e *gumble.ConnectEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.client = e.Client
+	state, err := p.Store.LoadState(p.server(e.Client))
+	p.fail(err)
+	if state == nil {
+		state = &ClientState{}
+	}
+	p.state = state
+
+	for _, user := range e.Client.Users {
+		p.applyUser(user)
+	}
+	for id, vtState := range state.VoiceTargets {
+		target := &gumble.VoiceTarget{
+			ID: id,
+		}
+		for _, name := range vtState.Users {
+			if user := e.Client.Users.Find(name); user != nil {
+				target.AddUser(user)
+			}
+		}
+		for _, vtChannel := range vtState.Channels {
+			if len(vtChannel.Path) == 0 {
+				continue
+			}
+			if channel := e.Client.Channels.Find(vtChannel.Path[1:]...); channel != nil {
+				target.AddChannel(channel, vtChannel.Recursive, vtChannel.Links, vtChannel.Group)
+			}
+		}
+		e.Client.Send(target)
+	}
+	if len(state.LastChannel) > 0 {
+		channel := e.Client.Channels.Find(state.LastChannel[1:]...)
+		if channel != nil && e.Client.Self != nil && channel != e.Client.Self.Channel {
+			e.Client.Self.Move(channel)
+		}
+	}
+}
+
+func (p *StatePersister) onDisconnect(e *gumble.DisconnectEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state == nil {
+		return
+	}
+	for _, user := range e.Client.Users {
+		p.rememberUser(user)
+	}
+	p.save()
+	p.client = nil
+	p.state = nil
+}
+
+func (p *StatePersister) onUserChange(e *gumble.UserChangeEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state == nil {
+		return
+	}
+	switch {
+	case e.Type.Has(gumble.UserChangeConnected):
+		p.applyUser(e.User)
+	case e.Type.Has(gumble.UserChangeDisconnected):
+		p.rememberUser(e.User)
+		p.save()
+	case e.User == e.Client.Self && e.Type.Has(gumble.UserChangeChannel):
+		p.state.LastChannel = ChannelPath(e.User.Channel)
+		p.save()
+	}
+}
+
+// SendVoiceTarget sends the voice target to the server, and saves it so that
+// it is re-sent the next time the client connects to the server.
+func (p *StatePersister) SendVoiceTarget(client *gumble.Client, target *gumble.VoiceTarget) {
+	client.Send(target)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.state == nil {
+		return
+	}
+	vtState := &VoiceTargetState{}
+	for _, user := range target.Users() {
+		vtState.Users = append(vtState.Users, user.Name)
+	}
+	for _, vtChannel := range target.Channels() {
+		vtState.Channels = append(vtState.Channels, VoiceTargetChannelState{
+			Path:      ChannelPath(vtChannel.Channel),
+			Recursive: vtChannel.Recursive,
+			Links:     vtChannel.Links,
+			Group:     vtChannel.Group,
+		})
+	}
+	if p.state.VoiceTargets == nil {
+		p.state.VoiceTargets = make(map[uint32]*VoiceTargetState)
+	}
+	p.state.VoiceTargets[target.ID] = vtState
+	p.save()
+}
+
+// fileStateStore is a StateStore that saves the states of all servers in a
+// single JSON file.
+type fileStateStore struct {
+	mu       sync.Mutex
+	filename string
+}
+
+// NewFileStateStore returns a StateStore that saves states to the given JSON
+// file.
+func NewFileStateStore(filename string) StateStore {
+	return &fileStateStore{
+		filename: filename,
+	}
+}
+
+func (f *fileStateStore) load() (map[string]*ClientState, error) {
+	states := make(map[string]*ClientState)
+	data, err := ioutil.ReadFile(f.filename)
+	if os.IsNotExist(err) {
+		return states, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &states); err != nil {
+		return nil, err
+	}
+	return states, nil
+}
+
+func (f *fileStateStore) LoadState(server string) (*ClientState, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	states, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+	return states[server], nil
+}
+
+func (f *fileStateStore) SaveState(server string, state *ClientState) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	states, err := f.load()
+	if err != nil {
+		return err
+	}
+	states[server] = state
+	data, err := json.MarshalIndent(states, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := f.filename + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.filename)
+}
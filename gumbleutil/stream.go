@@ -0,0 +1,28 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+// AudioStream is the interface implemented by the audio device backends
+// (gumbleopenal, gumbleportaudio). It allows an application to choose a
+// backend at runtime.
+type AudioStream interface {
+	gumble.AudioListener
+
+	// AttachStream starts playing the audio received by client.
+	AttachStream(client *gumble.Client)
+	// StartSource starts capturing audio from the given input device and
+	// sending it to the server. If inputDevice is nil, the device that was
+	// passed to the backend's constructor is used.
+	StartSource(inputDevice *string) error
+	// StopSource stops capturing audio.
+	StopSource() error
+	// GetMicVolume returns the capture volume.
+	GetMicVolume() float32
+	// SetMicVolume sets the capture volume, either to change or by change if
+	// relative is true.
+	SetMicVolume(change float32, relative bool)
+	// Destroy stops the stream and releases its devices.
+	Destroy()
+}
@@ -0,0 +1,230 @@
+package gumbleutil // import "github.com/bmmcginty/gumble/gumbleutil"
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/bmmcginty/gumble/gumble"
+)
+
+var (
+	errWAVInvalid     = errors.New("gumbleutil: invalid WAV file")
+	errWAVUnsupported = errors.New("gumbleutil: unsupported WAV format (only 8 and 16 bit PCM are supported)")
+)
+
+// WAVSource is a virtual microphone that "captures" audio from a WAV file. The
+// audio is sent to the server in real time, one frame every AudioInterval, as
+// if it were being captured from a device. It allows tests and demos to
+// exercise the outgoing audio path without audio hardware.
+//
+// Files with any sample rate and number of channels are accepted; the audio is
+// converted to mono at gumble.AudioSampleRate when the source is created.
+type WAVSource struct {
+	// If true, the file is played repeatedly until the source is stopped.
+	Loop bool
+
+	client  *gumble.Client
+	samples []int16
+
+	l    sync.Mutex
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+// OpenWAVSource returns a new WAVSource for the WAV file with the given name.
+func OpenWAVSource(client *gumble.Client, filename string) (*WAVSource, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return NewWAVSource(client, f)
+}
+
+// NewWAVSource returns a new WAVSource for the WAV file read from r.
+func NewWAVSource(client *gumble.Client, r io.Reader) (*WAVSource, error) {
+	samples, err := decodeWAV(r)
+	if err != nil {
+		return nil, err
+	}
+	return &WAVSource{
+		client:  client,
+		samples: samples,
+	}, nil
+}
+
+// Duration returns the length of the audio.
+func (w *WAVSource) Duration() time.Duration {
+	return time.Duration(len(w.samples)) * time.Second / gumble.AudioSampleRate
+}
+
+// Play starts sending the audio to the server.
+func (w *WAVSource) Play() error {
+	w.l.Lock()
+	defer w.l.Unlock()
+	if w.stop != nil {
+		return errors.New("gumbleutil: WAV source already playing")
+	}
+	w.stop = make(chan struct{})
+	w.wg.Add(1)
+	go w.process(w.stop)
+	return nil
+}
+
+// Stop stops sending audio, and waits until the source has stopped.
+func (w *WAVSource) Stop() error {
+	w.l.Lock()
+	if w.stop == nil {
+		w.l.Unlock()
+		return errors.New("gumbleutil: WAV source is not playing")
+	}
+	close(w.stop)
+	w.stop = nil
+	w.l.Unlock()
+	w.Wait()
+	return nil
+}
+
+// Wait returns once the source has stopped playing, either because the end
+// of the file was reached or because Stop was called.
+func (w *WAVSource) Wait() {
+	w.wg.Wait()
+}
+
+func (w *WAVSource) process(stop chan struct{}) {
+	defer w.wg.Done()
+
+	interval := w.client.AudioInterval()
+	frameSize := w.client.AudioFrameSize()
+
+	outgoing := w.client.AudioOutgoing()
+	defer close(outgoing)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	offset := 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		if offset >= len(w.samples) {
+			if !w.Loop || len(w.samples) == 0 {
+				break
+			}
+			offset = 0
+		}
+		frame := make(gumble.AudioBuffer, frameSize)
+		offset += copy(frame, w.samples[offset:])
+		outgoing <- frame
+	}
+
+	w.l.Lock()
+	if w.stop == stop {
+		w.stop = nil
+	}
+	w.l.Unlock()
+}
+
+// decodeWAV decodes a PCM WAV file into mono samples at
+// gumble.AudioSampleRate.
+func decodeWAV(r io.Reader) ([]int16, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, errWAVInvalid
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errWAVInvalid
+	}
+
+	var (
+		haveFormat    bool
+		channels      int
+		sampleRate    int
+		bitsPerSample int
+	)
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(r, chunk[:]); err != nil {
+			return nil, errWAVInvalid
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			if size < 16 {
+				return nil, errWAVInvalid
+			}
+			format := make([]byte, size)
+			if _, err := io.ReadFull(r, format); err != nil {
+				return nil, errWAVInvalid
+			}
+			audioFormat := binary.LittleEndian.Uint16(format[0:])
+			if audioFormat == 0xFFFE && size >= 26 {
+				// WAVE_FORMAT_EXTENSIBLE; the sub-format GUID starts with
+				// the actual format.
+				audioFormat = binary.LittleEndian.Uint16(format[24:])
+			}
+			channels = int(binary.LittleEndian.Uint16(format[2:]))
+			sampleRate = int(binary.LittleEndian.Uint32(format[4:]))
+			bitsPerSample = int(binary.LittleEndian.Uint16(format[14:]))
+			if audioFormat != 1 || (bitsPerSample != 8 && bitsPerSample != 16) || channels < 1 || sampleRate < 1 {
+				return nil, errWAVUnsupported
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return nil, errWAVInvalid
+			}
+			data, err := ioutil.ReadAll(io.LimitReader(r, size))
+			if err != nil {
+				return nil, err
+			}
+			return convertWAV(data, channels, sampleRate, bitsPerSample), nil
+		default:
+			if _, err := io.CopyN(ioutil.Discard, r, size+size%2); err != nil {
+				return nil, errWAVInvalid
+			}
+			continue
+		}
+		if size%2 == 1 {
+			if _, err := io.CopyN(ioutil.Discard, r, 1); err != nil {
+				return nil, errWAVInvalid
+			}
+		}
+	}
+}
+
+// convertWAV converts interleaved PCM data into mono samples at
+// gumble.AudioSampleRate.
+func convertWAV(data []byte, channels, sampleRate, bitsPerSample int) []int16 {
+	bytesPerSample := bitsPerSample / 8
+	frames := len(data) / (bytesPerSample * channels)
+	samples := make([]int16, frames)
+	for i := range samples {
+		var sum int
+		for c := 0; c < channels; c++ {
+			offset := (i*channels + c) * bytesPerSample
+			if bytesPerSample == 1 {
+				sum += (int(data[offset]) - 128) << 8
+			} else {
+				sum += int(int16(binary.LittleEndian.Uint16(data[offset:])))
+			}
+		}
+		samples[i] = int16(sum / channels)
+	}
+
+	if sampleRate == gumble.AudioSampleRate {
+		return samples
+	}
+	resampler := NewResampler(sampleRate, gumble.AudioSampleRate)
+	resampled := resampler.Process(samples)
+	// Flush the samples held back by the resampler.
+	return append(resampled, resampler.Process(make([]int16, resampler.halfWidth))...)
+}
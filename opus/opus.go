@@ -1,6 +1,8 @@
 package opus // import "github.com/bmmcginty/gumble/opus"
 
 import (
+	"math"
+
 	"layeh.com/gopus"
 	"github.com/bmmcginty/gumble/gumble"
 )
@@ -10,13 +12,50 @@ var Codec gumble.AudioCodec
 const ID = 4
 
 func init() {
-	Codec = &generator{}
-	gumble.RegisterAudioCodec(4, Codec)
+	Register(DecoderOptions{})
+}
+
+// DTXMode specifies how a Decoder handles zero-length frames, which clients
+// using discontinuous transmission (DTX) send when there is nothing to
+// transmit.
+type DTXMode int
+
+const (
+	// DTXConceal decodes zero-length frames with Opus packet loss
+	// concealment.
+	DTXConceal DTXMode = iota
+	// DTXSilence decodes zero-length frames as silence, as long as the
+	// previous frame.
+	DTXSilence
+)
+
+// DecoderOptions configures the decoders created by the codec.
+type DecoderOptions struct {
+	// Gain applied to the decoded audio, in decibels. Samples are clipped if
+	// needed. Zero leaves the audio unchanged.
+	Gain float64
+	// Should every channel of the decoded audio be replaced with the average
+	// of the channels? It has no effect when gumble.AudioChannels is 1, as
+	// Opus already downmixes to mono.
+	Mono bool
+	// How zero-length frames are decoded.
+	DTX DTXMode
+}
+
+// Register makes Codec a codec whose decoders use the given options, and
+// registers it with gumble, replacing the codec registered by the package.
+// Clients that have already connected keep using their current codec.
+func Register(options DecoderOptions) {
+	Codec = &generator{
+		options: options,
+	}
+	gumble.RegisterAudioCodec(ID, Codec)
 }
 
 // generator
 
 type generator struct {
+	options DecoderOptions
 }
 
 func (*generator) ID() int {
@@ -31,10 +70,12 @@ func (*generator) NewEncoder() gumble.AudioEncoder {
 	}
 }
 
-func (*generator) NewDecoder() gumble.AudioDecoder {
+func (g *generator) NewDecoder() gumble.AudioDecoder {
 	d, _ := gopus.NewDecoder(gumble.AudioSampleRate, gumble.AudioChannels)
 	return &Decoder{
-		d,
+		Decoder: d,
+		options: g.options,
+		gain:    math.Pow(10, g.options.Gain/20),
 	}
 }
 
@@ -60,6 +101,11 @@ func (e *Encoder) Reset() {
 
 type Decoder struct {
 	*gopus.Decoder
+
+	options DecoderOptions
+	gain    float64
+	// The number of samples of the last decoded frame.
+	lastSamples int
 }
 
 func (*Decoder) ID() int {
@@ -67,9 +113,54 @@ func (*Decoder) ID() int {
 }
 
 func (d *Decoder) Decode(data []byte, frameSize int) ([]int16, error) {
-	return d.Decoder.Decode(data, frameSize, false)
+	if len(data) == 0 && d.options.DTX == DTXSilence {
+		return make([]int16, d.lastSamples), nil
+	}
+	pcm, err := d.Decoder.Decode(data, frameSize, false)
+	if err != nil {
+		return nil, err
+	}
+	d.lastSamples = len(pcm)
+	if d.options.Mono && gumble.AudioChannels > 1 {
+		downmix(pcm, gumble.AudioChannels)
+	}
+	if d.gain != 1 {
+		applyGain(pcm, d.gain)
+	}
+	return pcm, nil
 }
 
 func (d *Decoder) Reset() {
 	d.Decoder.ResetState()
+	d.lastSamples = 0
+}
+
+// downmix replaces each channel of the interleaved samples in pcm with the
+// average of the channels.
+func downmix(pcm []int16, channels int) {
+	for i := 0; i+channels <= len(pcm); i += channels {
+		var sum int
+		for _, sample := range pcm[i : i+channels] {
+			sum += int(sample)
+		}
+		average := int16(sum / channels)
+		for j := i; j < i+channels; j++ {
+			pcm[j] = average
+		}
+	}
+}
+
+// applyGain multiplies the samples in pcm by gain, clipping them to the range
+// of int16.
+func applyGain(pcm []int16, gain float64) {
+	for i, sample := range pcm {
+		value := float64(sample) * gain
+		switch {
+		case value > math.MaxInt16:
+			value = math.MaxInt16
+		case value < math.MinInt16:
+			value = math.MinInt16
+		}
+		pcm[i] = int16(value)
+	}
 }